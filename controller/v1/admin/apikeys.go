@@ -0,0 +1,125 @@
+package admin
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"autumnomous-jobs-applicant-api/shared/repository/apikeys"
+	"autumnomous-jobs-applicant-api/shared/response"
+)
+
+type issueAPIKeyData struct {
+	Owner     string     `json:"owner"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expiresat"`
+}
+
+type revokeAPIKeyData struct {
+	PublicID string `json:"publicid"`
+}
+
+// IssueAPIKey creates a key for a client. The key itself is only ever shown in this response.
+func IssueAPIKey(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPost {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	var data issueAPIKeyData
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&data)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusBadRequest, response.FriendlyError)
+		return
+	}
+
+	if data.Owner == "" || len(data.Scopes) == 0 {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.MissingRequiredValue)
+		return
+	}
+
+	for _, scope := range data.Scopes {
+		if !apikeys.ValidScope(scope) {
+			response.SendJSONMessage(w, http.StatusBadRequest, response.InvalidScope)
+			return
+		}
+	}
+
+	if data.ExpiresAt != nil && data.ExpiresAt.Before(time.Now()) {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.InvalidExpiry)
+		return
+	}
+
+	secret, key, err := apikeys.NewAPIKeyRegistry().GetAPIKeyRepository().CreateAPIKey(data.Owner, data.Scopes, data.ExpiresAt)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	response.SendJSON(w, map[string]interface{}{"key": secret, "apikey": key})
+}
+
+// GetAPIKeys lists every key without the secrets
+func GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	keys, err := apikeys.NewAPIKeyRegistry().GetAPIKeyRepository().GetAPIKeys()
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	response.SendJSON(w, keys)
+}
+
+// RevokeAPIKey stops a key working immediately
+func RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPost {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	var data revokeAPIKeyData
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&data)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusBadRequest, response.FriendlyError)
+		return
+	}
+
+	if data.PublicID == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.MissingRequiredValue)
+		return
+	}
+
+	revoked, err := apikeys.NewAPIKeyRegistry().GetAPIKeyRepository().RevokeAPIKey(data.PublicID)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	if !revoked {
+		response.SendJSONMessage(w, http.StatusNotFound, response.EmptyResult)
+		return
+	}
+
+	response.SendJSONMessage(w, http.StatusOK, response.Success)
+}
@@ -0,0 +1,157 @@
+package admin_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"autumnomous-jobs-applicant-api/controller/v1/admin"
+	"autumnomous-jobs-applicant-api/route/middleware/acl"
+	"autumnomous-jobs-applicant-api/shared/repository/apikeys"
+	"autumnomous-jobs-applicant-api/shared/testhelper"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	testhelper.Init()
+}
+
+func sendWithAPIKey(t *testing.T, method, url, key string, body interface{}) (*http.Response, map[string]interface{}) {
+
+	requestBody, err := json.Marshal(body)
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	request, err := http.NewRequest(method, url, bytes.NewBuffer(requestBody))
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Authorization", "Key "+base64.StdEncoding.EncodeToString([]byte(key)))
+
+	response, err := http.DefaultClient.Do(request)
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	var result map[string]interface{}
+	json.NewDecoder(response.Body).Decode(&result)
+
+	return response, result
+}
+
+func Test_Admin_APIKeys_IssueUseRevoke(t *testing.T) {
+	assert := assert.New(t)
+
+	adminKey, _, err := apikeys.NewAPIKeyRegistry().GetAPIKeyRepository().CreateAPIKey("support", []string{apikeys.ScopeAdmin}, nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issue := httptest.NewServer(acl.RequireAPIKeyScope(apikeys.ScopeAdmin)(http.HandlerFunc(admin.IssueAPIKey)))
+	defer issue.Close()
+
+	revoke := httptest.NewServer(acl.RequireAPIKeyScope(apikeys.ScopeAdmin)(http.HandlerFunc(admin.RevokeAPIKey)))
+	defer revoke.Close()
+
+	applicantOnly := httptest.NewServer(acl.RequireAPIKeyScope(apikeys.ScopeApplicant)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+	defer applicantOnly.Close()
+
+	response, result := sendWithAPIKey(t, "POST", issue.URL, adminKey, map[string]interface{}{"owner": "web", "scopes": []string{apikeys.ScopeApplicant}})
+
+	assert.Equal(http.StatusOK, response.StatusCode)
+	webKey, _ := result["key"].(string)
+	assert.NotEmpty(webKey)
+	issued, _ := result["apikey"].(map[string]interface{})
+	assert.Equal("web", issued["owner"])
+
+	response, _ = sendWithAPIKey(t, "POST", applicantOnly.URL, webKey, nil)
+	assert.Equal(http.StatusOK, response.StatusCode)
+
+	// the admin key can't call the public applicant endpoints
+	response, _ = sendWithAPIKey(t, "POST", applicantOnly.URL, adminKey, nil)
+	assert.Equal(http.StatusForbidden, response.StatusCode)
+
+	// the applicant key can't issue keys itself
+	response, _ = sendWithAPIKey(t, "POST", issue.URL, webKey, map[string]interface{}{"owner": "web", "scopes": []string{apikeys.ScopeAdmin}})
+	assert.Equal(http.StatusForbidden, response.StatusCode)
+
+	response, _ = sendWithAPIKey(t, "POST", revoke.URL, adminKey, map[string]string{"publicid": issued["publicid"].(string)})
+	assert.Equal(http.StatusOK, response.StatusCode)
+
+	response, _ = sendWithAPIKey(t, "POST", applicantOnly.URL, webKey, nil)
+	assert.Equal(http.StatusUnauthorized, response.StatusCode)
+}
+
+func Test_Admin_EnvAPIKeys(t *testing.T) {
+	assert := assert.New(t)
+
+	for name, value := range map[string]string{"API_KEY": "shared-client-key", "ADMIN_API_KEY": "bootstrap-admin-key"} {
+		name := name
+		previous, set := os.LookupEnv(name)
+		os.Setenv(name, value)
+
+		t.Cleanup(func() {
+			if set {
+				os.Setenv(name, previous)
+			} else {
+				os.Unsetenv(name)
+			}
+		})
+	}
+
+	adminOnly := httptest.NewServer(acl.RequireAPIKeyScope(apikeys.ScopeAdmin)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+	defer adminOnly.Close()
+
+	applicantOnly := httptest.NewServer(acl.RequireAPIKeyScope(apikeys.ScopeApplicant)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+	defer applicantOnly.Close()
+
+	// the key shared with every client only reaches the applicant endpoints
+	response, _ := sendWithAPIKey(t, "POST", applicantOnly.URL, "shared-client-key", nil)
+	assert.Equal(http.StatusOK, response.StatusCode)
+
+	response, _ = sendWithAPIKey(t, "POST", adminOnly.URL, "shared-client-key", nil)
+	assert.Equal(http.StatusForbidden, response.StatusCode)
+
+	response, _ = sendWithAPIKey(t, "POST", adminOnly.URL, "bootstrap-admin-key", nil)
+	assert.Equal(http.StatusOK, response.StatusCode)
+
+	response, _ = sendWithAPIKey(t, "POST", applicantOnly.URL, "bootstrap-admin-key", nil)
+	assert.Equal(http.StatusForbidden, response.StatusCode)
+}
+
+func Test_Admin_IssueAPIKey_InvalidData(t *testing.T) {
+	assert := assert.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(admin.IssueAPIKey))
+	defer ts.Close()
+
+	bodies := []map[string]interface{}{
+		{"owner": "", "scopes": []string{apikeys.ScopeApplicant}},
+		{"owner": "web", "scopes": []string{}},
+		{"owner": "web", "scopes": []string{"superuser"}},
+		{"owner": "web", "scopes": []string{apikeys.ScopeApplicant}, "expiresat": "2001-01-01T00:00:00Z"},
+	}
+
+	for _, body := range bodies {
+		response, _ := sendWithAPIKey(t, "POST", ts.URL, "", body)
+		assert.Equal(http.StatusBadRequest, response.StatusCode)
+	}
+}
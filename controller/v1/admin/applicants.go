@@ -0,0 +1,172 @@
+package admin
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	applicantscontroller "autumnomous-jobs-applicant-api/controller/v1/applicants"
+	"autumnomous-jobs-applicant-api/shared/repository/applicants"
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+	"autumnomous-jobs-applicant-api/shared/response"
+	"autumnomous-jobs-applicant-api/shared/services/audit"
+	"autumnomous-jobs-applicant-api/shared/services/utils"
+)
+
+type applicantData struct {
+	PublicID string `json:"publicid"`
+}
+
+// findApplicant looks the applicant up by public id, responding 404 and returning nil when there isn't one
+func findApplicant(w http.ResponseWriter, publicID string) *accountmanagement.Applicant {
+
+	if publicID == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.MissingRequiredValue)
+		return nil
+	}
+
+	applicant, err := applicants.NewApplicantRegistry().GetApplicantRepository().GetApplicant(publicID)
+
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			response.SendJSONMessage(w, http.StatusNotFound, response.EmptyResult)
+			return nil
+		}
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return nil
+	}
+
+	return applicant
+}
+
+// decodeApplicant reads {"publicid": ...} from a POST body and loads that applicant
+func decodeApplicant(w http.ResponseWriter, r *http.Request) *accountmanagement.Applicant {
+
+	if r.Method != http.MethodPost {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return nil
+	}
+
+	var data applicantData
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&data)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusBadRequest, response.FriendlyError)
+		return nil
+	}
+
+	return findApplicant(w, data.PublicID)
+}
+
+// GetApplicant returns an applicant's account by ?publicid= or ?email=
+func GetApplicant(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	publicID := r.URL.Query().Get("publicid")
+
+	if email := r.URL.Query().Get("email"); publicID == "" && email != "" {
+		found, err := applicants.NewApplicantRegistry().GetApplicantRepository().GetApplicantByEmail(email)
+
+		if err != nil {
+			log.Println(err)
+			response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+			return
+		}
+
+		if found == nil {
+			response.SendJSONMessage(w, http.StatusNotFound, response.EmptyResult)
+			return
+		}
+
+		publicID = found.PublicID
+	}
+
+	applicant := findApplicant(w, publicID)
+
+	if applicant == nil {
+		return
+	}
+
+	response.SendJSON(w, applicant)
+}
+
+// ForcePasswordReset logs the applicant out everywhere, makes their current password stop
+// working and emails them a reset link
+func ForcePasswordReset(w http.ResponseWriter, r *http.Request) {
+
+	applicant := decodeApplicant(w, r)
+
+	if applicant == nil {
+		return
+	}
+
+	_, err := applicants.NewApplicantRegistry().GetApplicantRepository().ForcePasswordReset(applicant.PublicID)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	audit.Record(r, accountmanagement.AuditAdminPasswordResetForced, applicant.PublicID, nil)
+
+	err = applicantscontroller.SendPasswordReset(applicant)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	response.SendJSONMessage(w, http.StatusOK, response.Success)
+}
+
+// UnlockApplicant clears a lockout caused by failed logins
+func UnlockApplicant(w http.ResponseWriter, r *http.Request) {
+
+	applicant := decodeApplicant(w, r)
+
+	if applicant == nil {
+		return
+	}
+
+	err := applicantscontroller.UnlockApplicant(applicant, utils.ClientIP(r))
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	audit.Record(r, accountmanagement.AuditAdminApplicantUnlocked, applicant.PublicID, nil)
+
+	response.SendJSONMessage(w, http.StatusOK, response.Success)
+}
+
+// ExportApplicantData queues an export of the applicant's data to answer a data access request.
+// The applicant is emailed when it's ready and downloads it themselves.
+func ExportApplicantData(w http.ResponseWriter, r *http.Request) {
+
+	applicant := decodeApplicant(w, r)
+
+	if applicant == nil {
+		return
+	}
+
+	export, err := applicantscontroller.QueueDataExport(r, applicant.PublicID)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	response.SendJSON(w, export)
+}
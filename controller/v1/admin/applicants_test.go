@@ -0,0 +1,154 @@
+package admin_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"autumnomous-jobs-applicant-api/controller/v1/admin"
+	"autumnomous-jobs-applicant-api/controller/v1/applicants"
+	"autumnomous-jobs-applicant-api/route/middleware/acl"
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+	"autumnomous-jobs-applicant-api/shared/services/security/jwt"
+	"autumnomous-jobs-applicant-api/shared/services/security/principal"
+	"autumnomous-jobs-applicant-api/shared/services/security/rbac"
+	"autumnomous-jobs-applicant-api/shared/testhelper"
+
+	"github.com/justinas/alice"
+	"github.com/stretchr/testify/assert"
+)
+
+func staffToken(t *testing.T, roles ...string) string {
+
+	staff := testhelper.Helper_RandomStaff(t, roles...)
+
+	token, err := jwt.GenerateAccessToken(staff.PublicID, roles, principal.AuthMethodPassword, "")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return token
+}
+
+func sendWithToken(t *testing.T, method, url, token string, body interface{}) (*http.Response, map[string]interface{}) {
+
+	requestBody, err := json.Marshal(body)
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	request, err := http.NewRequest(method, url, bytes.NewBuffer(requestBody))
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Authorization", "Bearer "+base64.StdEncoding.EncodeToString([]byte(token)))
+
+	response, err := http.DefaultClient.Do(request)
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	var result map[string]interface{}
+	json.NewDecoder(response.Body).Decode(&result)
+
+	return response, result
+}
+
+func Test_Admin_GetApplicant_RequiresPermission(t *testing.T) {
+	assert := assert.New(t)
+
+	ts := httptest.NewServer(alice.New(acl.ValidateJWT, acl.RequirePermission(rbac.PermissionViewApplicants)).ThenFunc(admin.GetApplicant))
+	defer ts.Close()
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+
+	// an ordinary applicant can't look up other accounts
+	token, err := jwt.GenerateToken(applicant.PublicID)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, _ := sendWithToken(t, "GET", ts.URL+"?publicid="+applicant.PublicID, token, nil)
+	assert.Equal(http.StatusForbidden, response.StatusCode)
+
+	support := staffToken(t, rbac.RoleSupport)
+
+	response, result := sendWithToken(t, "GET", ts.URL+"?publicid="+applicant.PublicID, support, nil)
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Equal(applicant.Email, result["email"])
+
+	response, result = sendWithToken(t, "GET", ts.URL+"?email="+applicant.Email, support, nil)
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Equal(applicant.PublicID, result["publicid"])
+
+	response, _ = sendWithToken(t, "GET", ts.URL+"?email=nobody-"+applicant.Email, support, nil)
+	assert.Equal(http.StatusNotFound, response.StatusCode)
+}
+
+func Test_Admin_ForcePasswordReset(t *testing.T) {
+	assert := assert.New(t)
+
+	ts := httptest.NewServer(alice.New(acl.ValidateJWT, acl.RequirePermission(rbac.PermissionForcePasswordReset)).ThenFunc(admin.ForcePasswordReset))
+	defer ts.Close()
+
+	var emailed string
+	applicants.SendPasswordResetMessageFunction = func(domain, apiKey, token string, applicant *accountmanagement.Applicant) (string, error) {
+		emailed = applicant.Email
+		return "", nil
+	}
+
+	defer func() {
+		applicants.SendPasswordResetMessageFunction = applicants.SendPasswordResetMessage
+	}()
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+
+	response, _ := sendWithToken(t, "POST", ts.URL, staffToken(t, rbac.RoleSupport), map[string]string{"publicid": applicant.PublicID})
+
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Equal(applicant.Email, emailed)
+
+	// the old password no longer works
+	match, _, _, err := applicants.AuthenticatePassword(applicant.Email, applicant.Password)
+	assert.Nil(err)
+	assert.False(match)
+}
+
+func Test_Admin_UnlockApplicant(t *testing.T) {
+	assert := assert.New(t)
+
+	ts := httptest.NewServer(alice.New(acl.ValidateJWT, acl.RequirePermission(rbac.PermissionUnlockApplicants)).ThenFunc(admin.UnlockApplicant))
+	defer ts.Close()
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+
+	limiter := applicants.LoginLimiterFunction()
+
+	for i := 0; i < limiter.EmailPolicy.MaxAttempts; i++ {
+		limiter.Fail(applicant.Email, "10.0.0.1")
+	}
+
+	decision, err := limiter.Check(applicant.Email, "10.0.0.2")
+	assert.Nil(err)
+	assert.True(decision.EmailLocked)
+
+	response, _ := sendWithToken(t, "POST", ts.URL, staffToken(t, rbac.RoleAdmin), map[string]string{"publicid": applicant.PublicID})
+	assert.Equal(http.StatusOK, response.StatusCode)
+
+	decision, err = limiter.Check(applicant.Email, "10.0.0.2")
+	assert.Nil(err)
+	assert.True(decision.Allowed)
+
+	response, _ = sendWithToken(t, "POST", ts.URL, staffToken(t, rbac.RoleAdmin), map[string]string{"publicid": ""})
+	assert.Equal(http.StatusBadRequest, response.StatusCode)
+}
@@ -0,0 +1,80 @@
+package admin
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"autumnomous-jobs-applicant-api/shared/repository/applicants"
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+	"autumnomous-jobs-applicant-api/shared/response"
+)
+
+// GetAuditEvents searches the audit log, newest first. It takes ?actor=, ?target= and ?type= to
+// match exactly, ?since= and ?until= as RFC 3339 times, and ?limit= and ?before= to page through
+// the results with the id of the last event seen.
+func GetAuditEvents(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	query := r.URL.Query()
+
+	filter, err := auditFilter(query)
+
+	if err != nil {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.InvalidQuery)
+		return
+	}
+
+	events, err := applicants.NewApplicantRegistry().GetAuditRepository().GetAuditEvents(filter)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	response.SendJSON(w, map[string]interface{}{"events": events})
+}
+
+func auditFilter(query url.Values) (accountmanagement.AuditFilter, error) {
+
+	filter := accountmanagement.AuditFilter{
+		ActorID:   query.Get("actor"),
+		TargetID:  query.Get("target"),
+		EventType: query.Get("type"),
+	}
+
+	var err error
+
+	if since := query.Get("since"); since != "" {
+		if filter.Since, err = time.Parse(time.RFC3339, since); err != nil {
+			return filter, err
+		}
+	}
+
+	if until := query.Get("until"); until != "" {
+		if filter.Until, err = time.Parse(time.RFC3339, until); err != nil {
+			return filter, err
+		}
+	}
+
+	if before := query.Get("before"); before != "" {
+		if filter.BeforeID, err = strconv.ParseInt(before, 10, 64); err != nil {
+			return filter, err
+		}
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil {
+			return filter, err
+		}
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,95 @@
+package admin_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"autumnomous-jobs-applicant-api/controller/v1/admin"
+	"autumnomous-jobs-applicant-api/controller/v1/applicants"
+	"autumnomous-jobs-applicant-api/route/middleware/acl"
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+	"autumnomous-jobs-applicant-api/shared/services/security/jwt"
+	"autumnomous-jobs-applicant-api/shared/services/security/rbac"
+	"autumnomous-jobs-applicant-api/shared/testhelper"
+
+	"github.com/justinas/alice"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Admin_GetAuditEvents_RecordsAdminAction(t *testing.T) {
+	assert := assert.New(t)
+
+	reset := httptest.NewServer(alice.New(acl.ValidateJWT, acl.RequirePermission(rbac.PermissionForcePasswordReset)).ThenFunc(admin.ForcePasswordReset))
+	defer reset.Close()
+
+	events := httptest.NewServer(alice.New(acl.ValidateJWT, acl.RequirePermission(rbac.PermissionViewAuditLog)).ThenFunc(admin.GetAuditEvents))
+	defer events.Close()
+
+	applicants.SendPasswordResetMessageFunction = func(domain, apiKey, token string, applicant *accountmanagement.Applicant) (string, error) {
+		return "", nil
+	}
+
+	defer func() {
+		applicants.SendPasswordResetMessageFunction = applicants.SendPasswordResetMessage
+	}()
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	token := staffToken(t, rbac.RoleSupport)
+
+	claims, err := jwt.ParseToken(token)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, _ := sendWithToken(t, "POST", reset.URL, token, map[string]string{"publicid": applicant.PublicID})
+	assert.Equal(http.StatusOK, response.StatusCode)
+
+	response, result := sendWithToken(t, "GET", events.URL+"?target="+applicant.PublicID+"&type="+accountmanagement.AuditAdminPasswordResetForced, token, nil)
+	assert.Equal(http.StatusOK, response.StatusCode)
+
+	list := result["events"].([]interface{})
+
+	if assert.Len(list, 1) {
+		event := list[0].(map[string]interface{})
+		assert.Equal(claims.CustomClaims["user"], event["actorid"])
+		assert.Equal(applicant.PublicID, event["targetid"])
+		assert.NotEmpty(event["ipaddress"])
+	}
+
+	// the staff member's own actions can be found by actor too
+	response, result = sendWithToken(t, "GET", events.URL+"?actor="+claims.CustomClaims["user"], token, nil)
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Len(result["events"], 1)
+}
+
+func Test_Admin_GetAuditEvents_InvalidQuery(t *testing.T) {
+	assert := assert.New(t)
+
+	ts := httptest.NewServer(alice.New(acl.ValidateJWT, acl.RequirePermission(rbac.PermissionViewAuditLog)).ThenFunc(admin.GetAuditEvents))
+	defer ts.Close()
+
+	token := staffToken(t, rbac.RoleAdmin)
+
+	for _, query := range []string{"?since=yesterday", "?until=2021-01-01", "?before=abc", "?limit=ten"} {
+		response, _ := sendWithToken(t, "GET", ts.URL+query, token, nil)
+		assert.Equal(http.StatusBadRequest, response.StatusCode, query)
+	}
+}
+
+func Test_Admin_GetAuditEvents_RequiresPermission(t *testing.T) {
+	assert := assert.New(t)
+
+	ts := httptest.NewServer(alice.New(acl.ValidateJWT, acl.RequirePermission(rbac.PermissionViewAuditLog)).ThenFunc(admin.GetAuditEvents))
+	defer ts.Close()
+
+	token, err := jwt.GenerateToken(testhelper.Helper_RandomApplicant(t).PublicID)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, _ := sendWithToken(t, "GET", ts.URL, token, nil)
+	assert.Equal(http.StatusForbidden, response.StatusCode)
+}
@@ -0,0 +1,36 @@
+package admin
+
+import (
+	"log"
+	"net/http"
+
+	"autumnomous-jobs-applicant-api/shared/repository/applicants"
+	"autumnomous-jobs-applicant-api/shared/response"
+)
+
+// GetDeletionReceipts returns the receipts for the deleted account with ?publicid=, for answering
+// questions about a deletion after the applicant is gone
+func GetDeletionReceipts(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	publicID := r.URL.Query().Get("publicid")
+
+	if publicID == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.MissingRequiredValue)
+		return
+	}
+
+	receipts, err := applicants.NewApplicantRegistry().GetDeletionRepository().GetDeletionReceipts(publicID)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	response.SendJSON(w, map[string]interface{}{"receipts": receipts})
+}
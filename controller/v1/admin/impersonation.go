@@ -0,0 +1,139 @@
+package admin
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"autumnomous-jobs-applicant-api/shared/repository/applicants"
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+	"autumnomous-jobs-applicant-api/shared/response"
+	"autumnomous-jobs-applicant-api/shared/services/audit"
+	"autumnomous-jobs-applicant-api/shared/services/security/jwt"
+	"autumnomous-jobs-applicant-api/shared/services/security/principal"
+	"autumnomous-jobs-applicant-api/shared/services/security/rbac"
+)
+
+type impersonateData struct {
+	PublicID string `json:"publicid"`
+	Reason   string `json:"reason"`
+	Write    bool   `json:"write"`
+}
+
+// Impersonate mints a short-lived token that lets the signed in staff member see the API as the
+// applicant does. The token is read-only unless write is requested and the staff member may grant it.
+func Impersonate(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPost {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	var data impersonateData
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&data)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusBadRequest, response.FriendlyError)
+		return
+	}
+
+	if data.Reason == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.MissingRequiredValue)
+		return
+	}
+
+	staff := principal.FromRequest(r)
+
+	if staff == nil {
+		response.SendJSONMessage(w, http.StatusUnauthorized, response.Unauthorized)
+		return
+	}
+
+	if data.Write && !staff.HasPermission(rbac.PermissionImpersonateWrite) {
+		response.SendJSONMessage(w, http.StatusForbidden, response.Forbidden)
+		return
+	}
+
+	applicant := findApplicant(w, data.PublicID)
+
+	if applicant == nil {
+		return
+	}
+
+	actorID := staff.ApplicantID
+
+	if actorID == applicant.PublicID {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.Forbidden)
+		return
+	}
+
+	token, err := jwt.GenerateImpersonationToken(applicant.PublicID, actorID, data.Write)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	claims, err := jwt.ParseToken(token)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	// the token only works once it's on record, see acl.ValidateJWT
+	err = applicants.NewApplicantRegistry().GetImpersonationRepository().CreateImpersonation(claims.Id, actorID, applicant.PublicID, data.Reason, data.Write, time.Unix(claims.ExpiresAt, 0))
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	audit.Record(r, accountmanagement.AuditAdminImpersonation, applicant.PublicID, audit.Changes{
+		"impersonationid": {After: claims.Id},
+		"reason":          {After: data.Reason},
+		"write":           {After: data.Write},
+	})
+
+	response.SendJSON(w, map[string]interface{}{
+		"token":            base64.StdEncoding.EncodeToString([]byte(token)),
+		"expiresin":        int64(jwt.ImpersonationLifetime.Seconds()),
+		"write":            data.Write,
+		"publicid":         applicant.PublicID,
+		"registrationstep": applicant.RegistrationStep,
+		"impersonationid":  claims.Id,
+	})
+}
+
+// GetImpersonatedRequests lists everything done with an impersonation token, by ?impersonationid=
+func GetImpersonatedRequests(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	impersonationID := r.URL.Query().Get("impersonationid")
+
+	if impersonationID == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.MissingRequiredValue)
+		return
+	}
+
+	requests, err := applicants.NewApplicantRegistry().GetImpersonationRepository().GetImpersonatedRequests(impersonationID)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	response.SendJSON(w, requests)
+}
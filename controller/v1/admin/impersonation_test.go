@@ -0,0 +1,104 @@
+package admin_test
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"autumnomous-jobs-applicant-api/controller/v1/admin"
+	"autumnomous-jobs-applicant-api/controller/v1/applicants"
+	"autumnomous-jobs-applicant-api/route/middleware/acl"
+	applicantsrepository "autumnomous-jobs-applicant-api/shared/repository/applicants"
+	"autumnomous-jobs-applicant-api/shared/services/security/rbac"
+	"autumnomous-jobs-applicant-api/shared/testhelper"
+
+	"github.com/justinas/alice"
+	"github.com/stretchr/testify/assert"
+)
+
+func impersonationToken(t *testing.T, result map[string]interface{}) string {
+
+	encoded, _ := result["token"].(string)
+	token, err := base64.StdEncoding.DecodeString(encoded)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(token)
+}
+
+func Test_Admin_Impersonate_ReadOnly(t *testing.T) {
+	assert := assert.New(t)
+
+	impersonate := httptest.NewServer(alice.New(acl.ValidateJWT, acl.RequirePermission(rbac.PermissionImpersonate)).ThenFunc(admin.Impersonate))
+	defer impersonate.Close()
+
+	get := httptest.NewServer(alice.New(acl.ValidateJWT).ThenFunc(applicants.GetApplicant))
+	defer get.Close()
+
+	update := httptest.NewServer(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.UpdateAccount))
+	defer update.Close()
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	support := staffToken(t, rbac.RoleSupport)
+
+	response, _ := sendWithToken(t, "POST", impersonate.URL, support, map[string]interface{}{"publicid": applicant.PublicID})
+	assert.Equal(http.StatusBadRequest, response.StatusCode)
+
+	response, result := sendWithToken(t, "POST", impersonate.URL, support, map[string]interface{}{"publicid": applicant.PublicID, "reason": "ticket 1234"})
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Equal(false, result["write"])
+
+	impersonationID, _ := result["impersonationid"].(string)
+	token := impersonationToken(t, result)
+
+	response, result = sendWithToken(t, "GET", get.URL, token, nil)
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Equal(applicant.Email, result["email"])
+
+	response, _ = sendWithToken(t, "POST", update.URL, token, map[string]string{"firstname": "Changed"})
+	assert.Equal(http.StatusForbidden, response.StatusCode)
+
+	// both requests are on record with how they were answered
+	requests, err := applicantsrepository.NewApplicantRegistry().GetImpersonationRepository().GetImpersonatedRequests(impersonationID)
+	assert.Nil(err)
+
+	if assert.Len(requests, 2) {
+		assert.Equal("GET", requests[0].Method)
+		assert.Equal(http.StatusOK, requests[0].Status)
+		assert.Equal("POST", requests[1].Method)
+		assert.Equal(http.StatusForbidden, requests[1].Status)
+	}
+
+	// impersonation tokens carry no staff permissions of their own
+	response, _ = sendWithToken(t, "POST", impersonate.URL, token, map[string]interface{}{"publicid": applicant.PublicID, "reason": "chained"})
+	assert.Equal(http.StatusForbidden, response.StatusCode)
+}
+
+func Test_Admin_Impersonate_Write(t *testing.T) {
+	assert := assert.New(t)
+
+	impersonate := httptest.NewServer(alice.New(acl.ValidateJWT, acl.RequirePermission(rbac.PermissionImpersonate)).ThenFunc(admin.Impersonate))
+	defer impersonate.Close()
+
+	writeCheck := httptest.NewServer(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer writeCheck.Close()
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	body := map[string]interface{}{"publicid": applicant.PublicID, "reason": "ticket 1234", "write": true}
+
+	// support can only look
+	response, _ := sendWithToken(t, "POST", impersonate.URL, staffToken(t, rbac.RoleSupport), body)
+	assert.Equal(http.StatusForbidden, response.StatusCode)
+
+	response, result := sendWithToken(t, "POST", impersonate.URL, staffToken(t, rbac.RoleAdmin), body)
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Equal(true, result["write"])
+
+	response, _ = sendWithToken(t, "POST", writeCheck.URL, impersonationToken(t, result), nil)
+	assert.Equal(http.StatusNoContent, response.StatusCode)
+}
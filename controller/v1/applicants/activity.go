@@ -0,0 +1,89 @@
+package applicants
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	applicants "autumnomous-jobs-applicant-api/shared/repository/applicants"
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+	"autumnomous-jobs-applicant-api/shared/response"
+	"autumnomous-jobs-applicant-api/shared/services/security/principal"
+)
+
+// Who an activity event was made by, as shown to the applicant
+const (
+	activityByApplicant = "applicant"
+	activityByStaff     = "staff"
+	activityByUnknown   = "unknown"
+)
+
+// GetActivity lists the audit events about the applicant's account, newest first. ?limit= and
+// ?before= page through them with the id of the last event seen. Staff members are never named.
+func GetActivity(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	publicID := principal.ApplicantID(r)
+
+	if publicID == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.FriendlyError)
+		return
+	}
+
+	filter := accountmanagement.AuditFilter{TargetID: publicID}
+	var err error
+
+	if before := r.URL.Query().Get("before"); before != "" {
+		if filter.BeforeID, err = strconv.ParseInt(before, 10, 64); err != nil {
+			response.SendJSONMessage(w, http.StatusBadRequest, response.InvalidQuery)
+			return
+		}
+	}
+
+	if limit := r.URL.Query().Get("limit"); limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil {
+			response.SendJSONMessage(w, http.StatusBadRequest, response.InvalidQuery)
+			return
+		}
+	}
+
+	events, err := applicants.NewApplicantRegistry().GetAuditRepository().GetAuditEvents(filter)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	list := []map[string]interface{}{}
+
+	for _, event := range events {
+		list = append(list, map[string]interface{}{
+			"id":         event.ID,
+			"type":       event.EventType,
+			"actor":      activityActor(event, publicID),
+			"ipaddress":  event.IPAddress,
+			"requestid":  event.RequestID,
+			"changes":    event.Changes,
+			"createdate": event.CreateDate,
+		})
+	}
+
+	response.SendJSON(w, map[string]interface{}{"events": list})
+}
+
+func activityActor(event *accountmanagement.AuditEvent, publicID string) string {
+
+	switch event.ActorID {
+	case publicID:
+		return activityByApplicant
+	case "":
+		return activityByUnknown
+	default:
+		return activityByStaff
+	}
+}
@@ -0,0 +1,110 @@
+package applicants_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"autumnomous-jobs-applicant-api/controller/v1/applicants"
+	"autumnomous-jobs-applicant-api/route/middleware/acl"
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+	"autumnomous-jobs-applicant-api/shared/services/security/jwt"
+	"autumnomous-jobs-applicant-api/shared/testhelper"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Applicant_GetActivity(t *testing.T) {
+	assert := assert.New(t)
+
+	useTestLoginLimiter(t, 10)
+
+	login := httptest.NewServer(http.HandlerFunc(applicants.Login))
+	defer login.Close()
+
+	updatePassword := httptest.NewServer(acl.ValidateJWT(http.HandlerFunc(applicants.UpdatePassword)))
+	defer updatePassword.Close()
+
+	activity := httptest.NewServer(acl.ValidateJWT(http.HandlerFunc(applicants.GetActivity)))
+	defer activity.Close()
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+
+	requestBody, err := json.Marshal(map[string]string{"email": applicant.Email, "password": "wrong-password"})
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	response, err := http.Post(login.URL, "application/json", bytes.NewBuffer(requestBody))
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	assert.Equal(http.StatusUnauthorized, response.StatusCode)
+
+	sessionLogin(t, login.URL, "browser", applicant)
+
+	token, err := jwt.GenerateToken(applicant.PublicID)
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	response, result := postJSONWithToken(t, updatePassword.URL, token, map[string]string{"password": applicant.Password, "newpassword": "A-much-longer-Passw0rd!"})
+	assert.Equal(http.StatusOK, response.StatusCode)
+
+	newToken := result["token"].(string)
+
+	response, result = sendWithSessionToken(t, "GET", activity.URL, newToken)
+	assert.Equal(http.StatusOK, response.StatusCode)
+
+	events := result["events"].([]interface{})
+
+	if assert.Len(events, 3) {
+		types := []string{accountmanagement.AuditPasswordChanged, accountmanagement.AuditLoginSucceeded, accountmanagement.AuditLoginFailed}
+		actors := []string{"applicant", "applicant", "unknown"}
+
+		for i, e := range events {
+			event := e.(map[string]interface{})
+			assert.Equal(types[i], event["type"])
+			assert.Equal(actors[i], event["actor"])
+			assert.Nil(event["actorid"])
+		}
+	}
+
+	// paging backwards from the newest event skips it
+	newest := events[0].(map[string]interface{})["id"].(float64)
+
+	response, result = sendWithSessionToken(t, "GET", fmt.Sprintf("%s?limit=1&before=%.0f", activity.URL, newest), newToken)
+	assert.Equal(http.StatusOK, response.StatusCode)
+
+	events = result["events"].([]interface{})
+
+	if assert.Len(events, 1) {
+		assert.Equal(accountmanagement.AuditLoginSucceeded, events[0].(map[string]interface{})["type"])
+	}
+}
+
+func Test_Applicant_GetActivity_InvalidQuery(t *testing.T) {
+	assert := assert.New(t)
+
+	ts := httptest.NewServer(acl.ValidateJWT(http.HandlerFunc(applicants.GetActivity)))
+	defer ts.Close()
+
+	token, err := jwt.GenerateToken(testhelper.Helper_RandomApplicant(t).PublicID)
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	for _, query := range []string{"?before=abc", "?limit=ten"} {
+		response, _ := sendWithSessionToken(t, "GET", ts.URL+query, base64.StdEncoding.EncodeToString([]byte(token)))
+		assert.Equal(http.StatusBadRequest, response.StatusCode, query)
+	}
+}
@@ -2,7 +2,6 @@ package applicants
 
 import (
 	"context"
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -14,8 +13,10 @@ import (
 
 	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
 	"autumnomous-jobs-applicant-api/shared/response"
+	"autumnomous-jobs-applicant-api/shared/services/audit"
 	"autumnomous-jobs-applicant-api/shared/services/security/encryption"
-	"autumnomous-jobs-applicant-api/shared/services/security/jwt"
+	"autumnomous-jobs-applicant-api/shared/services/security/principal"
+	"autumnomous-jobs-applicant-api/shared/services/utils"
 
 	mailgun "github.com/mailgun/mailgun-go/v4"
 )
@@ -57,7 +58,14 @@ func SignUp(w http.ResponseWriter, r *http.Request) {
 
 	repository := applicants.NewApplicantRegistry().GetApplicantRepository()
 
-	password := encryption.GeneratePassword(9)
+	password, err := encryption.GeneratePasswordWithPolicy(encryption.TemporaryPasswordPolicy)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
 	hashedPassword, err := encryption.HashPassword([]byte(password))
 
 	if err != nil {
@@ -82,6 +90,14 @@ func SignUp(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	err = sendVerification(employer, employer.Email, accountmanagement.EmailVerification)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
 	response.SendJSON(w, "")
 }
 
@@ -89,12 +105,19 @@ func SignUp(w http.ResponseWriter, r *http.Request) {
 func SendWelcomeMessage(domain, apiKey, password string, employer *accountmanagement.Applicant) (string, error) {
 
 	message := fmt.Sprintf("Thank you for joining BiT Jobs, %s!\nYour temporary password is %s", employer.FirstName, password)
+
+	return sendMessage(domain, apiKey, "Welcome to BiT Jobs!", message, employer.Email)
+}
+
+// sendMessage sends a plain text email to a single recipient through Mailgun
+func sendMessage(domain, apiKey, subject, message, recipient string) (string, error) {
+
 	mg := mailgun.NewMailgun(domain, apiKey)
 	m := mg.NewMessage(
 		"BiT Jobs Support <admin@autumnomous.git.beanstalkapp.com/autumnomous-jobs-applicant-api>",
-		"Welcome to BiT Jobs!",
+		subject,
 		message,
-		employer.Email,
+		recipient,
 	)
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
 	defer cancel()
@@ -119,17 +142,60 @@ func Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	limiter := LoginLimiterFunction()
+	ip := utils.ClientIP(r)
+
+	if !checkLoginAllowed(w, limiter, credentials.Email, ip) {
+		return
+	}
+
 	match, registrationStep, publicID, err := AuthenticationFunction(credentials.Email, credentials.Password)
 
 	if err != nil {
 		log.Println(err)
 		response.SendJSONMessage(w, http.StatusInternalServerError, "Internal Server Error")
+		return
 	}
 
 	if match {
 
-		// use   to create JWT
-		tokenStr, err := jwt.GenerateToken(publicID)
+		err = limiter.Succeed(credentials.Email)
+
+		if err != nil {
+			log.Println(err)
+		}
+
+		completeLogin(w, r, publicID, registrationStep, principal.AuthMethodPassword)
+		return
+	} else {
+		err = recordLoginFailure(limiter, credentials.Email, ip)
+
+		if err != nil {
+			log.Println(err)
+		}
+
+		auditLoginFailure(r, credentials.Email)
+
+		response.SendJSONMessage(w, http.StatusUnauthorized, "Login failed")
+		return
+	}
+
+}
+
+// completeLogin responds with the applicant's tokens once they have proved who they are, or with
+// an MFA challenge when they still need to enter their second factor
+func completeLogin(w http.ResponseWriter, r *http.Request, publicID, registrationStep, authMethod string) {
+
+	settings, err := applicants.NewApplicantRegistry().GetMFARepository().GetTOTPSettings(publicID)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	if settings.Enabled {
+		challenge, err := mfaChallengeResponse(publicID)
 
 		if err != nil {
 			log.Println(err)
@@ -137,19 +203,37 @@ func Login(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		encodedTokenStr := base64.StdEncoding.EncodeToString([]byte(tokenStr))
+		response.SendJSON(w, challenge)
+		return
+	}
+
+	token, err := issueTokens(r, publicID, registrationStep, authMethod)
 
-		token := map[string]interface{}{
-			"token":            encodedTokenStr,
-			"registrationstep": registrationStep,
-		}
-		response.SendJSON(w, token)
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
 		return
-	} else {
-		response.SendJSONMessage(w, http.StatusUnauthorized, "Login failed")
+	}
+
+	audit.RecordAs(r, publicID, accountmanagement.AuditLoginSucceeded, publicID, nil)
+
+	response.SendJSON(w, token)
+}
+
+// auditLoginFailure records a failed password login against the account email belongs to. Attempts
+// on unknown emails are only counted by the login limiter.
+func auditLoginFailure(r *http.Request, email string) {
+
+	applicant, err := applicants.NewApplicantRegistry().GetApplicantRepository().GetApplicantByEmail(email)
+
+	if err != nil {
+		log.Println(err)
 		return
 	}
 
+	if applicant != nil {
+		audit.RecordAs(r, "", accountmanagement.AuditLoginFailed, applicant.PublicID, nil)
+	}
 }
 
 func AuthenticatePassword(email, password string) (bool, string, string, error) {
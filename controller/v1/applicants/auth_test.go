@@ -125,8 +125,13 @@ func Test_Applicant_SignUp_CorrectData(t *testing.T) {
 		return "", nil
 	}
 
+	applicants.SendVerificationMessageFunction = func(domain, apiKey, token, address string, applicant *accountmanagement.Applicant) (string, error) {
+		return "", nil
+	}
+
 	defer func() {
 		applicants.SendWelcomeMessageFunction = applicants.SendWelcomeMessage
+		applicants.SendVerificationMessageFunction = applicants.SendVerificationMessage
 	}()
 
 	request, err := http.NewRequest("POST", ts.URL, bytes.NewBuffer(data))
@@ -180,8 +185,10 @@ func Test_EmployerLogin_Success(t *testing.T) {
 		t.Fatal()
 	}
 
+	applicant := testhelper.Helper_RandomApplicant(t)
+
 	applicants.AuthenticationFunction = func(email, password string) (bool, string, string, error) {
-		return true, "", "", nil
+		return true, "", applicant.PublicID, nil
 	}
 
 	defer func() {
@@ -207,6 +214,8 @@ func Test_EmployerLogin_Success(t *testing.T) {
 
 	assert.Equal(int(http.StatusOK), response.StatusCode)
 	assert.NotNil(result)
+	assert.NotEmpty(result["token"])
+	assert.NotEmpty(result["refreshtoken"])
 }
 
 func Test_ApplicantLogin_NoDataReceived(t *testing.T) {
@@ -0,0 +1,360 @@
+package applicants
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	applicants "autumnomous-jobs-applicant-api/shared/repository/applicants"
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+	"autumnomous-jobs-applicant-api/shared/response"
+	"autumnomous-jobs-applicant-api/shared/services/audit"
+	"autumnomous-jobs-applicant-api/shared/services/security/principal"
+	"autumnomous-jobs-applicant-api/shared/services/storage"
+	"autumnomous-jobs-applicant-api/shared/services/utils"
+)
+
+var StorageFunction = storage.NewSpacesStore
+var SendDeletionScheduledMessageFunction = SendDeletionScheduledMessage
+var SendDeletionReceiptMessageFunction = SendDeletionReceiptMessage
+
+// DefaultDeletionGracePeriod is how long an applicant has to change their mind after asking for
+// their account to be deleted, unless ACCOUNT_DELETION_GRACE_PERIOD says otherwise
+const DefaultDeletionGracePeriod = 14 * 24 * time.Hour
+
+// deletionBatchSize is how many due deletions one pass of the worker carries out
+const deletionBatchSize = 20
+
+type deleteAccountData struct {
+	Password string `json:"password"`
+}
+
+// DeletionGracePeriod reads ACCOUNT_DELETION_GRACE_PERIOD as a Go duration such as "72h"
+func DeletionGracePeriod() time.Duration {
+
+	value := os.Getenv("ACCOUNT_DELETION_GRACE_PERIOD")
+
+	if value == "" {
+		return DefaultDeletionGracePeriod
+	}
+
+	gracePeriod, err := time.ParseDuration(value)
+
+	if err != nil || gracePeriod < 0 {
+		log.Println("invalid ACCOUNT_DELETION_GRACE_PERIOD, using the default:", value)
+		return DefaultDeletionGracePeriod
+	}
+
+	return gracePeriod
+}
+
+// DeleteAccount schedules the applicant's account for deletion once the grace period is over. The
+// applicant has to enter their password again, and is logged out everywhere; logging back in
+// before the deletion date cancels it.
+func DeleteAccount(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPost {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	current := principal.FromRequest(r)
+
+	if current == nil || current.ApplicantID == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.FriendlyError)
+		return
+	}
+
+	// only the applicant can delete their account, never staff acting as them
+	if current.IsImpersonation() {
+		response.SendJSONMessage(w, http.StatusForbidden, response.Forbidden)
+		return
+	}
+
+	var data deleteAccountData
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&data)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	if data.Password == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.PasswordRequired)
+		return
+	}
+
+	applicant, err := applicants.NewApplicantRegistry().GetApplicantRepository().GetApplicant(current.ApplicantID)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	// wrong passwords count against the same limits as logins
+	limiter := LoginLimiterFunction()
+	ip := utils.ClientIP(r)
+
+	if !checkLoginAllowed(w, limiter, applicant.Email, ip) {
+		return
+	}
+
+	match, _, _, err := AuthenticationFunction(applicant.Email, data.Password)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	if !match {
+		err = recordLoginFailure(limiter, applicant.Email, ip)
+
+		if err != nil {
+			log.Println(err)
+		}
+
+		response.SendJSONMessage(w, http.StatusUnauthorized, response.InvalidCredentials)
+		return
+	}
+
+	err = limiter.Succeed(applicant.Email)
+
+	if err != nil {
+		log.Println(err)
+	}
+
+	deletion, err := applicants.NewApplicantRegistry().GetDeletionRepository().ScheduleDeletion(applicant.PublicID, DeletionGracePeriod())
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	err = applicants.NewApplicantRegistry().GetRevocationRepository().RevokeAllTokens(applicant.PublicID)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	audit.Record(r, accountmanagement.AuditDeletionScheduled, applicant.PublicID, audit.Changes{
+		"scheduledfor": {After: deletion.ScheduledFor},
+	})
+
+	domain := os.Getenv("MAILGUN_DOMAIN")
+	apiKey := os.Getenv("MAILGUN_API_KEY")
+	_, err = SendDeletionScheduledMessageFunction(domain, apiKey, deletion, applicant)
+
+	if err != nil {
+		log.Println(err)
+	}
+
+	response.SendJSON(w, deletion)
+}
+
+// cancelDeletion calls off the applicant's pending deletion when they log back in, and reports
+// whether there was one to cancel
+func cancelDeletion(r *http.Request, publicID string) (bool, error) {
+
+	cancelled, err := applicants.NewApplicantRegistry().GetDeletionRepository().CancelDeletion(publicID)
+
+	if err != nil || !cancelled {
+		return false, err
+	}
+
+	audit.RecordAs(r, publicID, accountmanagement.AuditDeletionCancelled, publicID, nil)
+
+	return true, nil
+}
+
+// RunAccountDeletions carries out due account deletions every interval until the process exits
+func RunAccountDeletions(interval time.Duration) {
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		_, err := ProcessAccountDeletions()
+
+		if err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// ProcessAccountDeletions deletes the accounts whose grace period is over: first the applicant's
+// files, then their rows, after which the applicant is emailed a receipt. It returns the receipts
+// of the deletions it completed. A deletion that fails part way is retried on the next pass.
+func ProcessAccountDeletions() ([]*accountmanagement.DeletionReceipt, error) {
+
+	deletionRepository := applicants.NewApplicantRegistry().GetDeletionRepository()
+
+	deletions, err := deletionRepository.GetDueDeletions(deletionBatchSize)
+
+	if err != nil {
+		return nil, err
+	}
+
+	receipts := []*accountmanagement.DeletionReceipt{}
+
+	for _, deletion := range deletions {
+		receipt, err := processAccountDeletion(deletion)
+
+		if err != nil {
+			log.Println("account deletion", deletion.PublicID, "failed:", err)
+			continue
+		}
+
+		if receipt != nil {
+			receipts = append(receipts, receipt)
+		}
+	}
+
+	return receipts, nil
+}
+
+func processAccountDeletion(deletion *accountmanagement.AccountDeletion) (*accountmanagement.DeletionReceipt, error) {
+
+	registry := applicants.NewApplicantRegistry()
+
+	started, err := registry.GetDeletionRepository().StartDeletion(deletion)
+
+	if err != nil || !started {
+		return nil, err
+	}
+
+	// the email address is gone once the rows are, so look it up first to send the receipt to
+	applicant, err := registry.GetApplicantRepository().GetApplicant(deletion.ApplicantPublicID)
+
+	if err != nil && err.Error() != "sql: no rows in result set" {
+		return nil, err
+	}
+
+	uploads, err := registry.GetUploadRepository().GetUploads(deletion.ApplicantPublicID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	resumes, err := registry.GetResumeRepository().GetResumes(deletion.ApplicantPublicID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []string{}
+
+	for _, file := range applicantFiles(uploads, resumes) {
+		keys = append(keys, file.ObjectKey)
+	}
+
+	// copies of their data made by exports go too
+	exports, err := registry.GetExportRepository().GetExports(deletion.ApplicantPublicID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, export := range exports {
+		if export.ObjectKey != "" {
+			keys = append(keys, export.ObjectKey)
+		}
+	}
+
+	if len(keys) > 0 {
+		store, err := StorageFunction()
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, key := range keys {
+			err = store.Delete(key)
+
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	receipt, err := registry.GetDeletionRepository().CompleteDeletion(deletion, len(keys))
+
+	if err != nil || receipt == nil {
+		return nil, err
+	}
+
+	err = registry.GetAuditRepository().RecordEvent(&accountmanagement.AuditEvent{
+		EventType: accountmanagement.AuditAccountDeleted,
+		TargetID:  deletion.ApplicantPublicID,
+	})
+
+	if err != nil {
+		log.Println(err)
+	}
+
+	if applicant != nil && applicant.Email != "" {
+		domain := os.Getenv("MAILGUN_DOMAIN")
+		apiKey := os.Getenv("MAILGUN_API_KEY")
+		_, err = SendDeletionReceiptMessageFunction(domain, apiKey, receipt, applicant)
+
+		if err != nil {
+			log.Println(err)
+		}
+	}
+
+	return receipt, nil
+}
+
+func SendDeletionScheduledMessage(domain, apiKey string, deletion *accountmanagement.AccountDeletion, applicant *accountmanagement.Applicant) (string, error) {
+
+	date := deletion.ScheduledFor.UTC().Format("2 January 2006 at 15:04 MST")
+	message := fmt.Sprintf("Hi %s,\nWe've received your request to delete your BiT Jobs account. It will be deleted on %s, along with your profile, desired cities and uploaded files.\n\nIf you change your mind, just log in before then and the deletion will be cancelled.", applicant.FirstName, date)
+
+	return sendMessage(domain, apiKey, "Your BiT Jobs account will be deleted", message, applicant.Email)
+}
+
+func SendDeletionReceiptMessage(domain, apiKey string, receipt *accountmanagement.DeletionReceipt, applicant *accountmanagement.Applicant) (string, error) {
+
+	message := fmt.Sprintf("Hi %s,\nYour BiT Jobs account has been deleted as you asked. Please keep this email as your receipt.\n\nReceipt: %s\nCompleted: %s\n\nDeleted:\n%s\nAnonymised:\n%s\nKept:\n%s",
+		applicant.FirstName, receipt.ReceiptID, receipt.CompletedAt.UTC().Format(time.RFC1123),
+		receiptCounts(receipt.Deleted), receiptCounts(receipt.Anonymised), receiptReasons(receipt.Retained))
+
+	return sendMessage(domain, apiKey, "Your BiT Jobs account has been deleted", message, applicant.Email)
+}
+
+func receiptCounts(counts map[string]int64) string {
+
+	lines := []string{}
+
+	for name, count := range counts {
+		lines = append(lines, fmt.Sprintf("  %s: %d", name, count))
+	}
+
+	sort.Strings(lines)
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func receiptReasons(reasons map[string]string) string {
+
+	lines := []string{}
+
+	for name, reason := range reasons {
+		lines = append(lines, fmt.Sprintf("  %s: %s", name, reason))
+	}
+
+	sort.Strings(lines)
+
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,178 @@
+package applicants_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"autumnomous-jobs-applicant-api/controller/v1/applicants"
+	"autumnomous-jobs-applicant-api/route/middleware/acl"
+	hr "autumnomous-jobs-applicant-api/route/middleware/httprouterwrapper"
+	"autumnomous-jobs-applicant-api/shared/database"
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+	"autumnomous-jobs-applicant-api/shared/services/security/jwt"
+	"autumnomous-jobs-applicant-api/shared/services/storage"
+	"autumnomous-jobs-applicant-api/shared/testhelper"
+
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+	"github.com/justinas/alice"
+	"github.com/stretchr/testify/assert"
+)
+
+// useTestDeletion keeps deleted files in memory and records the deletion emails instead of sending them
+func useTestDeletion(t *testing.T) (*storage.MemoryStore, *[]string) {
+
+	store := storage.NewMemoryStore()
+	emails := []string{}
+
+	applicants.StorageFunction = func() (storage.Store, error) { return store, nil }
+
+	applicants.SendDeletionScheduledMessageFunction = func(domain, apiKey string, deletion *accountmanagement.AccountDeletion, applicant *accountmanagement.Applicant) (string, error) {
+		emails = append(emails, "scheduled:"+applicant.Email)
+		return "", nil
+	}
+
+	applicants.SendDeletionReceiptMessageFunction = func(domain, apiKey string, receipt *accountmanagement.DeletionReceipt, applicant *accountmanagement.Applicant) (string, error) {
+		emails = append(emails, "receipt:"+applicant.Email)
+		return "", nil
+	}
+
+	t.Cleanup(func() {
+		applicants.StorageFunction = storage.NewSpacesStore
+		applicants.SendDeletionScheduledMessageFunction = applicants.SendDeletionScheduledMessage
+		applicants.SendDeletionReceiptMessageFunction = applicants.SendDeletionReceiptMessage
+	})
+
+	return store, &emails
+}
+
+// createResumeFile stores a resume version whose file has no upload record, as older resumes don't
+func createResumeFile(t *testing.T, store storage.Store, applicant *testhelper.TestApplicant, key string) {
+
+	err := store.Put(key, bytes.NewReader([]byte("%PDF")), "application/pdf", false)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = database.DB.Exec(`
+		INSERT INTO resumes(publicid, applicantid, name, version, objectkey, filename, contenttype, size, extractedtext)
+		SELECT $1, id, 'CV', 1, $3, 'cv.pdf', 'application/pdf', 4, '' FROM applicants WHERE publicid=$2;`, uuid.NewString(), applicant.PublicID, key)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func deletionServer() *httptest.Server {
+
+	r := httprouter.New()
+	r.POST("/login", hr.Handler(alice.New().ThenFunc(applicants.Login)))
+	r.POST("/delete-account", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.DeleteAccount)))
+	r.GET("/sessions", hr.Handler(alice.New(acl.ValidateJWT).ThenFunc(applicants.GetSessions)))
+
+	return httptest.NewServer(r)
+}
+
+func Test_Applicant_DeleteAccount_WrongPassword(t *testing.T) {
+	assert := assert.New(t)
+
+	useTestLoginLimiter(t, 10)
+	useTestDeletion(t)
+
+	ts := deletionServer()
+	defer ts.Close()
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	token, err := jwt.GenerateToken(applicant.PublicID)
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	response, _ := postJSONWithToken(t, ts.URL+"/delete-account", token, map[string]string{"password": "not-" + applicant.Password})
+	assert.Equal(http.StatusUnauthorized, response.StatusCode)
+
+	pending, err := accountmanagement.NewDeletionRepository(database.DB).GetPendingDeletion(applicant.PublicID)
+	assert.Nil(err)
+	assert.Nil(pending)
+}
+
+func Test_Applicant_DeleteAccount_CancelledByLogin(t *testing.T) {
+	assert := assert.New(t)
+
+	useTestLoginLimiter(t, 10)
+	_, emails := useTestDeletion(t)
+
+	ts := deletionServer()
+	defer ts.Close()
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	token := sessionLogin(t, ts.URL+"/login", "laptop-browser", applicant)
+
+	request, _ := http.NewRequest("POST", ts.URL+"/delete-account", bytes.NewBufferString(`{"password":"`+applicant.Password+`"}`))
+	request.Header.Set("Authorization", "Bearer "+token)
+
+	response, err := http.DefaultClient.Do(request)
+	assert.Nil(err)
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Equal([]string{"scheduled:" + applicant.Email}, *emails)
+
+	// every session is logged out
+	response, _ = sendWithSessionToken(t, "GET", ts.URL+"/sessions", token)
+	assert.Equal(http.StatusUnauthorized, response.StatusCode)
+
+	response, result := postJSONWithToken(t, ts.URL+"/login", "", map[string]string{"email": applicant.Email, "password": applicant.Password})
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Equal(true, result["deletioncancelled"])
+
+	pending, err := accountmanagement.NewDeletionRepository(database.DB).GetPendingDeletion(applicant.PublicID)
+	assert.Nil(err)
+	assert.Nil(pending)
+}
+
+func Test_Applicant_ProcessAccountDeletions(t *testing.T) {
+	assert := assert.New(t)
+
+	store, emails := useTestDeletion(t)
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	key := "uploads/" + applicant.PublicID + ".png"
+
+	err := store.Put(key, bytes.NewReader([]byte("image")), "image/png", true)
+	assert.Nil(err)
+
+	err = accountmanagement.NewUploadRepository(database.DB).CreateUpload(applicant.PublicID, key, "me.png", "image/png", 5)
+	assert.Nil(err)
+
+	resumeKey := "resumes/" + applicant.PublicID + "/cv.pdf"
+	createResumeFile(t, store, applicant, resumeKey)
+
+	deletion, err := accountmanagement.NewDeletionRepository(database.DB).ScheduleDeletion(applicant.PublicID, 0)
+	assert.Nil(err)
+
+	receipts, err := applicants.ProcessAccountDeletions()
+	assert.Nil(err)
+
+	var receipt *accountmanagement.DeletionReceipt
+
+	for _, r := range receipts {
+		if r.ReceiptID == deletion.PublicID {
+			receipt = r
+		}
+	}
+
+	if assert.NotNil(receipt) {
+		assert.Equal(int64(2), receipt.Deleted["files"])
+		assert.Equal(int64(1), receipt.Deleted["applicants"])
+	}
+
+	_, found := store.Object(key)
+	assert.False(found)
+
+	_, found = store.Object(resumeKey)
+	assert.False(found)
+	assert.Contains(*emails, "receipt:"+applicant.Email)
+}
@@ -0,0 +1,256 @@
+package applicants
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	applicants "autumnomous-jobs-applicant-api/shared/repository/applicants"
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+	"autumnomous-jobs-applicant-api/shared/response"
+	"autumnomous-jobs-applicant-api/shared/services/audit"
+	"autumnomous-jobs-applicant-api/shared/services/security/principal"
+	"autumnomous-jobs-applicant-api/shared/services/zipcode"
+)
+
+// LocateFunction looks up the place at a latitude and longitude
+var LocateFunction = func(latitude, longitude float64) (*zipcode.ZipCodeResponse, error) {
+	return zipcode.NewZipCodeGateway(os.Getenv("ZIPCODESERVICES_API_KEY")).GetLocationByLatLong(longitude, latitude)
+}
+
+var desiredCitiesSection = profileSection{
+	name:      "desiredcities",
+	auditType: accountmanagement.AuditPreferencesChanged,
+	list: func(publicID string) (interface{}, error) {
+		return applicants.NewApplicantRegistry().GetDesiredCityRepository().GetDesiredCities(publicID)
+	},
+	delete: func(publicID, id string) (bool, error) {
+		return applicants.NewApplicantRegistry().GetDesiredCityRepository().RemoveDesiredCity(publicID, id)
+	},
+}
+
+// desiredCitiesData is the body of a request to replace the applicant's desired cities
+type desiredCitiesData struct {
+	DesiredCities []*accountmanagement.DesiredCity `json:"desiredcities"`
+}
+
+func GetDesiredCities(w http.ResponseWriter, r *http.Request) {
+	listProfileSection(w, r, desiredCitiesSection)
+}
+
+// AddDesiredCity adds a city the applicant wants to work in, or updates it if they already have it
+func AddDesiredCity(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPost {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	publicID := principal.ApplicantID(r)
+
+	if publicID == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.FriendlyError)
+		return
+	}
+
+	var city accountmanagement.DesiredCity
+
+	if !decodeProfileEntry(w, r, &city) || !locateDesiredCities(w, "", []*accountmanagement.DesiredCity{&city}) {
+		return
+	}
+
+	err := applicants.NewApplicantRegistry().GetDesiredCityRepository().AddDesiredCity(publicID, &city)
+
+	if err == accountmanagement.ErrTooManyEntries {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.TooManyEntries)
+		return
+	}
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	audit.Record(r, desiredCitiesSection.changeType(), publicID, audit.Changes{desiredCitiesSection.name: {After: &city}})
+
+	response.SendJSON(w, &city)
+}
+
+// ReplaceDesiredCities makes the cities in the body the applicant's only desired cities
+func ReplaceDesiredCities(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPut {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	publicID := principal.ApplicantID(r)
+
+	if publicID == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.FriendlyError)
+		return
+	}
+
+	var details desiredCitiesData
+
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&details)
+
+	if err != nil || details.DesiredCities == nil {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.MissingRequiredValue)
+		return
+	}
+
+	if violations := accountmanagement.ValidateDesiredCities(details.DesiredCities); len(violations) > 0 {
+		sendFieldViolations(w, violations)
+		return
+	}
+
+	if !locateDesiredCities(w, desiredCitiesSection.name, details.DesiredCities) {
+		return
+	}
+
+	repository := applicants.NewApplicantRegistry().GetDesiredCityRepository()
+
+	before, err := repository.GetDesiredCities(publicID)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	err = repository.ReplaceDesiredCities(publicID, details.DesiredCities)
+
+	if err == accountmanagement.ErrTooManyEntries {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.TooManyEntries)
+		return
+	}
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	after, err := repository.GetDesiredCities(publicID)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	audit.Record(r, desiredCitiesSection.changeType(), publicID, audit.Changes{desiredCitiesSection.name: {Before: before, After: after}})
+
+	response.SendJSON(w, map[string]interface{}{desiredCitiesSection.name: after})
+}
+
+func RemoveDesiredCity(w http.ResponseWriter, r *http.Request) {
+	deleteProfileEntry(w, r, desiredCitiesSection)
+}
+
+// locateDesiredCities checks with the zipcode service that each city's latitude and longitude is a
+// real place in that city and state, and fills in a missing state or country from it. It responds
+// and returns false when one isn't.
+func locateDesiredCities(w http.ResponseWriter, field string, cities []*accountmanagement.DesiredCity) bool {
+
+	var violations []accountmanagement.FieldViolation
+
+	for i, city := range cities {
+		location, err := LocateFunction(float64(city.Latitude), float64(city.Longitude))
+
+		if err != nil {
+			log.Println(err)
+			response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+			return false
+		}
+
+		prefix := ""
+
+		if field != "" {
+			prefix = fmt.Sprintf("%s[%d].", field, i)
+		}
+
+		if location == nil || location.ZipCode == "" {
+			violations = append(violations, accountmanagement.FieldViolation{
+				Field:   prefix + "latitude",
+				Code:    accountmanagement.ViolationInvalid,
+				Message: "latitude and longitude aren't a known location.",
+			})
+			continue
+		}
+
+		if !samePlaceName(city.City, location.City) {
+			violations = append(violations, accountmanagement.FieldViolation{
+				Field:   prefix + "city",
+				Code:    accountmanagement.ViolationInvalid,
+				Message: fmt.Sprintf("latitude and longitude are in %s, not %s.", location.City, city.City),
+			})
+			continue
+		}
+
+		if city.State != "" && !sameState(city.State, location.State) {
+			violations = append(violations, accountmanagement.FieldViolation{
+				Field:   prefix + "state",
+				Code:    accountmanagement.ViolationInvalid,
+				Message: fmt.Sprintf("latitude and longitude are in %s, not %s.", location.State, city.State),
+			})
+			continue
+		}
+
+		if city.State == "" {
+			city.State = location.State
+		}
+
+		if city.Country == "" {
+			city.Country = location.Country
+		}
+	}
+
+	if len(violations) > 0 {
+		sendFieldViolations(w, violations)
+		return false
+	}
+
+	return true
+}
+
+// samePlaceName reports whether two names for a place match, ignoring case and spacing
+func samePlaceName(a, b string) bool {
+	return strings.EqualFold(strings.Join(strings.Fields(a), " "), strings.Join(strings.Fields(b), " "))
+}
+
+// sameState reports whether two names for a state match, allowing a US state's name for its postal code
+func sameState(a, b string) bool {
+
+	if code, ok := usStates[strings.ToLower(strings.Join(strings.Fields(a), " "))]; ok {
+		a = code
+	}
+
+	if code, ok := usStates[strings.ToLower(strings.Join(strings.Fields(b), " "))]; ok {
+		b = code
+	}
+
+	return samePlaceName(a, b)
+}
+
+// usStates maps the names of US states and territories to their postal codes
+var usStates = map[string]string{
+	"alabama": "AL", "alaska": "AK", "arizona": "AZ", "arkansas": "AR", "california": "CA",
+	"colorado": "CO", "connecticut": "CT", "delaware": "DE", "district of columbia": "DC", "florida": "FL",
+	"georgia": "GA", "hawaii": "HI", "idaho": "ID", "illinois": "IL", "indiana": "IN",
+	"iowa": "IA", "kansas": "KS", "kentucky": "KY", "louisiana": "LA", "maine": "ME",
+	"maryland": "MD", "massachusetts": "MA", "michigan": "MI", "minnesota": "MN", "mississippi": "MS",
+	"missouri": "MO", "montana": "MT", "nebraska": "NE", "nevada": "NV", "new hampshire": "NH",
+	"new jersey": "NJ", "new mexico": "NM", "new york": "NY", "north carolina": "NC", "north dakota": "ND",
+	"ohio": "OH", "oklahoma": "OK", "oregon": "OR", "pennsylvania": "PA", "rhode island": "RI",
+	"south carolina": "SC", "south dakota": "SD", "tennessee": "TN", "texas": "TX", "utah": "UT",
+	"vermont": "VT", "virginia": "VA", "washington": "WA", "west virginia": "WV", "wisconsin": "WI",
+	"wyoming": "WY", "puerto rico": "PR", "guam": "GU", "us virgin islands": "VI", "american samoa": "AS",
+	"northern mariana islands": "MP",
+}
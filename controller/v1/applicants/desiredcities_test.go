@@ -0,0 +1,128 @@
+package applicants_test
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"autumnomous-jobs-applicant-api/controller/v1/applicants"
+	"autumnomous-jobs-applicant-api/route/middleware/acl"
+	hr "autumnomous-jobs-applicant-api/route/middleware/httprouterwrapper"
+	"autumnomous-jobs-applicant-api/shared/services/zipcode"
+	"autumnomous-jobs-applicant-api/shared/testhelper"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/justinas/alice"
+	"github.com/stretchr/testify/assert"
+)
+
+// useTestLocations stands in for the zipcode service, which knows Cleveland, AL and Akron, OH
+func useTestLocations(t *testing.T) {
+
+	locate := applicants.LocateFunction
+
+	places := []zipcode.ZipCodeResponse{
+		{ZipCode: "35049", City: "Cleveland", State: "AL", Country: "US", Latitude: 34.0165, Longitude: -86.5538},
+		{ZipCode: "44308", City: "Akron", State: "OH", Country: "US", Latitude: 41.0814, Longitude: -81.519},
+	}
+
+	applicants.LocateFunction = func(latitude, longitude float64) (*zipcode.ZipCodeResponse, error) {
+		for _, place := range places {
+			if math.Abs(place.Latitude-latitude) < 0.1 && math.Abs(place.Longitude-longitude) < 0.1 {
+				return &place, nil
+			}
+		}
+		return &zipcode.ZipCodeResponse{}, nil
+	}
+
+	t.Cleanup(func() {
+		applicants.LocateFunction = locate
+	})
+}
+
+func Test_Applicant_DesiredCities(t *testing.T) {
+	assert := assert.New(t)
+
+	useTestLoginLimiter(t, 10)
+	useTestLocations(t)
+
+	r := httprouter.New()
+	r.POST("/login", hr.Handler(alice.New().ThenFunc(applicants.Login)))
+	r.GET("/desired-cities", hr.Handler(alice.New(acl.ValidateJWT).ThenFunc(applicants.GetDesiredCities)))
+	r.POST("/desired-cities", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.AddDesiredCity)))
+	r.PUT("/desired-cities", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.ReplaceDesiredCities)))
+	r.DELETE("/desired-cities/:id", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.RemoveDesiredCity)))
+
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	token := sessionLogin(t, ts.URL+"/login", "browser", applicant)
+
+	cleveland := map[string]interface{}{"city": "Cleveland", "latitude": 34.0165, "longitude": -86.5538}
+
+	response, city := sendJSONWithSessionToken(t, "POST", ts.URL+"/desired-cities", token, cleveland)
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Equal("AL", city["state"])
+
+	// adding the same city again doesn't duplicate it
+	response, again := sendJSONWithSessionToken(t, "POST", ts.URL+"/desired-cities", token, cleveland)
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Equal(city["id"], again["id"])
+
+	response, _ = sendJSONWithSessionToken(t, "POST", ts.URL+"/desired-cities", token, map[string]interface{}{"city": "Atlantis", "latitude": -10, "longitude": 20})
+	assert.Equal(http.StatusBadRequest, response.StatusCode)
+
+	// the coordinates have to be in the city and state given for them
+	response, result := sendJSONWithSessionToken(t, "POST", ts.URL+"/desired-cities", token, map[string]interface{}{"city": "London", "latitude": 41.0814, "longitude": -81.519})
+	assert.Equal(http.StatusBadRequest, response.StatusCode)
+	assert.Contains(fmt.Sprint(result["errors"]), "field:city")
+
+	response, result = sendJSONWithSessionToken(t, "POST", ts.URL+"/desired-cities", token, map[string]interface{}{"city": "akron", "state": "Texas", "latitude": 41.0814, "longitude": -81.519})
+	assert.Equal(http.StatusBadRequest, response.StatusCode)
+	assert.Contains(fmt.Sprint(result["errors"]), "field:state")
+
+	cities := []map[string]interface{}{
+		cleveland,
+		{"city": "Akron", "state": "OH", "latitude": 41.0814, "longitude": -81.519},
+	}
+
+	// replacing twice with the same list leaves the same cities
+	for i := 0; i < 2; i++ {
+		response, result := sendJSONWithSessionToken(t, "PUT", ts.URL+"/desired-cities", token, map[string]interface{}{"desiredcities": cities})
+		assert.Equal(http.StatusOK, response.StatusCode)
+
+		if list := result["desiredcities"].([]interface{}); assert.Len(list, 2) {
+			assert.Equal(city["id"], list[0].(map[string]interface{})["id"])
+		}
+	}
+
+	response, _ = sendWithSessionToken(t, "DELETE", ts.URL+"/desired-cities/"+city["id"].(string), token)
+	assert.Equal(http.StatusOK, response.StatusCode)
+
+	response, _ = sendWithSessionToken(t, "DELETE", ts.URL+"/desired-cities/"+city["id"].(string), token)
+	assert.Equal(http.StatusNotFound, response.StatusCode)
+
+	response, result = sendWithSessionToken(t, "GET", ts.URL+"/desired-cities", token)
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Len(result["desiredcities"], 1)
+
+	// the whole list is rejected when there are too many
+	tooMany := []map[string]interface{}{}
+
+	for i := 0; i <= 20; i++ {
+		tooMany = append(tooMany, map[string]interface{}{"city": "Town " + string(rune('A'+i)), "latitude": 40 + float64(i)/10, "longitude": -80})
+	}
+
+	response, _ = sendJSONWithSessionToken(t, "PUT", ts.URL+"/desired-cities", token, map[string]interface{}{"desiredcities": tooMany})
+	assert.Equal(http.StatusBadRequest, response.StatusCode)
+
+	response, _ = sendJSONWithSessionToken(t, "PUT", ts.URL+"/desired-cities", token, map[string]interface{}{"desiredcities": []interface{}{}})
+	assert.Equal(http.StatusOK, response.StatusCode)
+
+	response, result = sendWithSessionToken(t, "GET", ts.URL+"/desired-cities", token)
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Empty(result["desiredcities"])
+}
@@ -0,0 +1,230 @@
+package applicants
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	applicants "autumnomous-jobs-applicant-api/shared/repository/applicants"
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+	"autumnomous-jobs-applicant-api/shared/repository/jobs"
+	"autumnomous-jobs-applicant-api/shared/response"
+	"autumnomous-jobs-applicant-api/shared/services/security/principal"
+)
+
+var GetJobFunction = func(publicID string) (*jobs.Job, error) {
+	return jobs.NewJobRegistry().GetJobRepository().GetJob(publicID)
+}
+
+var coverLettersSection = profileSection{
+	name: "coverletters",
+	entry: func() profileEntry {
+		return &accountmanagement.CoverLetter{}
+	},
+	list: func(publicID string) (interface{}, error) {
+		return applicants.NewApplicantRegistry().GetCoverLetterRepository().GetCoverLetters(publicID)
+	},
+	create: func(publicID string, entry profileEntry) error {
+		return applicants.NewApplicantRegistry().GetCoverLetterRepository().CreateCoverLetter(publicID, entry.(*accountmanagement.CoverLetter))
+	},
+	update: func(publicID, id string, entry profileEntry) (bool, error) {
+		entry.(*accountmanagement.CoverLetter).PublicID = id
+		return applicants.NewApplicantRegistry().GetCoverLetterRepository().UpdateCoverLetter(publicID, entry.(*accountmanagement.CoverLetter))
+	},
+	delete: func(publicID, id string) (bool, error) {
+		return applicants.NewApplicantRegistry().GetCoverLetterRepository().DeleteCoverLetter(publicID, id)
+	},
+}
+
+var documentSetsSection = profileSection{
+	name: "documentsets",
+	entry: func() profileEntry {
+		return &accountmanagement.DocumentSet{}
+	},
+	list: func(publicID string) (interface{}, error) {
+		return applicants.NewApplicantRegistry().GetDocumentSetRepository().GetDocumentSets(publicID)
+	},
+	create: func(publicID string, entry profileEntry) error {
+		return applicants.NewApplicantRegistry().GetDocumentSetRepository().CreateDocumentSet(publicID, entry.(*accountmanagement.DocumentSet))
+	},
+	update: func(publicID, id string, entry profileEntry) (bool, error) {
+		entry.(*accountmanagement.DocumentSet).PublicID = id
+		return applicants.NewApplicantRegistry().GetDocumentSetRepository().UpdateDocumentSet(publicID, entry.(*accountmanagement.DocumentSet))
+	},
+	delete: func(publicID, id string) (bool, error) {
+		return applicants.NewApplicantRegistry().GetDocumentSetRepository().DeleteDocumentSet(publicID, id)
+	},
+}
+
+func GetCoverLetters(w http.ResponseWriter, r *http.Request) {
+	listProfileSection(w, r, coverLettersSection)
+}
+
+// AddCoverLetter saves a cover letter template, which is checked by filling it in with an empty job
+func AddCoverLetter(w http.ResponseWriter, r *http.Request) {
+	addProfileEntry(w, r, coverLettersSection)
+}
+
+func UpdateCoverLetter(w http.ResponseWriter, r *http.Request) {
+	updateProfileEntry(w, r, coverLettersSection)
+}
+
+func DeleteCoverLetter(w http.ResponseWriter, r *http.Request) {
+	deleteProfileEntry(w, r, coverLettersSection)
+}
+
+func GetDocumentSets(w http.ResponseWriter, r *http.Request) {
+	listProfileSection(w, r, documentSetsSection)
+}
+
+// AddDocumentSet saves a resume and cover letter to apply with; the applicant's first set is their default
+func AddDocumentSet(w http.ResponseWriter, r *http.Request) {
+	addProfileEntry(w, r, documentSetsSection)
+}
+
+// UpdateDocumentSet changes a document set, and makes it the default when isdefault is true
+func UpdateDocumentSet(w http.ResponseWriter, r *http.Request) {
+	updateProfileEntry(w, r, documentSetsSection)
+}
+
+func DeleteDocumentSet(w http.ResponseWriter, r *http.Request) {
+	deleteProfileEntry(w, r, documentSetsSection)
+}
+
+// applicationDocumentsData chooses the documents to apply for a job with. A resume version or
+// cover letter given by id takes the place of the one in the document set, and the applicant's
+// default set is used when no set is given.
+type applicationDocumentsData struct {
+	JobID         string `json:"jobid"`
+	DocumentSetID string `json:"documentset"`
+	ResumeID      string `json:"resume"`
+	CoverLetterID string `json:"coverletter"`
+}
+
+// applicationCoverLetter is a cover letter filled in for a job
+type applicationCoverLetter struct {
+	PublicID string `json:"id"`
+	Name     string `json:"name"`
+	Text     string `json:"text"`
+}
+
+// GetApplicationDocuments returns the resume and filled in cover letter the applicant has chosen
+// to apply for a job with. Either can be null when nothing was chosen and the document set has none.
+func GetApplicationDocuments(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPost {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	publicID := principal.ApplicantID(r)
+
+	if publicID == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.FriendlyError)
+		return
+	}
+
+	var details applicationDocumentsData
+
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&details)
+
+	if err != nil || details.JobID == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.MissingRequiredValue)
+		return
+	}
+
+	job, err := GetJobFunction(details.JobID)
+
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			response.SendJSONMessage(w, http.StatusNotFound, response.EmptyResult)
+			return
+		}
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	registry := applicants.NewApplicantRegistry()
+
+	set, err := registry.GetDocumentSetRepository().GetDocumentSet(publicID, details.DocumentSetID)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	if set == nil && details.DocumentSetID != "" {
+		response.SendJSONMessage(w, http.StatusNotFound, response.EmptyResult)
+		return
+	}
+
+	var resumeName, coverLetterID string
+
+	if set != nil {
+		resumeName, coverLetterID = set.ResumeName, set.CoverLetterID
+	}
+
+	var chosenResume *accountmanagement.Resume
+
+	switch {
+	case details.ResumeID != "":
+		chosenResume, err = registry.GetResumeRepository().GetResume(publicID, details.ResumeID)
+
+		if err == nil && chosenResume == nil {
+			response.SendJSONMessage(w, http.StatusBadRequest, response.UnknownDocument)
+			return
+		}
+	case resumeName != "":
+		chosenResume, err = registry.GetResumeRepository().GetLatestResume(publicID, resumeName)
+	}
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	if details.CoverLetterID != "" {
+		coverLetterID = details.CoverLetterID
+	}
+
+	var coverLetter *applicationCoverLetter
+
+	if coverLetterID != "" {
+		letter, err := registry.GetCoverLetterRepository().GetCoverLetter(publicID, coverLetterID)
+
+		if err != nil {
+			log.Println(err)
+			response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+			return
+		}
+
+		if letter == nil {
+			response.SendJSONMessage(w, http.StatusBadRequest, response.UnknownDocument)
+			return
+		}
+
+		text, err := letter.Render(job)
+
+		if err != nil {
+			sendFieldViolations(w, []accountmanagement.FieldViolation{{
+				Field:   "coverletter",
+				Code:    accountmanagement.ViolationInvalid,
+				Message: "the cover letter couldn't be filled in: " + err.Error(),
+			}})
+			return
+		}
+
+		coverLetter = &applicationCoverLetter{PublicID: letter.PublicID, Name: letter.Name, Text: text}
+	}
+
+	response.SendJSON(w, map[string]interface{}{
+		"jobid":       job.PublicID,
+		"documentset": set,
+		"resume":      chosenResume,
+		"coverletter": coverLetter,
+	})
+}
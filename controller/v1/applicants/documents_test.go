@@ -0,0 +1,130 @@
+package applicants_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"autumnomous-jobs-applicant-api/controller/v1/applicants"
+	"autumnomous-jobs-applicant-api/route/middleware/acl"
+	hr "autumnomous-jobs-applicant-api/route/middleware/httprouterwrapper"
+	"autumnomous-jobs-applicant-api/shared/repository/jobs"
+	"autumnomous-jobs-applicant-api/shared/services/storage"
+	"autumnomous-jobs-applicant-api/shared/testhelper"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/justinas/alice"
+	"github.com/stretchr/testify/assert"
+)
+
+func postNamedResume(t *testing.T, url, token, name string, data []byte) map[string]interface{} {
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	writer.WriteField("name", name)
+
+	part, err := writer.CreateFormFile("file", name+".docx")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	part.Write(data)
+	writer.Close()
+
+	request, _ := http.NewRequest("POST", url, &body)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	request.Header.Set("Authorization", "Bearer "+token)
+
+	response, err := http.DefaultClient.Do(request)
+
+	if err != nil || response.StatusCode != http.StatusOK {
+		t.Fatal(err)
+	}
+
+	var result map[string]interface{}
+	json.NewDecoder(response.Body).Decode(&result)
+
+	return result
+}
+
+func Test_Applicant_ApplicationDocuments(t *testing.T) {
+	assert := assert.New(t)
+
+	useTestLoginLimiter(t, 10)
+
+	store := storage.NewMemoryStore()
+	applicants.StorageFunction = func() (storage.Store, error) { return store, nil }
+
+	getJob := applicants.GetJobFunction
+
+	applicants.GetJobFunction = func(publicID string) (*jobs.Job, error) {
+		if publicID != "welder" {
+			return nil, errors.New("sql: no rows in result set")
+		}
+		return &jobs.Job{PublicID: "welder", Title: "Welder", CompanyName: "Acme"}, nil
+	}
+
+	t.Cleanup(func() {
+		applicants.StorageFunction = storage.NewSpacesStore
+		applicants.GetJobFunction = getJob
+	})
+
+	r := httprouter.New()
+	r.POST("/login", hr.Handler(alice.New().ThenFunc(applicants.Login)))
+	r.POST("/resume", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.UploadResume)))
+	r.POST("/cover-letters", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.AddCoverLetter)))
+	r.POST("/document-sets", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.AddDocumentSet)))
+	r.POST("/application-documents", hr.Handler(alice.New(acl.ValidateJWT).ThenFunc(applicants.GetApplicationDocuments)))
+	r.GET("/get", hr.Handler(alice.New(acl.ValidateJWT).ThenFunc(applicants.GetApplicant)))
+
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	token := sessionLogin(t, ts.URL+"/login", "browser", applicant)
+
+	engineering := postNamedResume(t, ts.URL+"/resume", token, "Engineering", testResumeDOCX(t, "Engineer"))["resume"].(map[string]interface{})
+	management := postNamedResume(t, ts.URL+"/resume", token, "Management", testResumeDOCX(t, "Manager"))["resume"].(map[string]interface{})
+	assert.Equal(float64(1), management["version"])
+
+	response, _ := sendJSONWithSessionToken(t, "POST", ts.URL+"/cover-letters", token, map[string]string{"name": "Typo", "body": "Dear {{.Company}}"})
+	assert.Equal(http.StatusBadRequest, response.StatusCode)
+
+	response, letter := sendJSONWithSessionToken(t, "POST", ts.URL+"/cover-letters", token, map[string]string{"name": "Engineering", "body": "Dear {{.CompanyName}}, hire me as your {{.Title}}."})
+	assert.Equal(http.StatusOK, response.StatusCode)
+
+	response, _ = sendJSONWithSessionToken(t, "POST", ts.URL+"/document-sets", token, map[string]string{"name": "Sales", "resume": "Sales"})
+	assert.Equal(http.StatusBadRequest, response.StatusCode)
+
+	response, set := sendJSONWithSessionToken(t, "POST", ts.URL+"/document-sets", token, map[string]string{"name": "Engineering", "resume": "Engineering", "coverletter": letter["id"].(string)})
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Equal(true, set["isdefault"])
+
+	// the default set is used when none is chosen
+	response, result := sendJSONWithSessionToken(t, "POST", ts.URL+"/application-documents", token, map[string]string{"jobid": "welder"})
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Equal(engineering["id"], result["resume"].(map[string]interface{})["id"])
+	assert.Equal("Dear Acme, hire me as your Welder.", result["coverletter"].(map[string]interface{})["text"])
+
+	// a chosen resume replaces the set's
+	response, result = sendJSONWithSessionToken(t, "POST", ts.URL+"/application-documents", token, map[string]string{"jobid": "welder", "resume": management["id"].(string)})
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Equal(management["id"], result["resume"].(map[string]interface{})["id"])
+	assert.NotNil(result["coverletter"])
+
+	response, _ = sendJSONWithSessionToken(t, "POST", ts.URL+"/application-documents", token, map[string]string{"jobid": "missing"})
+	assert.Equal(http.StatusNotFound, response.StatusCode)
+
+	response, _ = sendJSONWithSessionToken(t, "POST", ts.URL+"/application-documents", token, map[string]string{"jobid": "welder", "coverletter": "not-an-id"})
+	assert.Equal(http.StatusBadRequest, response.StatusCode)
+
+	response, result = sendWithSessionToken(t, "GET", ts.URL+"/get?include=coverletters,documentsets", token)
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Len(result["coverletters"], 1)
+	assert.Len(result["documentsets"], 1)
+}
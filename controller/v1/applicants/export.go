@@ -0,0 +1,474 @@
+package applicants
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	hr "autumnomous-jobs-applicant-api/route/middleware/httprouterwrapper"
+	applicants "autumnomous-jobs-applicant-api/shared/repository/applicants"
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+	"autumnomous-jobs-applicant-api/shared/response"
+	"autumnomous-jobs-applicant-api/shared/services/audit"
+	"autumnomous-jobs-applicant-api/shared/services/dataexport"
+	"autumnomous-jobs-applicant-api/shared/services/security/principal"
+	"autumnomous-jobs-applicant-api/shared/services/storage"
+)
+
+var SendDataExportReadyMessageFunction = SendDataExportReadyMessage
+
+// exportBatchSize is how many exports one pass of the worker builds or expires
+const exportBatchSize = 5
+
+// exportNotes explain data the export doesn't include, and are written to its manifest
+var exportNotes = map[string]string{
+	"savedjobs": "Saved jobs aren't stored by BiT Jobs, so there are none to export.",
+	"passwords": "Passwords and security codes are only kept as one-way hashes and aren't exported.",
+}
+
+// RequestDataExport queues a copy of everything held about the applicant. They are emailed when it
+// is ready to download.
+func RequestDataExport(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPost {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	publicID := principal.ApplicantID(r)
+
+	if publicID == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.FriendlyError)
+		return
+	}
+
+	export, err := QueueDataExport(r, publicID)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	response.SendJSON(w, export)
+}
+
+// QueueDataExport queues an export of the applicant's data on behalf of whoever made request r
+func QueueDataExport(r *http.Request, publicID string) (*accountmanagement.DataExport, error) {
+
+	export, err := applicants.NewApplicantRegistry().GetExportRepository().CreateExport(publicID, audit.Actor(r))
+
+	if err != nil {
+		return nil, err
+	}
+
+	audit.Record(r, accountmanagement.AuditDataExportRequested, publicID, nil)
+
+	return export, nil
+}
+
+// GetDataExports lists the applicant's exports, newest first
+func GetDataExports(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	publicID := principal.ApplicantID(r)
+
+	if publicID == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.FriendlyError)
+		return
+	}
+
+	exports, err := applicants.NewApplicantRegistry().GetExportRepository().GetExports(publicID)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	response.SendJSON(w, map[string]interface{}{"exports": exports})
+}
+
+// DownloadDataExport sends the ZIP of a ready export. Only the applicant can download it, not
+// staff impersonating them.
+func DownloadDataExport(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	current := principal.FromRequest(r)
+
+	if current == nil || current.ApplicantID == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.FriendlyError)
+		return
+	}
+
+	if current.IsImpersonation() {
+		response.SendJSONMessage(w, http.StatusForbidden, response.Forbidden)
+		return
+	}
+
+	export, err := applicants.NewApplicantRegistry().GetExportRepository().GetExport(current.ApplicantID, hr.Params(r).ByName("id"))
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	if export == nil || export.Status != accountmanagement.ExportReady || export.ExpiresAt == nil || export.ExpiresAt.Before(time.Now()) {
+		response.SendJSONMessage(w, http.StatusNotFound, response.EmptyResult)
+		return
+	}
+
+	store, err := StorageFunction()
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	body, err := store.Get(export.ObjectKey)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	defer body.Close()
+
+	audit.Record(r, accountmanagement.AuditDataExportDownloaded, current.ApplicantID, nil)
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="bitjobs-data-%s.zip"`, export.CompletedAt.UTC().Format("2006-01-02")))
+
+	if export.Size > 0 {
+		w.Header().Set("Content-Length", fmt.Sprint(export.Size))
+	}
+
+	_, err = io.Copy(w, body)
+
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// RunDataExports builds queued exports and removes expired ones every interval until the process exits
+func RunDataExports(interval time.Duration) {
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		_, err := ProcessDataExports()
+
+		if err != nil {
+			log.Println(err)
+		}
+
+		err = ExpireDataExports()
+
+		if err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// ProcessDataExports builds the queued exports, stores each privately and emails the applicant that
+// it's ready. It returns the exports it finished. An export that fails is marked failed so the
+// applicant can ask again.
+func ProcessDataExports() ([]*accountmanagement.DataExport, error) {
+
+	exportRepository := applicants.NewApplicantRegistry().GetExportRepository()
+
+	exports, err := exportRepository.ClaimExports(exportBatchSize)
+
+	if err != nil {
+		return nil, err
+	}
+
+	finished := []*accountmanagement.DataExport{}
+
+	for _, export := range exports {
+		err = processDataExport(export)
+
+		if err != nil {
+			log.Println("data export", export.PublicID, "failed:", err)
+
+			err = exportRepository.FailExport(export)
+
+			if err != nil {
+				log.Println(err)
+			}
+			continue
+		}
+
+		finished = append(finished, export)
+	}
+
+	return finished, nil
+}
+
+func processDataExport(export *accountmanagement.DataExport) error {
+
+	registry := applicants.NewApplicantRegistry()
+
+	applicant, err := registry.GetApplicantRepository().GetApplicant(export.ApplicantPublicID)
+
+	if err != nil {
+		return err
+	}
+
+	archive, err := exportArchive(export, applicant)
+
+	if err != nil {
+		return err
+	}
+
+	store, err := StorageFunction()
+
+	if err != nil {
+		return err
+	}
+
+	file, err := ioutil.TempFile("", "dataexport-*.zip")
+
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	err = archive.Write(file, store)
+
+	if err != nil {
+		return err
+	}
+
+	size, err := file.Seek(0, io.SeekCurrent)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Seek(0, io.SeekStart)
+
+	if err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf("exports/%s/%s.zip", export.ApplicantPublicID, export.PublicID)
+
+	err = store.Put(key, file, "application/zip", false)
+
+	if err != nil {
+		return err
+	}
+
+	err = registry.GetExportRepository().CompleteExport(export, key, size)
+
+	if err != nil {
+		return err
+	}
+
+	domain := os.Getenv("MAILGUN_DOMAIN")
+	apiKey := os.Getenv("MAILGUN_API_KEY")
+	_, err = SendDataExportReadyMessageFunction(domain, apiKey, export, applicant)
+
+	if err != nil {
+		log.Println(err)
+	}
+
+	return nil
+}
+
+// exportArchive collects everything held about the applicant
+func exportArchive(export *accountmanagement.DataExport, applicant *accountmanagement.Applicant) (*dataexport.Archive, error) {
+
+	registry := applicants.NewApplicantRegistry()
+	publicID := export.ApplicantPublicID
+
+	profile := accountFields(applicant)
+	profile["publicid"] = publicID
+	profile["email"] = applicant.Email
+	profile["emailverified"] = applicant.EmailVerified
+	profile["registrationstep"] = applicant.RegistrationStep
+
+	desiredCities, err := registry.GetExportRepository().GetDesiredCities(publicID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	preferences, err := registry.GetApplicantRepository().GetApplicantJobPreferences(publicID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	applications, err := registry.GetExportRepository().GetApplications(publicID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	uploads, err := registry.GetUploadRepository().GetUploads(publicID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	resumes, err := registry.GetResumeRepository().GetResumes(publicID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	files := applicantFiles(uploads, resumes)
+
+	events, err := allAuditEvents(publicID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sections := map[string]interface{}{
+		"profile":        profile,
+		"desiredcities":  desiredCities,
+		"jobpreferences": preferences,
+		"applications":   applications,
+		"uploads":        uploads,
+		"resumes":        resumes,
+		"auditevents":    events,
+	}
+
+	profileSections, _ := includedSections("all")
+
+	for _, section := range profileSections {
+		sections[section.name], err = section.list(publicID)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &dataexport.Archive{
+		ExportID:    export.PublicID,
+		ApplicantID: publicID,
+		CreatedAt:   time.Now().UTC(),
+		Sections:    sections,
+		Files:       files,
+		Notes:       exportNotes,
+	}, nil
+}
+
+// applicantFiles lists the applicant's files in Spaces: their uploads and every version of their
+// resumes, each once
+func applicantFiles(uploads []*accountmanagement.Upload, resumes []*accountmanagement.Resume) []*accountmanagement.Upload {
+
+	files := []*accountmanagement.Upload{}
+	seen := map[string]bool{}
+
+	add := func(file *accountmanagement.Upload) {
+		if file.ObjectKey != "" && !seen[file.ObjectKey] {
+			seen[file.ObjectKey] = true
+			files = append(files, file)
+		}
+	}
+
+	for _, upload := range uploads {
+		add(upload)
+	}
+
+	for _, resume := range resumes {
+		add(&accountmanagement.Upload{
+			ObjectKey:   resume.ObjectKey,
+			FileName:    resume.FileName,
+			ContentType: resume.ContentType,
+			Size:        resume.Size,
+			CreateDate:  resume.CreateDate,
+		})
+	}
+
+	return files
+}
+
+// allAuditEvents pages through every audit event about the applicant
+func allAuditEvents(publicID string) ([]*accountmanagement.AuditEvent, error) {
+
+	repository := applicants.NewApplicantRegistry().GetAuditRepository()
+	filter := accountmanagement.AuditFilter{TargetID: publicID, Limit: accountmanagement.MaxAuditPageSize}
+	events := []*accountmanagement.AuditEvent{}
+
+	for {
+		page, err := repository.GetAuditEvents(filter)
+
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, page...)
+
+		if len(page) < filter.Limit {
+			return events, nil
+		}
+
+		filter.BeforeID = page[len(page)-1].ID
+	}
+}
+
+// ExpireDataExports deletes the archives of exports that can no longer be downloaded
+func ExpireDataExports() error {
+
+	exportRepository := applicants.NewApplicantRegistry().GetExportRepository()
+
+	exports, err := exportRepository.GetExpiredExports(exportBatchSize)
+
+	if err != nil || len(exports) == 0 {
+		return err
+	}
+
+	store, err := StorageFunction()
+
+	if err != nil {
+		return err
+	}
+
+	for _, export := range exports {
+		err = store.Delete(export.ObjectKey)
+
+		if err != nil && err != storage.ErrNotFound {
+			return err
+		}
+
+		err = exportRepository.ExpireExport(export)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func SendDataExportReadyMessage(domain, apiKey string, export *accountmanagement.DataExport, applicant *accountmanagement.Applicant) (string, error) {
+
+	link := fmt.Sprintf("%s/data-exports/%s", os.Getenv("APPLICANT_SITE_URL"), export.PublicID)
+	message := fmt.Sprintf("Hi %s,\nThe copy of your BiT Jobs data you asked for is ready. Log in and use the link below to download it before %s:\n%s\n\nIf you didn't ask for this, please reset your password.",
+		applicant.FirstName, export.ExpiresAt.UTC().Format("2 January 2006"), link)
+
+	return sendMessage(domain, apiKey, "Your BiT Jobs data is ready to download", message, applicant.Email)
+}
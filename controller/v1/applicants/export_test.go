@@ -0,0 +1,101 @@
+package applicants_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"autumnomous-jobs-applicant-api/controller/v1/applicants"
+	"autumnomous-jobs-applicant-api/route/middleware/acl"
+	hr "autumnomous-jobs-applicant-api/route/middleware/httprouterwrapper"
+	"autumnomous-jobs-applicant-api/shared/database"
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+	"autumnomous-jobs-applicant-api/shared/services/storage"
+	"autumnomous-jobs-applicant-api/shared/testhelper"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/justinas/alice"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Applicant_DataExport(t *testing.T) {
+	assert := assert.New(t)
+
+	useTestLoginLimiter(t, 10)
+
+	store := storage.NewMemoryStore()
+	applicants.StorageFunction = func() (storage.Store, error) { return store, nil }
+
+	var emailed *accountmanagement.DataExport
+
+	applicants.SendDataExportReadyMessageFunction = func(domain, apiKey string, export *accountmanagement.DataExport, applicant *accountmanagement.Applicant) (string, error) {
+		emailed = export
+		return "", nil
+	}
+
+	t.Cleanup(func() {
+		applicants.StorageFunction = storage.NewSpacesStore
+		applicants.SendDataExportReadyMessageFunction = applicants.SendDataExportReadyMessage
+	})
+
+	r := httprouter.New()
+	r.POST("/login", hr.Handler(alice.New().ThenFunc(applicants.Login)))
+	r.POST("/data-export", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.RequestDataExport)))
+	r.GET("/data-exports/:id", hr.Handler(alice.New(acl.ValidateJWT).ThenFunc(applicants.DownloadDataExport)))
+
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	key := "uploads/" + applicant.PublicID + ".png"
+
+	store.Put(key, bytes.NewReader([]byte("picture")), "image/png", true)
+
+	err := accountmanagement.NewUploadRepository(database.DB).CreateUpload(applicant.PublicID, key, "me.png", "image/png", 7)
+	assert.Nil(err)
+
+	createResumeFile(t, store, applicant, "resumes/"+applicant.PublicID+"/cv.pdf")
+
+	token := sessionLogin(t, ts.URL+"/login", "browser", applicant)
+
+	response, result := sendWithSessionToken(t, "POST", ts.URL+"/data-export", token)
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Equal(accountmanagement.ExportPending, result["status"])
+
+	exportID := result["id"].(string)
+
+	// not ready until the worker has built it
+	response, _ = sendWithSessionToken(t, "GET", ts.URL+"/data-exports/"+exportID, token)
+	assert.Equal(http.StatusNotFound, response.StatusCode)
+
+	_, err = applicants.ProcessDataExports()
+	assert.Nil(err)
+
+	if assert.NotNil(emailed) {
+		assert.Equal(exportID, emailed.PublicID)
+	}
+
+	request, _ := http.NewRequest("GET", ts.URL+"/data-exports/"+exportID, nil)
+	request.Header.Set("Authorization", "Bearer "+token)
+
+	response, err = http.DefaultClient.Do(request)
+	assert.Nil(err)
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Equal("application/zip", response.Header.Get("Content-Type"))
+
+	data, _ := ioutil.ReadAll(response.Body)
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+
+	if assert.Nil(err) {
+		names := []string{}
+
+		for _, file := range archive.File {
+			names = append(names, file.Name)
+		}
+
+		assert.Subset(names, []string{"manifest.json", "profile.json", "desiredcities.json", "applications.json", "uploads.json", "auditevents.json", "files/1-me.png", "files/2-cv.pdf"})
+	}
+}
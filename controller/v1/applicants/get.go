@@ -2,14 +2,16 @@ package applicants
 
 import (
 	"autumnomous-jobs-applicant-api/shared/repository/applicants"
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
 	"autumnomous-jobs-applicant-api/shared/repository/jobs"
 	"autumnomous-jobs-applicant-api/shared/response"
-	"autumnomous-jobs-applicant-api/shared/services/security/jwt"
+	"autumnomous-jobs-applicant-api/shared/services/security/principal"
 	"autumnomous-jobs-applicant-api/shared/services/zipcode"
 	"encoding/json"
 	"log"
 	"net/http"
 	"os"
+	"strings"
 )
 
 type AutocompleteLocationData struct {
@@ -21,6 +23,9 @@ type GetJobsByRadiusData struct {
 	Radius  float64 `json:"radius"`
 }
 
+// GetApplicant returns the applicant's account. ?include= embeds sections of their profile, as a
+// comma separated list of workexperience, education, certifications, skills, coverletters and
+// documentsets, or "all".
 func GetApplicant(w http.ResponseWriter, r *http.Request) {
 
 	if r.Method != http.MethodGet {
@@ -28,7 +33,7 @@ func GetApplicant(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	publicID := jwt.GetUserClaim(r)
+	publicID := principal.ApplicantID(r)
 
 	if publicID == "" {
 
@@ -36,6 +41,13 @@ func GetApplicant(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	sections, ok := includedSections(r.URL.Query().Get("include"))
+
+	if !ok {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.InvalidQuery)
+		return
+	}
+
 	repository := applicants.NewApplicantRegistry().GetApplicantRepository()
 
 	employer, err := repository.GetApplicant(publicID)
@@ -46,7 +58,88 @@ func GetApplicant(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response.SendJSON(w, employer)
+	if len(sections) == 0 {
+		response.SendJSON(w, employer)
+		return
+	}
+
+	profile := &applicantProfile{Applicant: employer}
+
+	for _, section := range sections {
+		entries, err := section.list(publicID)
+
+		if err != nil {
+			log.Println(err)
+			response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+			return
+		}
+
+		profile.set(section.name, entries)
+	}
+
+	response.SendJSON(w, profile)
+}
+
+// applicantProfile is an applicant with the sections of their profile asked for with ?include=
+type applicantProfile struct {
+	*accountmanagement.Applicant
+	WorkExperience interface{} `json:"workexperience,omitempty"`
+	Education      interface{} `json:"education,omitempty"`
+	Certifications interface{} `json:"certifications,omitempty"`
+	Skills         interface{} `json:"skills,omitempty"`
+	CoverLetters   interface{} `json:"coverletters,omitempty"`
+	DocumentSets   interface{} `json:"documentsets,omitempty"`
+}
+
+func (profile *applicantProfile) set(name string, entries interface{}) {
+	switch name {
+	case workExperienceSection.name:
+		profile.WorkExperience = entries
+	case educationSection.name:
+		profile.Education = entries
+	case certificationsSection.name:
+		profile.Certifications = entries
+	case skillsSection.name:
+		profile.Skills = entries
+	case coverLettersSection.name:
+		profile.CoverLetters = entries
+	case documentSetsSection.name:
+		profile.DocumentSets = entries
+	}
+}
+
+// includedSections reads a comma separated list of profile sections, or "all". It returns false if
+// any of them isn't a section.
+func includedSections(include string) ([]profileSection, bool) {
+
+	all := []profileSection{workExperienceSection, educationSection, certificationsSection, skillsSection, coverLettersSection, documentSetsSection}
+
+	if include == "" {
+		return nil, true
+	}
+
+	if include == "all" {
+		return all, true
+	}
+
+	sections := []profileSection{}
+
+	for _, name := range strings.Split(include, ",") {
+		found := false
+
+		for _, section := range all {
+			if section.name == strings.TrimSpace(name) {
+				sections = append(sections, section)
+				found = true
+			}
+		}
+
+		if !found {
+			return nil, false
+		}
+	}
+
+	return sections, true
 }
 
 func GetAutocompleteLocationData(w http.ResponseWriter, r *http.Request) {
@@ -2,7 +2,9 @@ package applicants_test
 
 import (
 	"autumnomous-jobs-applicant-api/controller/v1/applicants"
+	"autumnomous-jobs-applicant-api/route/middleware/acl"
 	"autumnomous-jobs-applicant-api/shared/services/security/jwt"
+	"autumnomous-jobs-applicant-api/shared/services/security/principal"
 	"autumnomous-jobs-applicant-api/shared/testhelper"
 	"bytes"
 	"encoding/base64"
@@ -20,7 +22,7 @@ func init() {
 
 func Test_Applicant_GetApplicant_Correct(t *testing.T) {
 	assert := assert.New(t)
-	ts := httptest.NewServer(http.HandlerFunc(applicants.GetApplicant))
+	ts := httptest.NewServer(acl.ValidateJWT(http.HandlerFunc(applicants.GetApplicant)))
 
 	defer ts.Close()
 
@@ -108,7 +110,7 @@ func Test_Applicant_GetApplicant_Incorrect(t *testing.T) {
 
 func Test_Applicant_GetAutocompleteLocationData_Correct(t *testing.T) {
 	assert := assert.New(t)
-	ts := httptest.NewServer(http.HandlerFunc(applicants.GetAutocompleteLocationData))
+	ts := httptest.NewServer(acl.ValidateJWT(http.HandlerFunc(applicants.GetAutocompleteLocationData)))
 
 	defer ts.Close()
 
@@ -193,7 +195,7 @@ func Test_Applicant_GetAutocompleteLocationData_IncorrectMethod(t *testing.T) {
 func Test_Applicant_GetJobs_Correct(t *testing.T) {
 	assert := assert.New(t)
 
-	ts := httptest.NewServer(http.HandlerFunc(applicants.GetJobs))
+	ts := httptest.NewServer(acl.ValidateJWT(http.HandlerFunc(applicants.GetJobs)))
 
 	defer ts.Close()
 
@@ -262,7 +264,7 @@ func Test_Applicant_GetJobs_IncorrectMethod(t *testing.T) {
 func Test_Applicant_GetJobsByRadius_Correct(t *testing.T) {
 	assert := assert.New(t)
 
-	ts := httptest.NewServer(http.HandlerFunc(applicants.GetJobsByRadius))
+	ts := httptest.NewServer(acl.ValidateJWT(http.HandlerFunc(applicants.GetJobsByRadius)))
 
 	defer ts.Close()
 
@@ -343,3 +345,22 @@ func Test_Applicant_GetJobsByRadius_IncorrectMethod(t *testing.T) {
 	}
 
 }
+
+func Test_Applicant_GetApplicant_InjectedPrincipal(t *testing.T) {
+	assert := assert.New(t)
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+
+	request := httptest.NewRequest("GET", "/applicant/get", nil)
+	request = principal.WithPrincipal(request, &principal.Principal{ApplicantID: applicant.PublicID, AuthMethod: principal.AuthMethodPassword})
+
+	recorder := httptest.NewRecorder()
+	applicants.GetApplicant(recorder, request)
+
+	var result map[string]interface{}
+	err := json.NewDecoder(recorder.Body).Decode(&result)
+
+	assert.Nil(err)
+	assert.Equal(http.StatusOK, recorder.Code)
+	assert.Equal(applicant.Email, result["email"])
+}
@@ -0,0 +1,273 @@
+package applicants
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	applicants "autumnomous-jobs-applicant-api/shared/repository/applicants"
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+	"autumnomous-jobs-applicant-api/shared/response"
+	"autumnomous-jobs-applicant-api/shared/services/security/encryption"
+	"autumnomous-jobs-applicant-api/shared/services/security/lockout"
+	"autumnomous-jobs-applicant-api/shared/services/utils"
+)
+
+var LoginLimiterFunction = LoginLimiter
+var EmailLinkLimiterFunction = EmailLinkLimiter
+var SendUnlockMessageFunction = SendUnlockMessage
+
+type unlockAccountData struct {
+	Token string `json:"token"`
+}
+
+// LoginLimiter tracks failed logins using the limits in LOGIN_EMAIL_* and LOGIN_IP_*
+func LoginLimiter() *lockout.Limiter {
+	return lockout.NewLimiter(
+		applicants.NewApplicantRegistry().GetLoginAttemptStore(),
+		lockout.PolicyFromEnv("LOGIN_EMAIL", lockout.DefaultEmailPolicy),
+		lockout.PolicyFromEnv("LOGIN_IP", lockout.DefaultIPPolicy),
+	)
+}
+
+// emailLinkEmailPolicy lets one address be sent a password reset or sign-in link every few minutes
+var emailLinkEmailPolicy = lockout.Policy{
+	MaxAttempts:     5,
+	Window:          time.Hour,
+	LockoutDuration: time.Hour,
+	FreeAttempts:    1,
+	BaseDelay:       30 * time.Second,
+	MaxDelay:        10 * time.Minute,
+}
+
+// emailLinkIPPolicy stops one address from mailing links to a long list of accounts
+var emailLinkIPPolicy = lockout.Policy{
+	MaxAttempts:     100,
+	Window:          time.Hour,
+	LockoutDuration: time.Hour,
+	FreeAttempts:    20,
+	BaseDelay:       time.Second,
+	MaxDelay:        30 * time.Second,
+}
+
+// EmailLinkLimiter counts requests for emailed password reset and sign-in links using the limits
+// in EMAIL_LINK_EMAIL_* and EMAIL_LINK_IP_*. It shares the login attempt store under its own namespace.
+func EmailLinkLimiter() *lockout.Limiter {
+
+	limiter := lockout.NewLimiter(
+		applicants.NewApplicantRegistry().GetLoginAttemptStore(),
+		lockout.PolicyFromEnv("EMAIL_LINK_EMAIL", emailLinkEmailPolicy),
+		lockout.PolicyFromEnv("EMAIL_LINK_IP", emailLinkIPPolicy),
+	)
+	limiter.Namespace = "email-link:"
+
+	return limiter
+}
+
+// allowEmailLink counts a request to email a link to email. When the address or the requester
+// has asked too often it responds with 429 and returns false. Requests are counted whether or
+// not the email belongs to an account, so the limit doesn't reveal which addresses are registered.
+func allowEmailLink(w http.ResponseWriter, email, ip string) bool {
+
+	limiter := EmailLinkLimiterFunction()
+
+	decision, err := limiter.Check(email, ip)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return false
+	}
+
+	if !decision.Allowed {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(decision.RetryAfter.Seconds()))))
+		response.SendJSONMessage(w, http.StatusTooManyRequests, response.TooManyRequests)
+		return false
+	}
+
+	_, err = limiter.Fail(email, ip)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return false
+	}
+
+	return true
+}
+
+// checkLoginAllowed responds with 429 and returns false when the email or address has to wait
+func checkLoginAllowed(w http.ResponseWriter, limiter *lockout.Limiter, email, ip string) bool {
+
+	decision, err := limiter.Check(email, ip)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return false
+	}
+
+	if decision.Allowed {
+		return true
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(decision.RetryAfter.Seconds()))))
+
+	if decision.EmailLocked {
+		response.SendJSONMessage(w, http.StatusTooManyRequests, response.AccountLocked)
+		return false
+	}
+
+	response.SendJSONMessage(w, http.StatusTooManyRequests, response.TooManyAttempts)
+	return false
+}
+
+// recordLoginFailure counts a failed attempt, and when it locks the email or address
+// writes the lockout to the audit table and emails the account owner an unlock link
+func recordLoginFailure(limiter *lockout.Limiter, email, ip string) error {
+
+	result, err := limiter.Fail(email, ip)
+
+	if err != nil {
+		return err
+	}
+
+	lockoutRepository := applicants.NewApplicantRegistry().GetLockoutRepository()
+
+	if result.IPLocked {
+		err = lockoutRepository.RecordLockoutEvent(accountmanagement.AddressLocked, email, ip)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if !result.EmailLocked {
+		return nil
+	}
+
+	err = lockoutRepository.RecordLockoutEvent(accountmanagement.AccountLocked, email, ip)
+
+	if err != nil {
+		return err
+	}
+
+	return sendUnlock(email)
+}
+
+// sendUnlock emails an unlock link if email belongs to an account
+func sendUnlock(email string) error {
+
+	applicant, err := applicants.NewApplicantRegistry().GetApplicantRepository().GetApplicantByEmail(email)
+
+	if err != nil || applicant == nil {
+		return err
+	}
+
+	tokenRepository := applicants.NewApplicantRegistry().GetTokenRepository()
+
+	err = tokenRepository.RevokeTokens(applicant.PublicID, accountmanagement.AccountUnlock)
+
+	if err != nil {
+		return err
+	}
+
+	token, tokenHash, err := encryption.NewSecureToken()
+
+	if err != nil {
+		return err
+	}
+
+	err = tokenRepository.CreateToken(applicant.PublicID, accountmanagement.AccountUnlock, tokenHash, accountmanagement.AccountUnlockTokenLifetime)
+
+	if err != nil {
+		return err
+	}
+
+	domain := os.Getenv("MAILGUN_DOMAIN")
+	apiKey := os.Getenv("MAILGUN_API_KEY")
+	_, err = SendUnlockMessageFunction(domain, apiKey, token, applicant)
+
+	return err
+}
+
+// UnlockAccount redeems an emailed unlock token and clears the account's failed logins
+func UnlockAccount(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPost {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	var data unlockAccountData
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&data)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	if data.Token == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.MissingRequiredValue)
+		return
+	}
+
+	publicID, err := applicants.NewApplicantRegistry().GetTokenRepository().RedeemToken(accountmanagement.AccountUnlock, encryption.HashToken(data.Token))
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	if publicID == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.InvalidToken)
+		return
+	}
+
+	applicant, err := applicants.NewApplicantRegistry().GetApplicantRepository().GetApplicant(publicID)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	err = UnlockApplicant(applicant, utils.ClientIP(r))
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	response.SendJSONMessage(w, http.StatusOK, response.Success)
+}
+
+// UnlockApplicant clears the applicant's failed logins and records the unlock in the lockout audit table
+func UnlockApplicant(applicant *accountmanagement.Applicant, ip string) error {
+
+	err := LoginLimiterFunction().Unlock(applicant.Email)
+
+	if err != nil {
+		return err
+	}
+
+	return applicants.NewApplicantRegistry().GetLockoutRepository().RecordLockoutEvent(accountmanagement.AccountUnlocked, applicant.Email, ip)
+}
+
+// SendUnlockMessage emails the applicant a link that unlocks their account
+func SendUnlockMessage(domain, apiKey, token string, applicant *accountmanagement.Applicant) (string, error) {
+
+	link := fmt.Sprintf("%s/unlock-account?token=%s", os.Getenv("APPLICANT_SITE_URL"), token)
+	message := fmt.Sprintf("Hi %s,\nWe've temporarily locked your BiT Jobs account after several failed login attempts. If that was you, use the link below to unlock it now:\n%s\n\nIf it wasn't you, we recommend resetting your password.", applicant.FirstName, link)
+
+	return sendMessage(domain, apiKey, "Your BiT Jobs account has been locked", message, applicant.Email)
+}
@@ -0,0 +1,119 @@
+package applicants_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"autumnomous-jobs-applicant-api/controller/v1/applicants"
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+	"autumnomous-jobs-applicant-api/shared/services/security/lockout"
+	"autumnomous-jobs-applicant-api/shared/testhelper"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func useTestLoginLimiter(t *testing.T, maxAttempts int) {
+
+	limiter := lockout.NewLimiter(lockout.NewMemoryStore(), lockout.Policy{
+		MaxAttempts:     maxAttempts,
+		Window:          time.Minute,
+		LockoutDuration: time.Minute,
+		FreeAttempts:    maxAttempts,
+	}, lockout.DefaultIPPolicy)
+
+	applicants.LoginLimiterFunction = func() *lockout.Limiter { return limiter }
+
+	t.Cleanup(func() {
+		applicants.LoginLimiterFunction = applicants.LoginLimiter
+	})
+}
+
+// useTestEmailLinkLimiter keeps emailed link requests in memory so earlier test runs can't slow them down
+func useTestEmailLinkLimiter(t *testing.T) {
+
+	limiter := applicants.EmailLinkLimiter()
+	limiter.Store = lockout.NewMemoryStore()
+
+	applicants.EmailLinkLimiterFunction = func() *lockout.Limiter { return limiter }
+
+	t.Cleanup(func() {
+		applicants.EmailLinkLimiterFunction = applicants.EmailLinkLimiter
+	})
+}
+
+func Test_ApplicantLogin_LockoutAndUnlock(t *testing.T) {
+	assert := assert.New(t)
+
+	useTestLoginLimiter(t, 3)
+
+	var unlockToken string
+	applicants.SendUnlockMessageFunction = func(domain, apiKey, token string, applicant *accountmanagement.Applicant) (string, error) {
+		unlockToken = token
+		return "", nil
+	}
+
+	defer func() {
+		applicants.SendUnlockMessageFunction = applicants.SendUnlockMessage
+	}()
+
+	login := httptest.NewServer(http.HandlerFunc(applicants.Login))
+	defer login.Close()
+
+	unlock := httptest.NewServer(http.HandlerFunc(applicants.UnlockAccount))
+	defer unlock.Close()
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+
+	for i := 0; i < 3; i++ {
+		response, _ := postJSONWithToken(t, login.URL, "", map[string]string{"email": applicant.Email, "password": "wrong-password"})
+		assert.Equal(http.StatusUnauthorized, response.StatusCode)
+	}
+
+	assert.NotEmpty(unlockToken)
+
+	// the correct password is refused while the account is locked
+	response, result := postJSONWithToken(t, login.URL, "", map[string]string{"email": applicant.Email, "password": applicant.Password})
+	assert.Equal(http.StatusTooManyRequests, response.StatusCode)
+	assert.NotEmpty(response.Header.Get("Retry-After"))
+	assert.Equal("This account is temporarily locked. Check your email for a link to unlock it.", result["message"])
+
+	response, _ = postJSONWithToken(t, unlock.URL, "", map[string]string{"token": "not-a-token"})
+	assert.Equal(http.StatusBadRequest, response.StatusCode)
+
+	response, _ = postJSONWithToken(t, unlock.URL, "", map[string]string{"token": unlockToken})
+	assert.Equal(http.StatusOK, response.StatusCode)
+
+	response, result = postJSONWithToken(t, login.URL, "", map[string]string{"email": applicant.Email, "password": applicant.Password})
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.NotEmpty(result["token"])
+
+	// the unlock link is single use
+	response, _ = postJSONWithToken(t, unlock.URL, "", map[string]string{"token": unlockToken})
+	assert.Equal(http.StatusBadRequest, response.StatusCode)
+}
+
+func Test_Applicant_UnlockAccount_IncorrectMethod(t *testing.T) {
+	assert := assert.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(applicants.UnlockAccount))
+	defer ts.Close()
+
+	for _, method := range []string{"GET", "PUT", "DELETE"} {
+
+		request, err := http.NewRequest(method, ts.URL, nil)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		response, err := http.DefaultClient.Do(request)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(http.StatusMethodNotAllowed, response.StatusCode)
+	}
+}
@@ -0,0 +1,104 @@
+package applicants
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	applicants "autumnomous-jobs-applicant-api/shared/repository/applicants"
+	"autumnomous-jobs-applicant-api/shared/response"
+	"autumnomous-jobs-applicant-api/shared/services/security/encryption"
+	"autumnomous-jobs-applicant-api/shared/services/security/principal"
+)
+
+type logoutData struct {
+	RefreshToken string `json:"refreshtoken"`
+}
+
+// Logout revokes the access token used for the request and ends its session and, when supplied,
+// the refresh token family issued with it
+func Logout(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPost {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	current := principal.FromRequest(r)
+
+	if current == nil || current.ApplicantID == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.FriendlyError)
+		return
+	}
+
+	publicID := current.ApplicantID
+
+	var data logoutData
+	if r.Body != nil {
+		json.NewDecoder(r.Body).Decode(&data)
+	}
+
+	revocationRepository := applicants.NewApplicantRegistry().GetRevocationRepository()
+
+	if current.TokenID != "" {
+		err := revocationRepository.RevokeToken(publicID, current.TokenID, current.ExpiresAt)
+
+		if err != nil {
+			log.Println(err)
+			response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+			return
+		}
+	}
+
+	if current.SessionID != "" {
+		_, err := applicants.NewApplicantRegistry().GetSessionRepository().RevokeSession(publicID, current.SessionID)
+
+		if err != nil {
+			log.Println(err)
+			response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+			return
+		}
+	}
+
+	if data.RefreshToken != "" {
+		refreshTokenRepository := applicants.NewApplicantRegistry().GetRefreshTokenRepository()
+
+		err := refreshTokenRepository.RevokeRefreshTokenFamily(publicID, encryption.HashToken(data.RefreshToken))
+
+		if err != nil {
+			log.Println(err)
+			response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+			return
+		}
+	}
+
+	response.SendJSONMessage(w, http.StatusOK, response.Success)
+}
+
+// LogoutAll revokes every access and refresh token the applicant holds
+func LogoutAll(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPost {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	publicID := principal.ApplicantID(r)
+
+	if publicID == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.FriendlyError)
+		return
+	}
+
+	revocationRepository := applicants.NewApplicantRegistry().GetRevocationRepository()
+
+	err := revocationRepository.RevokeAllTokens(publicID)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	response.SendJSONMessage(w, http.StatusOK, response.Success)
+}
@@ -0,0 +1,97 @@
+package applicants_test
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"autumnomous-jobs-applicant-api/controller/v1/applicants"
+	"autumnomous-jobs-applicant-api/route/middleware/acl"
+	"autumnomous-jobs-applicant-api/shared/services/security/jwt"
+	"autumnomous-jobs-applicant-api/shared/testhelper"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Applicant_Logout_RevokesToken(t *testing.T) {
+	assert := assert.New(t)
+
+	logout := httptest.NewServer(acl.ValidateJWT(http.HandlerFunc(applicants.Logout)))
+	defer logout.Close()
+
+	get := httptest.NewServer(acl.ValidateJWT(http.HandlerFunc(applicants.GetApplicant)))
+	defer get.Close()
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+
+	token, err := jwt.GenerateToken(applicant.PublicID)
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	token = base64.StdEncoding.EncodeToString([]byte(token))
+
+	request, err := http.NewRequest("POST", logout.URL, nil)
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	request.Header.Set("Authorization", "Bearer "+token)
+
+	httpClient := &http.Client{}
+	response, err := httpClient.Do(request)
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	assert.Equal(http.StatusOK, response.StatusCode)
+
+	request, err = http.NewRequest("GET", get.URL, nil)
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	request.Header.Set("Authorization", "Bearer "+token)
+
+	response, err = httpClient.Do(request)
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	assert.Equal(http.StatusUnauthorized, response.StatusCode)
+}
+
+func Test_Applicant_LogoutAll_IncorrectMethod(t *testing.T) {
+	assert := assert.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(applicants.LogoutAll))
+
+	defer ts.Close()
+
+	methods := []string{"GET", "PUT", "DELETE"}
+
+	for _, method := range methods {
+
+		request, err := http.NewRequest(method, ts.URL, nil)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		client := &http.Client{}
+
+		response, err := client.Do(request)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(http.StatusMethodNotAllowed, response.StatusCode)
+	}
+}
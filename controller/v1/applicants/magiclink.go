@@ -0,0 +1,174 @@
+package applicants
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	applicants "autumnomous-jobs-applicant-api/shared/repository/applicants"
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+	"autumnomous-jobs-applicant-api/shared/response"
+	"autumnomous-jobs-applicant-api/shared/services/security/encryption"
+	"autumnomous-jobs-applicant-api/shared/services/security/principal"
+	"autumnomous-jobs-applicant-api/shared/services/utils"
+)
+
+var SendMagicLinkMessageFunction = SendMagicLinkMessage
+
+type magicLinkData struct {
+	Email string `json:"email"`
+}
+
+type magicLinkLoginData struct {
+	Token string `json:"token"`
+}
+
+// RequestMagicLink emails a single-use sign-in link. Like ForgotPassword it responds the same
+// way whether or not the email belongs to an account.
+func RequestMagicLink(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPost {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	var data magicLinkData
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&data)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	if data.Email == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.EmailRequired)
+		return
+	}
+
+	if !allowEmailLink(w, data.Email, utils.ClientIP(r)) {
+		return
+	}
+
+	applicant, err := applicants.NewApplicantRegistry().GetApplicantRepository().GetApplicantByEmail(data.Email)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	if applicant == nil {
+		response.SendJSONMessage(w, http.StatusOK, response.MagicLinkRequested)
+		return
+	}
+
+	// a failure is only logged: answering differently would tell the caller the account exists
+	if err = sendMagicLink(applicant); err != nil {
+		log.Println(err)
+	}
+
+	response.SendJSONMessage(w, http.StatusOK, response.MagicLinkRequested)
+}
+
+// sendMagicLink replaces any sign-in link the applicant already holds with a new one and emails it
+func sendMagicLink(applicant *accountmanagement.Applicant) error {
+
+	tokenRepository := applicants.NewApplicantRegistry().GetTokenRepository()
+
+	err := tokenRepository.RevokeTokens(applicant.PublicID, accountmanagement.MagicLink)
+
+	if err != nil {
+		return err
+	}
+
+	token, tokenHash, err := encryption.NewSecureToken()
+
+	if err != nil {
+		return err
+	}
+
+	err = tokenRepository.CreateToken(applicant.PublicID, accountmanagement.MagicLink, tokenHash, accountmanagement.MagicLinkTokenLifetime)
+
+	if err != nil {
+		return err
+	}
+
+	domain := os.Getenv("MAILGUN_DOMAIN")
+	apiKey := os.Getenv("MAILGUN_API_KEY")
+	_, err = SendMagicLinkMessageFunction(domain, apiKey, token, applicant)
+
+	return err
+}
+
+// MagicLinkLogin redeems an emailed sign-in link, responding the same way Login does
+func MagicLinkLogin(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPost {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	var data magicLinkLoginData
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&data)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	if data.Token == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.MissingRequiredValue)
+		return
+	}
+
+	publicID, err := applicants.NewApplicantRegistry().GetTokenRepository().RedeemToken(accountmanagement.MagicLink, encryption.HashToken(data.Token))
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	if publicID == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.InvalidToken)
+		return
+	}
+
+	repository := applicants.NewApplicantRegistry().GetApplicantRepository()
+
+	applicant, err := repository.GetApplicant(publicID)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	// following the link proves the applicant can read mail sent to the address
+	if !applicant.EmailVerified {
+		err = repository.SetApplicantEmailVerified(publicID)
+
+		if err != nil {
+			log.Println(err)
+			response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+			return
+		}
+	}
+
+	completeLogin(w, r, publicID, applicant.RegistrationStep, principal.AuthMethodMagicLink)
+}
+
+// SendMagicLinkMessage emails the applicant a link that signs them in
+func SendMagicLinkMessage(domain, apiKey, token string, applicant *accountmanagement.Applicant) (string, error) {
+
+	link := fmt.Sprintf("%s/magic-login?token=%s", os.Getenv("APPLICANT_SITE_URL"), token)
+	message := fmt.Sprintf("Hi %s,\nUse the link below within the next 15 minutes to sign in to BiT Jobs:\n%s\n\nThe link only works once. If you didn't ask for it, you can ignore this email.", applicant.FirstName, link)
+
+	return sendMessage(domain, apiKey, "Your BiT Jobs sign-in link", message, applicant.Email)
+}
@@ -0,0 +1,135 @@
+package applicants_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"autumnomous-jobs-applicant-api/controller/v1/applicants"
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+	"autumnomous-jobs-applicant-api/shared/testhelper"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// captureMagicLinks stops sign-in links being mailed and returns where the last one is kept
+func captureMagicLinks(t *testing.T) *string {
+
+	var emailedToken string
+
+	applicants.SendMagicLinkMessageFunction = func(domain, apiKey, token string, applicant *accountmanagement.Applicant) (string, error) {
+		emailedToken = token
+		return "", nil
+	}
+
+	t.Cleanup(func() {
+		applicants.SendMagicLinkMessageFunction = applicants.SendMagicLinkMessage
+	})
+
+	return &emailedToken
+}
+
+func Test_Applicant_MagicLink_Login(t *testing.T) {
+	assert := assert.New(t)
+
+	useTestEmailLinkLimiter(t)
+	emailedToken := captureMagicLinks(t)
+
+	request := httptest.NewServer(http.HandlerFunc(applicants.RequestMagicLink))
+	defer request.Close()
+
+	login := httptest.NewServer(http.HandlerFunc(applicants.MagicLinkLogin))
+	defer login.Close()
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+
+	response, _ := postJSONWithToken(t, request.URL, "", map[string]string{"email": applicant.Email})
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.NotEqual("", *emailedToken)
+
+	response, result := postJSONWithToken(t, login.URL, "", map[string]string{"token": *emailedToken})
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.NotEmpty(result["token"])
+	assert.NotEmpty(result["refreshtoken"])
+	assert.Contains(result, "registrationstep")
+
+	// the link only works once
+	response, _ = postJSONWithToken(t, login.URL, "", map[string]string{"token": *emailedToken})
+	assert.Equal(http.StatusBadRequest, response.StatusCode)
+}
+
+func Test_Applicant_MagicLink_NewLinkReplacesOld(t *testing.T) {
+	assert := assert.New(t)
+
+	useTestEmailLinkLimiter(t)
+	emailedToken := captureMagicLinks(t)
+
+	request := httptest.NewServer(http.HandlerFunc(applicants.RequestMagicLink))
+	defer request.Close()
+
+	login := httptest.NewServer(http.HandlerFunc(applicants.MagicLinkLogin))
+	defer login.Close()
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+
+	postJSONWithToken(t, request.URL, "", map[string]string{"email": applicant.Email})
+	first := *emailedToken
+
+	// the limiter wants a pause between links to the same address
+	response, _ := postJSONWithToken(t, request.URL, "", map[string]string{"email": applicant.Email})
+	assert.Equal(http.StatusTooManyRequests, response.StatusCode)
+	assert.NotEqual("", response.Header.Get("Retry-After"))
+
+	useTestEmailLinkLimiter(t)
+
+	postJSONWithToken(t, request.URL, "", map[string]string{"email": applicant.Email})
+	assert.NotEqual(first, *emailedToken)
+
+	response, _ = postJSONWithToken(t, login.URL, "", map[string]string{"token": first})
+	assert.Equal(http.StatusBadRequest, response.StatusCode)
+
+	response, _ = postJSONWithToken(t, login.URL, "", map[string]string{"token": *emailedToken})
+	assert.Equal(http.StatusOK, response.StatusCode)
+}
+
+func Test_Applicant_MagicLink_UnknownEmail(t *testing.T) {
+	assert := assert.New(t)
+
+	useTestEmailLinkLimiter(t)
+	emailedToken := captureMagicLinks(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(applicants.RequestMagicLink))
+	defer ts.Close()
+
+	response, result := postJSONWithToken(t, ts.URL, "", map[string]string{"email": "nobody-here@example.com"})
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Equal("If an account exists for that email, a sign-in link has been sent.", result["message"])
+	assert.Equal("", *emailedToken)
+}
+
+func Test_Applicant_MagicLink_MailFailureLooksLikeUnknownEmail(t *testing.T) {
+	assert := assert.New(t)
+
+	useTestEmailLinkLimiter(t)
+
+	applicants.SendMagicLinkMessageFunction = func(domain, apiKey, token string, applicant *accountmanagement.Applicant) (string, error) {
+		return "", errors.New("mailgun unavailable")
+	}
+
+	t.Cleanup(func() {
+		applicants.SendMagicLinkMessageFunction = applicants.SendMagicLinkMessage
+	})
+
+	request := httptest.NewServer(http.HandlerFunc(applicants.RequestMagicLink))
+	defer request.Close()
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+
+	known, knownResult := postJSONWithToken(t, request.URL, "", map[string]string{"email": applicant.Email})
+	unknown, unknownResult := postJSONWithToken(t, request.URL, "", map[string]string{"email": "nobody-" + applicant.Email})
+
+	assert.Equal(http.StatusOK, known.StatusCode)
+	assert.Equal(unknown.StatusCode, known.StatusCode)
+	assert.Equal(unknownResult, knownResult)
+}
@@ -0,0 +1,393 @@
+package applicants
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	applicants "autumnomous-jobs-applicant-api/shared/repository/applicants"
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+	"autumnomous-jobs-applicant-api/shared/response"
+	"autumnomous-jobs-applicant-api/shared/services/audit"
+	"autumnomous-jobs-applicant-api/shared/services/security/encryption"
+	"autumnomous-jobs-applicant-api/shared/services/security/jwt"
+	"autumnomous-jobs-applicant-api/shared/services/security/principal"
+	"autumnomous-jobs-applicant-api/shared/services/security/totp"
+	"autumnomous-jobs-applicant-api/shared/services/utils"
+)
+
+const (
+	totpIssuer = "BiT Jobs"
+
+	recoveryCodeCount    = 10
+	recoveryCodeLength   = 10
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+)
+
+type totpCodeData struct {
+	Code string `json:"code"`
+}
+
+type loginMFAData struct {
+	MFAToken     string `json:"mfatoken"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recoverycode"`
+}
+
+// EnrollTOTP creates a new authenticator secret. It isn't required at login until confirmed with EnableTOTP.
+func EnrollTOTP(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPost {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	publicID := principal.ApplicantID(r)
+
+	if publicID == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.FriendlyError)
+		return
+	}
+
+	repository := applicants.NewApplicantRegistry().GetApplicantRepository()
+
+	applicant, err := repository.GetApplicant(publicID)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	key, err := mfaEncryptionKey()
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	sealed, err := encryption.Seal(key, []byte(secret))
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	mfaRepository := applicants.NewApplicantRegistry().GetMFARepository()
+
+	stored, err := mfaRepository.SetPendingTOTPSecret(publicID, sealed)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	if !stored {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.MFAAlreadyEnabled)
+		return
+	}
+
+	response.SendJSON(w, map[string]string{
+		"secret": secret,
+		"uri":    totp.URI(totpIssuer, applicant.Email, secret),
+	})
+}
+
+// EnableTOTP confirms enrollment with a code from the authenticator and returns one-time recovery codes
+func EnableTOTP(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPost {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	publicID := principal.ApplicantID(r)
+
+	if publicID == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.FriendlyError)
+		return
+	}
+
+	var data totpCodeData
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&data)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	if data.Code == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.MissingRequiredValue)
+		return
+	}
+
+	mfaRepository := applicants.NewApplicantRegistry().GetMFARepository()
+
+	settings, err := mfaRepository.GetTOTPSettings(publicID)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	if settings.Enabled {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.MFAAlreadyEnabled)
+		return
+	}
+
+	if settings.SealedSecret == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.MFANotEnrolled)
+		return
+	}
+
+	step, err := validateTOTPCode(settings.SealedSecret, data.Code)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	if step < 0 {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.InvalidMFACode)
+		return
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	err = mfaRepository.EnableTOTP(publicID, step, hashes)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	response.SendJSON(w, map[string][]string{"recoverycodes": codes})
+}
+
+// LoginMFA completes a two-step login with the challenge token from Login and either an
+// authenticator code or an unused recovery code
+func LoginMFA(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPost {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	var data loginMFAData
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&data)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	if data.MFAToken == "" || (data.Code == "" && data.RecoveryCode == "") {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.MissingRequiredValue)
+		return
+	}
+
+	challenge, err := base64.StdEncoding.DecodeString(data.MFAToken)
+
+	if err != nil {
+		response.SendJSONMessage(w, http.StatusUnauthorized, response.Unauthorized)
+		return
+	}
+
+	publicID, err := jwt.ParseMFAChallengeToken(string(challenge))
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusUnauthorized, response.Unauthorized)
+		return
+	}
+
+	repository := applicants.NewApplicantRegistry().GetApplicantRepository()
+
+	applicant, err := repository.GetApplicant(publicID)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	// wrong codes count against the same limits as wrong passwords
+	limiter := LoginLimiterFunction()
+	ip := utils.ClientIP(r)
+
+	if !checkLoginAllowed(w, limiter, applicant.Email, ip) {
+		return
+	}
+
+	mfaRepository := applicants.NewApplicantRegistry().GetMFARepository()
+
+	var verified bool
+
+	if data.Code != "" {
+		settings, err := mfaRepository.GetTOTPSettings(publicID)
+
+		if err != nil {
+			log.Println(err)
+			response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+			return
+		}
+
+		step, err := validateTOTPCode(settings.SealedSecret, data.Code)
+
+		if err != nil {
+			log.Println(err)
+			response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+			return
+		}
+
+		if step >= 0 {
+			verified, err = mfaRepository.RecordTOTPStep(publicID, step)
+		}
+	} else {
+		verified, err = mfaRepository.UseRecoveryCode(publicID, encryption.HashToken(normalizeRecoveryCode(data.RecoveryCode)))
+	}
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	if !verified {
+		err = recordLoginFailure(limiter, applicant.Email, ip)
+
+		if err != nil {
+			log.Println(err)
+		}
+
+		audit.RecordAs(r, "", accountmanagement.AuditLoginFailed, publicID, nil)
+
+		response.SendJSONMessage(w, http.StatusUnauthorized, response.InvalidMFACode)
+		return
+	}
+
+	err = limiter.Succeed(applicant.Email)
+
+	if err != nil {
+		log.Println(err)
+	}
+
+	token, err := issueTokens(r, publicID, applicant.RegistrationStep, principal.AuthMethodMFA)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	audit.RecordAs(r, publicID, accountmanagement.AuditLoginSucceeded, publicID, nil)
+
+	response.SendJSON(w, token)
+}
+
+// mfaChallengeResponse is what Login returns in place of tokens when a second factor is required
+func mfaChallengeResponse(publicID string) (map[string]interface{}, error) {
+
+	challenge, err := jwt.GenerateMFAChallengeToken(publicID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"mfarequired": true,
+		"mfatoken":    base64.StdEncoding.EncodeToString([]byte(challenge)),
+		"expiresin":   int64(jwt.MFAChallengeLifetime.Seconds()),
+	}, nil
+}
+
+// validateTOTPCode returns the time step the code belongs to, or -1 if it doesn't match
+func validateTOTPCode(sealedSecret, code string) (int64, error) {
+
+	if sealedSecret == "" {
+		return -1, nil
+	}
+
+	key, err := mfaEncryptionKey()
+
+	if err != nil {
+		return -1, err
+	}
+
+	secret, err := encryption.Open(key, sealedSecret)
+
+	if err != nil {
+		return -1, err
+	}
+
+	return totp.Validate(string(secret), code, time.Now())
+}
+
+// mfaEncryptionKey reads the base64 encoded AES key authenticator secrets are sealed with
+func mfaEncryptionKey() ([]byte, error) {
+
+	key, err := base64.StdEncoding.DecodeString(os.Getenv("MFA_ENCRYPTION_KEY"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(key) != 32 {
+		return nil, errors.New("MFA_ENCRYPTION_KEY must be 32 bytes")
+	}
+
+	return key, nil
+}
+
+// generateRecoveryCodes returns the codes to show the applicant once and the hashes to store
+func generateRecoveryCodes() ([]string, []string, error) {
+
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+
+	for i := range codes {
+		code, err := encryption.RandomString(recoveryCodeLength, recoveryCodeAlphabet)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		codes[i] = code[:recoveryCodeLength/2] + "-" + code[recoveryCodeLength/2:]
+		hashes[i] = encryption.HashToken(normalizeRecoveryCode(codes[i]))
+	}
+
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
@@ -0,0 +1,135 @@
+package applicants_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"autumnomous-jobs-applicant-api/controller/v1/applicants"
+	"autumnomous-jobs-applicant-api/route/middleware/acl"
+	"autumnomous-jobs-applicant-api/shared/services/security/jwt"
+	"autumnomous-jobs-applicant-api/shared/services/security/totp"
+	"autumnomous-jobs-applicant-api/shared/testhelper"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func postJSONWithToken(t *testing.T, url, token string, body interface{}) (*http.Response, map[string]interface{}) {
+
+	requestBody, err := json.Marshal(body)
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	request, err := http.NewRequest("POST", url, bytes.NewBuffer(requestBody))
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+base64.StdEncoding.EncodeToString([]byte(token)))
+	}
+
+	httpClient := &http.Client{}
+	response, err := httpClient.Do(request)
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	var result map[string]interface{}
+	json.NewDecoder(response.Body).Decode(&result)
+
+	return response, result
+}
+
+func Test_Applicant_EnableTOTP_InvalidCode(t *testing.T) {
+	assert := assert.New(t)
+
+	enroll := httptest.NewServer(acl.ValidateJWT(http.HandlerFunc(applicants.EnrollTOTP)))
+	defer enroll.Close()
+
+	enable := httptest.NewServer(acl.ValidateJWT(http.HandlerFunc(applicants.EnableTOTP)))
+	defer enable.Close()
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+
+	token, err := jwt.GenerateToken(applicant.PublicID)
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	response, _ := postJSONWithToken(t, enroll.URL, token, nil)
+	assert.Equal(http.StatusOK, response.StatusCode)
+
+	response, _ = postJSONWithToken(t, enable.URL, token, map[string]string{"code": "000000x"})
+	assert.Equal(http.StatusBadRequest, response.StatusCode)
+}
+
+func Test_Applicant_TOTP_TwoStepLogin(t *testing.T) {
+	assert := assert.New(t)
+
+	enroll := httptest.NewServer(acl.ValidateJWT(http.HandlerFunc(applicants.EnrollTOTP)))
+	defer enroll.Close()
+
+	enable := httptest.NewServer(acl.ValidateJWT(http.HandlerFunc(applicants.EnableTOTP)))
+	defer enable.Close()
+
+	login := httptest.NewServer(http.HandlerFunc(applicants.Login))
+	defer login.Close()
+
+	loginMFA := httptest.NewServer(http.HandlerFunc(applicants.LoginMFA))
+	defer loginMFA.Close()
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+
+	token, err := jwt.GenerateToken(applicant.PublicID)
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	response, enrollment := postJSONWithToken(t, enroll.URL, token, nil)
+
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Contains(enrollment["uri"], "otpauth://totp/")
+
+	code, err := totp.GenerateCode(enrollment["secret"].(string), totp.Step(time.Now()))
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	response, enabled := postJSONWithToken(t, enable.URL, token, map[string]string{"code": code})
+
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Len(enabled["recoverycodes"], 10)
+
+	// the password alone now only gets a challenge
+	response, challenge := postJSONWithToken(t, login.URL, "", map[string]string{"email": applicant.Email, "password": applicant.Password})
+
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Equal(true, challenge["mfarequired"])
+	assert.Nil(challenge["token"])
+
+	recoveryCode := enabled["recoverycodes"].([]interface{})[0]
+
+	response, tokens := postJSONWithToken(t, loginMFA.URL, "", map[string]interface{}{"mfatoken": challenge["mfatoken"], "recoverycode": recoveryCode})
+
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.NotEmpty(tokens["token"])
+
+	// recovery codes are single use
+	response, _ = postJSONWithToken(t, loginMFA.URL, "", map[string]interface{}{"mfatoken": challenge["mfatoken"], "recoverycode": recoveryCode})
+
+	assert.Equal(http.StatusUnauthorized, response.StatusCode)
+}
@@ -0,0 +1,279 @@
+package applicants
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	applicants "autumnomous-jobs-applicant-api/shared/repository/applicants"
+	"autumnomous-jobs-applicant-api/shared/response"
+	"autumnomous-jobs-applicant-api/shared/services/security/encryption"
+	"autumnomous-jobs-applicant-api/shared/services/security/oidc"
+	"autumnomous-jobs-applicant-api/shared/services/security/principal"
+)
+
+// OIDCLoginStateLifetime is how long an applicant has to finish signing in at the provider
+const OIDCLoginStateLifetime = time.Minute * 10
+
+var OIDCProvidersFunction = oidc.GetProviders
+
+var errUnverifiedProviderEmail = errors.New("provider email is not verified")
+
+type oidcAuthorizeData struct {
+	Provider string `json:"provider"`
+}
+
+type oidcCallbackData struct {
+	State string `json:"state"`
+	Code  string `json:"code"`
+}
+
+// GetOIDCProviders lists the providers applicants can sign in with
+func GetOIDCProviders(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	providers, err := OIDCProvidersFunction()
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	list := []map[string]string{}
+
+	for _, name := range providers.Names() {
+		list = append(list, map[string]string{"name": name, "displayname": providers[name].DisplayName})
+	}
+
+	response.SendJSON(w, map[string]interface{}{"providers": list})
+}
+
+// OIDCAuthorize starts a login with a provider. The client sends the applicant to the returned
+// authorizationurl and keeps state to check against the provider's redirect.
+func OIDCAuthorize(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPost {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	var data oidcAuthorizeData
+	err := json.NewDecoder(r.Body).Decode(&data)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusBadRequest, response.FriendlyError)
+		return
+	}
+
+	provider := findProvider(w, data.Provider)
+
+	if provider == nil {
+		return
+	}
+
+	attempt, err := oidc.NewLoginAttempt()
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	authorizationURL, err := provider.AuthCodeURL(r.Context(), attempt.State, attempt.Nonce, oidc.CodeChallenge(attempt.Verifier))
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusBadGateway, response.ProviderLoginFailed)
+		return
+	}
+
+	repository := applicants.NewApplicantRegistry().GetOIDCRepository()
+
+	err = repository.CreateLoginState(encryption.HashToken(attempt.State), provider.Name, attempt.Nonce, attempt.Verifier, OIDCLoginStateLifetime)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	response.SendJSON(w, map[string]interface{}{
+		"authorizationurl": authorizationURL,
+		"state":            attempt.State,
+		"expiresin":        int64(OIDCLoginStateLifetime.Seconds()),
+	})
+}
+
+// OIDCCallback finishes a provider login with the code and state the provider redirected back
+// with, responding the same way Login does
+func OIDCCallback(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPost {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	var data oidcCallbackData
+	err := json.NewDecoder(r.Body).Decode(&data)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusBadRequest, response.FriendlyError)
+		return
+	}
+
+	if data.State == "" || data.Code == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.MissingRequiredValue)
+		return
+	}
+
+	state, err := applicants.NewApplicantRegistry().GetOIDCRepository().ConsumeLoginState(encryption.HashToken(data.State))
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	if state == nil {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.InvalidToken)
+		return
+	}
+
+	provider := findProvider(w, state.Provider)
+
+	if provider == nil {
+		return
+	}
+
+	token, err := provider.Exchange(r.Context(), data.Code, state.CodeVerifier, state.Nonce)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusUnauthorized, response.ProviderLoginFailed)
+		return
+	}
+
+	publicID, err := federatedApplicant(provider.Name, token)
+
+	if err == errUnverifiedProviderEmail {
+		response.SendJSONMessage(w, http.StatusForbidden, response.ProviderEmailUnverified)
+		return
+	}
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	applicant, err := applicants.NewApplicantRegistry().GetApplicantRepository().GetApplicant(publicID)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	completeLogin(w, r, publicID, applicant.RegistrationStep, principal.AuthMethodOIDC)
+}
+
+// findProvider returns the named provider, responding with an error when there isn't one
+func findProvider(w http.ResponseWriter, name string) *oidc.Provider {
+
+	if name == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.MissingRequiredValue)
+		return nil
+	}
+
+	providers, err := OIDCProvidersFunction()
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return nil
+	}
+
+	provider, ok := providers[name]
+
+	if !ok {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.UnknownProvider)
+		return nil
+	}
+
+	return provider
+}
+
+// federatedApplicant returns the applicant the provider's user signs in as. An identity seen before
+// keeps its link; otherwise the provider must have verified the email, which is then linked to
+// the applicant holding it or used to sign a new applicant up.
+func federatedApplicant(provider string, token *oidc.IDToken) (string, error) {
+
+	oidcRepository := applicants.NewApplicantRegistry().GetOIDCRepository()
+
+	publicID, err := oidcRepository.GetIdentityApplicant(provider, token.Subject)
+
+	if err != nil || publicID != "" {
+		return publicID, err
+	}
+
+	if !token.HasVerifiedEmail() {
+		return "", errUnverifiedProviderEmail
+	}
+
+	repository := applicants.NewApplicantRegistry().GetApplicantRepository()
+
+	applicant, err := repository.GetApplicantByEmail(token.Email)
+
+	if err != nil {
+		return "", err
+	}
+
+	if applicant == nil {
+		firstName, lastName := providerNames(token)
+
+		applicant, err = repository.CreateFederatedApplicant(firstName, lastName, token.Email)
+
+		if err != nil {
+			return "", err
+		}
+	} else {
+		// the provider has just proved the applicant owns the address
+		err = repository.SetApplicantEmailVerified(applicant.PublicID)
+
+		if err != nil {
+			return "", err
+		}
+	}
+
+	err = oidcRepository.LinkIdentity(applicant.PublicID, provider, token.Subject, token.Email)
+
+	if err != nil {
+		return "", err
+	}
+
+	return applicant.PublicID, nil
+}
+
+// providerNames picks the best first and last name the provider shared, falling back to the email address
+func providerNames(token *oidc.IDToken) (string, string) {
+
+	if token.GivenName != "" {
+		return token.GivenName, token.FamilyName
+	}
+
+	if name := strings.Fields(token.Name); len(name) > 0 {
+		return name[0], strings.Join(name[1:], " ")
+	}
+
+	return strings.SplitN(token.Email, "@", 2)[0], token.FamilyName
+}
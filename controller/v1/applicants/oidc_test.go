@@ -0,0 +1,144 @@
+package applicants_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"autumnomous-jobs-applicant-api/controller/v1/applicants"
+	applicantsrepository "autumnomous-jobs-applicant-api/shared/repository/applicants"
+	"autumnomous-jobs-applicant-api/shared/services/security/encryption"
+	"autumnomous-jobs-applicant-api/shared/services/security/oidc"
+	"autumnomous-jobs-applicant-api/shared/services/security/oidc/oidctest"
+	"autumnomous-jobs-applicant-api/shared/testhelper"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// useMockOIDCProvider points the OIDC handlers at a local provider named "mock"
+func useMockOIDCProvider(t *testing.T) *oidctest.Server {
+
+	server, err := oidctest.NewServer()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	previous := applicants.OIDCProvidersFunction
+	applicants.OIDCProvidersFunction = func() (oidc.Providers, error) {
+		return oidc.Providers{"mock": server.Provider("mock", "https://jobs.example.com/oidc/callback")}, nil
+	}
+
+	t.Cleanup(func() {
+		applicants.OIDCProvidersFunction = previous
+		server.Close()
+	})
+
+	return server
+}
+
+// oidcLogin runs the whole authorization code flow against the mock provider
+func oidcLogin(t *testing.T, server *oidctest.Server) (*http.Response, map[string]interface{}) {
+
+	authorize := httptest.NewServer(http.HandlerFunc(applicants.OIDCAuthorize))
+	defer authorize.Close()
+
+	callback := httptest.NewServer(http.HandlerFunc(applicants.OIDCCallback))
+	defer callback.Close()
+
+	response, result := postJSONWithToken(t, authorize.URL, "", map[string]string{"provider": "mock"})
+
+	if response.StatusCode != http.StatusOK {
+		t.Fatal(result)
+	}
+
+	code, state, err := server.Authorize(result["authorizationurl"].(string))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if state != result["state"] {
+		t.Fatal("state mismatch")
+	}
+
+	return postJSONWithToken(t, callback.URL, "", map[string]string{"state": state, "code": code})
+}
+
+func Test_Applicant_OIDCAuthorize_UnknownProvider(t *testing.T) {
+	assert := assert.New(t)
+
+	useMockOIDCProvider(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(applicants.OIDCAuthorize))
+	defer ts.Close()
+
+	response, _ := postJSONWithToken(t, ts.URL, "", map[string]string{"provider": "myspace"})
+	assert.Equal(http.StatusBadRequest, response.StatusCode)
+}
+
+func Test_Applicant_OIDCCallback_InvalidState(t *testing.T) {
+	assert := assert.New(t)
+
+	useMockOIDCProvider(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(applicants.OIDCCallback))
+	defer ts.Close()
+
+	response, _ := postJSONWithToken(t, ts.URL, "", map[string]string{"state": "made-up", "code": "made-up"})
+	assert.Equal(http.StatusBadRequest, response.StatusCode)
+}
+
+func Test_Applicant_OIDCLogin_SignsUpNewApplicant(t *testing.T) {
+	assert := assert.New(t)
+
+	server := useMockOIDCProvider(t)
+
+	subject, _ := encryption.GenerateToken(16)
+	email := subject + "@example.com"
+
+	server.SetUser(oidctest.User{Subject: subject, Email: email, EmailVerified: true, GivenName: "Ada", FamilyName: "Lovelace"})
+
+	response, result := oidcLogin(t, server)
+
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.NotEmpty(result["token"])
+	assert.NotEmpty(result["refreshtoken"])
+	assert.Equal("personal-information", result["registrationstep"])
+
+	applicant, err := applicantsrepository.NewApplicantRegistry().GetApplicantRepository().GetApplicantByEmail(email)
+	assert.Nil(err)
+
+	if assert.NotNil(applicant) {
+		assert.Equal("Ada", applicant.FirstName)
+	}
+
+	// signing in again finds the linked identity
+	response, _ = oidcLogin(t, server)
+	assert.Equal(http.StatusOK, response.StatusCode)
+}
+
+func Test_Applicant_OIDCLogin_LinksVerifiedEmail(t *testing.T) {
+	assert := assert.New(t)
+
+	server := useMockOIDCProvider(t)
+	applicant := testhelper.Helper_RandomApplicant(t)
+
+	subject, _ := encryption.GenerateToken(16)
+
+	// an unverified email can't claim the existing account
+	server.SetUser(oidctest.User{Subject: subject, Email: applicant.Email, EmailVerified: false, GivenName: "Someone"})
+
+	response, _ := oidcLogin(t, server)
+	assert.Equal(http.StatusForbidden, response.StatusCode)
+
+	server.SetUser(oidctest.User{Subject: subject, Email: applicant.Email, EmailVerified: true, GivenName: "Someone"})
+
+	response, result := oidcLogin(t, server)
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.NotEmpty(result["token"])
+
+	publicID, err := applicantsrepository.NewApplicantRegistry().GetOIDCRepository().GetIdentityApplicant("mock", subject)
+	assert.Nil(err)
+	assert.Equal(applicant.PublicID, publicID)
+}
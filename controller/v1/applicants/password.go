@@ -0,0 +1,223 @@
+package applicants
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	applicants "autumnomous-jobs-applicant-api/shared/repository/applicants"
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+	"autumnomous-jobs-applicant-api/shared/response"
+	"autumnomous-jobs-applicant-api/shared/services/audit"
+	"autumnomous-jobs-applicant-api/shared/services/security/encryption"
+	"autumnomous-jobs-applicant-api/shared/services/utils"
+)
+
+var SendPasswordResetMessageFunction = SendPasswordResetMessage
+
+type forgotPasswordData struct {
+	Email string `json:"email"`
+}
+
+type resetPasswordData struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"newpassword"`
+}
+
+// ForgotPassword emails a single-use reset link. It responds the same way whether or not
+// the email belongs to an account so it can't be used to discover registered addresses.
+func ForgotPassword(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPost {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	var data forgotPasswordData
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&data)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	if data.Email == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.EmailRequired)
+		return
+	}
+
+	if !allowEmailLink(w, data.Email, utils.ClientIP(r)) {
+		return
+	}
+
+	repository := applicants.NewApplicantRegistry().GetApplicantRepository()
+
+	applicant, err := repository.GetApplicantByEmail(data.Email)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	if applicant == nil {
+		response.SendJSONMessage(w, http.StatusOK, response.PasswordResetRequested)
+		return
+	}
+
+	// a failure is only logged: answering differently would tell the caller the account exists
+	if err = SendPasswordReset(applicant); err != nil {
+		log.Println(err)
+	}
+
+	response.SendJSONMessage(w, http.StatusOK, response.PasswordResetRequested)
+}
+
+// SendPasswordReset stores a new reset token for the applicant and emails them the link
+func SendPasswordReset(applicant *accountmanagement.Applicant) error {
+
+	token, tokenHash, err := encryption.NewSecureToken()
+
+	if err != nil {
+		return err
+	}
+
+	tokenRepository := applicants.NewApplicantRegistry().GetTokenRepository()
+
+	err = tokenRepository.CreateToken(applicant.PublicID, accountmanagement.PasswordReset, tokenHash, accountmanagement.PasswordResetTokenLifetime)
+
+	if err != nil {
+		return err
+	}
+
+	domain := os.Getenv("MAILGUN_DOMAIN")
+	apiKey := os.Getenv("MAILGUN_API_KEY")
+	_, err = SendPasswordResetMessageFunction(domain, apiKey, token, applicant)
+
+	return err
+}
+
+// ResetPassword redeems a reset token and sets the applicant's new password
+func ResetPassword(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPost {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	var data resetPasswordData
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&data)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	if data.Token == "" || data.NewPassword == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.MissingRequiredValue)
+		return
+	}
+
+	tokenRepository := applicants.NewApplicantRegistry().GetTokenRepository()
+	tokenHash := encryption.HashToken(data.Token)
+
+	// check the password before redeeming so a rejected one doesn't use up the link
+	publicID, err := tokenRepository.LookupToken(accountmanagement.PasswordReset, tokenHash)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	if publicID == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.InvalidToken)
+		return
+	}
+
+	repository := applicants.NewApplicantRegistry().GetApplicantRepository()
+
+	applicant, err := repository.GetApplicant(publicID)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	if !checkNewPassword(w, data.NewPassword, applicant) {
+		return
+	}
+
+	publicID, err = tokenRepository.RedeemToken(accountmanagement.PasswordReset, tokenHash)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	if publicID == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.InvalidToken)
+		return
+	}
+
+	updated, err := repository.ResetApplicantPassword(publicID, data.NewPassword)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	if !updated {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.InvalidToken)
+		return
+	}
+
+	audit.RecordAs(r, publicID, accountmanagement.AuditPasswordChanged, publicID, nil)
+
+	response.SendJSONMessage(w, http.StatusOK, response.Success)
+}
+
+// checkNewPassword responds with the policy's field errors and returns false when password isn't acceptable
+func checkNewPassword(w http.ResponseWriter, password string, applicant *accountmanagement.Applicant) bool {
+
+	policy, err := encryption.PasswordPolicyFromEnv()
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return false
+	}
+
+	violations := policy.Check(password, encryption.PasswordOwner{Email: applicant.Email, FirstName: applicant.FirstName, LastName: applicant.LastName})
+
+	if len(violations) == 0 {
+		return true
+	}
+
+	errors := make([]response.FieldError, len(violations))
+
+	for i, violation := range violations {
+		errors[i] = response.FieldError{Field: "newpassword", Code: violation.Code, Message: violation.Message}
+	}
+
+	response.SendValidationErrors(w, response.WeakPassword, errors)
+	return false
+}
+
+// SendPasswordResetMessage emails the applicant a link containing their reset token
+func SendPasswordResetMessage(domain, apiKey, token string, applicant *accountmanagement.Applicant) (string, error) {
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", os.Getenv("APPLICANT_SITE_URL"), token)
+	message := fmt.Sprintf("Hi %s,\nWe received a request to reset your BiT Jobs password. Use the link below within the next hour to choose a new one:\n%s\n\nIf you didn't ask for this, you can ignore this email.", applicant.FirstName, link)
+
+	return sendMessage(domain, apiKey, "Reset your BiT Jobs password", message, applicant.Email)
+}
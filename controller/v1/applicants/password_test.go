@@ -0,0 +1,214 @@
+package applicants_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"autumnomous-jobs-applicant-api/controller/v1/applicants"
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+	"autumnomous-jobs-applicant-api/shared/services/security/encryption"
+	"autumnomous-jobs-applicant-api/shared/testhelper"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Applicant_ForgotPassword_IncorrectMethod(t *testing.T) {
+	assert := assert.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(applicants.ForgotPassword))
+
+	defer ts.Close()
+
+	methods := []string{"GET", "PUT", "DELETE"}
+
+	for _, method := range methods {
+
+		request, err := http.NewRequest(method, ts.URL, nil)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		client := &http.Client{}
+
+		response, err := client.Do(request)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(http.StatusMethodNotAllowed, response.StatusCode)
+	}
+}
+
+func Test_Applicant_ForgotPassword_NoEmail(t *testing.T) {
+	assert := assert.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(applicants.ForgotPassword))
+
+	defer ts.Close()
+
+	requestBody, err := json.Marshal(map[string]string{"email": ""})
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	response, err := http.Post(ts.URL, "application/json", bytes.NewBuffer(requestBody))
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	assert.Equal(http.StatusBadRequest, response.StatusCode)
+}
+
+func Test_Applicant_ForgotPassword_UnknownEmail(t *testing.T) {
+	assert := assert.New(t)
+
+	useTestEmailLinkLimiter(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(applicants.ForgotPassword))
+
+	defer ts.Close()
+
+	sent := false
+	applicants.SendPasswordResetMessageFunction = func(domain, apiKey, token string, applicant *accountmanagement.Applicant) (string, error) {
+		sent = true
+		return "", nil
+	}
+
+	defer func() {
+		applicants.SendPasswordResetMessageFunction = applicants.SendPasswordResetMessage
+	}()
+
+	requestBody, err := json.Marshal(map[string]string{"email": fmt.Sprintf("missing-%s@site.com", encryption.GeneratePassword(9))})
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	response, err := http.Post(ts.URL, "application/json", bytes.NewBuffer(requestBody))
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.False(sent)
+}
+
+func Test_Applicant_ForgotPassword_ResetPassword_Correct(t *testing.T) {
+	assert := assert.New(t)
+
+	useTestEmailLinkLimiter(t)
+
+	forgot := httptest.NewServer(http.HandlerFunc(applicants.ForgotPassword))
+	defer forgot.Close()
+
+	reset := httptest.NewServer(http.HandlerFunc(applicants.ResetPassword))
+	defer reset.Close()
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+
+	var emailedToken string
+	applicants.SendPasswordResetMessageFunction = func(domain, apiKey, token string, applicant *accountmanagement.Applicant) (string, error) {
+		emailedToken = token
+		return "", nil
+	}
+
+	defer func() {
+		applicants.SendPasswordResetMessageFunction = applicants.SendPasswordResetMessage
+	}()
+
+	requestBody, err := json.Marshal(map[string]string{"email": applicant.Email})
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	response, err := http.Post(forgot.URL, "application/json", bytes.NewBuffer(requestBody))
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.NotEqual("", emailedToken)
+
+	requestBody, err = json.Marshal(map[string]string{"token": emailedToken, "newpassword": string(encryption.GeneratePassword(12))})
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	response, err = http.Post(reset.URL, "application/json", bytes.NewBuffer(requestBody))
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	assert.Equal(http.StatusOK, response.StatusCode)
+
+	// the token is single use
+	response, err = http.Post(reset.URL, "application/json", bytes.NewBuffer(requestBody))
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	assert.Equal(http.StatusBadRequest, response.StatusCode)
+}
+
+func Test_Applicant_ResetPassword_InvalidToken(t *testing.T) {
+	assert := assert.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(applicants.ResetPassword))
+
+	defer ts.Close()
+
+	requestBody, err := json.Marshal(map[string]string{"token": "not-a-token", "newpassword": string(encryption.GeneratePassword(12))})
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	response, err := http.Post(ts.URL, "application/json", bytes.NewBuffer(requestBody))
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	assert.Equal(http.StatusBadRequest, response.StatusCode)
+}
+
+func Test_Applicant_ForgotPassword_MailFailureLooksLikeUnknownEmail(t *testing.T) {
+	assert := assert.New(t)
+
+	useTestEmailLinkLimiter(t)
+
+	applicants.SendPasswordResetMessageFunction = func(domain, apiKey, token string, applicant *accountmanagement.Applicant) (string, error) {
+		return "", errors.New("mailgun unavailable")
+	}
+
+	t.Cleanup(func() {
+		applicants.SendPasswordResetMessageFunction = applicants.SendPasswordResetMessage
+	})
+
+	forgot := httptest.NewServer(http.HandlerFunc(applicants.ForgotPassword))
+	defer forgot.Close()
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+
+	known, knownResult := postJSONWithToken(t, forgot.URL, "", map[string]string{"email": applicant.Email})
+	unknown, unknownResult := postJSONWithToken(t, forgot.URL, "", map[string]string{"email": "nobody-" + applicant.Email})
+
+	assert.Equal(http.StatusOK, known.StatusCode)
+	assert.Equal(unknown.StatusCode, known.StatusCode)
+	assert.Equal(unknownResult, knownResult)
+}
@@ -0,0 +1,388 @@
+package applicants
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	hr "autumnomous-jobs-applicant-api/route/middleware/httprouterwrapper"
+	applicants "autumnomous-jobs-applicant-api/shared/repository/applicants"
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+	"autumnomous-jobs-applicant-api/shared/response"
+	"autumnomous-jobs-applicant-api/shared/services/audit"
+	"autumnomous-jobs-applicant-api/shared/services/security/principal"
+)
+
+// profileEntry is one entry in a section of the applicant's profile
+type profileEntry interface {
+	Validate() []accountmanagement.FieldViolation
+}
+
+// profileSection is a list on the applicant's profile, such as their work experience, and how to
+// store its entries
+type profileSection struct {
+	name   string
+	entry  func() profileEntry
+	list   func(publicID string) (interface{}, error)
+	create func(publicID string, entry profileEntry) error
+	update func(publicID, id string, entry profileEntry) (bool, error)
+	delete func(publicID, id string) (bool, error)
+
+	// updateEntry is what an update's body holds, when that isn't a whole entry
+	updateEntry func() profileEntry
+
+	// auditType is recorded for changes to the section in place of AuditProfileChanged
+	auditType string
+}
+
+func (section profileSection) changeType() string {
+
+	if section.auditType != "" {
+		return section.auditType
+	}
+
+	return accountmanagement.AuditProfileChanged
+}
+
+var workExperienceSection = profileSection{
+	name: "workexperience",
+	entry: func() profileEntry {
+		return &accountmanagement.WorkExperience{}
+	},
+	list: func(publicID string) (interface{}, error) {
+		return applicants.NewApplicantRegistry().GetWorkExperienceRepository().GetWorkExperience(publicID)
+	},
+	create: func(publicID string, entry profileEntry) error {
+		return applicants.NewApplicantRegistry().GetWorkExperienceRepository().CreateWorkExperience(publicID, entry.(*accountmanagement.WorkExperience))
+	},
+	update: func(publicID, id string, entry profileEntry) (bool, error) {
+		entry.(*accountmanagement.WorkExperience).PublicID = id
+		return applicants.NewApplicantRegistry().GetWorkExperienceRepository().UpdateWorkExperience(publicID, entry.(*accountmanagement.WorkExperience))
+	},
+	delete: func(publicID, id string) (bool, error) {
+		return applicants.NewApplicantRegistry().GetWorkExperienceRepository().DeleteWorkExperience(publicID, id)
+	},
+}
+
+var educationSection = profileSection{
+	name: "education",
+	entry: func() profileEntry {
+		return &accountmanagement.Education{}
+	},
+	list: func(publicID string) (interface{}, error) {
+		return applicants.NewApplicantRegistry().GetEducationRepository().GetEducation(publicID)
+	},
+	create: func(publicID string, entry profileEntry) error {
+		return applicants.NewApplicantRegistry().GetEducationRepository().CreateEducation(publicID, entry.(*accountmanagement.Education))
+	},
+	update: func(publicID, id string, entry profileEntry) (bool, error) {
+		entry.(*accountmanagement.Education).PublicID = id
+		return applicants.NewApplicantRegistry().GetEducationRepository().UpdateEducation(publicID, entry.(*accountmanagement.Education))
+	},
+	delete: func(publicID, id string) (bool, error) {
+		return applicants.NewApplicantRegistry().GetEducationRepository().DeleteEducation(publicID, id)
+	},
+}
+
+var certificationsSection = profileSection{
+	name: "certifications",
+	entry: func() profileEntry {
+		return &accountmanagement.Certification{}
+	},
+	list: func(publicID string) (interface{}, error) {
+		return applicants.NewApplicantRegistry().GetCertificationRepository().GetCertifications(publicID)
+	},
+	create: func(publicID string, entry profileEntry) error {
+		return applicants.NewApplicantRegistry().GetCertificationRepository().CreateCertification(publicID, entry.(*accountmanagement.Certification))
+	},
+	update: func(publicID, id string, entry profileEntry) (bool, error) {
+		entry.(*accountmanagement.Certification).PublicID = id
+		return applicants.NewApplicantRegistry().GetCertificationRepository().UpdateCertification(publicID, entry.(*accountmanagement.Certification))
+	},
+	delete: func(publicID, id string) (bool, error) {
+		return applicants.NewApplicantRegistry().GetCertificationRepository().DeleteCertification(publicID, id)
+	},
+}
+
+// skillsSection adds skills by name, and updates only their proficiency
+var skillsSection = profileSection{
+	name: "skills",
+	entry: func() profileEntry {
+		return &accountmanagement.Skill{}
+	},
+	updateEntry: func() profileEntry {
+		return &skillProficiency{}
+	},
+	list: func(publicID string) (interface{}, error) {
+		return applicants.NewApplicantRegistry().GetSkillRepository().GetSkills(publicID)
+	},
+	create: func(publicID string, entry profileEntry) error {
+		return applicants.NewApplicantRegistry().GetSkillRepository().AddSkill(publicID, entry.(*accountmanagement.Skill))
+	},
+	update: func(publicID, id string, entry profileEntry) (bool, error) {
+		skill := &entry.(*skillProficiency).Skill
+		skill.PublicID = id
+		return applicants.NewApplicantRegistry().GetSkillRepository().UpdateSkillProficiency(publicID, skill)
+	},
+	delete: func(publicID, id string) (bool, error) {
+		return applicants.NewApplicantRegistry().GetSkillRepository().RemoveSkill(publicID, id)
+	},
+}
+
+// skillProficiency is the body of a request to change how well the applicant has a skill
+type skillProficiency struct {
+	accountmanagement.Skill
+}
+
+func (skill *skillProficiency) Validate() []accountmanagement.FieldViolation {
+	return skill.ValidateProficiency()
+}
+
+func GetWorkExperience(w http.ResponseWriter, r *http.Request) {
+	listProfileSection(w, r, workExperienceSection)
+}
+
+func AddWorkExperience(w http.ResponseWriter, r *http.Request) {
+	addProfileEntry(w, r, workExperienceSection)
+}
+
+func UpdateWorkExperience(w http.ResponseWriter, r *http.Request) {
+	updateProfileEntry(w, r, workExperienceSection)
+}
+
+func DeleteWorkExperience(w http.ResponseWriter, r *http.Request) {
+	deleteProfileEntry(w, r, workExperienceSection)
+}
+
+func GetEducation(w http.ResponseWriter, r *http.Request) {
+	listProfileSection(w, r, educationSection)
+}
+
+func AddEducation(w http.ResponseWriter, r *http.Request) {
+	addProfileEntry(w, r, educationSection)
+}
+
+func UpdateEducation(w http.ResponseWriter, r *http.Request) {
+	updateProfileEntry(w, r, educationSection)
+}
+
+func DeleteEducation(w http.ResponseWriter, r *http.Request) {
+	deleteProfileEntry(w, r, educationSection)
+}
+
+func GetCertifications(w http.ResponseWriter, r *http.Request) {
+	listProfileSection(w, r, certificationsSection)
+}
+
+func AddCertification(w http.ResponseWriter, r *http.Request) {
+	addProfileEntry(w, r, certificationsSection)
+}
+
+func UpdateCertification(w http.ResponseWriter, r *http.Request) {
+	updateProfileEntry(w, r, certificationsSection)
+}
+
+func DeleteCertification(w http.ResponseWriter, r *http.Request) {
+	deleteProfileEntry(w, r, certificationsSection)
+}
+
+func GetSkills(w http.ResponseWriter, r *http.Request) {
+	listProfileSection(w, r, skillsSection)
+}
+
+// AddSkill gives the applicant a skill by name, or changes their proficiency if they already have it
+func AddSkill(w http.ResponseWriter, r *http.Request) {
+	addProfileEntry(w, r, skillsSection)
+}
+
+// UpdateSkill changes how well the applicant has a skill; the skill's name can't be changed
+func UpdateSkill(w http.ResponseWriter, r *http.Request) {
+	updateProfileEntry(w, r, skillsSection)
+}
+
+func RemoveSkill(w http.ResponseWriter, r *http.Request) {
+	deleteProfileEntry(w, r, skillsSection)
+}
+
+func listProfileSection(w http.ResponseWriter, r *http.Request, section profileSection) {
+
+	if r.Method != http.MethodGet {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	publicID := principal.ApplicantID(r)
+
+	if publicID == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.FriendlyError)
+		return
+	}
+
+	entries, err := section.list(publicID)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	response.SendJSON(w, map[string]interface{}{section.name: entries})
+}
+
+func addProfileEntry(w http.ResponseWriter, r *http.Request, section profileSection) {
+
+	if r.Method != http.MethodPost {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	publicID := principal.ApplicantID(r)
+
+	if publicID == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.FriendlyError)
+		return
+	}
+
+	entry := section.entry()
+
+	if !decodeProfileEntry(w, r, entry) {
+		return
+	}
+
+	err := section.create(publicID, entry)
+
+	if err == accountmanagement.ErrTooManyEntries {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.TooManyEntries)
+		return
+	}
+
+	if err == accountmanagement.ErrUnknownDocument {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.UnknownDocument)
+		return
+	}
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	audit.Record(r, section.changeType(), publicID, audit.Changes{section.name: {After: entry}})
+
+	response.SendJSON(w, entry)
+}
+
+func updateProfileEntry(w http.ResponseWriter, r *http.Request, section profileSection) {
+
+	if r.Method != http.MethodPut {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	publicID := principal.ApplicantID(r)
+
+	if publicID == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.FriendlyError)
+		return
+	}
+
+	entry := section.entry()
+
+	if section.updateEntry != nil {
+		entry = section.updateEntry()
+	}
+
+	if !decodeProfileEntry(w, r, entry) {
+		return
+	}
+
+	updated, err := section.update(publicID, hr.Params(r).ByName("id"), entry)
+
+	if err == accountmanagement.ErrUnknownDocument {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.UnknownDocument)
+		return
+	}
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	if !updated {
+		response.SendJSONMessage(w, http.StatusNotFound, response.EmptyResult)
+		return
+	}
+
+	audit.Record(r, section.changeType(), publicID, audit.Changes{section.name: {After: entry}})
+
+	response.SendJSON(w, entry)
+}
+
+func deleteProfileEntry(w http.ResponseWriter, r *http.Request, section profileSection) {
+
+	if r.Method != http.MethodDelete {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	publicID := principal.ApplicantID(r)
+
+	if publicID == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.FriendlyError)
+		return
+	}
+
+	id := hr.Params(r).ByName("id")
+
+	deleted, err := section.delete(publicID, id)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	if !deleted {
+		response.SendJSONMessage(w, http.StatusNotFound, response.EmptyResult)
+		return
+	}
+
+	audit.Record(r, section.changeType(), publicID, audit.Changes{section.name: {Before: id}})
+
+	response.SendJSONMessage(w, http.StatusOK, response.Success)
+}
+
+// decodeProfileEntry reads entry from the request body and responds with its field errors when it isn't valid
+func decodeProfileEntry(w http.ResponseWriter, r *http.Request, entry profileEntry) bool {
+
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(entry)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusBadRequest, response.FriendlyError)
+		return false
+	}
+
+	violations := entry.Validate()
+
+	if len(violations) == 0 {
+		return true
+	}
+
+	sendFieldViolations(w, violations)
+	return false
+}
+
+// sendFieldViolations responds 400 with the field errors
+func sendFieldViolations(w http.ResponseWriter, violations []accountmanagement.FieldViolation) {
+
+	errors := make([]response.FieldError, len(violations))
+
+	for i, violation := range violations {
+		errors[i] = response.FieldError{Field: violation.Field, Code: violation.Code, Message: violation.Message}
+	}
+
+	response.SendValidationErrors(w, response.InvalidFields, errors)
+}
@@ -0,0 +1,136 @@
+package applicants_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"autumnomous-jobs-applicant-api/controller/v1/applicants"
+	"autumnomous-jobs-applicant-api/route/middleware/acl"
+	hr "autumnomous-jobs-applicant-api/route/middleware/httprouterwrapper"
+	"autumnomous-jobs-applicant-api/shared/testhelper"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/justinas/alice"
+	"github.com/stretchr/testify/assert"
+)
+
+func sendJSONWithSessionToken(t *testing.T, method, url, token string, body interface{}) (*http.Response, map[string]interface{}) {
+
+	requestBody, err := json.Marshal(body)
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	request, err := http.NewRequest(method, url, bytes.NewBuffer(requestBody))
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Authorization", "Bearer "+token)
+
+	response, err := http.DefaultClient.Do(request)
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	var result map[string]interface{}
+	json.NewDecoder(response.Body).Decode(&result)
+
+	return response, result
+}
+
+func profileServer() *httptest.Server {
+
+	r := httprouter.New()
+	r.POST("/login", hr.Handler(alice.New().ThenFunc(applicants.Login)))
+	r.GET("/get", hr.Handler(alice.New(acl.ValidateJWT).ThenFunc(applicants.GetApplicant)))
+	r.POST("/work-experience", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.AddWorkExperience)))
+	r.PUT("/work-experience/:id", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.UpdateWorkExperience)))
+	r.DELETE("/work-experience/:id", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.DeleteWorkExperience)))
+	r.POST("/skills", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.AddSkill)))
+	r.PUT("/skills/:id", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.UpdateSkill)))
+
+	return httptest.NewServer(r)
+}
+
+func Test_Applicant_WorkExperience(t *testing.T) {
+	assert := assert.New(t)
+
+	useTestLoginLimiter(t, 10)
+
+	ts := profileServer()
+	defer ts.Close()
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	token := sessionLogin(t, ts.URL+"/login", "browser", applicant)
+
+	response, result := sendJSONWithSessionToken(t, "POST", ts.URL+"/work-experience", token, map[string]string{"employer": "Acme", "startdate": "2020-13-01"})
+	assert.Equal(http.StatusBadRequest, response.StatusCode)
+	assert.Len(result["errors"], 2)
+
+	response, result = sendJSONWithSessionToken(t, "POST", ts.URL+"/work-experience", token, map[string]string{"employer": "Acme", "title": "Engineer", "startdate": "2020-01-01"})
+	assert.Equal(http.StatusOK, response.StatusCode)
+
+	id := result["id"].(string)
+
+	// the id in the path wins over one in the body
+	response, _ = sendJSONWithSessionToken(t, "PUT", ts.URL+"/work-experience/"+id, token, map[string]string{"id": "other", "employer": "Acme", "title": "Lead Engineer", "startdate": "2020-01-01"})
+	assert.Equal(http.StatusOK, response.StatusCode)
+
+	response, result = sendWithSessionToken(t, "GET", ts.URL+"/get?include=workexperience", token)
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Equal(applicant.Email, result["email"])
+
+	experiences := result["workexperience"].([]interface{})
+
+	if assert.Len(experiences, 1) {
+		assert.Equal("Lead Engineer", experiences[0].(map[string]interface{})["title"])
+	}
+
+	assert.Nil(result["skills"])
+
+	response, _ = sendWithSessionToken(t, "GET", ts.URL+"/get?include=hobbies", token)
+	assert.Equal(http.StatusBadRequest, response.StatusCode)
+
+	response, _ = sendWithSessionToken(t, "DELETE", ts.URL+"/work-experience/"+id, token)
+	assert.Equal(http.StatusOK, response.StatusCode)
+
+	response, _ = sendWithSessionToken(t, "DELETE", ts.URL+"/work-experience/"+id, token)
+	assert.Equal(http.StatusNotFound, response.StatusCode)
+}
+
+func Test_Applicant_Skills(t *testing.T) {
+	assert := assert.New(t)
+
+	useTestLoginLimiter(t, 10)
+
+	ts := profileServer()
+	defer ts.Close()
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	token := sessionLogin(t, ts.URL+"/login", "browser", applicant)
+
+	response, result := sendJSONWithSessionToken(t, "POST", ts.URL+"/skills", token, map[string]string{"name": "Welding", "proficiency": "expert"})
+	assert.Equal(http.StatusOK, response.StatusCode)
+
+	id := result["id"].(string)
+
+	response, _ = sendJSONWithSessionToken(t, "PUT", ts.URL+"/skills/"+id, token, map[string]string{"proficiency": "guru"})
+	assert.Equal(http.StatusBadRequest, response.StatusCode)
+
+	response, result = sendJSONWithSessionToken(t, "PUT", ts.URL+"/skills/"+id, token, map[string]string{"proficiency": "advanced"})
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Equal("advanced", result["proficiency"])
+
+	response, result = sendWithSessionToken(t, "GET", ts.URL+"/get?include=all", token)
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Len(result["skills"], 1)
+	assert.Len(result["education"], 0)
+}
@@ -0,0 +1,375 @@
+package applicants
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+
+	hr "autumnomous-jobs-applicant-api/route/middleware/httprouterwrapper"
+	applicants "autumnomous-jobs-applicant-api/shared/repository/applicants"
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+	"autumnomous-jobs-applicant-api/shared/response"
+	"autumnomous-jobs-applicant-api/shared/services/audit"
+	"autumnomous-jobs-applicant-api/shared/services/resume"
+	"autumnomous-jobs-applicant-api/shared/services/security/principal"
+	"autumnomous-jobs-applicant-api/shared/services/storage"
+
+	"github.com/google/uuid"
+)
+
+// MaxResumeSize caps the size of an uploaded resume
+const MaxResumeSize = 5 << 20
+
+var resumeExtensions = map[string]string{
+	resume.ContentTypePDF:  ".pdf",
+	resume.ContentTypeDOCX: ".docx",
+}
+
+// UploadResume stores a new version of one of the applicant's resumes, privately, from the "file"
+// field of a multipart form. The "name" field says which resume it's a version of. Its text is read
+// to suggest work experience and skills for their profile, which are returned with it for the
+// applicant to check; nothing is added to the profile itself.
+func UploadResume(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPost {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	publicID := principal.ApplicantID(r)
+
+	if publicID == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.FriendlyError)
+		return
+	}
+
+	// leave room for the rest of the form around the file
+	r.Body = http.MaxBytesReader(w, r.Body, MaxResumeSize+(1<<20))
+
+	err := r.ParseMultipartForm(MaxResumeSize)
+
+	if err != nil {
+		if strings.Contains(err.Error(), "request body too large") {
+			response.SendJSONMessage(w, http.StatusRequestEntityTooLarge, response.FileTooLarge)
+			return
+		}
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusBadRequest, response.MissingRequiredValue)
+		return
+	}
+
+	file, handler, err := r.FormFile("file")
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusBadRequest, response.MissingRequiredValue)
+		return
+	}
+
+	defer file.Close()
+
+	version := &accountmanagement.Resume{Name: r.FormValue("name")}
+
+	if violations := version.ValidateName(); len(violations) > 0 {
+		sendFieldViolations(w, violations)
+		return
+	}
+
+	if handler.Size > MaxResumeSize {
+		response.SendJSONMessage(w, http.StatusRequestEntityTooLarge, response.FileTooLarge)
+		return
+	}
+
+	data, err := ioutil.ReadAll(file)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	contentType, err := resume.DetectType(data)
+
+	if err != nil {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.UnsupportedResume)
+		return
+	}
+
+	// a resume whose text can't be read is still kept, it just suggests nothing
+	text, err := resume.ExtractText(data, contentType)
+
+	if err != nil {
+		log.Println(err)
+	}
+
+	store, err := StorageFunction()
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	version.ObjectKey = fmt.Sprintf("resumes/%s/%s%s", publicID, uuid.NewString(), resumeExtensions[contentType])
+	version.FileName = handler.Filename
+	version.ContentType = contentType
+	version.Size = int64(len(data))
+	version.Text = text
+
+	err = store.Put(version.ObjectKey, bytes.NewReader(data), contentType, false)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	err = applicants.NewApplicantRegistry().GetResumeRepository().CreateResume(publicID, version)
+
+	if err != nil {
+		if deleteErr := store.Delete(version.ObjectKey); deleteErr != nil {
+			log.Println(deleteErr)
+		}
+
+		if err == accountmanagement.ErrTooManyEntries {
+			response.SendJSONMessage(w, http.StatusBadRequest, response.TooManyEntries)
+			return
+		}
+
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	pruneResumes(publicID, store)
+
+	audit.Record(r, accountmanagement.AuditResumeUploaded, publicID, audit.Changes{
+		"name":     {After: version.Name},
+		"filename": {After: version.FileName},
+		"version":  {After: version.Version},
+	})
+
+	response.SendJSON(w, map[string]interface{}{
+		"resume":      version,
+		"suggestions": resume.Parse(text),
+	})
+}
+
+// pruneResumes removes the oldest versions of each of the applicant's resumes beyond the number
+// they keep
+func pruneResumes(publicID string, store storage.Store) {
+
+	repository := applicants.NewApplicantRegistry().GetResumeRepository()
+
+	versions, err := repository.GetResumes(publicID)
+
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	kept := map[string]int{}
+
+	// versions come newest first within each name
+	for _, version := range versions {
+		kept[version.Name]++
+
+		if kept[version.Name] <= accountmanagement.MaxResumeVersions {
+			continue
+		}
+
+		objectKey, err := repository.DeleteResume(publicID, version.PublicID)
+
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		if objectKey == "" {
+			continue
+		}
+
+		if err = store.Delete(objectKey); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// GetResumes lists the versions of the applicant's resumes by name, newest first
+func GetResumes(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	publicID := principal.ApplicantID(r)
+
+	if publicID == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.FriendlyError)
+		return
+	}
+
+	versions, err := applicants.NewApplicantRegistry().GetResumeRepository().GetResumes(publicID)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	response.SendJSON(w, map[string]interface{}{"resumes": versions})
+}
+
+// DownloadResume sends the file of a version of the applicant's resume
+func DownloadResume(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	publicID := principal.ApplicantID(r)
+
+	if publicID == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.FriendlyError)
+		return
+	}
+
+	version, ok := getResume(w, publicID, hr.Params(r).ByName("id"))
+
+	if !ok {
+		return
+	}
+
+	store, err := StorageFunction()
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	body, err := store.Get(version.ObjectKey)
+
+	if err == storage.ErrNotFound {
+		response.SendJSONMessage(w, http.StatusNotFound, response.EmptyResult)
+		return
+	}
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	defer body.Close()
+
+	w.Header().Set("Content-Type", version.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": version.FileName}))
+	w.Header().Set("Content-Length", fmt.Sprint(version.Size))
+
+	_, err = io.Copy(w, body)
+
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// GetResumeSuggestions reads the work experience and skills suggested by a version of the
+// applicant's resume again, to pre-fill their profile with
+func GetResumeSuggestions(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	publicID := principal.ApplicantID(r)
+
+	if publicID == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.FriendlyError)
+		return
+	}
+
+	version, ok := getResume(w, publicID, hr.Params(r).ByName("id"))
+
+	if !ok {
+		return
+	}
+
+	response.SendJSON(w, map[string]interface{}{
+		"resume":      version,
+		"suggestions": resume.Parse(version.Text),
+	})
+}
+
+// DeleteResume removes a version of the applicant's resume and its file
+func DeleteResume(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodDelete {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	publicID := principal.ApplicantID(r)
+
+	if publicID == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.FriendlyError)
+		return
+	}
+
+	id := hr.Params(r).ByName("id")
+
+	objectKey, err := applicants.NewApplicantRegistry().GetResumeRepository().DeleteResume(publicID, id)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	if objectKey == "" {
+		response.SendJSONMessage(w, http.StatusNotFound, response.EmptyResult)
+		return
+	}
+
+	store, err := StorageFunction()
+
+	if err == nil {
+		err = store.Delete(objectKey)
+	}
+
+	// the record is gone either way; a file left behind is only logged
+	if err != nil {
+		log.Println(err)
+	}
+
+	audit.Record(r, accountmanagement.AuditResumeDeleted, publicID, audit.Changes{"resume": {Before: id}})
+
+	response.SendJSONMessage(w, http.StatusOK, response.Success)
+}
+
+// getResume looks up a version of the applicant's resume, responding itself when there isn't one
+func getResume(w http.ResponseWriter, publicID, id string) (*accountmanagement.Resume, bool) {
+
+	version, err := applicants.NewApplicantRegistry().GetResumeRepository().GetResume(publicID, id)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return nil, false
+	}
+
+	if version == nil {
+		response.SendJSONMessage(w, http.StatusNotFound, response.EmptyResult)
+		return nil, false
+	}
+
+	return version, true
+}
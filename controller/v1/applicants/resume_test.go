@@ -0,0 +1,170 @@
+package applicants_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"autumnomous-jobs-applicant-api/controller/v1/applicants"
+	"autumnomous-jobs-applicant-api/route/middleware/acl"
+	hr "autumnomous-jobs-applicant-api/route/middleware/httprouterwrapper"
+	"autumnomous-jobs-applicant-api/shared/services/resume"
+	"autumnomous-jobs-applicant-api/shared/services/storage"
+	"autumnomous-jobs-applicant-api/shared/testhelper"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/justinas/alice"
+	"github.com/stretchr/testify/assert"
+)
+
+func testResumeDOCX(t *testing.T, paragraphs ...string) []byte {
+
+	var buffer bytes.Buffer
+	writer := zip.NewWriter(&buffer)
+
+	part, err := writer.Create("word/document.xml")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := `<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>`
+
+	for _, paragraph := range paragraphs {
+		body += `<w:p><w:r><w:t>` + paragraph + `</w:t></w:r></w:p>`
+	}
+
+	part.Write([]byte(body + `</w:body></w:document>`))
+	writer.Close()
+
+	return buffer.Bytes()
+}
+
+func postResume(t *testing.T, url, token, fileName string, data []byte) (*http.Response, map[string]interface{}) {
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	part, err := writer.CreateFormFile("file", fileName)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	part.Write(data)
+	writer.Close()
+
+	request, err := http.NewRequest("POST", url, &body)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	request.Header.Set("Authorization", "Bearer "+token)
+
+	response, err := http.DefaultClient.Do(request)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var result map[string]interface{}
+	json.NewDecoder(response.Body).Decode(&result)
+
+	return response, result
+}
+
+func Test_Applicant_Resume(t *testing.T) {
+	assert := assert.New(t)
+
+	useTestLoginLimiter(t, 10)
+
+	store := storage.NewMemoryStore()
+	applicants.StorageFunction = func() (storage.Store, error) { return store, nil }
+
+	t.Cleanup(func() {
+		applicants.StorageFunction = storage.NewSpacesStore
+	})
+
+	r := httprouter.New()
+	r.POST("/login", hr.Handler(alice.New().ThenFunc(applicants.Login)))
+	r.POST("/resume", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.UploadResume)))
+	r.GET("/resume", hr.Handler(alice.New(acl.ValidateJWT).ThenFunc(applicants.GetResumes)))
+	r.GET("/resume/:id", hr.Handler(alice.New(acl.ValidateJWT).ThenFunc(applicants.DownloadResume)))
+	r.GET("/resume/:id/suggestions", hr.Handler(alice.New(acl.ValidateJWT).ThenFunc(applicants.GetResumeSuggestions)))
+	r.DELETE("/resume/:id", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.DeleteResume)))
+
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	token := sessionLogin(t, ts.URL+"/login", "browser", applicant)
+
+	// the type comes from the content, not the name
+	response, _ := postResume(t, ts.URL+"/resume", token, "resume.pdf", []byte("just some text"))
+	assert.Equal(http.StatusBadRequest, response.StatusCode)
+
+	data := testResumeDOCX(t, "Jo Bloggs", "Experience", "Engineer at Acme Jan 2019 - Present", "Skills", "Go, SQL")
+
+	response, result := postResume(t, ts.URL+"/resume", token, "jo.docx", data)
+	assert.Equal(http.StatusOK, response.StatusCode)
+
+	uploaded := result["resume"].(map[string]interface{})
+	assert.Equal(float64(1), uploaded["version"])
+	assert.Equal(resume.ContentTypeDOCX, uploaded["contenttype"])
+
+	suggestions := result["suggestions"].(map[string]interface{})
+	assert.Equal([]interface{}{"Go", "SQL"}, suggestions["skills"])
+
+	if experience := suggestions["workexperience"].([]interface{}); assert.Len(experience, 1) {
+		assert.Equal("Acme", experience[0].(map[string]interface{})["employer"])
+		assert.Equal("2019-01-01", experience[0].(map[string]interface{})["startdate"])
+	}
+
+	response, _ = postResume(t, ts.URL+"/resume", token, "jo-2.docx", data)
+	assert.Equal(http.StatusOK, response.StatusCode)
+
+	response, result = sendWithSessionToken(t, "GET", ts.URL+"/resume", token)
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Len(result["resumes"], 2)
+
+	id := uploaded["id"].(string)
+
+	response, result = sendWithSessionToken(t, "GET", ts.URL+"/resume/"+id+"/suggestions", token)
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Equal([]interface{}{"Go", "SQL"}, result["suggestions"].(map[string]interface{})["skills"])
+
+	request, _ := http.NewRequest("GET", ts.URL+"/resume/"+id, nil)
+	request.Header.Set("Authorization", "Bearer "+token)
+
+	download, err := http.DefaultClient.Do(request)
+	assert.Nil(err)
+	assert.Equal(http.StatusOK, download.StatusCode)
+	assert.Equal(`attachment; filename=jo.docx`, download.Header.Get("Content-Disposition"))
+
+	downloaded, _ := ioutil.ReadAll(download.Body)
+	download.Body.Close()
+	assert.Equal(data, downloaded)
+
+	// someone else can't see it
+	other := testhelper.Helper_RandomApplicant(t)
+	otherToken := sessionLogin(t, ts.URL+"/login", "browser", other)
+
+	response, _ = sendWithSessionToken(t, "GET", ts.URL+"/resume/"+id, otherToken)
+	assert.Equal(http.StatusNotFound, response.StatusCode)
+
+	response, _ = sendWithSessionToken(t, "DELETE", ts.URL+"/resume/"+id, token)
+	assert.Equal(http.StatusOK, response.StatusCode)
+
+	response, _ = sendWithSessionToken(t, "GET", ts.URL+"/resume/"+id, token)
+	assert.Equal(http.StatusNotFound, response.StatusCode)
+
+	response, result = sendWithSessionToken(t, "GET", ts.URL+"/resume", token)
+	assert.Len(result["resumes"], 1)
+}
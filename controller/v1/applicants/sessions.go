@@ -0,0 +1,90 @@
+package applicants
+
+import (
+	"log"
+	"net/http"
+
+	hr "autumnomous-jobs-applicant-api/route/middleware/httprouterwrapper"
+	applicants "autumnomous-jobs-applicant-api/shared/repository/applicants"
+	"autumnomous-jobs-applicant-api/shared/response"
+	"autumnomous-jobs-applicant-api/shared/services/security/principal"
+)
+
+// GetSessions lists the devices the applicant is signed in on, marking the one making the request
+func GetSessions(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	current := principal.FromRequest(r)
+
+	if current == nil || current.ApplicantID == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.FriendlyError)
+		return
+	}
+
+	sessions, err := applicants.NewApplicantRegistry().GetSessionRepository().GetSessions(current.ApplicantID)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	list := []map[string]interface{}{}
+
+	for _, session := range sessions {
+		list = append(list, map[string]interface{}{
+			"id":         session.PublicID,
+			"authmethod": session.AuthMethod,
+			"useragent":  session.UserAgent,
+			"ipaddress":  session.IPAddress,
+			"lastseenip": session.LastSeenIP,
+			"createdate": session.CreateDate,
+			"lastseenat": session.LastSeenAt,
+			"current":    session.PublicID == current.SessionID,
+		})
+	}
+
+	response.SendJSON(w, map[string]interface{}{"sessions": list})
+}
+
+// RevokeSession signs the applicant out of the session named in the path
+func RevokeSession(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodDelete {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	publicID := principal.ApplicantID(r)
+
+	if publicID == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.FriendlyError)
+		return
+	}
+
+	sessionID := hr.Params(r).ByName("id")
+
+	if sessionID == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.MissingRequiredValue)
+		return
+	}
+
+	revoked, err := applicants.NewApplicantRegistry().GetSessionRepository().RevokeSession(publicID, sessionID)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	if !revoked {
+		response.SendJSONMessage(w, http.StatusNotFound, response.EmptyResult)
+		return
+	}
+
+	response.SendJSONMessage(w, http.StatusOK, response.Success)
+}
@@ -0,0 +1,177 @@
+package applicants_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"autumnomous-jobs-applicant-api/controller/v1/applicants"
+	"autumnomous-jobs-applicant-api/route/middleware/acl"
+	hr "autumnomous-jobs-applicant-api/route/middleware/httprouterwrapper"
+	"autumnomous-jobs-applicant-api/shared/testhelper"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/justinas/alice"
+	"github.com/stretchr/testify/assert"
+)
+
+// sessionLogin signs the applicant in from userAgent and returns the encoded access token
+func sessionLogin(t *testing.T, url, userAgent string, applicant *testhelper.TestApplicant) string {
+
+	requestBody, err := json.Marshal(map[string]string{"email": applicant.Email, "password": applicant.Password})
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	request, err := http.NewRequest("POST", url, bytes.NewBuffer(requestBody))
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	request.Header.Set("User-Agent", userAgent)
+
+	response, err := http.DefaultClient.Do(request)
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	var tokens map[string]interface{}
+	json.NewDecoder(response.Body).Decode(&tokens)
+
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("login failed with %d", response.StatusCode)
+	}
+
+	return tokens["token"].(string)
+}
+
+func sendWithSessionToken(t *testing.T, method, url, token string) (*http.Response, map[string]interface{}) {
+
+	request, err := http.NewRequest(method, url, nil)
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	request.Header.Set("Authorization", "Bearer "+token)
+
+	response, err := http.DefaultClient.Do(request)
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	var result map[string]interface{}
+	json.NewDecoder(response.Body).Decode(&result)
+
+	return response, result
+}
+
+func Test_Applicant_Sessions_ListAndRevoke(t *testing.T) {
+	assert := assert.New(t)
+
+	useTestLoginLimiter(t, 10)
+
+	r := httprouter.New()
+	r.POST("/login", hr.Handler(alice.New().ThenFunc(applicants.Login)))
+	r.GET("/sessions", hr.Handler(alice.New(acl.ValidateJWT).ThenFunc(applicants.GetSessions)))
+	r.DELETE("/sessions/:id", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.RevokeSession)))
+
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+
+	laptop := sessionLogin(t, ts.URL+"/login", "laptop-browser", applicant)
+	phone := sessionLogin(t, ts.URL+"/login", "phone-app", applicant)
+
+	response, result := sendWithSessionToken(t, "GET", ts.URL+"/sessions", laptop)
+	assert.Equal(http.StatusOK, response.StatusCode)
+
+	sessions := result["sessions"].([]interface{})
+	assert.Len(sessions, 2)
+
+	var phoneSession string
+
+	for _, s := range sessions {
+		session := s.(map[string]interface{})
+
+		if session["useragent"] == "phone-app" {
+			phoneSession = session["id"].(string)
+			assert.Equal(false, session["current"])
+		} else {
+			assert.Equal("laptop-browser", session["useragent"])
+			assert.Equal(true, session["current"])
+		}
+	}
+
+	response, _ = sendWithSessionToken(t, "DELETE", ts.URL+"/sessions/"+phoneSession, laptop)
+	assert.Equal(http.StatusOK, response.StatusCode)
+
+	// the phone's access token stops working straight away
+	response, _ = sendWithSessionToken(t, "GET", ts.URL+"/sessions", phone)
+	assert.Equal(http.StatusUnauthorized, response.StatusCode)
+
+	response, result = sendWithSessionToken(t, "GET", ts.URL+"/sessions", laptop)
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Len(result["sessions"], 1)
+
+	response, _ = sendWithSessionToken(t, "DELETE", ts.URL+"/sessions/"+phoneSession, laptop)
+	assert.Equal(http.StatusNotFound, response.StatusCode)
+}
+
+func Test_Applicant_RevokeSession_OtherApplicant(t *testing.T) {
+	assert := assert.New(t)
+
+	useTestLoginLimiter(t, 10)
+
+	r := httprouter.New()
+	r.POST("/login", hr.Handler(alice.New().ThenFunc(applicants.Login)))
+	r.GET("/sessions", hr.Handler(alice.New(acl.ValidateJWT).ThenFunc(applicants.GetSessions)))
+	r.DELETE("/sessions/:id", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.RevokeSession)))
+
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	victim := sessionLogin(t, ts.URL+"/login", "victim", testhelper.Helper_RandomApplicant(t))
+	attacker := sessionLogin(t, ts.URL+"/login", "attacker", testhelper.Helper_RandomApplicant(t))
+
+	_, result := sendWithSessionToken(t, "GET", ts.URL+"/sessions", victim)
+	session := result["sessions"].([]interface{})[0].(map[string]interface{})
+
+	response, _ := sendWithSessionToken(t, "DELETE", ts.URL+"/sessions/"+session["id"].(string), attacker)
+	assert.Equal(http.StatusNotFound, response.StatusCode)
+
+	response, _ = sendWithSessionToken(t, "GET", ts.URL+"/sessions", victim)
+	assert.Equal(http.StatusOK, response.StatusCode)
+}
+
+func Test_Applicant_GetSessions_IncorrectMethod(t *testing.T) {
+	assert := assert.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(applicants.GetSessions))
+
+	defer ts.Close()
+
+	for _, method := range []string{"POST", "PUT", "DELETE"} {
+
+		request, err := http.NewRequest(method, ts.URL, nil)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		response, err := http.DefaultClient.Do(request)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(http.StatusMethodNotAllowed, response.StatusCode)
+	}
+}
@@ -0,0 +1,153 @@
+package applicants
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	applicants "autumnomous-jobs-applicant-api/shared/repository/applicants"
+	"autumnomous-jobs-applicant-api/shared/response"
+	"autumnomous-jobs-applicant-api/shared/services/security/encryption"
+	"autumnomous-jobs-applicant-api/shared/services/security/jwt"
+	"autumnomous-jobs-applicant-api/shared/services/security/principal"
+	"autumnomous-jobs-applicant-api/shared/services/utils"
+)
+
+type refreshTokenData struct {
+	RefreshToken string `json:"refreshtoken"`
+}
+
+// RefreshToken exchanges a refresh token for a new access token and a rotated refresh token
+func RefreshToken(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPost {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	var data refreshTokenData
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&data)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	if data.RefreshToken == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.MissingRequiredValue)
+		return
+	}
+
+	newRefreshToken, err := jwt.GenerateRefreshToken()
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	refreshTokenRepository := applicants.NewApplicantRegistry().GetRefreshTokenRepository()
+
+	publicID, sessionID, reused, err := refreshTokenRepository.RotateRefreshToken(encryption.HashToken(data.RefreshToken), encryption.HashToken(newRefreshToken), jwt.RefreshTokenLifetime)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	if reused {
+		log.Println("refresh token reuse detected, token family revoked")
+	}
+
+	if publicID == "" {
+		response.SendJSONMessage(w, http.StatusUnauthorized, response.Unauthorized)
+		return
+	}
+
+	repository := applicants.NewApplicantRegistry().GetApplicantRepository()
+
+	applicant, err := repository.GetApplicant(publicID)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusUnauthorized, response.Unauthorized)
+		return
+	}
+
+	accessToken, err := jwt.GenerateAccessToken(publicID, applicant.Roles, principal.AuthMethodRefresh, sessionID)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	response.SendJSON(w, tokenResponse(accessToken, newRefreshToken, applicant.RegistrationStep))
+}
+
+// issueTokens starts a new session for the applicant, recording the device that made request r,
+// and signs an access token and the first refresh token of the session's family. It also cancels
+// any deletion of the account that is still in its grace period.
+func issueTokens(r *http.Request, publicID, registrationStep, authMethod string) (map[string]interface{}, error) {
+
+	roles, err := applicants.NewApplicantRegistry().GetApplicantRepository().GetApplicantRoles(publicID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := jwt.GenerateRefreshToken()
+
+	if err != nil {
+		return nil, err
+	}
+
+	refreshTokenRepository := applicants.NewApplicantRegistry().GetRefreshTokenRepository()
+
+	// the refresh token family is the session
+	sessionID, err := refreshTokenRepository.CreateRefreshToken(publicID, encryption.HashToken(refreshToken), jwt.RefreshTokenLifetime)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = applicants.NewApplicantRegistry().GetSessionRepository().CreateSession(publicID, sessionID, authMethod, r.UserAgent(), utils.ClientIP(r))
+
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := jwt.GenerateAccessToken(publicID, roles, authMethod, sessionID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	token := tokenResponse(accessToken, refreshToken, registrationStep)
+
+	// logging in during the grace period keeps the account
+	cancelled, err := cancelDeletion(r, publicID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if cancelled {
+		token["deletioncancelled"] = true
+	}
+
+	return token, nil
+}
+
+func tokenResponse(accessToken, refreshToken, registrationStep string) map[string]interface{} {
+	return map[string]interface{}{
+		"token":            base64.StdEncoding.EncodeToString([]byte(accessToken)),
+		"refreshtoken":     refreshToken,
+		"expiresin":        int64(jwt.AccessTokenLifetime.Seconds()),
+		"registrationstep": registrationStep,
+	}
+}
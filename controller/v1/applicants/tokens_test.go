@@ -0,0 +1,129 @@
+package applicants_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"autumnomous-jobs-applicant-api/controller/v1/applicants"
+	"autumnomous-jobs-applicant-api/shared/testhelper"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Applicant_RefreshToken_IncorrectMethod(t *testing.T) {
+	assert := assert.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(applicants.RefreshToken))
+
+	defer ts.Close()
+
+	methods := []string{"GET", "PUT", "DELETE"}
+
+	for _, method := range methods {
+
+		request, err := http.NewRequest(method, ts.URL, nil)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		client := &http.Client{}
+
+		response, err := client.Do(request)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(http.StatusMethodNotAllowed, response.StatusCode)
+	}
+}
+
+func Test_Applicant_RefreshToken_InvalidToken(t *testing.T) {
+	assert := assert.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(applicants.RefreshToken))
+
+	defer ts.Close()
+
+	requestBody, err := json.Marshal(map[string]string{"refreshtoken": "not-a-token"})
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	response, err := http.Post(ts.URL, "application/json", bytes.NewBuffer(requestBody))
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	assert.Equal(http.StatusUnauthorized, response.StatusCode)
+}
+
+func Test_Applicant_Login_RefreshToken_Rotates(t *testing.T) {
+	assert := assert.New(t)
+
+	login := httptest.NewServer(http.HandlerFunc(applicants.Login))
+	defer login.Close()
+
+	refresh := httptest.NewServer(http.HandlerFunc(applicants.RefreshToken))
+	defer refresh.Close()
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+
+	requestBody, err := json.Marshal(map[string]string{"email": applicant.Email, "password": applicant.Password})
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	response, err := http.Post(login.URL, "application/json", bytes.NewBuffer(requestBody))
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	var tokens map[string]interface{}
+	err = json.NewDecoder(response.Body).Decode(&tokens)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(http.StatusOK, response.StatusCode)
+
+	requestBody, err = json.Marshal(map[string]interface{}{"refreshtoken": tokens["refreshtoken"]})
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	response, err = http.Post(refresh.URL, "application/json", bytes.NewBuffer(requestBody))
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	var refreshed map[string]interface{}
+	err = json.NewDecoder(response.Body).Decode(&refreshed)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.NotEqual(tokens["refreshtoken"], refreshed["refreshtoken"])
+
+	// the first refresh token has been used, replaying it is rejected
+	response, err = http.Post(refresh.URL, "application/json", bytes.NewBuffer(requestBody))
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	assert.Equal(http.StatusUnauthorized, response.StatusCode)
+}
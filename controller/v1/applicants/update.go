@@ -2,8 +2,10 @@ package applicants
 
 import (
 	applicants "autumnomous-jobs-applicant-api/shared/repository/applicants"
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
 	"autumnomous-jobs-applicant-api/shared/response"
-	"autumnomous-jobs-applicant-api/shared/services/security/jwt"
+	"autumnomous-jobs-applicant-api/shared/services/audit"
+	"autumnomous-jobs-applicant-api/shared/services/security/principal"
 	"autumnomous-jobs-applicant-api/shared/services/zipcode"
 	"encoding/json"
 	"log"
@@ -31,10 +33,6 @@ type updateAccountData struct {
 	// Bio          string `json:"bio"`
 }
 
-type updateJobPreferencesData struct {
-	DesiredCities []map[string]interface{} `json:"desiredcities"`
-}
-
 func UpdatePassword(w http.ResponseWriter, r *http.Request) {
 
 	if r.Method != http.MethodPost {
@@ -57,7 +55,7 @@ func UpdatePassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	publicID := jwt.GetUserClaim(r)
+	publicID := principal.ApplicantID(r)
 
 	if publicID == "" {
 		response.SendJSONMessage(w, http.StatusBadRequest, response.FriendlyError)
@@ -66,6 +64,18 @@ func UpdatePassword(w http.ResponseWriter, r *http.Request) {
 
 	repository := applicants.NewApplicantRegistry().GetApplicantRepository()
 
+	applicant, err := repository.GetApplicant(publicID)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	if !checkNewPassword(w, credentials.NewPassword, applicant) {
+		return
+	}
+
 	updated, err := repository.UpdateApplicantPassword(publicID, credentials.Password, credentials.NewPassword)
 
 	if err != nil {
@@ -75,7 +85,18 @@ func UpdatePassword(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if updated {
-		response.SendJSONMessage(w, http.StatusOK, response.Success)
+		audit.Record(r, accountmanagement.AuditPasswordChanged, publicID, nil)
+
+		// changing the password logs out every session, so hand this one fresh tokens
+		token, err := issueTokens(r, publicID, applicant.RegistrationStep, principal.AuthMethodPassword)
+
+		if err != nil {
+			log.Println(err)
+			response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+			return
+		}
+
+		response.SendJSON(w, token)
 		return
 	} else {
 		response.SendJSONMessage(w, http.StatusBadRequest, response.FriendlyError)
@@ -90,7 +111,7 @@ func UpdateAccount(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	publicID := jwt.GetUserClaim(r)
+	publicID := principal.ApplicantID(r)
 
 	if publicID == "" {
 		response.SendJSONMessage(w, http.StatusBadRequest, response.FriendlyError)
@@ -109,6 +130,14 @@ func UpdateAccount(w http.ResponseWriter, r *http.Request) {
 
 	repository := applicants.NewApplicantRegistry().GetApplicantRepository()
 
+	before, err := repository.GetApplicant(publicID)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
 	gateway := zipcode.NewZipCodeGateway(os.Getenv("ZIPCODESERVICES_API_KEY"))
 
 	zip_code, err := gateway.GetZipCode(data.Zipcode)
@@ -121,15 +150,36 @@ func UpdateAccount(w http.ResponseWriter, r *http.Request) {
 
 	applicant, err := repository.UpdateApplicantAccount(publicID, data.FirstName, data.LastName, data.Email, data.PhoneNumber, data.Address, data.City, data.State, data.Zipcode, zip_code.Latitude, zip_code.Longitude)
 
+	if err == accountmanagement.ErrEmailTaken {
+		response.SendJSONMessage(w, http.StatusConflict, response.EmailTaken)
+		return
+	}
+
 	if err != nil {
 		log.Println(err)
 		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
 		return
 	}
 
+	if changes := audit.Diff(accountFields(before), accountFields(applicant)); len(changes) > 0 {
+		audit.Record(r, accountmanagement.AuditAccountUpdated, publicID, changes)
+	}
+
+	if data.Email != "" && data.Email == applicant.PendingEmail {
+		err = sendVerification(applicant, applicant.PendingEmail, accountmanagement.EmailChange)
+
+		if err != nil {
+			log.Println(err)
+			response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+			return
+		}
+	}
+
 	response.SendJSON(w, applicant)
 }
 
+// UpdateJobPreferences changes the preferences given in the body, leaving the rest as they were.
+// Desired cities are added to those the applicant already has; ones they already have aren't added twice.
 func UpdateJobPreferences(w http.ResponseWriter, r *http.Request) {
 
 	if r.Method != http.MethodPost {
@@ -137,16 +187,54 @@ func UpdateJobPreferences(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	publicID := jwt.GetUserClaim(r)
+	publicID := principal.ApplicantID(r)
 
 	if publicID == "" {
 		response.SendJSONMessage(w, http.StatusBadRequest, response.FriendlyError)
 		return
 	}
 
-	var data updateJobPreferencesData
+	repository := applicants.NewApplicantRegistry().GetApplicantRepository()
+
+	before, err := repository.GetApplicantJobPreferences(publicID)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	// decoding over a copy keeps whatever the body leaves out; its own lists leave before unchanged
+	preferences := *before
+	preferences.DesiredCities = nil
+	preferences.JobTypes = append([]string{}, before.JobTypes...)
+	preferences.Categories = append([]string{}, before.Categories...)
+	preferences.Workplaces = append([]string{}, before.Workplaces...)
+
 	decoder := json.NewDecoder(r.Body)
-	err := decoder.Decode(&data)
+	err = decoder.Decode(&preferences)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusBadRequest, response.FriendlyError)
+		return
+	}
+
+	if violations := preferences.Validate(); len(violations) > 0 {
+		sendFieldViolations(w, violations)
+		return
+	}
+
+	if !locateDesiredCities(w, desiredCitiesSection.name, preferences.DesiredCities) {
+		return
+	}
+
+	err = repository.UpdateApplicantJobPreferences(publicID, &preferences)
+
+	if err == accountmanagement.ErrTooManyEntries {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.TooManyEntries)
+		return
+	}
 
 	if err != nil {
 		log.Println(err)
@@ -154,9 +242,37 @@ func UpdateJobPreferences(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	repository := applicants.NewApplicantRegistry().GetApplicantRepository()
+	after, err := repository.GetApplicantJobPreferences(publicID)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	audit.Record(r, accountmanagement.AuditPreferencesChanged, publicID, audit.Changes{
+		"jobpreferences": {Before: before, After: after},
+	})
+
+	response.SendJSON(w, after)
+}
+
+// GetJobPreferences returns the applicant's job preferences, including the cities they want to work in
+func GetJobPreferences(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	publicID := principal.ApplicantID(r)
+
+	if publicID == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.FriendlyError)
+		return
+	}
 
-	err = repository.UpdateApplicantJobPreferences(publicID, data.DesiredCities)
+	preferences, err := applicants.NewApplicantRegistry().GetApplicantRepository().GetApplicantJobPreferences(publicID)
 
 	if err != nil {
 		log.Println(err)
@@ -164,4 +280,21 @@ func UpdateJobPreferences(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	response.SendJSON(w, preferences)
+}
+
+// accountFields are the parts of the account UpdateAccount can change, as recorded in the audit log
+func accountFields(applicant *accountmanagement.Applicant) map[string]interface{} {
+	return map[string]interface{}{
+		"firstname":    applicant.FirstName,
+		"lastname":     applicant.LastName,
+		"pendingemail": applicant.PendingEmail,
+		"phonenumber":  applicant.PhoneNumber,
+		"address":      applicant.Address,
+		"city":         applicant.City,
+		"state":        applicant.State,
+		"zipcode":      applicant.Zipcode,
+		"latitude":     applicant.Latitude,
+		"longitude":    applicant.Longitude,
+	}
 }
@@ -2,6 +2,9 @@ package applicants_test
 
 import (
 	"autumnomous-jobs-applicant-api/controller/v1/applicants"
+	"autumnomous-jobs-applicant-api/route/middleware/acl"
+	hr "autumnomous-jobs-applicant-api/route/middleware/httprouterwrapper"
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
 	"autumnomous-jobs-applicant-api/shared/services/security/encryption"
 	"autumnomous-jobs-applicant-api/shared/services/security/jwt"
 	"autumnomous-jobs-applicant-api/shared/testhelper"
@@ -14,6 +17,8 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/julienschmidt/httprouter"
+	"github.com/justinas/alice"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -25,7 +30,7 @@ func Test_Applicant_UpdatePassword_Success(t *testing.T) {
 
 	assert := assert.New(t)
 
-	ts := httptest.NewServer(http.HandlerFunc(applicants.UpdatePassword))
+	ts := httptest.NewServer(acl.ValidateJWT(http.HandlerFunc(applicants.UpdatePassword)))
 
 	data := map[string]string{
 		"password":    string(encryption.GeneratePassword(9)),
@@ -83,6 +88,45 @@ func Test_Applicant_UpdatePassword_Success(t *testing.T) {
 		t.Fatal()
 	}
 	assert.Equal(int(http.StatusOK), response.StatusCode)
+	assert.NotEmpty(result["token"])
+}
+
+func Test_Applicant_UpdatePassword_WeakPassword(t *testing.T) {
+	assert := assert.New(t)
+
+	ts := httptest.NewServer(acl.ValidateJWT(http.HandlerFunc(applicants.UpdatePassword)))
+
+	defer ts.Close()
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+
+	token, err := jwt.GenerateToken(applicant.PublicID)
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	response, result := postJSONWithToken(t, ts.URL, token, map[string]string{"password": applicant.Password, "newpassword": "a"})
+
+	assert.Equal(http.StatusBadRequest, response.StatusCode)
+
+	errors, ok := result["errors"].([]interface{})
+	assert.True(ok)
+	assert.NotEmpty(errors)
+
+	first := errors[0].(map[string]interface{})
+	assert.Equal("newpassword", first["field"])
+	assert.Equal(encryption.PasswordTooShort, first["code"])
+
+	response, result = postJSONWithToken(t, ts.URL, token, map[string]string{"password": applicant.Password, "newpassword": applicant.Email + "!"})
+
+	assert.Equal(http.StatusBadRequest, response.StatusCode)
+	assert.Contains(fmt.Sprint(result["errors"]), encryption.PasswordPersonalInfo)
+
+	// the old password still works
+	match, _, _, err := applicants.AuthenticatePassword(applicant.Email, applicant.Password)
+	assert.Nil(err)
+	assert.True(match)
 }
 
 func Test_Applicant_UpdatePassword_IncorrectMethod(t *testing.T) {
@@ -311,7 +355,7 @@ func Test_Applicant_UpdateAccount_CorrectDataReceived(t *testing.T) {
 
 	assert := assert.New(t)
 
-	ts := httptest.NewServer((http.HandlerFunc(applicants.UpdateAccount)))
+	ts := httptest.NewServer(acl.ValidateJWT(http.HandlerFunc(applicants.UpdateAccount)))
 
 	applicant := &testhelper.TestApplicant{FirstName: "First", LastName: "Last", Email: fmt.Sprintf("email-%s@site.com", encryption.GeneratePassword(9)), Password: string(encryption.GeneratePassword(9))}
 
@@ -325,6 +369,14 @@ func Test_Applicant_UpdateAccount_CorrectDataReceived(t *testing.T) {
 
 	applicant = testhelper.Helper_CreateApplicant(applicant, t)
 
+	applicants.SendVerificationMessageFunction = func(domain, apiKey, token, address string, applicant *accountmanagement.Applicant) (string, error) {
+		return "", nil
+	}
+
+	defer func() {
+		applicants.SendVerificationMessageFunction = applicants.SendVerificationMessage
+	}()
+
 	tests := map[string]map[string]string{
 		"NewBio": {
 			"firstname": "First",
@@ -392,7 +444,14 @@ func Test_Applicant_UpdateAccount_CorrectDataReceived(t *testing.T) {
 		assert.Equal(int(http.StatusOK), response.StatusCode)
 		assert.Equal(test["firstname"], result["firstname"])
 		assert.Equal(test["lastname"], result["lastname"])
-		assert.Equal(test["email"], result["email"])
+
+		// a changed email waits for verification before replacing the current one
+		if test["email"] == applicant.Email {
+			assert.Equal(test["email"], result["email"])
+		} else {
+			assert.Equal(applicant.Email, result["email"])
+			assert.Equal(test["email"], result["pendingemail"])
+		}
 
 	}
 
@@ -402,7 +461,9 @@ func Test_Applicant_UpdateJobPreferences_Correct(t *testing.T) {
 
 	assert := assert.New(t)
 
-	ts := httptest.NewServer(http.HandlerFunc(applicants.UpdateJobPreferences))
+	useTestLocations(t)
+
+	ts := httptest.NewServer(acl.ValidateJWT(http.HandlerFunc(applicants.UpdateJobPreferences)))
 
 	data := map[string][]map[string]string{
 		"desiredcities": {
@@ -498,3 +559,57 @@ func Test_Applicant_UpdateJobPreferences_IncorrectMethod(t *testing.T) {
 		assert.Equal(http.StatusMethodNotAllowed, response.StatusCode)
 	}
 }
+
+func Test_Applicant_JobPreferences(t *testing.T) {
+	assert := assert.New(t)
+
+	useTestLoginLimiter(t, 10)
+
+	r := httprouter.New()
+	r.POST("/login", hr.Handler(alice.New().ThenFunc(applicants.Login)))
+	r.GET("/job-preferences", hr.Handler(alice.New(acl.ValidateJWT).ThenFunc(applicants.GetJobPreferences)))
+	r.POST("/update-job-preferences", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.UpdateJobPreferences)))
+
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	token := sessionLogin(t, ts.URL+"/login", "browser", applicant)
+
+	response, preferences := sendWithSessionToken(t, "GET", ts.URL+"/job-preferences", token)
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Empty(preferences["desiredcities"])
+	assert.Empty(preferences["jobtypes"])
+
+	response, preferences = sendJSONWithSessionToken(t, "POST", ts.URL+"/update-job-preferences", token, map[string]interface{}{
+		"jobtypes":   []string{"Full time"},
+		"workplaces": []string{"Remote"},
+		"minsalary":  60000,
+		"payperiod":  "yearly",
+	})
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Equal([]interface{}{"remote"}, preferences["workplaces"])
+
+	// fields left out keep their saved values
+	response, preferences = sendJSONWithSessionToken(t, "POST", ts.URL+"/update-job-preferences", token, map[string]interface{}{
+		"willingtorelocate": true,
+	})
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Equal([]interface{}{"Full time"}, preferences["jobtypes"])
+	assert.Equal(float64(60000), preferences["minsalary"])
+	assert.Equal(true, preferences["willingtorelocate"])
+
+	response, _ = sendJSONWithSessionToken(t, "POST", ts.URL+"/update-job-preferences", token, map[string]interface{}{
+		"workplaces": []string{"moon"},
+	})
+	assert.Equal(http.StatusBadRequest, response.StatusCode)
+
+	response, _ = sendJSONWithSessionToken(t, "POST", ts.URL+"/update-job-preferences", token, map[string]interface{}{
+		"minsalary": "lots",
+	})
+	assert.Equal(http.StatusBadRequest, response.StatusCode)
+
+	response, preferences = sendWithSessionToken(t, "GET", ts.URL+"/job-preferences", token)
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Equal([]interface{}{"remote"}, preferences["workplaces"])
+}
@@ -0,0 +1,200 @@
+package applicants
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	applicants "autumnomous-jobs-applicant-api/shared/repository/applicants"
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+	"autumnomous-jobs-applicant-api/shared/response"
+	"autumnomous-jobs-applicant-api/shared/services/audit"
+	"autumnomous-jobs-applicant-api/shared/services/security/encryption"
+	"autumnomous-jobs-applicant-api/shared/services/security/principal"
+)
+
+var SendVerificationMessageFunction = SendVerificationMessage
+
+type verifyEmailData struct {
+	Token string `json:"token"`
+}
+
+// VerifyEmail redeems an emailed verification token. Tokens sent at signup verify the current
+// email, tokens sent after an email change swap the pending email in.
+func VerifyEmail(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPost {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	var data verifyEmailData
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&data)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	if data.Token == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.MissingRequiredValue)
+		return
+	}
+
+	tokenHash := encryption.HashToken(data.Token)
+	tokenRepository := applicants.NewApplicantRegistry().GetTokenRepository()
+	repository := applicants.NewApplicantRegistry().GetApplicantRepository()
+
+	publicID, err := tokenRepository.RedeemToken(accountmanagement.EmailVerification, tokenHash)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	if publicID != "" {
+		err = repository.SetApplicantEmailVerified(publicID)
+
+		if err != nil {
+			log.Println(err)
+			response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+			return
+		}
+
+		response.SendJSONMessage(w, http.StatusOK, response.Success)
+		return
+	}
+
+	publicID, err = tokenRepository.RedeemToken(accountmanagement.EmailChange, tokenHash)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	if publicID == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.InvalidToken)
+		return
+	}
+
+	before, err := repository.GetApplicant(publicID)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	confirmed, err := repository.ConfirmApplicantPendingEmail(publicID)
+
+	if err == accountmanagement.ErrEmailTaken {
+		response.SendJSONMessage(w, http.StatusConflict, response.EmailTaken)
+		return
+	}
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	if !confirmed {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.InvalidToken)
+		return
+	}
+
+	audit.RecordAs(r, publicID, accountmanagement.AuditEmailChanged, publicID, audit.Changes{
+		"email": {Before: before.Email, After: before.PendingEmail},
+	})
+
+	response.SendJSONMessage(w, http.StatusOK, response.Success)
+}
+
+// ResendVerification sends a fresh verification link for the pending email, or for the
+// current email if it hasn't been verified yet
+func ResendVerification(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPost {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	publicID := principal.ApplicantID(r)
+
+	if publicID == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.FriendlyError)
+		return
+	}
+
+	repository := applicants.NewApplicantRegistry().GetApplicantRepository()
+
+	applicant, err := repository.GetApplicant(publicID)
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	if applicant.PendingEmail != "" {
+		err = sendVerification(applicant, applicant.PendingEmail, accountmanagement.EmailChange)
+	} else if !applicant.EmailVerified {
+		err = sendVerification(applicant, applicant.Email, accountmanagement.EmailVerification)
+	} else {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.EmailAlreadyVerified)
+		return
+	}
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	response.SendJSONMessage(w, http.StatusOK, response.Success)
+}
+
+// sendVerification replaces any outstanding verification tokens for the purpose and emails a new one to address
+func sendVerification(applicant *accountmanagement.Applicant, address string, purpose accountmanagement.TokenPurpose) error {
+
+	tokenRepository := applicants.NewApplicantRegistry().GetTokenRepository()
+
+	err := tokenRepository.RevokeTokens(applicant.PublicID, purpose)
+
+	if err != nil {
+		return err
+	}
+
+	token, tokenHash, err := encryption.NewSecureToken()
+
+	if err != nil {
+		return err
+	}
+
+	err = tokenRepository.CreateToken(applicant.PublicID, purpose, tokenHash, accountmanagement.EmailVerificationTokenLifetime)
+
+	if err != nil {
+		return err
+	}
+
+	domain := os.Getenv("MAILGUN_DOMAIN")
+	apiKey := os.Getenv("MAILGUN_API_KEY")
+	_, err = SendVerificationMessageFunction(domain, apiKey, token, address, applicant)
+
+	return err
+}
+
+// SendVerificationMessage emails a link containing a verification token to address
+func SendVerificationMessage(domain, apiKey, token, address string, applicant *accountmanagement.Applicant) (string, error) {
+
+	link := fmt.Sprintf("%s/verify-email?token=%s", os.Getenv("APPLICANT_SITE_URL"), token)
+	message := fmt.Sprintf("Hi %s,\nPlease confirm this is your email address for BiT Jobs by opening the link below:\n%s", applicant.FirstName, link)
+
+	return sendMessage(domain, apiKey, "Confirm your BiT Jobs email address", message, address)
+}
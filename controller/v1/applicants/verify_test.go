@@ -0,0 +1,101 @@
+package applicants_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"autumnomous-jobs-applicant-api/controller/v1/applicants"
+	"autumnomous-jobs-applicant-api/route/middleware/acl"
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+	"autumnomous-jobs-applicant-api/shared/services/security/jwt"
+	"autumnomous-jobs-applicant-api/shared/testhelper"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Applicant_VerifyEmail_InvalidToken(t *testing.T) {
+	assert := assert.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(applicants.VerifyEmail))
+
+	defer ts.Close()
+
+	requestBody, err := json.Marshal(map[string]string{"token": "not-a-token"})
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	response, err := http.Post(ts.URL, "application/json", bytes.NewBuffer(requestBody))
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	assert.Equal(http.StatusBadRequest, response.StatusCode)
+}
+
+func Test_Applicant_ResendVerification_VerifyEmail_Correct(t *testing.T) {
+	assert := assert.New(t)
+
+	resend := httptest.NewServer(acl.ValidateJWT(http.HandlerFunc(applicants.ResendVerification)))
+	defer resend.Close()
+
+	verify := httptest.NewServer(http.HandlerFunc(applicants.VerifyEmail))
+	defer verify.Close()
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+
+	var emailedToken, emailedAddress string
+	applicants.SendVerificationMessageFunction = func(domain, apiKey, token, address string, applicant *accountmanagement.Applicant) (string, error) {
+		emailedToken = token
+		emailedAddress = address
+		return "", nil
+	}
+
+	defer func() {
+		applicants.SendVerificationMessageFunction = applicants.SendVerificationMessage
+	}()
+
+	token, err := jwt.GenerateToken(applicant.PublicID)
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	request, err := http.NewRequest("POST", resend.URL, nil)
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	request.Header.Set("Authorization", "Bearer "+base64.StdEncoding.EncodeToString([]byte(token)))
+
+	httpClient := &http.Client{}
+	response, err := httpClient.Do(request)
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Equal(applicant.Email, emailedAddress)
+
+	requestBody, err := json.Marshal(map[string]string{"token": emailedToken})
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	response, err = http.Post(verify.URL, "application/json", bytes.NewBuffer(requestBody))
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	assert.Equal(http.StatusOK, response.StatusCode)
+}
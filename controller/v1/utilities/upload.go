@@ -1,20 +1,33 @@
 package utilities
 
 import (
+	"autumnomous-jobs-applicant-api/shared/repository/applicants"
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
 	"autumnomous-jobs-applicant-api/shared/response"
+	"autumnomous-jobs-applicant-api/shared/services/audit"
+	"autumnomous-jobs-applicant-api/shared/services/security/principal"
+	"autumnomous-jobs-applicant-api/shared/services/storage"
+	"io"
+	"mime/multipart"
 	"net/http"
-	"os"
 
-	// Additional imports needed for examples below
 	"fmt"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/credentials"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/google/uuid"
 )
 
+var StorageFunction = storage.NewSpacesStore
+
+// storedImage is an image saved by storeImage
+type storedImage struct {
+	key         string
+	url         string
+	contentType string
+	header      *multipart.FileHeader
+}
+
+// UploadImage stores a public image for an API client. The file doesn't belong to any applicant;
+// applicants upload their own through UploadApplicantImage.
 func UploadImage(w http.ResponseWriter, r *http.Request) {
 
 	if r.Method != http.MethodPost {
@@ -22,8 +35,56 @@ func UploadImage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	key := os.Getenv("SPACES_KEY")
-	secret := os.Getenv("SPACES_SECRET")
+	image, ok := storeImage(w, r)
+
+	if !ok {
+		return
+	}
+
+	response.SendJSON(w, map[string]string{"url": image.url})
+}
+
+// UploadApplicantImage stores a public image for the signed-in applicant. The file is recorded as
+// theirs, so it is exported and deleted with the rest of their data.
+func UploadApplicantImage(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPost {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	publicID := principal.ApplicantID(r)
+
+	if publicID == "" {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.FriendlyError)
+		return
+	}
+
+	image, ok := storeImage(w, r)
+
+	if !ok {
+		return
+	}
+
+	err := applicants.NewApplicantRegistry().GetUploadRepository().CreateUpload(publicID, image.key, image.header.Filename, image.contentType, image.header.Size)
+
+	if err != nil {
+		fmt.Println(err.Error())
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	audit.Record(r, accountmanagement.AuditFileUploaded, publicID, audit.Changes{
+		"url":      {After: image.url},
+		"filename": {After: image.header.Filename},
+	})
+
+	response.SendJSON(w, map[string]string{"url": image.url})
+}
+
+// storeImage saves the image in the request's "file" field where anyone can fetch it. It responds
+// and returns false when it can't.
+func storeImage(w http.ResponseWriter, r *http.Request) (*storedImage, bool) {
 
 	// Parse multipart form, 10 << 20 specifies a maximum
 	// upload of 10 MB files.
@@ -34,37 +95,60 @@ func UploadImage(w http.ResponseWriter, r *http.Request) {
 		fmt.Println("Error Retrieving the File")
 		fmt.Println(err)
 		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
-		return
+		return nil, false
 	}
 	defer file.Close()
 
-	s3Config := &aws.Config{
-		Credentials: credentials.NewStaticCredentials(key, secret, ""),
-		Endpoint:    aws.String(os.Getenv("SPACES_ENDPOINT")),
-		Region:      aws.String("us-east-1"),
-	}
+	// the type the client claims isn't trusted, since the file is served publicly from our bucket
+	contentType, err := detectImageType(file)
 
-	newSession, err := session.NewSession(s3Config)
 	if err != nil {
 		fmt.Println(err.Error())
 		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return nil, false
 	}
 
-	s3Client := s3.New(newSession)
+	if !storage.IsImage(contentType) {
+		response.SendJSONMessage(w, http.StatusBadRequest, response.UnsupportedImage)
+		return nil, false
+	}
 
-	keyname := fmt.Sprintf("%s-%s", uuid.NewString(), handler.Filename)
-	object := s3.PutObjectInput{
-		Bucket: aws.String(os.Getenv("SPACES_BUCKET")),
-		Key:    aws.String(keyname),
-		Body:   file,
-		ACL:    aws.String("public-read"),
+	store, err := StorageFunction()
+	if err != nil {
+		fmt.Println(err.Error())
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return nil, false
 	}
-	_, err = s3Client.PutObject(&object)
+
+	keyname := fmt.Sprintf("%s-%s", uuid.NewString(), handler.Filename)
+
+	err = store.Put(keyname, file, contentType, true)
 
 	if err != nil {
 		fmt.Println(err.Error())
 		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return nil, false
+	}
+
+	return &storedImage{key: keyname, url: store.URL(keyname), contentType: contentType, header: handler}, true
+}
+
+// detectImageType sniffs the file's content type from its first bytes and rewinds it
+func detectImageType(file io.ReadSeeker) (string, error) {
+
+	head := make([]byte, 512)
+
+	n, err := io.ReadFull(file, head)
+
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+
+	_, err = file.Seek(0, io.SeekStart)
+
+	if err != nil {
+		return "", err
 	}
 
-	response.SendJSON(w, map[string]string{"url": fmt.Sprintf("https://%s.%s/%s", os.Getenv("SPACES_BUCKET"), os.Getenv("SPACES_ENDPOINT"), keyname)})
+	return http.DetectContentType(head[:n]), nil
 }
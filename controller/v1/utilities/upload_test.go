@@ -1 +1,121 @@
 package utilities_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"autumnomous-jobs-applicant-api/controller/v1/utilities"
+	"autumnomous-jobs-applicant-api/shared/services/security/principal"
+	"autumnomous-jobs-applicant-api/shared/services/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// uploadRequest builds a multipart upload of body, claiming it is contentType
+func uploadRequest(t *testing.T, filename, contentType string, body []byte) *http.Request {
+
+	var form bytes.Buffer
+	writer := multipart.NewWriter(&form)
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="file"; filename="`+filename+`"`)
+	header.Set("Content-Type", contentType)
+
+	part, err := writer.CreatePart(header)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	part.Write(body)
+	writer.Close()
+
+	request := httptest.NewRequest("POST", "/upload/image", &form)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+
+	return request
+}
+
+// useTestStorage keeps uploaded files in memory
+func useTestStorage(t *testing.T) *storage.MemoryStore {
+
+	store := storage.NewMemoryStore()
+	previous := utilities.StorageFunction
+
+	utilities.StorageFunction = func() (storage.Store, error) {
+		return store, nil
+	}
+
+	t.Cleanup(func() {
+		utilities.StorageFunction = previous
+	})
+
+	return store
+}
+
+func Test_UploadImage(t *testing.T) {
+	assert := assert.New(t)
+
+	store := useTestStorage(t)
+
+	// a PNG's signature is enough for it to be recognised, whatever the client says it is
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+	w := httptest.NewRecorder()
+	utilities.UploadImage(w, uploadRequest(t, "logo.png", "application/octet-stream", png))
+
+	assert.Equal(http.StatusOK, w.Code)
+
+	var result map[string]string
+	json.NewDecoder(w.Body).Decode(&result)
+
+	object, found := store.Object(strings.TrimPrefix(result["url"], "memory://"))
+
+	if assert.True(found) {
+		assert.Equal("image/png", object.ContentType)
+		assert.True(object.Public)
+		assert.False(object.Attachment)
+	}
+}
+
+func Test_UploadApplicantImage_NoApplicant(t *testing.T) {
+	assert := assert.New(t)
+
+	// an upload that nobody can be recorded as owning is refused before anything is stored
+	w := httptest.NewRecorder()
+	utilities.UploadApplicantImage(w, httptest.NewRequest("POST", "/applicant/upload", nil))
+
+	assert.Equal(http.StatusBadRequest, w.Code)
+}
+
+func Test_UploadImage_NotAnImage(t *testing.T) {
+	assert := assert.New(t)
+
+	useTestStorage(t)
+
+	// whatever the client claims, script isn't stored where the bucket would serve it
+	bodies := map[string][]byte{
+		"page.html": []byte("<html><script>alert(1)</script></html>"),
+		"logo.svg":  []byte(`<svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`),
+	}
+
+	for filename, body := range bodies {
+		w := httptest.NewRecorder()
+		utilities.UploadImage(w, uploadRequest(t, filename, "image/png", body))
+
+		assert.Equal(http.StatusBadRequest, w.Code, filename)
+
+		request := principal.WithPrincipal(uploadRequest(t, filename, "image/png", body), &principal.Principal{ApplicantID: "applicant"})
+
+		w = httptest.NewRecorder()
+		utilities.UploadApplicantImage(w, request)
+
+		assert.Equal(http.StatusBadRequest, w.Code, filename)
+	}
+}
@@ -0,0 +1,29 @@
+package wellknown
+
+import (
+	"log"
+	"net/http"
+
+	"autumnomous-jobs-applicant-api/shared/response"
+	"autumnomous-jobs-applicant-api/shared/services/security/jwt"
+)
+
+// JWKS publishes the public keys our access tokens can be verified with
+func JWKS(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet {
+		response.SendJSONMessage(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	keys, err := jwt.GetKeyring()
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	response.SendJSON(w, keys.JWKS())
+}
@@ -5,7 +5,9 @@ import (
 	"net/http"
 	"os"
 	"runtime"
+	"time"
 
+	"autumnomous-jobs-applicant-api/controller/v1/applicants"
 	"autumnomous-jobs-applicant-api/route"
 	"autumnomous-jobs-applicant-api/shared/database"
 
@@ -31,6 +33,12 @@ func main() {
 
 	database.Connect("HEROKU_POSTGRESQL_CYAN_URL")
 
+	// carry out account deletions once their grace period is over
+	go applicants.RunAccountDeletions(time.Hour)
+
+	// build requested data exports in the background and email applicants when they're ready
+	go applicants.RunDataExports(time.Minute)
+
 	port := os.Getenv("PORT")
 	if len(port) == 0 {
 		port = "7000"
@@ -1,98 +1,267 @@
 package acl
 
 import (
+	"crypto/subtle"
 	"encoding/base64"
 	"log"
 	"net/http"
 	"os"
 	"strings"
 
+	"autumnomous-jobs-applicant-api/shared/repository/apikeys"
 	"autumnomous-jobs-applicant-api/shared/repository/applicants"
 	"autumnomous-jobs-applicant-api/shared/response"
 	jwt "autumnomous-jobs-applicant-api/shared/services/security/jwt"
+	"autumnomous-jobs-applicant-api/shared/services/security/principal"
+	"autumnomous-jobs-applicant-api/shared/services/utils"
 )
 
+// ValidateJWT allows the request through when it carries a valid applicant token, and puts the
+// principal it authenticates into the request context
 func ValidateJWT(h http.Handler) http.Handler {
+	return validateJWT(h, false)
+}
+
+// ValidateVerifiedJWT is ValidateJWT for routes that also require a verified email address
+func ValidateVerifiedJWT(h http.Handler) http.Handler {
+	return validateJWT(h, true)
+}
+
+func validateJWT(h http.Handler, requireVerifiedEmail bool) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 
 		s := strings.SplitN(req.Header.Get("Authorization"), " ", 2)
-		if len(s) >= 2 {
-
-			b, err := base64.StdEncoding.DecodeString(s[1])
 
-			if err == nil {
-				bearerToken := strings.SplitN(string(b), ":", 2)
+		if len(s) < 2 {
+			response.SendJSONMessage(w, http.StatusUnauthorized, "Invalid Token")
+			return
+		}
 
-				if len(bearerToken) == 1 {
+		b, err := base64.StdEncoding.DecodeString(s[1])
 
-					data, err := jwt.ParseToken(bearerToken[0])
+		if err != nil || strings.Contains(string(b), ":") {
+			response.SendJSONMessage(w, http.StatusUnauthorized, "Invalid Token")
+			return
+		}
 
-					if err != nil {
-						log.Println(err)
-						response.SendJSONMessage(w, http.StatusUnauthorized, "Unauthorized")
-					}
+		data, err := jwt.ParseToken(string(b))
 
-					if data == nil {
+		if err != nil {
+			log.Println(err)
+		}
 
-						response.SendJSONMessage(w, http.StatusUnauthorized, "Unauthorized")
+		if data == nil || !data.IsAccessToken() || data.CustomClaims["user"] == "" {
+			response.SendJSONMessage(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
 
-					} else {
-						userId := data.CustomClaims["user"]
+		current := data.Principal()
 
-						if userId == "" {
+		repository := applicants.NewApplicantRegistry().GetApplicantRepository()
+		applicant, err := repository.GetApplicant(current.ApplicantID)
 
-							response.SendJSONMessage(w, http.StatusUnauthorized, "Unauthorized")
-						} else {
+		//validate user below
 
-							repository := applicants.NewApplicantRegistry().GetApplicantRepository()
-							_, err := repository.GetApplicant(userId)
+		revoked := true
+		if err == nil {
+			revoked, err = applicants.NewApplicantRegistry().GetRevocationRepository().IsTokenRevoked(current.ApplicantID, current.TokenID, current.IssuedAt)
+		}
 
-							//validate user below
+		if err != nil || revoked {
+			response.SendJSONMessage(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
 
-							if err != nil {
-								response.SendJSONMessage(w, http.StatusUnauthorized, "Unauthorized")
-							} else {
+		// tokens from a signed out session stop working straight away
+		if current.SessionID != "" {
+			active, err := applicants.NewApplicantRegistry().GetSessionRepository().TouchSession(current.ApplicantID, current.SessionID, utils.ClientIP(req))
 
-								h.ServeHTTP(w, req)
-							}
-						}
-					}
-				}
+			if err != nil || !active {
+				response.SendJSONMessage(w, http.StatusUnauthorized, "Unauthorized")
+				return
 			}
-		} else {
-			response.SendJSONMessage(w, http.StatusUnauthorized, "Invalid Token")
-			//respond with invalid token
 		}
+
+		if requireVerifiedEmail && !applicant.EmailVerified {
+			response.SendJSONMessage(w, http.StatusForbidden, response.EmailNotVerified)
+			return
+		}
+
+		req = principal.WithPrincipal(req, current)
+
+		if current.IsImpersonation() {
+			serveImpersonated(w, req, h, current)
+			return
+		}
+
+		h.ServeHTTP(w, req)
 	})
 }
 
-//AllowAPIKey allows authentication if an API key is present
-func AllowAPIKey(h http.Handler) http.Handler {
+// statusRecorder remembers the status code a handler responded with
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (recorder *statusRecorder) WriteHeader(status int) {
+	recorder.status = status
+	recorder.ResponseWriter.WriteHeader(status)
+}
+
+// serveImpersonated audits a request made with an impersonation token. The request is refused
+// if it can't be recorded, so nothing support does as an applicant goes unlogged.
+func serveImpersonated(w http.ResponseWriter, r *http.Request, h http.Handler, current *principal.Principal) {
+
+	repository := applicants.NewApplicantRegistry().GetImpersonationRepository()
+
+	id, err := repository.RecordImpersonatedRequest(current.TokenID, r.Method, r.URL.Path, utils.ClientIP(r))
+
+	if err != nil {
+		log.Println(err)
+		response.SendJSONMessage(w, http.StatusUnauthorized, response.Unauthorized)
+		return
+	}
+
+	recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+	h.ServeHTTP(recorder, r)
+
+	err = repository.SetImpersonatedRequestStatus(id, recorder.status)
+
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// RequireWriteAccess refuses read-only impersonation tokens. Put it after ValidateJWT on every
+// route that changes the applicant's data.
+func RequireWriteAccess(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
-		if r.Header.Get("Authorization") != "" {
+		current := principal.FromRequest(r)
 
-			auth := strings.Split(r.Header["Authorization"][0], " ")[1]
-			authKey, err := base64.StdEncoding.DecodeString(string(auth))
+		if current == nil {
+			response.SendJSONMessage(w, http.StatusUnauthorized, response.Unauthorized)
+			return
+		}
+
+		if !current.CanWrite() {
+			response.SendJSONMessage(w, http.StatusForbidden, response.ReadOnlyImpersonation)
+			return
+		}
 
-			if err != nil {
-				log.Println(err)
-				response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+		h.ServeHTTP(w, r)
+	})
+}
+
+// RequireRole allows the request through when its principal holds role. It must come after ValidateJWT.
+func RequireRole(role string) func(http.Handler) http.Handler {
+	return requirePrincipal(func(current *principal.Principal) bool { return current.HasRole(role) })
+}
+
+// RequirePermission allows the request through when its principal's roles grant permission. It must come after ValidateJWT.
+func RequirePermission(permission string) func(http.Handler) http.Handler {
+	return requirePrincipal(func(current *principal.Principal) bool { return current.HasPermission(permission) })
+}
+
+func requirePrincipal(allowed func(current *principal.Principal) bool) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+			current := principal.FromRequest(r)
+
+			if current == nil {
+				response.SendJSONMessage(w, http.StatusUnauthorized, response.Unauthorized)
 				return
 			}
 
-			apiKey := os.Getenv("API_KEY")
-			if strings.Compare(strings.TrimSpace(string(authKey)), apiKey) == 0 && apiKey != "" {
-				h.ServeHTTP(w, r)
-			} else {
-				response.SendJSONMessage(w, http.StatusUnauthorized, response.InvalidAPIKey)
+			if !allowed(current) {
+				response.SendJSONMessage(w, http.StatusForbidden, response.Forbidden)
+				return
 			}
 
-			// check for key in db
+			h.ServeHTTP(w, r)
+		})
+	}
+}
+
+// AllowAPIKey allows the request through when it carries any valid API key
+func AllowAPIKey(h http.Handler) http.Handler {
+	return requireAPIKey(h, "")
+}
+
+// RequireAPIKeyScope allows the request through when it carries a valid API key issued with scope
+func RequireAPIKeyScope(scope string) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return requireAPIKey(h, scope)
+	}
+}
+
+func requireAPIKey(h http.Handler, scope string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		s := strings.SplitN(r.Header.Get("Authorization"), " ", 2)
 
-		} else { // If user is not authenticated, don't allow them to access the page
+		// If the client is not authenticated, don't allow them to access the page
+		if len(s) < 2 {
 			response.SendJSONMessage(w, http.StatusUnauthorized, response.InvalidAPIKey)
+			return
 		}
 
+		authKey, err := base64.StdEncoding.DecodeString(s[1])
+
+		if err != nil {
+			response.SendJSONMessage(w, http.StatusUnauthorized, response.InvalidAPIKey)
+			return
+		}
+
+		key, err := authenticateAPIKey(strings.TrimSpace(string(authKey)))
+
+		if err != nil {
+			log.Println(err)
+			response.SendJSONMessage(w, http.StatusInternalServerError, response.FriendlyError)
+			return
+		}
+
+		if key == nil {
+			response.SendJSONMessage(w, http.StatusUnauthorized, response.InvalidAPIKey)
+			return
+		}
+
+		if scope != "" && !key.HasScope(scope) {
+			response.SendJSONMessage(w, http.StatusForbidden, response.InsufficientScope)
+			return
+		}
+
+		h.ServeHTTP(w, r)
 	})
 }
+
+// authenticateAPIKey looks the key up in api_keys. The shared API_KEY env var that clients were
+// given before keys were issued is still accepted, but only for the applicant endpoints. A
+// deployment bootstraps its first admin key with the separate ADMIN_API_KEY secret.
+func authenticateAPIKey(secret string) (*apikeys.APIKey, error) {
+
+	if secret == "" {
+		return nil, nil
+	}
+
+	if envKeyMatches("API_KEY", secret) {
+		return &apikeys.APIKey{Owner: "API_KEY", Scopes: []string{apikeys.ScopeApplicant}}, nil
+	}
+
+	if envKeyMatches("ADMIN_API_KEY", secret) {
+		return &apikeys.APIKey{Owner: "ADMIN_API_KEY", Scopes: []string{apikeys.ScopeAdmin}}, nil
+	}
+
+	return apikeys.NewAPIKeyRegistry().GetAPIKeyRepository().AuthenticateAPIKey(secret)
+}
+
+// envKeyMatches reports whether secret is the key set in the name env var
+func envKeyMatches(name, secret string) bool {
+
+	key := os.Getenv(name)
+
+	return key != "" && subtle.ConstantTimeCompare([]byte(secret), []byte(key)) == 1
+}
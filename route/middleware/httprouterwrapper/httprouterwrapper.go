@@ -4,26 +4,27 @@ package httprouterwrapper
 // Source: http://nicolasmerouze.com/guide-routers-golang/
 
 import (
+	"context"
 	"net/http"
 
-	"github.com/gorilla/context"
 	"github.com/julienschmidt/httprouter"
 )
 
 // HandlerFunc accepts the name of a function so you don't have to wrap it with http.HandlerFunc
 // Example: r.GET("/", httprouterwrapper.HandlerFunc(controller.Index))
 func HandlerFunc(h http.HandlerFunc) httprouter.Handle {
-	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
-		context.Set(r, "params", p)
-		h.ServeHTTP(w, r)
-	}
+	return Handler(h)
 }
 
 // Handler accepts a handler to make it compatible with http.HandlerFunc
 // Example: r.GET("/", httprouterwrapper.Handler(http.HandlerFunc(controller.Index)))
 func Handler(h http.Handler) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
-		context.Set(r, "params", p)
-		h.ServeHTTP(w, r)
+		h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), httprouter.ParamsKey, p)))
 	}
 }
+
+// Params returns the route parameters httprouter matched for the request
+func Params(r *http.Request) httprouter.Params {
+	return httprouter.ParamsFromContext(r.Context())
+}
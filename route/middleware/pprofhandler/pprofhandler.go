@@ -17,14 +17,13 @@ import (
 	"net/http"
 	"net/http/pprof"
 
-	"github.com/gorilla/context"
-	"github.com/julienschmidt/httprouter"
+	hr "autumnomous-jobs-applicant-api/route/middleware/httprouterwrapper"
 )
 
 // Handler routes the pprof pages using httprouter
 func Handler(w http.ResponseWriter, r *http.Request) {
 
-	p := context.Get(r, "params").(httprouter.Params)
+	p := hr.Params(r)
 
 	switch p.ByName("pprof") {
 	case "/cmdline":
@@ -0,0 +1,33 @@
+package requestid
+
+import (
+	"net/http"
+	"regexp"
+
+	"autumnomous-jobs-applicant-api/shared/services/utils"
+
+	"github.com/google/uuid"
+)
+
+// Header carries the request id in both directions
+const Header = "X-Request-ID"
+
+// validID limits which ids sent by the client or an upstream proxy are kept
+var validID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// Handler gives every request an id, keeping a well-formed one the caller sent, and echoes it in
+// the response so a client's report can be matched to our logs and audit events
+func Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		id := r.Header.Get(Header)
+
+		if !validID.MatchString(id) {
+			id = uuid.NewString()
+		}
+
+		w.Header().Set(Header, id)
+
+		next.ServeHTTP(w, utils.WithRequestID(r, id))
+	})
+}
@@ -3,14 +3,18 @@ package route
 import (
 	"net/http"
 
+	"autumnomous-jobs-applicant-api/controller/v1/admin"
 	"autumnomous-jobs-applicant-api/controller/v1/applicants"
 	"autumnomous-jobs-applicant-api/controller/v1/utilities"
+	"autumnomous-jobs-applicant-api/controller/v1/wellknown"
 	"autumnomous-jobs-applicant-api/route/middleware/acl"
 	"autumnomous-jobs-applicant-api/route/middleware/cors"
 	hr "autumnomous-jobs-applicant-api/route/middleware/httprouterwrapper"
 	"autumnomous-jobs-applicant-api/route/middleware/logrequest"
+	"autumnomous-jobs-applicant-api/route/middleware/requestid"
+	"autumnomous-jobs-applicant-api/shared/repository/apikeys"
+	"autumnomous-jobs-applicant-api/shared/services/security/rbac"
 
-	"github.com/gorilla/context"
 	"github.com/julienschmidt/httprouter"
 	"github.com/justinas/alice"
 )
@@ -28,19 +32,93 @@ func LoadRoutes() http.Handler {
 func routes() *httprouter.Router {
 	r := httprouter.New()
 
-	r.POST("/upload/image", hr.Handler(alice.New(acl.AllowAPIKey).ThenFunc(utilities.UploadImage)))
+	r.GET("/.well-known/jwks.json", hr.Handler(alice.New().ThenFunc(wellknown.JWKS)))
 
-	r.POST("/applicant/signup", hr.Handler(alice.New(acl.AllowAPIKey).ThenFunc(applicants.SignUp)))
-	r.POST("/applicant/login", hr.Handler(alice.New(acl.AllowAPIKey).ThenFunc(applicants.Login)))
+	r.POST("/upload/image", hr.Handler(alice.New(acl.AllowAPIKey).ThenFunc(utilities.UploadImage)))
 
-	r.POST("/applicant/update-password", hr.Handler(alice.New(acl.ValidateJWT).ThenFunc(applicants.UpdatePassword)))
-	r.POST("/applicant/update-account", hr.Handler(alice.New(acl.ValidateJWT).ThenFunc(applicants.UpdateAccount)))
-	r.POST("/applicant/update-job-preferences", hr.Handler(alice.New(acl.ValidateJWT).ThenFunc(applicants.UpdateJobPreferences)))
+	r.POST("/applicant/signup", hr.Handler(alice.New(acl.RequireAPIKeyScope(apikeys.ScopeApplicant)).ThenFunc(applicants.SignUp)))
+	r.POST("/applicant/login", hr.Handler(alice.New(acl.RequireAPIKeyScope(apikeys.ScopeApplicant)).ThenFunc(applicants.Login)))
+	r.POST("/applicant/login/mfa", hr.Handler(alice.New(acl.RequireAPIKeyScope(apikeys.ScopeApplicant)).ThenFunc(applicants.LoginMFA)))
+	r.POST("/applicant/token/refresh", hr.Handler(alice.New(acl.RequireAPIKeyScope(apikeys.ScopeApplicant)).ThenFunc(applicants.RefreshToken)))
+	r.POST("/applicant/forgot-password", hr.Handler(alice.New(acl.RequireAPIKeyScope(apikeys.ScopeApplicant)).ThenFunc(applicants.ForgotPassword)))
+	r.POST("/applicant/reset-password", hr.Handler(alice.New(acl.RequireAPIKeyScope(apikeys.ScopeApplicant)).ThenFunc(applicants.ResetPassword)))
+	r.POST("/applicant/magic-link", hr.Handler(alice.New(acl.RequireAPIKeyScope(apikeys.ScopeApplicant)).ThenFunc(applicants.RequestMagicLink)))
+	r.POST("/applicant/magic-link/login", hr.Handler(alice.New(acl.RequireAPIKeyScope(apikeys.ScopeApplicant)).ThenFunc(applicants.MagicLinkLogin)))
+	r.POST("/applicant/unlock-account", hr.Handler(alice.New(acl.RequireAPIKeyScope(apikeys.ScopeApplicant)).ThenFunc(applicants.UnlockAccount)))
+	r.GET("/applicant/oidc/providers", hr.Handler(alice.New(acl.RequireAPIKeyScope(apikeys.ScopeApplicant)).ThenFunc(applicants.GetOIDCProviders)))
+	r.POST("/applicant/oidc/authorize", hr.Handler(alice.New(acl.RequireAPIKeyScope(apikeys.ScopeApplicant)).ThenFunc(applicants.OIDCAuthorize)))
+	r.POST("/applicant/oidc/callback", hr.Handler(alice.New(acl.RequireAPIKeyScope(apikeys.ScopeApplicant)).ThenFunc(applicants.OIDCCallback)))
+	r.POST("/applicant/verify-email", hr.Handler(alice.New(acl.RequireAPIKeyScope(apikeys.ScopeApplicant)).ThenFunc(applicants.VerifyEmail)))
+
+	r.POST("/applicant/logout", hr.Handler(alice.New(acl.ValidateJWT).ThenFunc(applicants.Logout)))
+	r.POST("/applicant/logout-all", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.LogoutAll)))
+	r.GET("/applicant/sessions", hr.Handler(alice.New(acl.ValidateJWT).ThenFunc(applicants.GetSessions)))
+	r.DELETE("/applicant/sessions/:id", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.RevokeSession)))
+	r.GET("/applicant/activity", hr.Handler(alice.New(acl.ValidateJWT).ThenFunc(applicants.GetActivity)))
+	r.POST("/applicant/upload", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(utilities.UploadApplicantImage)))
+	r.POST("/applicant/resend-verification", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.ResendVerification)))
+	r.POST("/applicant/mfa/totp/enroll", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.EnrollTOTP)))
+	r.POST("/applicant/mfa/totp/enable", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.EnableTOTP)))
+	r.POST("/applicant/update-password", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.UpdatePassword)))
+	r.POST("/applicant/update-account", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.UpdateAccount)))
+	r.POST("/applicant/update-job-preferences", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.UpdateJobPreferences)))
+	r.GET("/applicant/job-preferences", hr.Handler(alice.New(acl.ValidateJWT).ThenFunc(applicants.GetJobPreferences)))
+	r.GET("/applicant/desired-cities", hr.Handler(alice.New(acl.ValidateJWT).ThenFunc(applicants.GetDesiredCities)))
+	r.POST("/applicant/desired-cities", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.AddDesiredCity)))
+	r.PUT("/applicant/desired-cities", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.ReplaceDesiredCities)))
+	r.DELETE("/applicant/desired-cities/:id", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.RemoveDesiredCity)))
+	r.POST("/applicant/delete-account", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.DeleteAccount)))
+	r.POST("/applicant/data-export", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.RequestDataExport)))
+	r.GET("/applicant/data-exports", hr.Handler(alice.New(acl.ValidateJWT).ThenFunc(applicants.GetDataExports)))
+	r.GET("/applicant/data-exports/:id", hr.Handler(alice.New(acl.ValidateJWT).ThenFunc(applicants.DownloadDataExport)))
 	r.GET("/applicant/get", hr.Handler(alice.New(acl.ValidateJWT).ThenFunc(applicants.GetApplicant)))
+	r.GET("/applicant/work-experience", hr.Handler(alice.New(acl.ValidateJWT).ThenFunc(applicants.GetWorkExperience)))
+	r.POST("/applicant/work-experience", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.AddWorkExperience)))
+	r.PUT("/applicant/work-experience/:id", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.UpdateWorkExperience)))
+	r.DELETE("/applicant/work-experience/:id", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.DeleteWorkExperience)))
+	r.GET("/applicant/education", hr.Handler(alice.New(acl.ValidateJWT).ThenFunc(applicants.GetEducation)))
+	r.POST("/applicant/education", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.AddEducation)))
+	r.PUT("/applicant/education/:id", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.UpdateEducation)))
+	r.DELETE("/applicant/education/:id", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.DeleteEducation)))
+	r.GET("/applicant/certifications", hr.Handler(alice.New(acl.ValidateJWT).ThenFunc(applicants.GetCertifications)))
+	r.POST("/applicant/certifications", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.AddCertification)))
+	r.PUT("/applicant/certifications/:id", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.UpdateCertification)))
+	r.DELETE("/applicant/certifications/:id", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.DeleteCertification)))
+	r.GET("/applicant/skills", hr.Handler(alice.New(acl.ValidateJWT).ThenFunc(applicants.GetSkills)))
+	r.POST("/applicant/skills", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.AddSkill)))
+	r.PUT("/applicant/skills/:id", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.UpdateSkill)))
+	r.DELETE("/applicant/skills/:id", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.RemoveSkill)))
+	r.POST("/applicant/resume", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.UploadResume)))
+	r.GET("/applicant/resume", hr.Handler(alice.New(acl.ValidateJWT).ThenFunc(applicants.GetResumes)))
+	r.GET("/applicant/resume/:id", hr.Handler(alice.New(acl.ValidateJWT).ThenFunc(applicants.DownloadResume)))
+	r.GET("/applicant/resume/:id/suggestions", hr.Handler(alice.New(acl.ValidateJWT).ThenFunc(applicants.GetResumeSuggestions)))
+	r.DELETE("/applicant/resume/:id", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.DeleteResume)))
+	r.GET("/applicant/cover-letters", hr.Handler(alice.New(acl.ValidateJWT).ThenFunc(applicants.GetCoverLetters)))
+	r.POST("/applicant/cover-letters", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.AddCoverLetter)))
+	r.PUT("/applicant/cover-letters/:id", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.UpdateCoverLetter)))
+	r.DELETE("/applicant/cover-letters/:id", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.DeleteCoverLetter)))
+	r.GET("/applicant/document-sets", hr.Handler(alice.New(acl.ValidateJWT).ThenFunc(applicants.GetDocumentSets)))
+	r.POST("/applicant/document-sets", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.AddDocumentSet)))
+	r.PUT("/applicant/document-sets/:id", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.UpdateDocumentSet)))
+	r.DELETE("/applicant/document-sets/:id", hr.Handler(alice.New(acl.ValidateJWT, acl.RequireWriteAccess).ThenFunc(applicants.DeleteDocumentSet)))
+	r.POST("/applicant/application-documents", hr.Handler(alice.New(acl.ValidateVerifiedJWT).ThenFunc(applicants.GetApplicationDocuments)))
 	r.POST("/applicant/get/location/autocomplete", hr.Handler(alice.New(acl.ValidateJWT).ThenFunc(applicants.GetAutocompleteLocationData)))
-	r.GET("/applicant/get/jobs", hr.Handler(alice.New(acl.ValidateJWT).ThenFunc(applicants.GetJobs)))
-	r.POST("/applicant/get/job", hr.Handler(alice.New(acl.ValidateJWT).ThenFunc(applicants.GetJob)))
-	r.POST("/applicant/get/jobs/search/radius", hr.Handler(alice.New(acl.ValidateJWT).ThenFunc(applicants.GetJobsByRadius)))
+	r.GET("/applicant/get/jobs", hr.Handler(alice.New(acl.ValidateVerifiedJWT).ThenFunc(applicants.GetJobs)))
+	r.POST("/applicant/get/job", hr.Handler(alice.New(acl.ValidateVerifiedJWT).ThenFunc(applicants.GetJob)))
+	r.POST("/applicant/get/jobs/search/radius", hr.Handler(alice.New(acl.ValidateVerifiedJWT).ThenFunc(applicants.GetJobsByRadius)))
+
+	r.GET("/admin/api-keys", hr.Handler(alice.New(acl.RequireAPIKeyScope(apikeys.ScopeAdmin)).ThenFunc(admin.GetAPIKeys)))
+	r.POST("/admin/api-keys", hr.Handler(alice.New(acl.RequireAPIKeyScope(apikeys.ScopeAdmin)).ThenFunc(admin.IssueAPIKey)))
+	r.POST("/admin/api-keys/revoke", hr.Handler(alice.New(acl.RequireAPIKeyScope(apikeys.ScopeAdmin)).ThenFunc(admin.RevokeAPIKey)))
+
+	r.GET("/admin/applicants", hr.Handler(alice.New(acl.ValidateJWT, acl.RequirePermission(rbac.PermissionViewApplicants)).ThenFunc(admin.GetApplicant)))
+	r.POST("/admin/applicants/force-password-reset", hr.Handler(alice.New(acl.ValidateJWT, acl.RequirePermission(rbac.PermissionForcePasswordReset)).ThenFunc(admin.ForcePasswordReset)))
+	r.POST("/admin/impersonate", hr.Handler(alice.New(acl.ValidateJWT, acl.RequirePermission(rbac.PermissionImpersonate)).ThenFunc(admin.Impersonate)))
+	r.GET("/admin/impersonate/requests", hr.Handler(alice.New(acl.ValidateJWT, acl.RequirePermission(rbac.PermissionViewApplicants)).ThenFunc(admin.GetImpersonatedRequests)))
+	r.POST("/admin/applicants/unlock", hr.Handler(alice.New(acl.ValidateJWT, acl.RequirePermission(rbac.PermissionUnlockApplicants)).ThenFunc(admin.UnlockApplicant)))
+	r.POST("/admin/applicants/data-export", hr.Handler(alice.New(acl.ValidateJWT, acl.RequirePermission(rbac.PermissionExportApplicants)).ThenFunc(admin.ExportApplicantData)))
+	r.GET("/admin/audit-events", hr.Handler(alice.New(acl.ValidateJWT, acl.RequirePermission(rbac.PermissionViewAuditLog)).ThenFunc(admin.GetAuditEvents)))
+	r.GET("/admin/deletion-receipts", hr.Handler(alice.New(acl.ValidateJWT, acl.RequirePermission(rbac.PermissionViewAuditLog)).ThenFunc(admin.GetDeletionReceipts)))
 
 	// r.POST("/employer/update-company", hr.Handler(alice.New(acl.ValidateJWT).ThenFunc(employers.UpdateCompany)))
 	// r.POST("/employer/update-payment-method", hr.Handler(alice.New(acl.ValidateJWT).ThenFunc(employers.UpdatePaymentMethod)))
@@ -80,8 +158,8 @@ func middleware(h http.Handler) http.Handler {
 	// Cors for swagger-ui
 	h = cors.Handler(h)
 
-	// Clear handler for Gorilla Context
-	h = context.ClearHandler(h)
+	// Tag every request with an id for the logs and audit events
+	h = requestid.Handler(h)
 
 	return h
 }
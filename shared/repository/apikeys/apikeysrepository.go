@@ -0,0 +1,252 @@
+package apikeys
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"autumnomous-jobs-applicant-api/shared/services/security/encryption"
+
+	"github.com/lib/pq"
+)
+
+// CREATE TABLE api_keys (
+//     id SERIAL PRIMARY KEY,
+//     publicid text NOT NULL DEFAULT uuid_generate_v4() UNIQUE,
+//     prefix text NOT NULL,
+//     keyhash text NOT NULL UNIQUE,
+//     owner text NOT NULL,
+//     scopes text[] NOT NULL DEFAULT '{}',
+//     createdate timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+//     expiresat timestamp with time zone,
+//     lastusedat timestamp with time zone,
+//     revoked boolean NOT NULL DEFAULT false,
+//     revokedat timestamp with time zone
+// );
+
+const (
+	// ScopeApplicant lets a client call the public applicant endpoints such as signup and login
+	ScopeApplicant = "applicant"
+
+	// ScopeAdmin lets a client manage API keys
+	ScopeAdmin = "admin"
+
+	// keyPrefix marks our keys so they are easy to recognise, e.g. by secret scanners
+	keyPrefix = "bitk_"
+
+	// displayPrefixLength is how much of a key is kept in plain text to tell keys apart
+	displayPrefixLength = len(keyPrefix) + 6
+
+	// lastUsedResolution limits how often a busy key's last used time is written
+	lastUsedResolution = time.Minute
+)
+
+// Scopes lists every scope a key may be issued with
+var Scopes = []string{ScopeApplicant, ScopeAdmin}
+
+type APIKey struct {
+	PublicID   string     `json:"publicid"`
+	Prefix     string     `json:"prefix"`
+	Owner      string     `json:"owner"`
+	Scopes     []string   `json:"scopes"`
+	CreateDate time.Time  `json:"createdate"`
+	ExpiresAt  *time.Time `json:"expiresat,omitempty"`
+	LastUsedAt *time.Time `json:"lastusedat,omitempty"`
+	Revoked    bool       `json:"revoked"`
+}
+
+// HasScope reports whether the key was issued with scope
+func (key *APIKey) HasScope(scope string) bool {
+	for _, s := range key.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidScope reports whether scope is one of Scopes
+func ValidScope(scope string) bool {
+	for _, s := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type APIKeyRepository struct {
+	Database *sql.DB
+}
+
+func NewAPIKeyRepository(db *sql.DB) *APIKeyRepository {
+	return &APIKeyRepository{Database: db}
+}
+
+const apiKeyColumns = `publicid, prefix, owner, scopes, createdate, expiresat, lastusedat, revoked`
+
+func scanAPIKey(row interface{ Scan(...interface{}) error }) (*APIKey, error) {
+
+	var key APIKey
+	var expiresAt, lastUsedAt sql.NullTime
+
+	err := row.Scan(&key.PublicID, &key.Prefix, &key.Owner, pq.Array(&key.Scopes), &key.CreateDate, &expiresAt, &lastUsedAt, &key.Revoked)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if expiresAt.Valid {
+		key.ExpiresAt = &expiresAt.Time
+	}
+
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+
+	return &key, nil
+}
+
+// CreateAPIKey issues a key for owner. The plain text key is only returned here; just its hash is stored.
+func (repository *APIKeyRepository) CreateAPIKey(owner string, scopes []string, expiresAt *time.Time) (string, *APIKey, error) {
+
+	if strings.TrimSpace(owner) == "" || len(scopes) == 0 {
+		return "", nil, errors.New("missing required value")
+	}
+
+	for _, scope := range scopes {
+		if !ValidScope(scope) {
+			return "", nil, errors.New("invalid scope")
+		}
+	}
+
+	token, err := encryption.GenerateToken(encryption.TokenByteLength)
+
+	if err != nil {
+		log.Println(err)
+		return "", nil, err
+	}
+
+	secret := keyPrefix + token
+
+	stmt, err := repository.Database.Prepare(`
+		INSERT INTO api_keys(prefix, keyhash, owner, scopes, expiresat)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING ` + apiKeyColumns + `;`)
+
+	if err != nil {
+		log.Println(err)
+		return "", nil, err
+	}
+
+	defer stmt.Close()
+
+	var expires sql.NullTime
+	if expiresAt != nil {
+		expires = sql.NullTime{Time: *expiresAt, Valid: true}
+	}
+
+	key, err := scanAPIKey(stmt.QueryRow(secret[:displayPrefixLength], encryption.HashToken(secret), strings.TrimSpace(owner), pq.Array(scopes), expires))
+
+	if err != nil {
+		log.Println(err)
+		return "", nil, err
+	}
+
+	return secret, key, nil
+}
+
+// AuthenticateAPIKey returns the key matching secret, or nil when it is unknown, revoked or expired.
+// The key's last used time is updated at most once per lastUsedResolution.
+func (repository *APIKeyRepository) AuthenticateAPIKey(secret string) (*APIKey, error) {
+
+	if secret == "" {
+		return nil, nil
+	}
+
+	stmt, err := repository.Database.Prepare(`
+		SELECT ` + apiKeyColumns + ` FROM api_keys
+		WHERE keyhash=$1 AND NOT revoked AND (expiresat IS NULL OR expiresat > now());`)
+
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	key, err := scanAPIKey(stmt.QueryRow(encryption.HashToken(secret)))
+
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			return nil, nil
+		}
+		log.Println(err)
+		return nil, err
+	}
+
+	if key.LastUsedAt == nil || time.Since(*key.LastUsedAt) > lastUsedResolution {
+		_, err = repository.Database.Exec(`UPDATE api_keys SET lastusedat=now() WHERE publicid=$1;`, key.PublicID)
+
+		if err != nil {
+			log.Println(err)
+			return nil, err
+		}
+	}
+
+	return key, nil
+}
+
+// GetAPIKeys lists every key, newest first
+func (repository *APIKeyRepository) GetAPIKeys() ([]*APIKey, error) {
+
+	rows, err := repository.Database.Query(`SELECT ` + apiKeyColumns + ` FROM api_keys ORDER BY createdate DESC;`)
+
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	keys := []*APIKey{}
+
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+
+		if err != nil {
+			log.Println(err)
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+// RevokeAPIKey stops a key working. It returns false when no unrevoked key has that public id.
+func (repository *APIKeyRepository) RevokeAPIKey(publicID string) (bool, error) {
+
+	if publicID == "" {
+		return false, errors.New("missing required value")
+	}
+
+	result, err := repository.Database.Exec(`UPDATE api_keys SET revoked=true, revokedat=now() WHERE publicid=$1 AND NOT revoked;`, publicID)
+
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	count, err := result.RowsAffected()
+
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	return count == 1, nil
+}
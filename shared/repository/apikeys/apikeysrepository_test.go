@@ -0,0 +1,105 @@
+package apikeys_test
+
+import (
+	"testing"
+	"time"
+
+	"autumnomous-jobs-applicant-api/shared/repository/apikeys"
+	"autumnomous-jobs-applicant-api/shared/testhelper"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	testhelper.Init()
+}
+
+func Test_APIKeyRepository_CreateAndAuthenticate(t *testing.T) {
+	assert := assert.New(t)
+
+	repository := apikeys.NewAPIKeyRegistry().GetAPIKeyRepository()
+
+	secret, key, err := repository.CreateAPIKey("mobile app", []string{apikeys.ScopeApplicant}, nil)
+
+	assert.Nil(err)
+	assert.NotEmpty(secret)
+	assert.Equal("mobile app", key.Owner)
+	assert.Equal([]string{apikeys.ScopeApplicant}, key.Scopes)
+	assert.Contains(secret, key.Prefix)
+	assert.Nil(key.LastUsedAt)
+
+	found, err := repository.AuthenticateAPIKey(secret)
+
+	assert.Nil(err)
+	assert.NotNil(found)
+	assert.Equal(key.PublicID, found.PublicID)
+	assert.True(found.HasScope(apikeys.ScopeApplicant))
+	assert.False(found.HasScope(apikeys.ScopeAdmin))
+
+	found, err = repository.AuthenticateAPIKey(secret + "x")
+
+	assert.Nil(err)
+	assert.Nil(found)
+
+	// the successful lookup recorded when the key was used
+	keys, err := repository.GetAPIKeys()
+	assert.Nil(err)
+
+	for _, listed := range keys {
+		if listed.PublicID == key.PublicID {
+			assert.NotNil(listed.LastUsedAt)
+		}
+	}
+}
+
+func Test_APIKeyRepository_CreateAPIKey_Invalid(t *testing.T) {
+	assert := assert.New(t)
+
+	repository := apikeys.NewAPIKeyRegistry().GetAPIKeyRepository()
+
+	_, _, err := repository.CreateAPIKey("", []string{apikeys.ScopeApplicant}, nil)
+	assert.NotNil(err)
+
+	_, _, err = repository.CreateAPIKey("web", nil, nil)
+	assert.NotNil(err)
+
+	_, _, err = repository.CreateAPIKey("web", []string{"superuser"}, nil)
+	assert.NotNil(err)
+}
+
+func Test_APIKeyRepository_Expired(t *testing.T) {
+	assert := assert.New(t)
+
+	repository := apikeys.NewAPIKeyRegistry().GetAPIKeyRepository()
+
+	expiresAt := time.Now().Add(-time.Minute)
+	secret, _, err := repository.CreateAPIKey("partner widget", []string{apikeys.ScopeApplicant}, &expiresAt)
+	assert.Nil(err)
+
+	found, err := repository.AuthenticateAPIKey(secret)
+
+	assert.Nil(err)
+	assert.Nil(found)
+}
+
+func Test_APIKeyRepository_RevokeAPIKey(t *testing.T) {
+	assert := assert.New(t)
+
+	repository := apikeys.NewAPIKeyRegistry().GetAPIKeyRepository()
+
+	secret, key, err := repository.CreateAPIKey("web", []string{apikeys.ScopeApplicant}, nil)
+	assert.Nil(err)
+
+	revoked, err := repository.RevokeAPIKey(key.PublicID)
+	assert.Nil(err)
+	assert.True(revoked)
+
+	found, err := repository.AuthenticateAPIKey(secret)
+	assert.Nil(err)
+	assert.Nil(found)
+
+	// revoking twice reports nothing to revoke
+	revoked, err = repository.RevokeAPIKey(key.PublicID)
+	assert.Nil(err)
+	assert.False(revoked)
+}
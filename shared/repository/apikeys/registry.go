@@ -0,0 +1,16 @@
+package apikeys
+
+import (
+	"autumnomous-jobs-applicant-api/shared/database"
+)
+
+type APIKeyRegistry struct {
+}
+
+func NewAPIKeyRegistry() *APIKeyRegistry {
+	return &APIKeyRegistry{}
+}
+
+func (*APIKeyRegistry) GetAPIKeyRepository() *APIKeyRepository {
+	return NewAPIKeyRepository(database.DB)
+}
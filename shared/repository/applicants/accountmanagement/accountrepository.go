@@ -2,17 +2,24 @@ package accountmanagement
 
 import (
 	"autumnomous-jobs-applicant-api/shared/services/security/encryption"
+	"autumnomous-jobs-applicant-api/shared/services/utils"
 	"database/sql"
 	"errors"
 	"log"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 type ApplicantRepository struct {
 	Database *sql.DB
 }
 
+// ALTER TABLE applicants ADD COLUMN emailverified boolean NOT NULL DEFAULT false;
+// ALTER TABLE applicants ADD COLUMN pendingemail text;
+// -- applicants past the change-password step signed in with the password emailed to them
+// UPDATE applicants SET emailverified=true WHERE registrationstep <> 'change-password';
+// ALTER TABLE applicants ADD COLUMN roles text[] NOT NULL DEFAULT '{}';
+
 type Applicant struct {
 	FirstName   string  `json:"firstname"`
 	LastName    string  `json:"lastname"`
@@ -25,12 +32,15 @@ type Applicant struct {
 	Latitude    float64 `json:"latitude"`
 	Longitude   float64 `json:"longitude"`
 	// MobileNumber     string `json:"mobilenumber"`
-	// Role             string `json:"role"`
+	// Roles are only held by staff accounts, see the rbac package
+	Roles []string `json:"roles,omitempty"`
 	// Facebook         string `json:"facebook"`
 	// Twitter          string `json:"twitter"`
 	// Instagram        string `json:"instagram"`
 	// TotalPostsBought int    `json:"totalpostsbought"`
 	RegistrationStep string `json:"registrationstep"`
+	EmailVerified    bool   `json:"emailverified"`
+	PendingEmail     string `json:"pendingemail,omitempty"`
 	Password         string
 	// CompanyPublicID  string `json:"companypublicid"`
 	PublicID string `json:"publicid"`
@@ -61,6 +71,9 @@ func NewApplicantRepository(db *sql.DB) *ApplicantRepository {
 	return &ApplicantRepository{Database: db}
 }
 
+// ErrEmailTaken is returned when an applicant asks to change to an email another account already has
+var ErrEmailTaken = errors.New("email address is already in use")
+
 func (repository *ApplicantRepository) CreateApplicant(firstName, lastName, email, password string) (*Applicant, error) {
 
 	if firstName == "" || lastName == "" || email == "" || password == "" {
@@ -86,6 +99,43 @@ func (repository *ApplicantRepository) CreateApplicant(firstName, lastName, emai
 	return applicant, nil
 }
 
+// CreateFederatedApplicant creates an applicant who signed up through an identity provider. The provider
+// has already verified their email, and they get a random password nobody knows so there is no
+// temporary password to change.
+func (repository *ApplicantRepository) CreateFederatedApplicant(firstName, lastName, email string) (*Applicant, error) {
+
+	if firstName == "" || email == "" {
+		return nil, errors.New("data cannot be empty")
+	}
+
+	unusable, err := encryption.GenerateToken(encryption.TokenByteLength)
+
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	hashedPassword, err := encryption.HashPassword([]byte(unusable))
+
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	applicant := &Applicant{FirstName: firstName, LastName: lastName, Email: email, EmailVerified: true, RegistrationStep: PersonalInformation.String()}
+
+	err = repository.Database.QueryRow(`
+		INSERT INTO applicants(email, firstname, lastname, password, emailverified, registrationstep)
+		VALUES ($1, $2, $3, $4, true, $5) RETURNING publicid;`, email, firstName, lastName, string(hashedPassword), applicant.RegistrationStep).Scan(&applicant.PublicID)
+
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return applicant, nil
+}
+
 func (repository *ApplicantRepository) GetApplicant(userID string) (*Applicant, error) {
 
 	if userID == "" {
@@ -94,7 +144,7 @@ func (repository *ApplicantRepository) GetApplicant(userID string) (*Applicant,
 	var applicant Applicant
 
 	stmt, err := repository.Database.Prepare(`
-		SELECT firstname, lastname, email, registrationstep, phonenumber, address, city, state, zipcode
+		SELECT firstname, lastname, email, registrationstep, phonenumber, address, city, state, zipcode, emailverified, pendingemail, roles
 		FROM applicants
 		WHERE publicid=$1;`,
 	)
@@ -104,9 +154,9 @@ func (repository *ApplicantRepository) GetApplicant(userID string) (*Applicant,
 		return nil, err
 	}
 
-	var app_phone_number, registrationstep, address, city, state, zipcode sql.NullString
+	var app_phone_number, registrationstep, address, city, state, zipcode, pendingemail sql.NullString
 
-	err = stmt.QueryRow(userID).Scan(&applicant.FirstName, &applicant.LastName, &applicant.Email, &registrationstep, &app_phone_number, &address, &city, &state, &zipcode)
+	err = stmt.QueryRow(userID).Scan(&applicant.FirstName, &applicant.LastName, &applicant.Email, &registrationstep, &app_phone_number, &address, &city, &state, &zipcode, &applicant.EmailVerified, &pendingemail, pq.Array(&applicant.Roles))
 
 	if err != nil {
 		log.Println(err)
@@ -136,6 +186,10 @@ func (repository *ApplicantRepository) GetApplicant(userID string) (*Applicant,
 	if zipcode.Valid {
 		applicant.Zipcode = zipcode.String
 	}
+
+	if pendingemail.Valid {
+		applicant.PendingEmail = pendingemail.String
+	}
 	// if emp_facebook.Valid {
 	// 	applicant.Facebook = emp_facebook.String
 	// }
@@ -181,7 +235,23 @@ func (repository *ApplicantRepository) AuthenticateApplicantPassword(email, pass
 	}
 
 	if databasePassword.Valid {
-		if encryption.CompareHashes([]byte(databasePassword.String), []byte(password)) {
+		match, rehash, err := encryption.VerifyPassword([]byte(databasePassword.String), []byte(password))
+
+		if err != nil {
+			log.Println(err)
+			return false, "", "", err
+		}
+
+		if match {
+			if rehash {
+				// failing to upgrade the hash shouldn't stop the applicant logging in
+				err = repository.rehashApplicantPassword(publicID, databasePassword.String, password)
+
+				if err != nil {
+					log.Println(err)
+				}
+			}
+
 			return true, registrationStep.String, publicID, nil
 		}
 	}
@@ -189,6 +259,29 @@ func (repository *ApplicantRepository) AuthenticateApplicantPassword(email, pass
 	return false, "", "", nil
 }
 
+// rehashApplicantPassword replaces a hash made with outdated parameters. The update only applies
+// if the stored hash is still oldHash, so it can't undo a password change made in the meantime.
+func (repository *ApplicantRepository) rehashApplicantPassword(publicID, oldHash, password string) error {
+
+	hashedPassword, err := encryption.HashPassword([]byte(password))
+
+	if err != nil {
+		return err
+	}
+
+	stmt, err := repository.Database.Prepare(`UPDATE applicants SET password=$1 WHERE publicid=$2 AND password=$3;`)
+
+	if err != nil {
+		return err
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.Exec(string(hashedPassword), publicID, oldHash)
+
+	return err
+}
+
 func (repository *ApplicantRepository) UpdateApplicantPassword(publicID, password, newPassword string) (bool, error) {
 
 	if publicID == "" || password == "" || newPassword == "" {
@@ -259,6 +352,14 @@ func (repository *ApplicantRepository) UpdateApplicantPassword(publicID, passwor
 				return false, err
 			}
 
+			// anyone holding a token issued under the old password is logged out
+			err = NewRevocationRepository(repository.Database).RevokeAllTokens(publicID)
+
+			if err != nil {
+				log.Println(err)
+				return false, err
+			}
+
 			return true, nil
 		} else {
 			return false, nil
@@ -268,24 +369,261 @@ func (repository *ApplicantRepository) UpdateApplicantPassword(publicID, passwor
 	}
 }
 
+// GetApplicantByEmail looks up an applicant by email, returning nil when no account matches
+func (repository *ApplicantRepository) GetApplicantByEmail(email string) (*Applicant, error) {
+
+	if email == "" {
+		return nil, errors.New("missing required value")
+	}
+
+	var applicant Applicant
+
+	stmt, err := repository.Database.Prepare(`SELECT firstname, lastname, email, publicid FROM applicants WHERE email=$1;`)
+
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	err = stmt.QueryRow(email).Scan(&applicant.FirstName, &applicant.LastName, &applicant.Email, &applicant.PublicID)
+
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			return nil, nil
+		}
+		log.Println(err)
+		return nil, err
+	}
+
+	return &applicant, nil
+}
+
+// ResetApplicantPassword sets a new password without the current one, for use once a reset token has been redeemed
+func (repository *ApplicantRepository) ResetApplicantPassword(publicID, newPassword string) (bool, error) {
+
+	if publicID == "" || newPassword == "" {
+		return false, nil
+	}
+
+	hashedNewPassword, err := encryption.HashPassword([]byte(newPassword))
+
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	stmt, err := repository.Database.Prepare(`
+		UPDATE applicants
+		SET password=$1,
+			registrationstep=CASE WHEN registrationstep='change-password' THEN 'personal-information' ELSE registrationstep END
+		WHERE publicid=$2;`)
+
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	result, err := stmt.Exec(hashedNewPassword, publicID)
+
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	if rows != 1 {
+		return false, nil
+	}
+
+	err = NewRevocationRepository(repository.Database).RevokeAllTokens(publicID)
+
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	return true, nil
+}
+
+// GetApplicantRoles returns the staff roles held by the applicant, usually none
+func (repository *ApplicantRepository) GetApplicantRoles(publicID string) ([]string, error) {
+
+	if publicID == "" {
+		return nil, errors.New("missing required value")
+	}
+
+	var roles []string
+
+	err := repository.Database.QueryRow(`SELECT roles FROM applicants WHERE publicid=$1;`, publicID).Scan(pq.Array(&roles))
+
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+// ForcePasswordReset replaces the applicant's password with a random one nobody knows and logs out
+// every session, so the account can only be used again after a password reset
+func (repository *ApplicantRepository) ForcePasswordReset(publicID string) (bool, error) {
+
+	if publicID == "" {
+		return false, errors.New("missing required value")
+	}
+
+	unusable, err := encryption.GenerateToken(encryption.TokenByteLength)
+
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	hashedPassword, err := encryption.HashPassword([]byte(unusable))
+
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	stmt, err := repository.Database.Prepare(`UPDATE applicants SET password=$1 WHERE publicid=$2;`)
+
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	result, err := stmt.Exec(hashedPassword, publicID)
+
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	if rows != 1 {
+		return false, nil
+	}
+
+	err = NewRevocationRepository(repository.Database).RevokeAllTokens(publicID)
+
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	return true, nil
+}
+
+// SetApplicantEmailVerified marks the applicant's current email as verified
+func (repository *ApplicantRepository) SetApplicantEmailVerified(publicID string) error {
+
+	if publicID == "" {
+		return errors.New("missing required value")
+	}
+
+	stmt, err := repository.Database.Prepare(`UPDATE applicants SET emailverified=true WHERE publicid=$1;`)
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	_, err = stmt.Exec(publicID)
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+// ConfirmApplicantPendingEmail replaces the applicant's email with their verified pending email.
+// It returns false when there is no pending email to confirm, and ErrEmailTaken when another account
+// has taken the address since it was requested.
+func (repository *ApplicantRepository) ConfirmApplicantPendingEmail(publicID string) (bool, error) {
+
+	if publicID == "" {
+		return false, errors.New("missing required value")
+	}
+
+	stmt, err := repository.Database.Prepare(`
+		UPDATE applicants
+		SET email=pendingemail, pendingemail=NULL, emailverified=true
+		WHERE publicid=$1 AND pendingemail IS NOT NULL;`)
+
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	result, err := stmt.Exec(publicID)
+
+	if isUniqueViolation(err) {
+		return false, ErrEmailTaken
+	}
+
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	return rows == 1, nil
+}
+
+// isUniqueViolation reports whether err is Postgres refusing a row that breaks a unique constraint
+func isUniqueViolation(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == "23505"
+}
+
+// UpdateApplicantAccount changes the applicant's details. A new email is only pending until it has
+// been verified, and ErrEmailTaken is returned if another account already has it. Giving the current
+// email again calls off a pending change, so its link can no longer be used.
 func (repository *ApplicantRepository) UpdateApplicantAccount(publicID, firstName, lastName, email, phoneNumber, address, city, state, zipcode string, latitude, longitude float64) (*Applicant, error) {
 
 	applicant := &Applicant{}
 
-	stmt, err := repository.Database.Prepare(`SELECT firstname, lastname, email FROM applicants WHERE publicid=$1;`)
+	var pendingEmail sql.NullString
+
+	stmt, err := repository.Database.Prepare(`SELECT firstname, lastname, email, pendingemail FROM applicants WHERE publicid=$1;`)
 
 	if err != nil {
 		log.Println(err)
 		return nil, err
 	}
 
-	err = stmt.QueryRow(publicID).Scan(&applicant.FirstName, &applicant.LastName, &applicant.Email)
+	err = stmt.QueryRow(publicID).Scan(&applicant.FirstName, &applicant.LastName, &applicant.Email, &pendingEmail)
 
 	if err != nil {
 		log.Println(err)
 		return nil, err
 	}
 
+	if pendingEmail.Valid {
+		applicant.PendingEmail = pendingEmail.String
+	}
+
 	if firstName != "" {
 		applicant.FirstName = firstName
 	}
@@ -294,8 +632,28 @@ func (repository *ApplicantRepository) UpdateApplicantAccount(publicID, firstNam
 		applicant.LastName = lastName
 	}
 
-	if email != "" {
-		applicant.Email = email
+	// a new email only replaces the current one once it has been verified
+	if email != "" && email != applicant.Email {
+		var taken bool
+
+		err = repository.Database.QueryRow(`SELECT EXISTS(SELECT 1 FROM applicants WHERE email=$1 AND publicid<>$2);`, email, publicID).Scan(&taken)
+
+		if err != nil {
+			log.Println(err)
+			return nil, err
+		}
+
+		if taken {
+			return nil, ErrEmailTaken
+		}
+
+		applicant.PendingEmail = email
+	}
+
+	cancelEmailChange := email != "" && email == applicant.Email && applicant.PendingEmail != ""
+
+	if cancelEmailChange {
+		applicant.PendingEmail = ""
 	}
 
 	if phoneNumber != "" {
@@ -334,68 +692,39 @@ func (repository *ApplicantRepository) UpdateApplicantAccount(publicID, firstNam
 	// applicant.Twitter = twitter
 	// applicant.Instagram = instagram
 	applicant.PublicID = publicID
-	stmt, err = repository.Database.Prepare(`UPDATE applicants SET firstname=$1, lastname=$2, email=$3, phonenumber=$4, address=$5, city=$6, state=$7, zipcode=$8, latitude=$9, longitude=$10 WHERE publicid=$11;`)
+	stmt, err = repository.Database.Prepare(`UPDATE applicants SET firstname=$1, lastname=$2, pendingemail=$3, phonenumber=$4, address=$5, city=$6, state=$7, zipcode=$8, latitude=$9, longitude=$10 WHERE publicid=$11;`)
 
 	if err != nil {
 		log.Println(err)
 		return nil, err
 	}
 
-	_, err = stmt.Exec(applicant.FirstName, applicant.LastName, applicant.Email, applicant.PhoneNumber, applicant.Address, applicant.City, applicant.State, applicant.Zipcode, applicant.Latitude, applicant.Longitude, applicant.PublicID)
+	_, err = stmt.Exec(applicant.FirstName, applicant.LastName, utils.NewNullString(applicant.PendingEmail), applicant.PhoneNumber, applicant.Address, applicant.City, applicant.State, applicant.Zipcode, applicant.Latitude, applicant.Longitude, applicant.PublicID)
 
 	if err != nil {
 		log.Println(err)
 		return nil, err
 	}
 
-	applicant, _ = repository.GetApplicant(publicID)
-
-	if applicant.RegistrationStep == PersonalInformation.String() {
-		stmt, _ = repository.Database.Prepare(`UPDATE applicants SET registrationstep='job-preferences' WHERE publicid=$1;`)
-
-		stmt.Exec(publicID)
-
-	}
-
-	return applicant, nil
-}
-
-func (repository *ApplicantRepository) UpdateApplicantJobPreferences(publicID string, desiredCities []map[string]interface{}) error {
-
-	for _, city := range desiredCities {
-
-		stmt, err := repository.Database.Prepare(`
-			INSERT INTO
-			desiredcities(city, state, country, latitude, longitude, text, applicantid)
-			VALUES ($1, $2, $3, $4, $5, $6, (SELECT id FROM applicants WHERE publicid=$7));
-		`)
+	if cancelEmailChange {
+		err = NewTokenRepository(repository.Database).RevokeTokens(publicID, EmailChange)
 
 		if err != nil {
 			log.Println(err)
-			return err
-		}
-
-		err = stmt.QueryRow(city["city"], city["state"], city["country"], city["latitude"], city["longitude"], city["text"], publicID).Scan()
-
-		if err != nil {
-			if err.Error() != "sql: no rows in result set" {
-				log.Println(err)
-				return err
-			}
+			return nil, err
 		}
-
 	}
 
-	applicant, _ := repository.GetApplicant(publicID)
+	applicant, _ = repository.GetApplicant(publicID)
 
-	if applicant.RegistrationStep == JobPreferences.String() {
-		stmt, _ := repository.Database.Prepare(`UPDATE applicants SET registrationstep='registration-complete' WHERE publicid=$1;`)
+	if applicant.RegistrationStep == PersonalInformation.String() {
+		stmt, _ = repository.Database.Prepare(`UPDATE applicants SET registrationstep='job-preferences' WHERE publicid=$1;`)
 
 		stmt.Exec(publicID)
 
 	}
 
-	return nil
+	return applicant, nil
 }
 
 // func (repository *EmployerRepository) UpdateEmployerCompany(employerPublicID, companyName, location, url, facebook, twitter, instagram, description, logo, extradetails string, longitude, latitude float64) (*companies.Company, error) {
@@ -2,6 +2,7 @@ package accountmanagement_test
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	// "autumnomous-jobs-applicant-api/controller/v1/applicants"
@@ -10,13 +11,63 @@ import (
 	"autumnomous-jobs-applicant-api/shared/services/security/encryption"
 	"autumnomous-jobs-applicant-api/shared/testhelper"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func init() {
 	testhelper.Init()
 }
 
+func Test_ApplicantRepository_AuthenticateApplicantPassword_Rehash(t *testing.T) {
+	assert := assert.New(t)
+
+	defer encryption.SetHasher(encryption.BcryptHasher{Cost: bcrypt.DefaultCost})
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	repository := accountmanagement.NewApplicantRepository(database.DB)
+
+	encryption.SetHasher(encryption.Argon2idHasher{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32})
+
+	// a wrong password leaves the bcrypt hash alone
+	match, _, _, err := repository.AuthenticateApplicantPassword(applicant.Email, "wrong-password")
+	assert.Nil(err)
+	assert.False(match)
+
+	var stored string
+	database.DB.QueryRow(`SELECT password FROM applicants WHERE publicid=$1;`, applicant.PublicID).Scan(&stored)
+	assert.True(strings.HasPrefix(stored, "$2"))
+
+	match, _, publicID, err := repository.AuthenticateApplicantPassword(applicant.Email, applicant.Password)
+	assert.Nil(err)
+	assert.True(match)
+	assert.Equal(applicant.PublicID, publicID)
+
+	database.DB.QueryRow(`SELECT password FROM applicants WHERE publicid=$1;`, applicant.PublicID).Scan(&stored)
+	assert.True(strings.HasPrefix(stored, "$argon2id$"))
+
+	// and the upgraded hash still logs in
+	match, _, _, err = repository.AuthenticateApplicantPassword(applicant.Email, applicant.Password)
+	assert.Nil(err)
+	assert.True(match)
+}
+
+func Test_ApplicantRepository_AuthenticateApplicantPassword_UnrecognisedHash(t *testing.T) {
+	assert := assert.New(t)
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	repository := accountmanagement.NewApplicantRepository(database.DB)
+
+	_, err := database.DB.Exec(`UPDATE applicants SET password='' WHERE publicid=$1;`, applicant.PublicID)
+	assert.Nil(err)
+
+	// an unusable hash is a failed login, not an error
+	match, _, _, err := repository.AuthenticateApplicantPassword(applicant.Email, "")
+	assert.Nil(err)
+	assert.False(match)
+}
+
 func Test_NewApplicantRepository(t *testing.T) {
 
 	assert := assert.New(t)
@@ -119,6 +170,171 @@ func Test_ApplicantRepository_GetApplicant_Fail_EmptyData(t *testing.T) {
 
 }
 
+func Test_ApplicantRepository_GetApplicantByEmail(t *testing.T) {
+	assert := assert.New(t)
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+
+	repository := accountmanagement.NewApplicantRepository(database.DB)
+
+	result, err := repository.GetApplicantByEmail(applicant.Email)
+
+	assert.Nil(err)
+	assert.Equal(applicant.PublicID, result.PublicID)
+}
+
+func Test_ApplicantRepository_GetApplicantByEmail_NotFound(t *testing.T) {
+	assert := assert.New(t)
+
+	repository := accountmanagement.NewApplicantRepository(database.DB)
+
+	result, err := repository.GetApplicantByEmail(fmt.Sprintf("missing-%s@site.com", string(encryption.GeneratePassword(9))))
+
+	assert.Nil(err)
+	assert.Nil(result)
+}
+
+func Test_ApplicantRepository_ResetApplicantPassword(t *testing.T) {
+	assert := assert.New(t)
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	newPassword := string(encryption.GeneratePassword(12))
+
+	repository := accountmanagement.NewApplicantRepository(database.DB)
+
+	updated, err := repository.ResetApplicantPassword(applicant.PublicID, newPassword)
+
+	assert.True(updated)
+	assert.Nil(err)
+
+	match, _, _, err := repository.AuthenticateApplicantPassword(applicant.Email, newPassword)
+
+	assert.True(match)
+	assert.Nil(err)
+}
+
+func Test_ApplicantRepository_SetApplicantEmailVerified(t *testing.T) {
+	assert := assert.New(t)
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+
+	repository := accountmanagement.NewApplicantRepository(database.DB)
+
+	err := repository.SetApplicantEmailVerified(applicant.PublicID)
+	assert.Nil(err)
+
+	result, err := repository.GetApplicant(applicant.PublicID)
+
+	assert.Nil(err)
+	assert.True(result.EmailVerified)
+}
+
+func Test_ApplicantRepository_UpdateApplicantAccount_EmailPendingUntilConfirmed(t *testing.T) {
+	assert := assert.New(t)
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	newEmail := fmt.Sprintf("new-email-%s@site.com", string(encryption.GeneratePassword(9)))
+
+	repository := accountmanagement.NewApplicantRepository(database.DB)
+
+	result, err := repository.UpdateApplicantAccount(applicant.PublicID, "", "", newEmail, "", "", "", "", "", 0, 0)
+
+	assert.Nil(err)
+	assert.Equal(applicant.Email, result.Email)
+	assert.Equal(newEmail, result.PendingEmail)
+
+	confirmed, err := repository.ConfirmApplicantPendingEmail(applicant.PublicID)
+
+	assert.Nil(err)
+	assert.True(confirmed)
+
+	result, err = repository.GetApplicant(applicant.PublicID)
+
+	assert.Nil(err)
+	assert.Equal(newEmail, result.Email)
+	assert.Equal("", result.PendingEmail)
+	assert.True(result.EmailVerified)
+}
+
+func Test_ApplicantRepository_UpdateApplicantAccount_EmailChangeCancelled(t *testing.T) {
+	assert := assert.New(t)
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	newEmail := fmt.Sprintf("new-email-%s@site.com", string(encryption.GeneratePassword(9)))
+
+	repository := accountmanagement.NewApplicantRepository(database.DB)
+	tokens := accountmanagement.NewTokenRepository(database.DB)
+
+	_, err := repository.UpdateApplicantAccount(applicant.PublicID, "", "", newEmail, "", "", "", "", "", 0, 0)
+	assert.Nil(err)
+
+	tokenHash := encryption.HashToken(uuid.NewString())
+	assert.Nil(tokens.CreateToken(applicant.PublicID, accountmanagement.EmailChange, tokenHash, accountmanagement.EmailVerificationTokenLifetime))
+
+	// going back to the current address calls off the change and its link
+	result, err := repository.UpdateApplicantAccount(applicant.PublicID, "", "", applicant.Email, "", "", "", "", "", 0, 0)
+
+	assert.Nil(err)
+	assert.Equal(applicant.Email, result.Email)
+	assert.Equal("", result.PendingEmail)
+
+	redeemedBy, err := tokens.RedeemToken(accountmanagement.EmailChange, tokenHash)
+	assert.Nil(err)
+	assert.Equal("", redeemedBy)
+
+	confirmed, err := repository.ConfirmApplicantPendingEmail(applicant.PublicID)
+	assert.Nil(err)
+	assert.False(confirmed)
+}
+
+func Test_ApplicantRepository_UpdateApplicantAccount_EmailTaken(t *testing.T) {
+	assert := assert.New(t)
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	other := testhelper.Helper_RandomApplicant(t)
+
+	repository := accountmanagement.NewApplicantRepository(database.DB)
+
+	result, err := repository.UpdateApplicantAccount(applicant.PublicID, "", "", other.Email, "", "", "", "", "", 0, 0)
+
+	assert.Equal(accountmanagement.ErrEmailTaken, err)
+	assert.Nil(result)
+}
+
+func Test_ApplicantRepository_ConfirmApplicantPendingEmail_TakenSinceRequested(t *testing.T) {
+	assert := assert.New(t)
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	newEmail := fmt.Sprintf("new-email-%s@site.com", string(encryption.GeneratePassword(9)))
+
+	repository := accountmanagement.NewApplicantRepository(database.DB)
+
+	_, err := repository.UpdateApplicantAccount(applicant.PublicID, "", "", newEmail, "", "", "", "", "", 0, 0)
+	assert.Nil(err)
+
+	// another account signs up with the address before the link is followed
+	_, err = repository.CreateApplicant("Other", "Applicant", newEmail, "password")
+	assert.Nil(err)
+
+	confirmed, err := repository.ConfirmApplicantPendingEmail(applicant.PublicID)
+
+	assert.Equal(accountmanagement.ErrEmailTaken, err)
+	assert.False(confirmed)
+}
+
+func Test_ApplicantRepository_ConfirmApplicantPendingEmail_NoPendingEmail(t *testing.T) {
+	assert := assert.New(t)
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+
+	repository := accountmanagement.NewApplicantRepository(database.DB)
+
+	confirmed, err := repository.ConfirmApplicantPendingEmail(applicant.PublicID)
+
+	assert.Nil(err)
+	assert.False(confirmed)
+}
+
 // func Test_EmployerRepository_AuthenticateEmployerPassword_NoDataReceived(t *testing.T) {
 
 // 	assert := assert.New(t)
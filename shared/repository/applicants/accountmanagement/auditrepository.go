@@ -0,0 +1,229 @@
+package accountmanagement
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"autumnomous-jobs-applicant-api/shared/services/utils"
+)
+
+// CREATE TABLE auditevents (
+//     id BIGSERIAL PRIMARY KEY,
+//     eventtype text NOT NULL,
+//     actorid text,
+//     targetid text,
+//     ipaddress text,
+//     requestid text,
+//     changes jsonb NOT NULL DEFAULT '{}',
+//     createdate timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP
+// );
+// CREATE INDEX auditevents_targetid_idx ON auditevents(targetid, id);
+// CREATE INDEX auditevents_actorid_idx ON auditevents(actorid, id);
+// -- the log is append-only, even for the application's own role
+// CREATE RULE auditevents_no_update AS ON UPDATE TO auditevents DO INSTEAD NOTHING;
+// CREATE RULE auditevents_no_delete AS ON DELETE TO auditevents DO INSTEAD NOTHING;
+
+// Audit event types
+const (
+	AuditLoginSucceeded     = "login.succeeded"
+	AuditLoginFailed        = "login.failed"
+	AuditPasswordChanged    = "password.changed"
+	AuditEmailChanged       = "email.changed"
+	AuditAccountUpdated     = "account.updated"
+	AuditProfileChanged     = "profile.changed"
+	AuditPreferencesChanged = "preferences.changed"
+	AuditFileUploaded       = "file.uploaded"
+	AuditDeletionScheduled  = "account.deletion-scheduled"
+	AuditDeletionCancelled  = "account.deletion-cancelled"
+	AuditAccountDeleted     = "account.deleted"
+
+	AuditDataExportRequested  = "data-export.requested"
+	AuditDataExportDownloaded = "data-export.downloaded"
+
+	AuditResumeUploaded = "resume.uploaded"
+	AuditResumeDeleted  = "resume.deleted"
+
+	// admin actions are taken by staff on an applicant's account
+	AuditAdminPasswordResetForced = "admin.password-reset-forced"
+	AuditAdminApplicantUnlocked   = "admin.applicant-unlocked"
+	AuditAdminImpersonation       = "admin.impersonation-started"
+)
+
+// DefaultAuditPageSize and MaxAuditPageSize bound how many events one query returns
+const (
+	DefaultAuditPageSize = 50
+	MaxAuditPageSize     = 500
+)
+
+// AuditChange is the value of one field before and after an event. Only the field's name is
+// written to the log: the values are dropped, since they may be personal data and the log is
+// kept after the account is deleted.
+type AuditChange struct {
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+// AuditEvent records who did what to which applicant. Actor and target are public ids; they are
+// kept as text so the log outlives the accounts it mentions.
+type AuditEvent struct {
+	ID         int64                  `json:"id"`
+	EventType  string                 `json:"type"`
+	ActorID    string                 `json:"actorid"`
+	TargetID   string                 `json:"targetid"`
+	IPAddress  string                 `json:"ipaddress"`
+	RequestID  string                 `json:"requestid"`
+	Changes    map[string]AuditChange `json:"changes"`
+	CreateDate time.Time              `json:"createdate"`
+}
+
+// AuditFilter narrows an audit query. Empty fields match everything; BeforeID pages backwards
+// from the last event of the previous page.
+type AuditFilter struct {
+	ActorID   string
+	TargetID  string
+	EventType string
+	Since     time.Time
+	Until     time.Time
+	BeforeID  int64
+	Limit     int
+}
+
+type AuditRepository struct {
+	Database *sql.DB
+}
+
+func NewAuditRepository(db *sql.DB) *AuditRepository {
+	return &AuditRepository{Database: db}
+}
+
+// RecordEvent appends event to the audit log, recording which fields changed but not their values
+func (repository *AuditRepository) RecordEvent(event *AuditEvent) error {
+
+	if event == nil || event.EventType == "" {
+		return errors.New("missing required value")
+	}
+
+	changes := map[string]AuditChange{}
+
+	for field := range event.Changes {
+		changes[field] = AuditChange{}
+	}
+
+	encoded, err := json.Marshal(changes)
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	_, err = repository.Database.Exec(`
+		INSERT INTO auditevents(eventtype, actorid, targetid, ipaddress, requestid, changes)
+		VALUES ($1, $2, $3, $4, $5, $6);`, event.EventType, utils.NewNullString(event.ActorID), utils.NewNullString(event.TargetID),
+		utils.NewNullString(event.IPAddress), utils.NewNullString(event.RequestID), string(encoded))
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+// GetAuditEvents returns the events matching filter, newest first
+func (repository *AuditRepository) GetAuditEvents(filter AuditFilter) ([]*AuditEvent, error) {
+
+	conditions := []string{}
+	args := []interface{}{}
+
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.ActorID != "" {
+		where("actorid=$%d", filter.ActorID)
+	}
+
+	if filter.TargetID != "" {
+		where("targetid=$%d", filter.TargetID)
+	}
+
+	if filter.EventType != "" {
+		where("eventtype=$%d", filter.EventType)
+	}
+
+	if !filter.Since.IsZero() {
+		where("createdate >= $%d", filter.Since)
+	}
+
+	if !filter.Until.IsZero() {
+		where("createdate < $%d", filter.Until)
+	}
+
+	if filter.BeforeID > 0 {
+		where("id < $%d", filter.BeforeID)
+	}
+
+	limit := filter.Limit
+
+	if limit <= 0 {
+		limit = DefaultAuditPageSize
+	}
+
+	if limit > MaxAuditPageSize {
+		limit = MaxAuditPageSize
+	}
+
+	query := `SELECT id, eventtype, actorid, targetid, ipaddress, requestid, changes, createdate FROM auditevents`
+
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	args = append(args, limit)
+	query += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d;", len(args))
+
+	rows, err := repository.Database.Query(query, args...)
+
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	events := []*AuditEvent{}
+
+	for rows.Next() {
+		var event AuditEvent
+		var actorID, targetID, ipAddress, requestID sql.NullString
+		var changes []byte
+
+		err = rows.Scan(&event.ID, &event.EventType, &actorID, &targetID, &ipAddress, &requestID, &changes, &event.CreateDate)
+
+		if err != nil {
+			log.Println(err)
+			return nil, err
+		}
+
+		err = json.Unmarshal(changes, &event.Changes)
+
+		if err != nil {
+			log.Println(err)
+			return nil, err
+		}
+
+		event.ActorID = actorID.String
+		event.TargetID = targetID.String
+		event.IPAddress = ipAddress.String
+		event.RequestID = requestID.String
+		events = append(events, &event)
+	}
+
+	return events, rows.Err()
+}
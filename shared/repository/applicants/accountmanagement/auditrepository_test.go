@@ -0,0 +1,95 @@
+package accountmanagement_test
+
+import (
+	"testing"
+	"time"
+
+	"autumnomous-jobs-applicant-api/shared/database"
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+	"autumnomous-jobs-applicant-api/shared/testhelper"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_AuditRepository_RecordEvent_Fail_EmptyData(t *testing.T) {
+	assert := assert.New(t)
+
+	repository := accountmanagement.NewAuditRepository(database.DB)
+
+	assert.NotNil(repository.RecordEvent(nil))
+	assert.NotNil(repository.RecordEvent(&accountmanagement.AuditEvent{TargetID: "target"}))
+}
+
+func Test_AuditRepository_RecordAndQuery(t *testing.T) {
+	assert := assert.New(t)
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	repository := accountmanagement.NewAuditRepository(database.DB)
+
+	err := repository.RecordEvent(&accountmanagement.AuditEvent{
+		EventType: accountmanagement.AuditLoginFailed,
+		TargetID:  applicant.PublicID,
+		IPAddress: "127.0.0.1",
+	})
+	assert.Nil(err)
+
+	err = repository.RecordEvent(&accountmanagement.AuditEvent{
+		EventType: accountmanagement.AuditAccountUpdated,
+		ActorID:   applicant.PublicID,
+		TargetID:  applicant.PublicID,
+		IPAddress: "127.0.0.1",
+		RequestID: "request-id",
+		Changes:   map[string]accountmanagement.AuditChange{"city": {Before: "Leeds", After: "York"}},
+	})
+	assert.Nil(err)
+
+	events, err := repository.GetAuditEvents(accountmanagement.AuditFilter{TargetID: applicant.PublicID})
+	assert.Nil(err)
+
+	if assert.Len(events, 2) {
+		assert.Equal(accountmanagement.AuditAccountUpdated, events[0].EventType)
+		assert.Equal(applicant.PublicID, events[0].ActorID)
+		assert.Equal("request-id", events[0].RequestID)
+		// the field is named but its values, which may be personal data, are not kept
+		assert.Equal(map[string]accountmanagement.AuditChange{"city": {}}, events[0].Changes)
+
+		assert.Equal(accountmanagement.AuditLoginFailed, events[1].EventType)
+		assert.Equal("", events[1].ActorID)
+		assert.Empty(events[1].Changes)
+
+		page, err := repository.GetAuditEvents(accountmanagement.AuditFilter{TargetID: applicant.PublicID, BeforeID: events[0].ID})
+		assert.Nil(err)
+		assert.Len(page, 1)
+	}
+
+	events, err = repository.GetAuditEvents(accountmanagement.AuditFilter{TargetID: applicant.PublicID, EventType: accountmanagement.AuditLoginFailed})
+	assert.Nil(err)
+	assert.Len(events, 1)
+
+	events, err = repository.GetAuditEvents(accountmanagement.AuditFilter{TargetID: applicant.PublicID, Since: time.Now().Add(time.Hour)})
+	assert.Nil(err)
+	assert.Len(events, 0)
+}
+
+func Test_AuditRepository_AppendOnly(t *testing.T) {
+	assert := assert.New(t)
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	repository := accountmanagement.NewAuditRepository(database.DB)
+
+	err := repository.RecordEvent(&accountmanagement.AuditEvent{EventType: accountmanagement.AuditPasswordChanged, TargetID: applicant.PublicID})
+	assert.Nil(err)
+
+	_, err = database.DB.Exec(`DELETE FROM auditevents WHERE targetid=$1;`, applicant.PublicID)
+	assert.Nil(err)
+
+	_, err = database.DB.Exec(`UPDATE auditevents SET eventtype='tampered' WHERE targetid=$1;`, applicant.PublicID)
+	assert.Nil(err)
+
+	events, err := repository.GetAuditEvents(accountmanagement.AuditFilter{TargetID: applicant.PublicID})
+	assert.Nil(err)
+
+	if assert.Len(events, 1) {
+		assert.Equal(accountmanagement.AuditPasswordChanged, events[0].EventType)
+	}
+}
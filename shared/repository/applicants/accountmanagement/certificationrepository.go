@@ -0,0 +1,149 @@
+package accountmanagement
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+
+	"autumnomous-jobs-applicant-api/shared/services/utils"
+
+	"github.com/google/uuid"
+)
+
+// CREATE TABLE certifications (
+//     id SERIAL PRIMARY KEY,
+//     publicid uuid NOT NULL UNIQUE,
+//     applicantid integer NOT NULL REFERENCES applicants(id) ON DELETE CASCADE,
+//     name text NOT NULL,
+//     issuer text,
+//     issuedate date,
+//     expirydate date,
+//     credentialid text,
+//     credentialurl text,
+//     createdate timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+//     updatedate timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP
+// );
+// CREATE INDEX certifications_applicantid_idx ON certifications(applicantid);
+
+// Certification is a licence or certificate the applicant holds
+type Certification struct {
+	PublicID      string `json:"id"`
+	Name          string `json:"name"`
+	Issuer        string `json:"issuer"`
+	IssueDate     string `json:"issuedate"`
+	ExpiryDate    string `json:"expirydate"`
+	CredentialID  string `json:"credentialid"`
+	CredentialURL string `json:"credentialurl"`
+}
+
+// Validate trims the entry's fields and returns what's wrong with it
+func (certification *Certification) Validate() []FieldViolation {
+
+	var v validator
+
+	v.text("name", &certification.Name, true, MaxShortTextLength)
+	v.text("issuer", &certification.Issuer, false, MaxShortTextLength)
+	v.text("credentialid", &certification.CredentialID, false, MaxShortTextLength)
+	v.link("credentialurl", &certification.CredentialURL)
+	v.dateRange("issuedate", certification.IssueDate, "expirydate", certification.ExpiryDate, false)
+
+	return v.violations
+}
+
+type CertificationRepository struct {
+	Database *sql.DB
+}
+
+func NewCertificationRepository(db *sql.DB) *CertificationRepository {
+	return &CertificationRepository{Database: db}
+}
+
+// GetCertifications returns the applicant's certifications, most recently issued first
+func (repository *CertificationRepository) GetCertifications(publicID string) ([]*Certification, error) {
+
+	rows, err := repository.Database.Query(`
+		SELECT publicid, name, coalesce(issuer, ''), coalesce(to_char(issuedate, 'YYYY-MM-DD'), ''),
+			coalesce(to_char(expirydate, 'YYYY-MM-DD'), ''), coalesce(credentialid, ''), coalesce(credentialurl, '')
+		FROM certifications
+		WHERE applicantid=(SELECT id FROM applicants WHERE publicid=$1)
+		ORDER BY issuedate DESC NULLS LAST, name;`, publicID)
+
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	certifications := []*Certification{}
+
+	for rows.Next() {
+		var certification Certification
+
+		err = rows.Scan(&certification.PublicID, &certification.Name, &certification.Issuer, &certification.IssueDate,
+			&certification.ExpiryDate, &certification.CredentialID, &certification.CredentialURL)
+
+		if err != nil {
+			log.Println(err)
+			return nil, err
+		}
+
+		certifications = append(certifications, &certification)
+	}
+
+	return certifications, rows.Err()
+}
+
+// CreateCertification adds a validated certification to the applicant's profile and sets its id
+func (repository *CertificationRepository) CreateCertification(publicID string, certification *Certification) error {
+
+	if publicID == "" || certification == nil {
+		return errors.New("missing required value")
+	}
+
+	certification.PublicID = uuid.NewString()
+
+	result, err := repository.Database.Exec(`
+		INSERT INTO certifications(publicid, applicantid, name, issuer, issuedate, expirydate, credentialid, credentialurl)
+		SELECT $1, applicants.id, $3, $4, $5, $6, $7, $8
+		FROM applicants
+		WHERE applicants.publicid=$2
+			AND (SELECT count(*) FROM certifications WHERE applicantid=applicants.id) < $9;`,
+		certification.PublicID, publicID, certification.Name, utils.NewNullString(certification.Issuer),
+		utils.NewNullString(certification.IssueDate), utils.NewNullString(certification.ExpiryDate),
+		utils.NewNullString(certification.CredentialID), utils.NewNullString(certification.CredentialURL), MaxProfileEntries)
+
+	return insertedOne(result, err)
+}
+
+// UpdateCertification replaces the applicant's certification with the same id. It returns false if
+// they have no such certification.
+func (repository *CertificationRepository) UpdateCertification(publicID string, certification *Certification) (bool, error) {
+
+	if _, err := uuid.Parse(certification.PublicID); err != nil {
+		return false, nil
+	}
+
+	result, err := repository.Database.Exec(`
+		UPDATE certifications SET name=$3, issuer=$4, issuedate=$5, expirydate=$6, credentialid=$7, credentialurl=$8, updatedate=now()
+		WHERE publicid=$1 AND applicantid=(SELECT id FROM applicants WHERE publicid=$2);`,
+		certification.PublicID, publicID, certification.Name, utils.NewNullString(certification.Issuer),
+		utils.NewNullString(certification.IssueDate), utils.NewNullString(certification.ExpiryDate),
+		utils.NewNullString(certification.CredentialID), utils.NewNullString(certification.CredentialURL))
+
+	return affectedAny(result, err)
+}
+
+// DeleteCertification removes the applicant's certification. It returns false if they have no such certification.
+func (repository *CertificationRepository) DeleteCertification(publicID, certificationID string) (bool, error) {
+
+	if _, err := uuid.Parse(certificationID); err != nil {
+		return false, nil
+	}
+
+	result, err := repository.Database.Exec(`
+		DELETE FROM certifications
+		WHERE publicid=$1 AND applicantid=(SELECT id FROM applicants WHERE publicid=$2);`, certificationID, publicID)
+
+	return affectedAny(result, err)
+}
@@ -0,0 +1,267 @@
+package accountmanagement
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"autumnomous-jobs-applicant-api/shared/repository/jobs"
+
+	"github.com/google/uuid"
+)
+
+// CREATE TABLE coverletters (
+//     id SERIAL PRIMARY KEY,
+//     publicid uuid NOT NULL UNIQUE,
+//     applicantid integer NOT NULL REFERENCES applicants(id) ON DELETE CASCADE,
+//     name text NOT NULL,
+//     body text NOT NULL,
+//     createdate timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+//     updatedate timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP
+// );
+// CREATE INDEX coverletters_applicantid_idx ON coverletters(applicantid);
+
+// MaxCoverLetterLength caps the length of a cover letter's template, and maxRenderedCoverLetter
+// what it can expand to
+const (
+	MaxCoverLetterLength   = 10000
+	maxRenderedCoverLetter = 4 * MaxCoverLetterLength
+)
+
+// ErrCoverLetterTooLong is returned when a cover letter renders to more than it could ever need
+var ErrCoverLetterTooLong = errors.New("the cover letter is too long once filled in")
+
+// CoverLetter is a reusable cover letter. Its body is a text/template filled in with the job being
+// applied for, so "Dear {{.CompanyName}}" names the company. Only plain {{.Field}} placeholders for
+// the job's fields are allowed; loops, conditions, functions and nested templates are rejected.
+type CoverLetter struct {
+	PublicID string `json:"id"`
+	Name     string `json:"name"`
+	Body     string `json:"body"`
+}
+
+// Validate trims the cover letter's fields and returns what's wrong with it, including a body that
+// isn't a template that can be filled in with a job
+func (letter *CoverLetter) Validate() []FieldViolation {
+
+	var v validator
+
+	v.text("name", &letter.Name, true, MaxShortTextLength)
+	v.text("body", &letter.Body, true, MaxCoverLetterLength)
+
+	if len(v.violations) == 0 {
+		if _, err := letter.Render(&jobs.Job{}); err != nil {
+			v.add("body", ViolationInvalid, "body isn't a valid template: "+err.Error())
+		}
+	}
+
+	return v.violations
+}
+
+// Render fills in the cover letter for job
+func (letter *CoverLetter) Render(job *jobs.Job) (string, error) {
+
+	body, err := template.New(letter.Name).Parse(letter.Body)
+
+	if err != nil {
+		return "", err
+	}
+
+	err = checkPlaceholders(body)
+
+	if err != nil {
+		return "", err
+	}
+
+	var text strings.Builder
+
+	err = body.Execute(&limitedWriter{writer: &text, remaining: maxRenderedCoverLetter}, job)
+
+	if err != nil {
+		return "", err
+	}
+
+	return text.String(), nil
+}
+
+// checkPlaceholders returns an error unless body is only text and {{.Field}} placeholders for the
+// fields of a job, so filling it in can't loop or call anything
+func checkPlaceholders(body *template.Template) error {
+
+	if len(body.Templates()) > 1 {
+		return errors.New("templates can't be defined")
+	}
+
+	if body.Tree == nil {
+		return nil
+	}
+
+	jobType := reflect.TypeOf(jobs.Job{})
+
+	for _, node := range body.Tree.Root.Nodes {
+		switch node := node.(type) {
+		case *parse.TextNode:
+			continue
+		case *parse.ActionNode:
+			pipe := node.Pipe
+
+			if len(pipe.Decl) == 0 && len(pipe.Cmds) == 1 && len(pipe.Cmds[0].Args) == 1 {
+				if field, ok := pipe.Cmds[0].Args[0].(*parse.FieldNode); ok && len(field.Ident) == 1 {
+					if _, found := jobType.FieldByName(field.Ident[0]); found {
+						continue
+					}
+
+					return fmt.Errorf("%s isn't a job field", node)
+				}
+			}
+
+			return fmt.Errorf("%s isn't a {{.Field}} placeholder", node)
+		default:
+			return fmt.Errorf("%s isn't allowed, only {{.Field}} placeholders", node)
+		}
+	}
+
+	return nil
+}
+
+// limitedWriter fails once more than remaining bytes are written, so a template that calls itself
+// can't fill memory
+type limitedWriter struct {
+	writer    *strings.Builder
+	remaining int
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+
+	if len(p) > w.remaining {
+		return 0, ErrCoverLetterTooLong
+	}
+
+	w.remaining -= len(p)
+
+	return w.writer.Write(p)
+}
+
+type CoverLetterRepository struct {
+	Database *sql.DB
+}
+
+func NewCoverLetterRepository(db *sql.DB) *CoverLetterRepository {
+	return &CoverLetterRepository{Database: db}
+}
+
+// GetCoverLetters returns the applicant's cover letters by name
+func (repository *CoverLetterRepository) GetCoverLetters(publicID string) ([]*CoverLetter, error) {
+
+	rows, err := repository.Database.Query(`
+		SELECT publicid, name, body
+		FROM coverletters
+		WHERE applicantid=(SELECT id FROM applicants WHERE publicid=$1)
+		ORDER BY name, id;`, publicID)
+
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	letters := []*CoverLetter{}
+
+	for rows.Next() {
+		var letter CoverLetter
+
+		err = rows.Scan(&letter.PublicID, &letter.Name, &letter.Body)
+
+		if err != nil {
+			log.Println(err)
+			return nil, err
+		}
+
+		letters = append(letters, &letter)
+	}
+
+	return letters, rows.Err()
+}
+
+// GetCoverLetter returns the applicant's cover letter, or nil if they have no such cover letter
+func (repository *CoverLetterRepository) GetCoverLetter(publicID, letterID string) (*CoverLetter, error) {
+
+	if _, err := uuid.Parse(letterID); err != nil {
+		return nil, nil
+	}
+
+	var letter CoverLetter
+
+	err := repository.Database.QueryRow(`
+		SELECT publicid, name, body
+		FROM coverletters
+		WHERE publicid=$1 AND applicantid=(SELECT id FROM applicants WHERE publicid=$2);`, letterID, publicID).Scan(
+		&letter.PublicID, &letter.Name, &letter.Body)
+
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			return nil, nil
+		}
+		log.Println(err)
+		return nil, err
+	}
+
+	return &letter, nil
+}
+
+// CreateCoverLetter adds a validated cover letter for the applicant and sets its id
+func (repository *CoverLetterRepository) CreateCoverLetter(publicID string, letter *CoverLetter) error {
+
+	if publicID == "" || letter == nil {
+		return errors.New("missing required value")
+	}
+
+	letter.PublicID = uuid.NewString()
+
+	result, err := repository.Database.Exec(`
+		INSERT INTO coverletters(publicid, applicantid, name, body)
+		SELECT $1, applicants.id, $3, $4
+		FROM applicants
+		WHERE applicants.publicid=$2
+			AND (SELECT count(*) FROM coverletters WHERE applicantid=applicants.id) < $5;`,
+		letter.PublicID, publicID, letter.Name, letter.Body, MaxProfileEntries)
+
+	return insertedOne(result, err)
+}
+
+// UpdateCoverLetter replaces the applicant's cover letter with the same id. It returns false if
+// they have no such cover letter.
+func (repository *CoverLetterRepository) UpdateCoverLetter(publicID string, letter *CoverLetter) (bool, error) {
+
+	if _, err := uuid.Parse(letter.PublicID); err != nil {
+		return false, nil
+	}
+
+	result, err := repository.Database.Exec(`
+		UPDATE coverletters SET name=$3, body=$4, updatedate=now()
+		WHERE publicid=$1 AND applicantid=(SELECT id FROM applicants WHERE publicid=$2);`,
+		letter.PublicID, publicID, letter.Name, letter.Body)
+
+	return affectedAny(result, err)
+}
+
+// DeleteCoverLetter removes the applicant's cover letter, taking it out of any document sets. It
+// returns false if they have no such cover letter.
+func (repository *CoverLetterRepository) DeleteCoverLetter(publicID, letterID string) (bool, error) {
+
+	if _, err := uuid.Parse(letterID); err != nil {
+		return false, nil
+	}
+
+	result, err := repository.Database.Exec(`
+		DELETE FROM coverletters
+		WHERE publicid=$1 AND applicantid=(SELECT id FROM applicants WHERE publicid=$2);`, letterID, publicID)
+
+	return affectedAny(result, err)
+}
@@ -0,0 +1,411 @@
+package accountmanagement
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// CREATE TABLE accountdeletions (
+//     id SERIAL PRIMARY KEY,
+//     publicid uuid NOT NULL UNIQUE,
+//     applicantpublicid text NOT NULL,
+//     requestedat timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+//     scheduledfor timestamp with time zone NOT NULL,
+//     startedat timestamp with time zone,
+//     cancelledat timestamp with time zone,
+//     completedat timestamp with time zone,
+//     receipt jsonb
+// );
+// CREATE UNIQUE INDEX accountdeletions_pending_idx ON accountdeletions(applicantpublicid)
+//     WHERE cancelledat IS NULL AND completedat IS NULL;
+//
+// applications belongs to the employer API. An applicant's applications are anonymised rather
+// than deleted so employers' job histories stay consistent, which needs at least these columns:
+// CREATE TABLE applications (
+//     id SERIAL PRIMARY KEY,
+//     publicid uuid NOT NULL UNIQUE,
+//     applicantid integer REFERENCES applicants(id),
+//     firstname text NOT NULL,
+//     lastname text NOT NULL,
+//     email text NOT NULL,
+//     ...
+// );
+// Where the table is missing or differs, applications are left alone with a warning so the rest
+// of the deletion still completes.
+
+// applicantTables hold rows that are deleted outright with the applicant, by applicantid
+var applicantTables = []string{
+	"applicanttokens",
+	"recoverycodes",
+	"refreshtokens",
+	"revokedtokens",
+	"applicantsessions",
+	"applicantidentities",
+	"documentsets",
+	"coverletters",
+	"resumes",
+	"applicantuploads",
+	"dataexports",
+	"impersonations",
+	"desiredcities",
+	"jobpreferences",
+	"workexperiences",
+	"educations",
+	"certifications",
+	"applicantskills",
+}
+
+// AccountDeletion is a request to delete an applicant's account once its grace period is over
+type AccountDeletion struct {
+	PublicID          string    `json:"id"`
+	ApplicantPublicID string    `json:"applicantid"`
+	RequestedAt       time.Time `json:"requestedat"`
+	ScheduledFor      time.Time `json:"scheduledfor"`
+}
+
+// DeletionReceipt records what was removed when an account was deleted. It names the applicant
+// only by public id so it can be kept as proof of the deletion.
+type DeletionReceipt struct {
+	ReceiptID   string    `json:"receiptid"`
+	ApplicantID string    `json:"applicantid"`
+	RequestedAt time.Time `json:"requestedat"`
+	CompletedAt time.Time `json:"completedat"`
+
+	// Deleted and Anonymised count rows by table; Deleted also counts the files removed from Spaces
+	Deleted    map[string]int64 `json:"deleted"`
+	Anonymised map[string]int64 `json:"anonymised"`
+
+	// Retained explains what was kept and why
+	Retained map[string]string `json:"retained"`
+}
+
+type DeletionRepository struct {
+	Database *sql.DB
+}
+
+func NewDeletionRepository(db *sql.DB) *DeletionRepository {
+	return &DeletionRepository{Database: db}
+}
+
+// ScheduleDeletion asks for the applicant's account to be deleted after gracePeriod. An
+// applicant who already has a deletion pending gets that one back unchanged.
+func (repository *DeletionRepository) ScheduleDeletion(publicID string, gracePeriod time.Duration) (*AccountDeletion, error) {
+
+	if publicID == "" {
+		return nil, errors.New("missing required value")
+	}
+
+	_, err := repository.Database.Exec(`
+		INSERT INTO accountdeletions(publicid, applicantpublicid, scheduledfor)
+		VALUES ($1, $2, now() + make_interval(secs => $3))
+		ON CONFLICT (applicantpublicid) WHERE cancelledat IS NULL AND completedat IS NULL DO NOTHING;`,
+		uuid.NewString(), publicID, gracePeriod.Seconds())
+
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return repository.GetPendingDeletion(publicID)
+}
+
+// GetPendingDeletion returns the applicant's scheduled deletion, or nil if there isn't one
+func (repository *DeletionRepository) GetPendingDeletion(publicID string) (*AccountDeletion, error) {
+
+	deletion := &AccountDeletion{ApplicantPublicID: publicID}
+
+	err := repository.Database.QueryRow(`
+		SELECT publicid, requestedat, scheduledfor FROM accountdeletions
+		WHERE applicantpublicid=$1 AND cancelledat IS NULL AND completedat IS NULL;`, publicID).Scan(&deletion.PublicID, &deletion.RequestedAt, &deletion.ScheduledFor)
+
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			return nil, nil
+		}
+		log.Println(err)
+		return nil, err
+	}
+
+	return deletion, nil
+}
+
+// CancelDeletion calls off the applicant's pending deletion. It returns false when there wasn't
+// one, or when it has already started.
+func (repository *DeletionRepository) CancelDeletion(publicID string) (bool, error) {
+
+	if publicID == "" {
+		return false, errors.New("missing required value")
+	}
+
+	result, err := repository.Database.Exec(`
+		UPDATE accountdeletions SET cancelledat=now()
+		WHERE applicantpublicid=$1 AND startedat IS NULL AND cancelledat IS NULL AND completedat IS NULL;`, publicID)
+
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	return rows > 0, nil
+}
+
+// GetDueDeletions returns up to limit pending deletions whose grace period is over, oldest first.
+// Deletions that were started but didn't finish are returned again so they can be retried.
+func (repository *DeletionRepository) GetDueDeletions(limit int) ([]*AccountDeletion, error) {
+
+	rows, err := repository.Database.Query(`
+		SELECT publicid, applicantpublicid, requestedat, scheduledfor FROM accountdeletions
+		WHERE cancelledat IS NULL AND completedat IS NULL AND scheduledfor <= now()
+		ORDER BY scheduledfor
+		LIMIT $1;`, limit)
+
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	deletions := []*AccountDeletion{}
+
+	for rows.Next() {
+		var deletion AccountDeletion
+
+		err = rows.Scan(&deletion.PublicID, &deletion.ApplicantPublicID, &deletion.RequestedAt, &deletion.ScheduledFor)
+
+		if err != nil {
+			log.Println(err)
+			return nil, err
+		}
+
+		deletions = append(deletions, &deletion)
+	}
+
+	return deletions, rows.Err()
+}
+
+// StartDeletion marks the deletion as under way, after which it can no longer be cancelled. It
+// returns false if it was cancelled first.
+func (repository *DeletionRepository) StartDeletion(deletion *AccountDeletion) (bool, error) {
+
+	result, err := repository.Database.Exec(`
+		UPDATE accountdeletions SET startedat=coalesce(startedat, now())
+		WHERE publicid=$1 AND cancelledat IS NULL AND completedat IS NULL;`, deletion.PublicID)
+
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	return rows > 0, nil
+}
+
+// CompleteDeletion deletes the applicant's rows, anonymises their applications and marks the
+// deletion done, all in one transaction, and returns the receipt. filesDeleted is the number
+// of their files already removed from Spaces. It returns nil if the deletion was already complete.
+func (repository *DeletionRepository) CompleteDeletion(deletion *AccountDeletion, filesDeleted int) (*DeletionReceipt, error) {
+
+	tx, err := repository.Database.Begin()
+
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	receipt := &DeletionReceipt{
+		ReceiptID:   deletion.PublicID,
+		ApplicantID: deletion.ApplicantPublicID,
+		RequestedAt: deletion.RequestedAt,
+		Deleted:     map[string]int64{"files": int64(filesDeleted)},
+		Anonymised:  map[string]int64{},
+		Retained: map[string]string{
+			"auditevents":     "kept for security, naming the applicant only by public id and recording which fields changed but not their values, with the IP address of each request",
+			"deletionreceipt": "this receipt, as proof of the deletion",
+		},
+	}
+
+	var applicantID int
+	var email string
+
+	err = tx.QueryRow(`SELECT id, email FROM applicants WHERE publicid=$1 FOR UPDATE;`, deletion.ApplicantPublicID).Scan(&applicantID, &email)
+
+	if err != nil && err.Error() != "sql: no rows in result set" {
+		log.Println(err)
+		return nil, err
+	}
+
+	if err == nil {
+		for _, table := range applicantTables {
+			receipt.Deleted[table], err = execCount(tx, fmt.Sprintf(`DELETE FROM %s WHERE applicantid=$1;`, table), applicantID)
+
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		receipt.Deleted["lockoutevents"], err = execCount(tx, `DELETE FROM lockoutevents WHERE lower(email)=lower($1);`, email)
+
+		if err != nil {
+			return nil, err
+		}
+
+		anonymisable, err := hasColumns(tx, "applications", "firstname", "lastname", "email", "applicantid")
+
+		if err != nil {
+			return nil, err
+		}
+
+		if anonymisable {
+			receipt.Anonymised["applications"], err = execCount(tx, `
+				UPDATE applications SET firstname='Deleted', lastname='Applicant', email='', applicantid=NULL
+				WHERE applicantid=$1;`, applicantID)
+
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			log.Printf("warning: applications table missing or without the expected columns; applications for deletion %s not anonymised", deletion.PublicID)
+		}
+
+		receipt.Deleted["applicants"], err = execCount(tx, `DELETE FROM applicants WHERE id=$1;`, applicantID)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	receipt.CompletedAt = time.Now().UTC()
+
+	encoded, err := json.Marshal(receipt)
+
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	completed, err := execCount(tx, `UPDATE accountdeletions SET completedat=$2, receipt=$3 WHERE publicid=$1 AND completedat IS NULL;`, deletion.PublicID, receipt.CompletedAt, string(encoded))
+
+	if err != nil {
+		return nil, err
+	}
+
+	// another instance finished it first
+	if completed == 0 {
+		return nil, nil
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return receipt, nil
+}
+
+// GetDeletionReceipts returns the receipts of the applicant's completed deletions
+func (repository *DeletionRepository) GetDeletionReceipts(publicID string) ([]*DeletionReceipt, error) {
+
+	rows, err := repository.Database.Query(`
+		SELECT receipt FROM accountdeletions
+		WHERE applicantpublicid=$1 AND completedat IS NOT NULL
+		ORDER BY completedat;`, publicID)
+
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	receipts := []*DeletionReceipt{}
+
+	for rows.Next() {
+		var encoded []byte
+
+		err = rows.Scan(&encoded)
+
+		if err != nil {
+			log.Println(err)
+			return nil, err
+		}
+
+		var receipt DeletionReceipt
+
+		err = json.Unmarshal(encoded, &receipt)
+
+		if err != nil {
+			log.Println(err)
+			return nil, err
+		}
+
+		receipts = append(receipts, &receipt)
+	}
+
+	return receipts, rows.Err()
+}
+
+// hasColumns reports whether table exists in the current schema with all of columns. Tables owned
+// by the employer API are checked before use, since this repo doesn't manage their schema.
+func hasColumns(db interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}, table string, columns ...string) (bool, error) {
+
+	var found int
+
+	err := db.QueryRow(`
+		SELECT count(DISTINCT column_name)
+		FROM information_schema.columns
+		WHERE table_schema=current_schema() AND table_name=$1 AND column_name=ANY($2);`, table, pq.Array(columns)).Scan(&found)
+
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	return found == len(columns), nil
+}
+
+// execCount runs a statement in tx and returns how many rows it affected
+func execCount(tx *sql.Tx, query string, args ...interface{}) (int64, error) {
+
+	result, err := tx.Exec(query, args...)
+
+	if err != nil {
+		log.Println(err)
+		return 0, err
+	}
+
+	rows, err := result.RowsAffected()
+
+	if err != nil {
+		log.Println(err)
+		return 0, err
+	}
+
+	return rows, nil
+}
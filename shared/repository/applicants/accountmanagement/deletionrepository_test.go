@@ -0,0 +1,159 @@
+package accountmanagement_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"autumnomous-jobs-applicant-api/shared/database"
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+	"autumnomous-jobs-applicant-api/shared/testhelper"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DeletionRepository_ScheduleDeletion_Fail_EmptyData(t *testing.T) {
+	assert := assert.New(t)
+
+	repository := accountmanagement.NewDeletionRepository(database.DB)
+
+	deletion, err := repository.ScheduleDeletion("", time.Hour)
+
+	assert.NotNil(err)
+	assert.Nil(deletion)
+}
+
+func Test_DeletionRepository_ScheduleAndCancel(t *testing.T) {
+	assert := assert.New(t)
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	repository := accountmanagement.NewDeletionRepository(database.DB)
+
+	deletion, err := repository.ScheduleDeletion(applicant.PublicID, 24*time.Hour)
+	assert.Nil(err)
+
+	if assert.NotNil(deletion) {
+		assert.WithinDuration(time.Now().Add(24*time.Hour), deletion.ScheduledFor, time.Minute)
+	}
+
+	// asking again keeps the original date
+	again, err := repository.ScheduleDeletion(applicant.PublicID, time.Hour)
+	assert.Nil(err)
+	assert.Equal(deletion.PublicID, again.PublicID)
+	assert.Equal(deletion.ScheduledFor, again.ScheduledFor)
+
+	cancelled, err := repository.CancelDeletion(applicant.PublicID)
+	assert.Nil(err)
+	assert.True(cancelled)
+
+	pending, err := repository.GetPendingDeletion(applicant.PublicID)
+	assert.Nil(err)
+	assert.Nil(pending)
+
+	cancelled, err = repository.CancelDeletion(applicant.PublicID)
+	assert.Nil(err)
+	assert.False(cancelled)
+}
+
+func Test_DeletionRepository_CompleteDeletion(t *testing.T) {
+	assert := assert.New(t)
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	repository := accountmanagement.NewDeletionRepository(database.DB)
+
+	err := accountmanagement.NewUploadRepository(database.DB).CreateUpload(applicant.PublicID, "uploads/"+applicant.PublicID+".png", "me.png", "image/png", 10)
+	assert.Nil(err)
+
+	deletion, err := repository.ScheduleDeletion(applicant.PublicID, 0)
+	assert.Nil(err)
+
+	due, err := repository.GetDueDeletions(1000)
+	assert.Nil(err)
+	assert.Contains(due, deletion)
+
+	started, err := repository.StartDeletion(deletion)
+	assert.Nil(err)
+	assert.True(started)
+
+	// once started it can't be called off
+	cancelled, err := repository.CancelDeletion(applicant.PublicID)
+	assert.Nil(err)
+	assert.False(cancelled)
+
+	receipt, err := repository.CompleteDeletion(deletion, 1)
+	assert.Nil(err)
+
+	if assert.NotNil(receipt) {
+		assert.Equal(deletion.PublicID, receipt.ReceiptID)
+		assert.Equal(int64(1), receipt.Deleted["applicants"])
+		assert.Equal(int64(1), receipt.Deleted["applicantuploads"])
+		assert.Equal(int64(1), receipt.Deleted["files"])
+	}
+
+	_, err = accountmanagement.NewApplicantRepository(database.DB).GetApplicant(applicant.PublicID)
+	assert.NotNil(err)
+
+	// completing it twice does nothing
+	receipt, err = repository.CompleteDeletion(deletion, 1)
+	assert.Nil(err)
+	assert.Nil(receipt)
+
+	receipts, err := repository.GetDeletionReceipts(applicant.PublicID)
+	assert.Nil(err)
+
+	if assert.Len(receipts, 1) {
+		assert.Equal(deletion.PublicID, receipts[0].ReceiptID)
+	}
+}
+
+func Test_DeletionRepository_CompleteDeletion_AnonymisesApplications(t *testing.T) {
+	assert := assert.New(t)
+
+	// the employer API owns applications; give the test database the columns deletion relies on
+	_, err := database.DB.Exec(`
+		CREATE TABLE IF NOT EXISTS applications (
+			id SERIAL PRIMARY KEY,
+			publicid uuid NOT NULL UNIQUE,
+			applicantid integer REFERENCES applicants(id),
+			firstname text NOT NULL,
+			lastname text NOT NULL,
+			email text NOT NULL
+		);`)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	applicationID := uuid.NewString()
+
+	_, err = database.DB.Exec(`
+		INSERT INTO applications(publicid, applicantid, firstname, lastname, email)
+		SELECT $1, id, firstname, lastname, email FROM applicants WHERE publicid=$2;`, applicationID, applicant.PublicID)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repository := accountmanagement.NewDeletionRepository(database.DB)
+
+	deletion, err := repository.ScheduleDeletion(applicant.PublicID, 0)
+	assert.Nil(err)
+
+	receipt, err := repository.CompleteDeletion(deletion, 0)
+	assert.Nil(err)
+
+	if assert.NotNil(receipt) {
+		assert.Equal(int64(1), receipt.Anonymised["applications"])
+	}
+
+	var firstName, email string
+	var applicantID sql.NullInt64
+
+	err = database.DB.QueryRow(`SELECT firstname, email, applicantid FROM applications WHERE publicid=$1;`, applicationID).Scan(&firstName, &email, &applicantID)
+	assert.Nil(err)
+	assert.Equal("Deleted", firstName)
+	assert.Empty(email)
+	assert.False(applicantID.Valid)
+}
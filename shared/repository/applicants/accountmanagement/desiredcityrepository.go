@@ -0,0 +1,300 @@
+package accountmanagement
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ALTER TABLE desiredcities ADD COLUMN publicid uuid NOT NULL UNIQUE DEFAULT gen_random_uuid();
+// ALTER TABLE desiredcities ADD COLUMN citykey text;
+// UPDATE desiredcities SET citykey=lower(regexp_replace(concat_ws(', ', nullif(trim(city), ''), nullif(trim(state), ''), nullif(trim(country), '')), '\s+', ' ', 'g'));
+// DELETE FROM desiredcities a USING desiredcities b WHERE a.applicantid=b.applicantid AND a.citykey=b.citykey AND a.id>b.id;
+// ALTER TABLE desiredcities ALTER COLUMN citykey SET NOT NULL;
+// CREATE UNIQUE INDEX desiredcities_city_idx ON desiredcities(applicantid, citykey);
+
+// DesiredCity is a city the applicant wants to work in. An applicant has each city once; two
+// cities are the same when DesiredCityKey gives the same key for them.
+type DesiredCity struct {
+	PublicID  string     `json:"id"`
+	City      string     `json:"city"`
+	State     string     `json:"state"`
+	Country   string     `json:"country"`
+	Latitude  Coordinate `json:"latitude"`
+	Longitude Coordinate `json:"longitude"`
+	Text      string     `json:"text"`
+}
+
+// Validate tidies the city and returns what's wrong with it
+func (city *DesiredCity) Validate() []FieldViolation {
+
+	var v validator
+	v.desiredCity("", city)
+
+	return v.violations
+}
+
+// ValidateDesiredCities tidies a whole list of cities and returns what's wrong with them
+func ValidateDesiredCities(cities []*DesiredCity) []FieldViolation {
+
+	var v validator
+	v.desiredCities("desiredcities", cities)
+
+	return v.violations
+}
+
+// DesiredCityKey returns the form of a city used to tell whether two are the same
+func DesiredCityKey(city *DesiredCity) string {
+
+	parts := []string{}
+
+	for _, part := range []string{city.City, city.State, city.Country} {
+		if part = strings.ToLower(strings.Join(strings.Fields(part), " ")); part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func (v *validator) desiredCities(field string, cities []*DesiredCity) {
+
+	if len(cities) > MaxDesiredCities {
+		v.add(field, ViolationTooLong, fmt.Sprintf("%s can have at most %d entries.", field, MaxDesiredCities))
+	}
+
+	for i, city := range cities {
+		entry := fmt.Sprintf("%s[%d]", field, i)
+
+		if city == nil {
+			v.add(entry, ViolationRequired, fmt.Sprintf("%s is required.", entry))
+			continue
+		}
+
+		v.desiredCity(entry+".", city)
+	}
+}
+
+// desiredCity checks one city, naming its fields with prefix
+func (v *validator) desiredCity(prefix string, city *DesiredCity) {
+
+	v.text(prefix+"city", &city.City, true, MaxShortTextLength)
+	v.text(prefix+"state", &city.State, false, MaxShortTextLength)
+	v.text(prefix+"country", &city.Country, false, MaxShortTextLength)
+	v.text(prefix+"text", &city.Text, false, MaxShortTextLength)
+	v.coordinates(prefix, city.Latitude, city.Longitude)
+}
+
+// coordinates checks a latitude and longitude are on the map. 0,0 is taken to be a missing location.
+func (v *validator) coordinates(prefix string, latitude, longitude Coordinate) {
+
+	if latitude == 0 && longitude == 0 {
+		v.add(prefix+"latitude", ViolationRequired, "a latitude and longitude are required.")
+		return
+	}
+
+	if latitude < -90 || latitude > 90 {
+		v.add(prefix+"latitude", ViolationInvalid, "latitude must be between -90 and 90.")
+	}
+
+	if longitude < -180 || longitude > 180 {
+		v.add(prefix+"longitude", ViolationInvalid, "longitude must be between -180 and 180.")
+	}
+}
+
+type DesiredCityRepository struct {
+	Database *sql.DB
+}
+
+func NewDesiredCityRepository(db *sql.DB) *DesiredCityRepository {
+	return &DesiredCityRepository{Database: db}
+}
+
+// GetDesiredCities returns the cities the applicant wants to work in, in the order they were added
+func (repository *DesiredCityRepository) GetDesiredCities(publicID string) ([]*DesiredCity, error) {
+	return getDesiredCities(repository.Database, publicID)
+}
+
+func getDesiredCities(db *sql.DB, publicID string) ([]*DesiredCity, error) {
+
+	rows, err := db.Query(`
+		SELECT publicid, coalesce(city, ''), coalesce(state, ''), coalesce(country, ''), coalesce(latitude, 0), coalesce(longitude, 0), coalesce(text, '')
+		FROM desiredcities
+		WHERE applicantid=(SELECT id FROM applicants WHERE publicid=$1)
+		ORDER BY id;`, publicID)
+
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	cities := []*DesiredCity{}
+
+	for rows.Next() {
+		var city DesiredCity
+
+		err = rows.Scan(&city.PublicID, &city.City, &city.State, &city.Country, &city.Latitude, &city.Longitude, &city.Text)
+
+		if err != nil {
+			log.Println(err)
+			return nil, err
+		}
+
+		cities = append(cities, &city)
+	}
+
+	return cities, rows.Err()
+}
+
+// AddDesiredCity adds a validated city for the applicant and sets its id. A city they already have
+// is updated in place and keeps its id. It returns ErrTooManyEntries when they have MaxDesiredCities.
+func (repository *DesiredCityRepository) AddDesiredCity(publicID string, city *DesiredCity) error {
+
+	if publicID == "" || city == nil {
+		return errors.New("missing required value")
+	}
+
+	return repository.inTransaction(publicID, func(tx *sql.Tx, applicantID int) error {
+
+		err := saveDesiredCity(tx, applicantID, city)
+
+		if err != nil {
+			return err
+		}
+
+		return checkDesiredCityCount(tx, applicantID)
+	})
+}
+
+// ReplaceDesiredCities makes cities the applicant's only desired cities. Cities they already have keep
+// their ids, so sending the same list again changes nothing.
+func (repository *DesiredCityRepository) ReplaceDesiredCities(publicID string, cities []*DesiredCity) error {
+
+	if publicID == "" {
+		return errors.New("missing required value")
+	}
+
+	return repository.inTransaction(publicID, func(tx *sql.Tx, applicantID int) error {
+
+		kept := []string{}
+
+		for _, city := range cities {
+			err := saveDesiredCity(tx, applicantID, city)
+
+			if err != nil {
+				return err
+			}
+
+			kept = append(kept, city.PublicID)
+		}
+
+		_, err := tx.Exec(`DELETE FROM desiredcities WHERE applicantid=$1 AND NOT publicid=ANY($2::uuid[]);`, applicantID, pq.Array(kept))
+
+		if err != nil {
+			log.Println(err)
+			return err
+		}
+
+		return checkDesiredCityCount(tx, applicantID)
+	})
+}
+
+// RemoveDesiredCity removes one of the applicant's cities. It returns false if they have no such city.
+func (repository *DesiredCityRepository) RemoveDesiredCity(publicID, cityID string) (bool, error) {
+
+	if _, err := uuid.Parse(cityID); err != nil {
+		return false, nil
+	}
+
+	result, err := repository.Database.Exec(`
+		DELETE FROM desiredcities
+		WHERE publicid=$1 AND applicantid=(SELECT id FROM applicants WHERE publicid=$2);`, cityID, publicID)
+
+	return affectedAny(result, err)
+}
+
+// inTransaction runs change with the applicant locked, so that concurrent changes to their cities
+// can't take them past the limit
+func (repository *DesiredCityRepository) inTransaction(publicID string, change func(tx *sql.Tx, applicantID int) error) error {
+
+	tx, err := repository.Database.Begin()
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	defer tx.Rollback()
+
+	var applicantID int
+
+	err = tx.QueryRow(`SELECT id FROM applicants WHERE publicid=$1 FOR UPDATE;`, publicID).Scan(&applicantID)
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	err = change(tx, applicantID)
+
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+// saveDesiredCity adds the city for the applicant, or updates the one they have with the same key,
+// and sets its id
+func saveDesiredCity(tx *sql.Tx, applicantID int, city *DesiredCity) error {
+
+	err := tx.QueryRow(`
+		INSERT INTO desiredcities(publicid, applicantid, citykey, city, state, country, latitude, longitude, text)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (applicantid, citykey) DO UPDATE SET
+			city=excluded.city, state=excluded.state, country=excluded.country,
+			latitude=excluded.latitude, longitude=excluded.longitude, text=excluded.text
+		RETURNING publicid;`,
+		uuid.NewString(), applicantID, DesiredCityKey(city), city.City, city.State, city.Country,
+		float64(city.Latitude), float64(city.Longitude), city.Text).Scan(&city.PublicID)
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+// checkDesiredCityCount returns ErrTooManyEntries when the applicant has more than MaxDesiredCities
+func checkDesiredCityCount(tx *sql.Tx, applicantID int) error {
+
+	var count int
+
+	err := tx.QueryRow(`SELECT count(*) FROM desiredcities WHERE applicantid=$1;`, applicantID).Scan(&count)
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	if count > MaxDesiredCities {
+		return ErrTooManyEntries
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package accountmanagement_test
+
+import (
+	"fmt"
+	"testing"
+
+	"autumnomous-jobs-applicant-api/shared/database"
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+	"autumnomous-jobs-applicant-api/shared/testhelper"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DesiredCityKey(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("cleveland, alabama, us", accountmanagement.DesiredCityKey(&accountmanagement.DesiredCity{City: " Cleveland ", State: "Alabama", Country: "US"}))
+	assert.Equal("new york, us", accountmanagement.DesiredCityKey(&accountmanagement.DesiredCity{City: "New  York", Country: "us"}))
+}
+
+func Test_DesiredCityRepository(t *testing.T) {
+	assert := assert.New(t)
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	repository := accountmanagement.NewDesiredCityRepository(database.DB)
+
+	cleveland := &accountmanagement.DesiredCity{City: "Cleveland", State: "Alabama", Country: "US", Latitude: 34.0165, Longitude: -86.5538}
+	assert.Nil(repository.AddDesiredCity(applicant.PublicID, cleveland))
+	assert.NotEmpty(cleveland.PublicID)
+
+	// the same city, however it's written, is updated rather than added
+	again := &accountmanagement.DesiredCity{City: "cleveland", State: "ALABAMA", Country: "us", Latitude: 34.0165, Longitude: -86.5538, Text: "Cleveland, AL"}
+	assert.Nil(repository.AddDesiredCity(applicant.PublicID, again))
+	assert.Equal(cleveland.PublicID, again.PublicID)
+
+	cities, err := repository.GetDesiredCities(applicant.PublicID)
+	assert.Nil(err)
+
+	if assert.Len(cities, 1) {
+		assert.Equal("Cleveland, AL", cities[0].Text)
+	}
+
+	akron := &accountmanagement.DesiredCity{City: "Akron", State: "Ohio", Country: "US", Latitude: 41.0814, Longitude: -81.519}
+	assert.Nil(repository.ReplaceDesiredCities(applicant.PublicID, []*accountmanagement.DesiredCity{cleveland, akron}))
+	assert.Equal(again.PublicID, cleveland.PublicID)
+
+	assert.Nil(repository.ReplaceDesiredCities(applicant.PublicID, []*accountmanagement.DesiredCity{akron}))
+
+	cities, err = repository.GetDesiredCities(applicant.PublicID)
+	assert.Nil(err)
+
+	if assert.Len(cities, 1) {
+		assert.Equal(akron.PublicID, cities[0].PublicID)
+	}
+
+	removed, err := repository.RemoveDesiredCity(testhelper.Helper_RandomApplicant(t).PublicID, akron.PublicID)
+	assert.Nil(err)
+	assert.False(removed)
+
+	removed, err = repository.RemoveDesiredCity(applicant.PublicID, akron.PublicID)
+	assert.Nil(err)
+	assert.True(removed)
+
+	removed, err = repository.RemoveDesiredCity(applicant.PublicID, "not-an-id")
+	assert.Nil(err)
+	assert.False(removed)
+
+	// a replacement past the limit changes nothing
+	many := []*accountmanagement.DesiredCity{}
+
+	for i := 0; i <= accountmanagement.MaxDesiredCities; i++ {
+		many = append(many, &accountmanagement.DesiredCity{City: fmt.Sprintf("Town %d", i), Latitude: 40, Longitude: -80})
+	}
+
+	assert.Equal(accountmanagement.ErrTooManyEntries, repository.ReplaceDesiredCities(applicant.PublicID, many))
+
+	for _, city := range many[:accountmanagement.MaxDesiredCities] {
+		assert.Nil(repository.AddDesiredCity(applicant.PublicID, city))
+	}
+
+	assert.Equal(accountmanagement.ErrTooManyEntries, repository.AddDesiredCity(applicant.PublicID, many[accountmanagement.MaxDesiredCities]))
+
+	cities, err = repository.GetDesiredCities(applicant.PublicID)
+	assert.Nil(err)
+	assert.Len(cities, accountmanagement.MaxDesiredCities)
+}
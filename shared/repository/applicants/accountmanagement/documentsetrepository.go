@@ -0,0 +1,339 @@
+package accountmanagement
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+
+	"autumnomous-jobs-applicant-api/shared/services/utils"
+
+	"github.com/google/uuid"
+)
+
+// CREATE TABLE documentsets (
+//     id SERIAL PRIMARY KEY,
+//     publicid uuid NOT NULL UNIQUE,
+//     applicantid integer NOT NULL REFERENCES applicants(id) ON DELETE CASCADE,
+//     name text NOT NULL,
+//     resumename text,
+//     coverletterid integer REFERENCES coverletters(id) ON DELETE SET NULL,
+//     isdefault boolean NOT NULL DEFAULT false,
+//     createdate timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+//     updatedate timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP
+// );
+// CREATE UNIQUE INDEX documentsets_default_idx ON documentsets(applicantid) WHERE isdefault;
+
+// ErrUnknownDocument is returned when a document set names a resume or cover letter the applicant doesn't have
+var ErrUnknownDocument = errors.New("unknown resume or cover letter")
+
+// DocumentSet pairs one of the applicant's resumes, by name, with one of their cover letters, for
+// applying to a kind of job. The applicant's default set is used when they don't choose one.
+type DocumentSet struct {
+	PublicID      string `json:"id"`
+	Name          string `json:"name"`
+	ResumeName    string `json:"resume"`
+	CoverLetterID string `json:"coverletter"`
+	IsDefault     bool   `json:"isdefault"`
+}
+
+// Validate trims the set's fields and returns what's wrong with it
+func (set *DocumentSet) Validate() []FieldViolation {
+
+	var v validator
+
+	v.text("name", &set.Name, true, MaxShortTextLength)
+	v.text("resume", &set.ResumeName, false, MaxShortTextLength)
+	v.text("coverletter", &set.CoverLetterID, false, MaxShortTextLength)
+
+	if set.ResumeName == "" && set.CoverLetterID == "" {
+		v.add("resume", ViolationRequired, "a document set needs a resume or a cover letter.")
+	}
+
+	return v.violations
+}
+
+type DocumentSetRepository struct {
+	Database *sql.DB
+}
+
+func NewDocumentSetRepository(db *sql.DB) *DocumentSetRepository {
+	return &DocumentSetRepository{Database: db}
+}
+
+const documentSetColumns = `
+	documentsets.publicid, documentsets.name, coalesce(documentsets.resumename, ''),
+	coalesce(coverletters.publicid::text, ''), documentsets.isdefault`
+
+// GetDocumentSets returns the applicant's document sets, the default first
+func (repository *DocumentSetRepository) GetDocumentSets(publicID string) ([]*DocumentSet, error) {
+
+	rows, err := repository.Database.Query(`
+		SELECT `+documentSetColumns+`
+		FROM documentsets
+		LEFT JOIN coverletters ON coverletters.id=documentsets.coverletterid
+		WHERE documentsets.applicantid=(SELECT id FROM applicants WHERE publicid=$1)
+		ORDER BY documentsets.isdefault DESC, documentsets.name, documentsets.id;`, publicID)
+
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	sets := []*DocumentSet{}
+
+	for rows.Next() {
+		var set DocumentSet
+
+		err = rows.Scan(&set.PublicID, &set.Name, &set.ResumeName, &set.CoverLetterID, &set.IsDefault)
+
+		if err != nil {
+			log.Println(err)
+			return nil, err
+		}
+
+		sets = append(sets, &set)
+	}
+
+	return sets, rows.Err()
+}
+
+// GetDocumentSet returns the applicant's document set with the given id or, when setID is empty,
+// their default set. It returns nil if there's no such set.
+func (repository *DocumentSetRepository) GetDocumentSet(publicID, setID string) (*DocumentSet, error) {
+
+	if setID != "" {
+		if _, err := uuid.Parse(setID); err != nil {
+			return nil, nil
+		}
+	}
+
+	var set DocumentSet
+
+	err := repository.Database.QueryRow(`
+		SELECT `+documentSetColumns+`
+		FROM documentsets
+		LEFT JOIN coverletters ON coverletters.id=documentsets.coverletterid
+		WHERE documentsets.applicantid=(SELECT id FROM applicants WHERE publicid=$1)
+			AND (documentsets.publicid::text=$2 OR ($2='' AND documentsets.isdefault));`, publicID, setID).Scan(
+		&set.PublicID, &set.Name, &set.ResumeName, &set.CoverLetterID, &set.IsDefault)
+
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			return nil, nil
+		}
+		log.Println(err)
+		return nil, err
+	}
+
+	return &set, nil
+}
+
+// CreateDocumentSet adds a validated document set for the applicant and sets its id. The first set
+// they add is their default, as is one added with IsDefault. It returns ErrUnknownDocument if the
+// set names a resume or cover letter they don't have.
+func (repository *DocumentSetRepository) CreateDocumentSet(publicID string, set *DocumentSet) error {
+
+	if publicID == "" || set == nil {
+		return errors.New("missing required value")
+	}
+
+	return repository.saveDocumentSet(publicID, set, true)
+}
+
+// UpdateDocumentSet replaces the applicant's document set with the same id. Making it the default
+// takes that from their other set; their default set stays the default until another is chosen.
+// It returns false if they have no such set.
+func (repository *DocumentSetRepository) UpdateDocumentSet(publicID string, set *DocumentSet) (bool, error) {
+
+	if _, err := uuid.Parse(set.PublicID); err != nil {
+		return false, nil
+	}
+
+	err := repository.saveDocumentSet(publicID, set, false)
+
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// saveDocumentSet inserts or updates set in one transaction, so the applicant always has exactly
+// one default once they have any sets. Updating a set that doesn't exist returns sql.ErrNoRows.
+func (repository *DocumentSetRepository) saveDocumentSet(publicID string, set *DocumentSet, create bool) error {
+
+	tx, err := repository.Database.Begin()
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	defer tx.Rollback()
+
+	var applicantID, sets int
+
+	// locking the applicant keeps two requests from both making a default
+	err = tx.QueryRow(`
+		SELECT applicants.id, (SELECT count(*) FROM documentsets WHERE applicantid=applicants.id)
+		FROM applicants
+		WHERE publicid=$1
+		FOR UPDATE;`, publicID).Scan(&applicantID, &sets)
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	coverLetterID, err := documentIDs(tx, applicantID, set)
+
+	if err != nil {
+		return err
+	}
+
+	if create {
+		if sets >= MaxProfileEntries {
+			return ErrTooManyEntries
+		}
+
+		set.PublicID = uuid.NewString()
+		set.IsDefault = set.IsDefault || sets == 0
+	}
+
+	if set.IsDefault {
+		_, err = tx.Exec(`
+			UPDATE documentsets SET isdefault=false, updatedate=now()
+			WHERE applicantid=$1 AND isdefault AND publicid<>$2;`, applicantID, set.PublicID)
+
+		if err != nil {
+			log.Println(err)
+			return err
+		}
+	}
+
+	if create {
+		_, err = tx.Exec(`
+			INSERT INTO documentsets(publicid, applicantid, name, resumename, coverletterid, isdefault)
+			VALUES ($1, $2, $3, $4, $5, $6);`,
+			set.PublicID, applicantID, set.Name, utils.NewNullString(set.ResumeName), coverLetterID, set.IsDefault)
+	} else {
+		err = tx.QueryRow(`
+			UPDATE documentsets SET name=$3, resumename=$4, coverletterid=$5, isdefault=isdefault OR $6, updatedate=now()
+			WHERE publicid=$1 AND applicantid=$2
+			RETURNING isdefault;`,
+			set.PublicID, applicantID, set.Name, utils.NewNullString(set.ResumeName), coverLetterID, set.IsDefault).Scan(&set.IsDefault)
+	}
+
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Println(err)
+		}
+		return err
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+// documentIDs checks the applicant has the resume and cover letter set names, and returns the
+// cover letter's internal id
+func documentIDs(tx *sql.Tx, applicantID int, set *DocumentSet) (sql.NullInt64, error) {
+
+	var coverLetterID sql.NullInt64
+
+	if set.ResumeName != "" {
+		var exists bool
+
+		err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM resumes WHERE applicantid=$1 AND name=$2);`, applicantID, set.ResumeName).Scan(&exists)
+
+		if err != nil {
+			log.Println(err)
+			return coverLetterID, err
+		}
+
+		if !exists {
+			return coverLetterID, ErrUnknownDocument
+		}
+	}
+
+	if set.CoverLetterID != "" {
+		if _, err := uuid.Parse(set.CoverLetterID); err != nil {
+			return coverLetterID, ErrUnknownDocument
+		}
+
+		err := tx.QueryRow(`SELECT id FROM coverletters WHERE publicid=$1 AND applicantid=$2;`, set.CoverLetterID, applicantID).Scan(&coverLetterID)
+
+		if err == sql.ErrNoRows {
+			return coverLetterID, ErrUnknownDocument
+		}
+
+		if err != nil {
+			log.Println(err)
+			return coverLetterID, err
+		}
+	}
+
+	return coverLetterID, nil
+}
+
+// DeleteDocumentSet removes the applicant's document set. When it was their default, their oldest
+// remaining set becomes the default. It returns false if they have no such set.
+func (repository *DocumentSetRepository) DeleteDocumentSet(publicID, setID string) (bool, error) {
+
+	if _, err := uuid.Parse(setID); err != nil {
+		return false, nil
+	}
+
+	tx, err := repository.Database.Begin()
+
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	defer tx.Rollback()
+
+	var applicantID int
+	var wasDefault bool
+
+	err = tx.QueryRow(`
+		DELETE FROM documentsets
+		WHERE publicid=$1 AND applicantid=(SELECT id FROM applicants WHERE publicid=$2)
+		RETURNING applicantid, isdefault;`, setID, publicID).Scan(&applicantID, &wasDefault)
+
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			return false, nil
+		}
+		log.Println(err)
+		return false, err
+	}
+
+	if wasDefault {
+		_, err = tx.Exec(`
+			UPDATE documentsets SET isdefault=true, updatedate=now()
+			WHERE id=(SELECT min(id) FROM documentsets WHERE applicantid=$1);`, applicantID)
+
+		if err != nil {
+			log.Println(err)
+			return false, err
+		}
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,147 @@
+package accountmanagement_test
+
+import (
+	"strings"
+	"testing"
+
+	"autumnomous-jobs-applicant-api/shared/database"
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+	"autumnomous-jobs-applicant-api/shared/repository/jobs"
+	"autumnomous-jobs-applicant-api/shared/testhelper"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CoverLetter_Validate(t *testing.T) {
+	assert := assert.New(t)
+
+	letter := &accountmanagement.CoverLetter{Name: " Engineering ", Body: "Dear {{.CompanyName}}, I'd like to be your {{.Title}}."}
+	assert.Empty(letter.Validate())
+	assert.Equal("Engineering", letter.Name)
+
+	letter = &accountmanagement.CoverLetter{Name: "Broken", Body: "Dear {{.CompanyName"}
+	violations := letter.Validate()
+
+	if assert.Len(violations, 1) {
+		assert.Equal("body", violations[0].Field)
+		assert.Equal(accountmanagement.ViolationInvalid, violations[0].Code)
+	}
+
+	// fields the job doesn't have are caught when the letter is saved
+	letter = &accountmanagement.CoverLetter{Name: "Typo", Body: "Dear {{.Company}}"}
+	violations = letter.Validate()
+
+	if assert.Len(violations, 1) {
+		assert.Equal(accountmanagement.ViolationInvalid, violations[0].Code)
+	}
+}
+
+func Test_CoverLetter_Render(t *testing.T) {
+	assert := assert.New(t)
+
+	letter := &accountmanagement.CoverLetter{Name: "Engineering", Body: "Dear {{.CompanyName}},\nI'd like to be your {{.Title}}."}
+
+	text, err := letter.Render(&jobs.Job{CompanyName: "Acme", Title: "Welder", Remote: true})
+	assert.Nil(err)
+	assert.Equal("Dear Acme,\nI'd like to be your Welder.", text)
+
+	// anything that could loop or call something is refused before it runs
+	bodies := []string{
+		`{{define "loop"}}` + strings.Repeat("x", 1000) + `{{template "loop"}}{{end}}{{template "loop"}}`,
+		`{{range 100000}}{{range 100000}}{{end}}{{end}}`,
+		`{{if .Remote}}remotely{{end}}`,
+		`{{with .Title}}{{.}}{{end}}`,
+		`{{.Title | printf "%s"}}`,
+		`{{printf "%s" .Title}}`,
+		`{{$title := .Title}}`,
+		`{{.}}`,
+	}
+
+	for _, body := range bodies {
+		letter = &accountmanagement.CoverLetter{Name: "Unsafe", Body: body}
+
+		_, err = letter.Render(&jobs.Job{})
+		assert.NotNil(err, body)
+		assert.Len(letter.Validate(), 1, body)
+	}
+}
+
+func Test_DocumentSetRepository_Default(t *testing.T) {
+	assert := assert.New(t)
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	repository := accountmanagement.NewDocumentSetRepository(database.DB)
+
+	assert.Nil(accountmanagement.NewResumeRepository(database.DB).CreateResume(applicant.PublicID, newTestResume("Engineering")))
+
+	letter := &accountmanagement.CoverLetter{Name: "Engineering", Body: "Dear {{.CompanyName}}"}
+	assert.Nil(accountmanagement.NewCoverLetterRepository(database.DB).CreateCoverLetter(applicant.PublicID, letter))
+
+	// the first set is the default
+	engineering := &accountmanagement.DocumentSet{Name: "Engineering", ResumeName: "Engineering", CoverLetterID: letter.PublicID}
+	assert.Nil(repository.CreateDocumentSet(applicant.PublicID, engineering))
+	assert.True(engineering.IsDefault)
+
+	management := &accountmanagement.DocumentSet{Name: "Management", CoverLetterID: letter.PublicID}
+	assert.Nil(repository.CreateDocumentSet(applicant.PublicID, management))
+	assert.False(management.IsDefault)
+
+	unknown := &accountmanagement.DocumentSet{Name: "Sales", ResumeName: "Sales"}
+	assert.Equal(accountmanagement.ErrUnknownDocument, repository.CreateDocumentSet(applicant.PublicID, unknown))
+
+	// another applicant's cover letter can't be used
+	other := testhelper.Helper_RandomApplicant(t)
+	assert.Equal(accountmanagement.ErrUnknownDocument, repository.CreateDocumentSet(other.PublicID, &accountmanagement.DocumentSet{Name: "Stolen", CoverLetterID: letter.PublicID}))
+
+	set, err := repository.GetDocumentSet(applicant.PublicID, "")
+	assert.Nil(err)
+
+	if assert.NotNil(set) {
+		assert.Equal(*engineering, *set)
+	}
+
+	management.IsDefault = true
+	updated, err := repository.UpdateDocumentSet(applicant.PublicID, management)
+	assert.Nil(err)
+	assert.True(updated)
+
+	sets, err := repository.GetDocumentSets(applicant.PublicID)
+	assert.Nil(err)
+
+	// only one set is ever the default, and it comes first
+	if assert.Len(sets, 2) {
+		assert.Equal(management.PublicID, sets[0].PublicID)
+		assert.True(sets[0].IsDefault)
+		assert.False(sets[1].IsDefault)
+	}
+
+	// the default can't be unset, only moved
+	management.IsDefault = false
+	updated, err = repository.UpdateDocumentSet(applicant.PublicID, management)
+	assert.Nil(err)
+	assert.True(updated)
+	assert.True(management.IsDefault)
+
+	deleted, err := repository.DeleteDocumentSet(applicant.PublicID, management.PublicID)
+	assert.Nil(err)
+	assert.True(deleted)
+
+	set, err = repository.GetDocumentSet(applicant.PublicID, "")
+	assert.Nil(err)
+
+	if assert.NotNil(set) {
+		assert.Equal(engineering.PublicID, set.PublicID)
+	}
+
+	// deleting a cover letter takes it out of its sets
+	deleted, err = accountmanagement.NewCoverLetterRepository(database.DB).DeleteCoverLetter(applicant.PublicID, letter.PublicID)
+	assert.Nil(err)
+	assert.True(deleted)
+
+	set, err = repository.GetDocumentSet(applicant.PublicID, engineering.PublicID)
+	assert.Nil(err)
+
+	if assert.NotNil(set) {
+		assert.Empty(set.CoverLetterID)
+	}
+}
@@ -0,0 +1,148 @@
+package accountmanagement
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+
+	"autumnomous-jobs-applicant-api/shared/services/utils"
+
+	"github.com/google/uuid"
+)
+
+// CREATE TABLE educations (
+//     id SERIAL PRIMARY KEY,
+//     publicid uuid NOT NULL UNIQUE,
+//     applicantid integer NOT NULL REFERENCES applicants(id) ON DELETE CASCADE,
+//     institution text NOT NULL,
+//     qualification text,
+//     fieldofstudy text,
+//     startdate date,
+//     enddate date,
+//     description text,
+//     createdate timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+//     updatedate timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP
+// );
+// CREATE INDEX educations_applicantid_idx ON educations(applicantid);
+
+// Education is a school, college or course the applicant attended
+type Education struct {
+	PublicID      string `json:"id"`
+	Institution   string `json:"institution"`
+	Qualification string `json:"qualification"`
+	FieldOfStudy  string `json:"fieldofstudy"`
+	StartDate     string `json:"startdate"`
+	EndDate       string `json:"enddate"`
+	Description   string `json:"description"`
+}
+
+// Validate trims the entry's fields and returns what's wrong with it
+func (education *Education) Validate() []FieldViolation {
+
+	var v validator
+
+	v.text("institution", &education.Institution, true, MaxShortTextLength)
+	v.text("qualification", &education.Qualification, false, MaxShortTextLength)
+	v.text("fieldofstudy", &education.FieldOfStudy, false, MaxShortTextLength)
+	v.text("description", &education.Description, false, MaxLongTextLength)
+	v.dateRange("startdate", education.StartDate, "enddate", education.EndDate, false)
+
+	return v.violations
+}
+
+type EducationRepository struct {
+	Database *sql.DB
+}
+
+func NewEducationRepository(db *sql.DB) *EducationRepository {
+	return &EducationRepository{Database: db}
+}
+
+// GetEducation returns the applicant's education, most recent first
+func (repository *EducationRepository) GetEducation(publicID string) ([]*Education, error) {
+
+	rows, err := repository.Database.Query(`
+		SELECT publicid, institution, coalesce(qualification, ''), coalesce(fieldofstudy, ''),
+			coalesce(to_char(startdate, 'YYYY-MM-DD'), ''), coalesce(to_char(enddate, 'YYYY-MM-DD'), ''), coalesce(description, '')
+		FROM educations
+		WHERE applicantid=(SELECT id FROM applicants WHERE publicid=$1)
+		ORDER BY enddate DESC NULLS FIRST, startdate DESC NULLS LAST;`, publicID)
+
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	educations := []*Education{}
+
+	for rows.Next() {
+		var education Education
+
+		err = rows.Scan(&education.PublicID, &education.Institution, &education.Qualification, &education.FieldOfStudy,
+			&education.StartDate, &education.EndDate, &education.Description)
+
+		if err != nil {
+			log.Println(err)
+			return nil, err
+		}
+
+		educations = append(educations, &education)
+	}
+
+	return educations, rows.Err()
+}
+
+// CreateEducation adds a validated entry to the applicant's profile and sets its id
+func (repository *EducationRepository) CreateEducation(publicID string, education *Education) error {
+
+	if publicID == "" || education == nil {
+		return errors.New("missing required value")
+	}
+
+	education.PublicID = uuid.NewString()
+
+	result, err := repository.Database.Exec(`
+		INSERT INTO educations(publicid, applicantid, institution, qualification, fieldofstudy, startdate, enddate, description)
+		SELECT $1, applicants.id, $3, $4, $5, $6, $7, $8
+		FROM applicants
+		WHERE applicants.publicid=$2
+			AND (SELECT count(*) FROM educations WHERE applicantid=applicants.id) < $9;`,
+		education.PublicID, publicID, education.Institution, utils.NewNullString(education.Qualification),
+		utils.NewNullString(education.FieldOfStudy), utils.NewNullString(education.StartDate), utils.NewNullString(education.EndDate),
+		utils.NewNullString(education.Description), MaxProfileEntries)
+
+	return insertedOne(result, err)
+}
+
+// UpdateEducation replaces the applicant's entry with the same id. It returns false if they have no such entry.
+func (repository *EducationRepository) UpdateEducation(publicID string, education *Education) (bool, error) {
+
+	if _, err := uuid.Parse(education.PublicID); err != nil {
+		return false, nil
+	}
+
+	result, err := repository.Database.Exec(`
+		UPDATE educations SET institution=$3, qualification=$4, fieldofstudy=$5, startdate=$6, enddate=$7, description=$8, updatedate=now()
+		WHERE publicid=$1 AND applicantid=(SELECT id FROM applicants WHERE publicid=$2);`,
+		education.PublicID, publicID, education.Institution, utils.NewNullString(education.Qualification),
+		utils.NewNullString(education.FieldOfStudy), utils.NewNullString(education.StartDate), utils.NewNullString(education.EndDate),
+		utils.NewNullString(education.Description))
+
+	return affectedAny(result, err)
+}
+
+// DeleteEducation removes the applicant's entry. It returns false if they have no such entry.
+func (repository *EducationRepository) DeleteEducation(publicID, educationID string) (bool, error) {
+
+	if _, err := uuid.Parse(educationID); err != nil {
+		return false, nil
+	}
+
+	result, err := repository.Database.Exec(`
+		DELETE FROM educations
+		WHERE publicid=$1 AND applicantid=(SELECT id FROM applicants WHERE publicid=$2);`, educationID, publicID)
+
+	return affectedAny(result, err)
+}
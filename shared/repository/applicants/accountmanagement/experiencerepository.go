@@ -0,0 +1,187 @@
+package accountmanagement
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+
+	"autumnomous-jobs-applicant-api/shared/services/utils"
+
+	"github.com/google/uuid"
+)
+
+// CREATE TABLE workexperiences (
+//     id SERIAL PRIMARY KEY,
+//     publicid uuid NOT NULL UNIQUE,
+//     applicantid integer NOT NULL REFERENCES applicants(id) ON DELETE CASCADE,
+//     employer text NOT NULL,
+//     title text NOT NULL,
+//     location text,
+//     startdate date NOT NULL,
+//     enddate date,
+//     description text,
+//     createdate timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+//     updatedate timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP
+// );
+// CREATE INDEX workexperiences_applicantid_idx ON workexperiences(applicantid);
+
+// MaxProfileEntries caps how many entries an applicant can add to each section of their profile
+const MaxProfileEntries = 50
+
+// ErrTooManyEntries is returned when a section of the applicant's profile is full
+var ErrTooManyEntries = errors.New("too many entries")
+
+// WorkExperience is a job the applicant has held. A job without an end date is their current one.
+type WorkExperience struct {
+	PublicID    string `json:"id"`
+	Employer    string `json:"employer"`
+	Title       string `json:"title"`
+	Location    string `json:"location"`
+	StartDate   string `json:"startdate"`
+	EndDate     string `json:"enddate"`
+	Description string `json:"description"`
+}
+
+// Validate trims the entry's fields and returns what's wrong with it
+func (experience *WorkExperience) Validate() []FieldViolation {
+
+	var v validator
+
+	v.text("employer", &experience.Employer, true, MaxShortTextLength)
+	v.text("title", &experience.Title, true, MaxShortTextLength)
+	v.text("location", &experience.Location, false, MaxShortTextLength)
+	v.text("description", &experience.Description, false, MaxLongTextLength)
+	v.dateRange("startdate", experience.StartDate, "enddate", experience.EndDate, true)
+
+	return v.violations
+}
+
+type WorkExperienceRepository struct {
+	Database *sql.DB
+}
+
+func NewWorkExperienceRepository(db *sql.DB) *WorkExperienceRepository {
+	return &WorkExperienceRepository{Database: db}
+}
+
+// GetWorkExperience returns the applicant's jobs, most recent first
+func (repository *WorkExperienceRepository) GetWorkExperience(publicID string) ([]*WorkExperience, error) {
+
+	rows, err := repository.Database.Query(`
+		SELECT publicid, employer, title, coalesce(location, ''), to_char(startdate, 'YYYY-MM-DD'),
+			coalesce(to_char(enddate, 'YYYY-MM-DD'), ''), coalesce(description, '')
+		FROM workexperiences
+		WHERE applicantid=(SELECT id FROM applicants WHERE publicid=$1)
+		ORDER BY enddate DESC NULLS FIRST, startdate DESC;`, publicID)
+
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	experiences := []*WorkExperience{}
+
+	for rows.Next() {
+		var experience WorkExperience
+
+		err = rows.Scan(&experience.PublicID, &experience.Employer, &experience.Title, &experience.Location,
+			&experience.StartDate, &experience.EndDate, &experience.Description)
+
+		if err != nil {
+			log.Println(err)
+			return nil, err
+		}
+
+		experiences = append(experiences, &experience)
+	}
+
+	return experiences, rows.Err()
+}
+
+// CreateWorkExperience adds a validated job to the applicant's profile and sets its id
+func (repository *WorkExperienceRepository) CreateWorkExperience(publicID string, experience *WorkExperience) error {
+
+	if publicID == "" || experience == nil {
+		return errors.New("missing required value")
+	}
+
+	experience.PublicID = uuid.NewString()
+
+	result, err := repository.Database.Exec(`
+		INSERT INTO workexperiences(publicid, applicantid, employer, title, location, startdate, enddate, description)
+		SELECT $1, applicants.id, $3, $4, $5, $6, $7, $8
+		FROM applicants
+		WHERE applicants.publicid=$2
+			AND (SELECT count(*) FROM workexperiences WHERE applicantid=applicants.id) < $9;`,
+		experience.PublicID, publicID, experience.Employer, experience.Title, utils.NewNullString(experience.Location),
+		experience.StartDate, utils.NewNullString(experience.EndDate), utils.NewNullString(experience.Description), MaxProfileEntries)
+
+	return insertedOne(result, err)
+}
+
+// UpdateWorkExperience replaces the applicant's job with the same id. It returns false if they have no such job.
+func (repository *WorkExperienceRepository) UpdateWorkExperience(publicID string, experience *WorkExperience) (bool, error) {
+
+	if _, err := uuid.Parse(experience.PublicID); err != nil {
+		return false, nil
+	}
+
+	result, err := repository.Database.Exec(`
+		UPDATE workexperiences SET employer=$3, title=$4, location=$5, startdate=$6, enddate=$7, description=$8, updatedate=now()
+		WHERE publicid=$1 AND applicantid=(SELECT id FROM applicants WHERE publicid=$2);`,
+		experience.PublicID, publicID, experience.Employer, experience.Title, utils.NewNullString(experience.Location),
+		experience.StartDate, utils.NewNullString(experience.EndDate), utils.NewNullString(experience.Description))
+
+	return affectedAny(result, err)
+}
+
+// DeleteWorkExperience removes the applicant's job. It returns false if they have no such job.
+func (repository *WorkExperienceRepository) DeleteWorkExperience(publicID, experienceID string) (bool, error) {
+
+	if _, err := uuid.Parse(experienceID); err != nil {
+		return false, nil
+	}
+
+	result, err := repository.Database.Exec(`
+		DELETE FROM workexperiences
+		WHERE publicid=$1 AND applicantid=(SELECT id FROM applicants WHERE publicid=$2);`, experienceID, publicID)
+
+	return affectedAny(result, err)
+}
+
+// insertedOne turns a capped INSERT ... SELECT into ErrTooManyEntries when it inserted nothing,
+// which also happens if the applicant doesn't exist
+func insertedOne(result sql.Result, err error) error {
+
+	inserted, err := affectedAny(result, err)
+
+	if err != nil {
+		return err
+	}
+
+	if !inserted {
+		return ErrTooManyEntries
+	}
+
+	return nil
+}
+
+// affectedAny reports whether a statement changed any rows
+func affectedAny(result sql.Result, err error) (bool, error) {
+
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	return rows > 0, nil
+}
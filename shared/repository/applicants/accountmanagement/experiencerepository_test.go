@@ -0,0 +1,97 @@
+package accountmanagement_test
+
+import (
+	"testing"
+
+	"autumnomous-jobs-applicant-api/shared/database"
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+	"autumnomous-jobs-applicant-api/shared/testhelper"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_WorkExperience_Validate(t *testing.T) {
+	assert := assert.New(t)
+
+	experience := &accountmanagement.WorkExperience{Employer: "  Acme  ", Title: "Engineer", StartDate: "2020-01-01"}
+	assert.Empty(experience.Validate())
+	assert.Equal("Acme", experience.Employer)
+
+	experience = &accountmanagement.WorkExperience{Title: "Engineer", StartDate: "2020-01-01", EndDate: "2019-12-31"}
+	violations := experience.Validate()
+
+	if assert.Len(violations, 2) {
+		assert.Equal(accountmanagement.FieldViolation{Field: "employer", Code: accountmanagement.ViolationRequired, Message: "employer is required."}, violations[0])
+		assert.Equal("enddate", violations[1].Field)
+		assert.Equal(accountmanagement.ViolationBeforeStart, violations[1].Code)
+	}
+
+	experience = &accountmanagement.WorkExperience{Employer: "Acme", Title: "Engineer", StartDate: "January 2020"}
+	violations = experience.Validate()
+
+	if assert.Len(violations, 1) {
+		assert.Equal(accountmanagement.ViolationInvalid, violations[0].Code)
+	}
+}
+
+func Test_WorkExperienceRepository_CRUD(t *testing.T) {
+	assert := assert.New(t)
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	other := testhelper.Helper_RandomApplicant(t)
+	repository := accountmanagement.NewWorkExperienceRepository(database.DB)
+
+	past := &accountmanagement.WorkExperience{Employer: "Acme", Title: "Intern", StartDate: "2018-06-01", EndDate: "2018-09-01"}
+	current := &accountmanagement.WorkExperience{Employer: "Globex", Title: "Engineer", StartDate: "2019-01-01"}
+
+	assert.Nil(repository.CreateWorkExperience(applicant.PublicID, past))
+	assert.Nil(repository.CreateWorkExperience(applicant.PublicID, current))
+
+	experiences, err := repository.GetWorkExperience(applicant.PublicID)
+	assert.Nil(err)
+
+	// the current job comes first
+	if assert.Len(experiences, 2) {
+		assert.Equal(*current, *experiences[0])
+		assert.Equal(*past, *experiences[1])
+	}
+
+	current.Title = "Senior Engineer"
+
+	updated, err := repository.UpdateWorkExperience(other.PublicID, current)
+	assert.Nil(err)
+	assert.False(updated)
+
+	updated, err = repository.UpdateWorkExperience(applicant.PublicID, current)
+	assert.Nil(err)
+	assert.True(updated)
+
+	deleted, err := repository.DeleteWorkExperience(other.PublicID, past.PublicID)
+	assert.Nil(err)
+	assert.False(deleted)
+
+	deleted, err = repository.DeleteWorkExperience(applicant.PublicID, past.PublicID)
+	assert.Nil(err)
+	assert.True(deleted)
+
+	experiences, err = repository.GetWorkExperience(applicant.PublicID)
+	assert.Nil(err)
+
+	if assert.Len(experiences, 1) {
+		assert.Equal("Senior Engineer", experiences[0].Title)
+	}
+}
+
+func Test_WorkExperienceRepository_TooManyEntries(t *testing.T) {
+	assert := assert.New(t)
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	repository := accountmanagement.NewWorkExperienceRepository(database.DB)
+
+	for i := 0; i < accountmanagement.MaxProfileEntries; i++ {
+		assert.Nil(repository.CreateWorkExperience(applicant.PublicID, &accountmanagement.WorkExperience{Employer: "Acme", Title: "Engineer", StartDate: "2020-01-01"}))
+	}
+
+	err := repository.CreateWorkExperience(applicant.PublicID, &accountmanagement.WorkExperience{Employer: "Acme", Title: "Engineer", StartDate: "2020-01-01"})
+	assert.Equal(accountmanagement.ErrTooManyEntries, err)
+}
@@ -0,0 +1,303 @@
+package accountmanagement
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"autumnomous-jobs-applicant-api/shared/services/utils"
+
+	"github.com/google/uuid"
+)
+
+// CREATE TABLE dataexports (
+//     id SERIAL PRIMARY KEY,
+//     publicid uuid NOT NULL UNIQUE,
+//     applicantid integer NOT NULL REFERENCES applicants(id) ON DELETE CASCADE,
+//     status text NOT NULL DEFAULT 'pending',
+//     requestedby text,
+//     objectkey text,
+//     size bigint,
+//     requestedat timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+//     startedat timestamp with time zone,
+//     completedat timestamp with time zone,
+//     expiresat timestamp with time zone
+// );
+// -- one export at a time per applicant
+// CREATE UNIQUE INDEX dataexports_running_idx ON dataexports(applicantid) WHERE status IN ('pending', 'processing');
+
+// Export statuses
+const (
+	ExportPending    = "pending"
+	ExportProcessing = "processing"
+	ExportReady      = "ready"
+	ExportFailed     = "failed"
+	ExportExpired    = "expired"
+)
+
+// ExportLifetime is how long a finished export can be downloaded
+const ExportLifetime = 7 * 24 * time.Hour
+
+// exportStaleAfter is how long an export can be processing before another worker picks it up again
+const exportStaleAfter = time.Hour
+
+// DataExport is a request for a copy of everything held about an applicant
+type DataExport struct {
+	PublicID          string     `json:"id"`
+	ApplicantPublicID string     `json:"applicantid"`
+	Status            string     `json:"status"`
+	RequestedBy       string     `json:"-"`
+	ObjectKey         string     `json:"-"`
+	Size              int64      `json:"size,omitempty"`
+	RequestedAt       time.Time  `json:"requestedat"`
+	CompletedAt       *time.Time `json:"completedat,omitempty"`
+	ExpiresAt         *time.Time `json:"expiresat,omitempty"`
+}
+
+type ExportRepository struct {
+	Database *sql.DB
+}
+
+func NewExportRepository(db *sql.DB) *ExportRepository {
+	return &ExportRepository{Database: db}
+}
+
+const exportColumns = `dataexports.publicid, applicants.publicid, dataexports.status, dataexports.requestedby,
+	dataexports.objectkey, dataexports.size, dataexports.requestedat, dataexports.completedat, dataexports.expiresat`
+
+// CreateExport queues an export of the applicant's data, requested by requestedBy. An applicant
+// whose export is still queued or running gets that one back instead.
+func (repository *ExportRepository) CreateExport(publicID, requestedBy string) (*DataExport, error) {
+
+	if publicID == "" {
+		return nil, errors.New("missing required value")
+	}
+
+	_, err := repository.Database.Exec(`
+		INSERT INTO dataexports(publicid, applicantid, requestedby)
+		VALUES ($1, (SELECT id FROM applicants WHERE publicid=$2), $3)
+		ON CONFLICT (applicantid) WHERE status IN ('pending', 'processing') DO NOTHING;`,
+		uuid.NewString(), publicID, utils.NewNullString(requestedBy))
+
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	return scanExport(repository.Database.QueryRow(`
+		SELECT `+exportColumns+` FROM dataexports
+		JOIN applicants ON applicants.id=dataexports.applicantid
+		WHERE applicants.publicid=$1 AND dataexports.status IN ('pending', 'processing');`, publicID))
+}
+
+// GetExport returns the applicant's export with id exportID, or nil if they have none by that id
+func (repository *ExportRepository) GetExport(publicID, exportID string) (*DataExport, error) {
+
+	if _, err := uuid.Parse(exportID); err != nil {
+		return nil, nil
+	}
+
+	export, err := scanExport(repository.Database.QueryRow(`
+		SELECT `+exportColumns+` FROM dataexports
+		JOIN applicants ON applicants.id=dataexports.applicantid
+		WHERE applicants.publicid=$1 AND dataexports.publicid=$2;`, publicID, exportID))
+
+	if err != nil && err.Error() == "sql: no rows in result set" {
+		return nil, nil
+	}
+
+	return export, err
+}
+
+// GetExports returns the applicant's exports, newest first
+func (repository *ExportRepository) GetExports(publicID string) ([]*DataExport, error) {
+
+	return queryExports(repository.Database, `
+		SELECT `+exportColumns+` FROM dataexports
+		JOIN applicants ON applicants.id=dataexports.applicantid
+		WHERE applicants.publicid=$1
+		ORDER BY dataexports.requestedat DESC;`, publicID)
+}
+
+// ClaimExports marks up to limit queued exports as processing and returns them. Exports that have
+// been processing for too long are assumed lost and claimed again.
+func (repository *ExportRepository) ClaimExports(limit int) ([]*DataExport, error) {
+
+	return queryExports(repository.Database, `
+		WITH claimed AS (
+			UPDATE dataexports SET status='processing', startedat=now()
+			WHERE id IN (
+				SELECT id FROM dataexports
+				WHERE status='pending' OR (status='processing' AND startedat < now() - make_interval(secs => $2))
+				ORDER BY requestedat
+				LIMIT $1
+				FOR UPDATE SKIP LOCKED)
+			RETURNING *)
+		SELECT `+exportColumns+` FROM claimed AS dataexports
+		JOIN applicants ON applicants.id=dataexports.applicantid
+		ORDER BY dataexports.requestedat;`, limit, exportStaleAfter.Seconds())
+}
+
+// CompleteExport records that the export's archive was stored under objectKey
+func (repository *ExportRepository) CompleteExport(export *DataExport, objectKey string, size int64) error {
+
+	err := repository.Database.QueryRow(`
+		UPDATE dataexports SET status='ready', objectkey=$2, size=$3, completedat=now(), expiresat=now() + make_interval(secs => $4)
+		WHERE publicid=$1
+		RETURNING completedat, expiresat;`, export.PublicID, objectKey, size, ExportLifetime.Seconds()).Scan(&export.CompletedAt, &export.ExpiresAt)
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	export.Status = ExportReady
+	export.ObjectKey = objectKey
+	export.Size = size
+
+	return nil
+}
+
+// FailExport records that the export couldn't be built, so the applicant can ask again
+func (repository *ExportRepository) FailExport(export *DataExport) error {
+
+	_, err := repository.Database.Exec(`UPDATE dataexports SET status='failed', completedat=now() WHERE publicid=$1;`, export.PublicID)
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	export.Status = ExportFailed
+
+	return nil
+}
+
+// GetExpiredExports returns up to limit ready exports that can no longer be downloaded
+func (repository *ExportRepository) GetExpiredExports(limit int) ([]*DataExport, error) {
+
+	return queryExports(repository.Database, `
+		SELECT `+exportColumns+` FROM dataexports
+		JOIN applicants ON applicants.id=dataexports.applicantid
+		WHERE dataexports.status='ready' AND dataexports.expiresat <= now()
+		ORDER BY dataexports.expiresat
+		LIMIT $1;`, limit)
+}
+
+// ExpireExport records that the export's archive has been deleted
+func (repository *ExportRepository) ExpireExport(export *DataExport) error {
+
+	_, err := repository.Database.Exec(`UPDATE dataexports SET status='expired', objectkey=NULL WHERE publicid=$1;`, export.PublicID)
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	export.Status = ExportExpired
+	export.ObjectKey = ""
+
+	return nil
+}
+
+// GetDesiredCities returns the cities the applicant wants to work in
+func (repository *ExportRepository) GetDesiredCities(publicID string) ([]*DesiredCity, error) {
+	return getDesiredCities(repository.Database, publicID)
+}
+
+// GetApplications returns the applicant's job applications as stored by the employer API, without
+// its internal ids. There are none to export when the applications table isn't there.
+func (repository *ExportRepository) GetApplications(publicID string) ([]json.RawMessage, error) {
+
+	exists, err := hasColumns(repository.Database, "applications", "id", "applicantid")
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		log.Println("warning: applications table missing or without the expected columns; no applications exported")
+		return []json.RawMessage{}, nil
+	}
+
+	rows, err := repository.Database.Query(`
+		SELECT to_jsonb(applications) - 'id' - 'applicantid'
+		FROM applications
+		WHERE applicantid=(SELECT id FROM applicants WHERE publicid=$1)
+		ORDER BY id;`, publicID)
+
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	applications := []json.RawMessage{}
+
+	for rows.Next() {
+		var application []byte
+
+		err = rows.Scan(&application)
+
+		if err != nil {
+			log.Println(err)
+			return nil, err
+		}
+
+		applications = append(applications, application)
+	}
+
+	return applications, rows.Err()
+}
+
+func scanExport(row interface{ Scan(...interface{}) error }) (*DataExport, error) {
+
+	var export DataExport
+	var requestedBy, objectKey sql.NullString
+	var size sql.NullInt64
+
+	err := row.Scan(&export.PublicID, &export.ApplicantPublicID, &export.Status, &requestedBy, &objectKey, &size,
+		&export.RequestedAt, &export.CompletedAt, &export.ExpiresAt)
+
+	if err != nil {
+		if err.Error() != "sql: no rows in result set" {
+			log.Println(err)
+		}
+		return nil, err
+	}
+
+	export.RequestedBy = requestedBy.String
+	export.ObjectKey = objectKey.String
+	export.Size = size.Int64
+
+	return &export, nil
+}
+
+func queryExports(db *sql.DB, query string, args ...interface{}) ([]*DataExport, error) {
+
+	rows, err := db.Query(query, args...)
+
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	exports := []*DataExport{}
+
+	for rows.Next() {
+		export, err := scanExport(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		exports = append(exports, export)
+	}
+
+	return exports, rows.Err()
+}
@@ -0,0 +1,70 @@
+package accountmanagement_test
+
+import (
+	"testing"
+
+	"autumnomous-jobs-applicant-api/shared/database"
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+	"autumnomous-jobs-applicant-api/shared/testhelper"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ExportRepository_CreateExport_Fail_EmptyData(t *testing.T) {
+	assert := assert.New(t)
+
+	repository := accountmanagement.NewExportRepository(database.DB)
+
+	export, err := repository.CreateExport("", "")
+
+	assert.NotNil(err)
+	assert.Nil(export)
+}
+
+func Test_ExportRepository_OneRunningExport(t *testing.T) {
+	assert := assert.New(t)
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	repository := accountmanagement.NewExportRepository(database.DB)
+
+	export, err := repository.CreateExport(applicant.PublicID, applicant.PublicID)
+	assert.Nil(err)
+
+	if assert.NotNil(export) {
+		assert.Equal(accountmanagement.ExportPending, export.Status)
+		assert.Equal(applicant.PublicID, export.ApplicantPublicID)
+	}
+
+	// asking again while it's queued returns the same export
+	again, err := repository.CreateExport(applicant.PublicID, applicant.PublicID)
+	assert.Nil(err)
+	assert.Equal(export.PublicID, again.PublicID)
+
+	err = repository.CompleteExport(export, "exports/key.zip", 42)
+	assert.Nil(err)
+	assert.NotNil(export.ExpiresAt)
+
+	found, err := repository.GetExport(applicant.PublicID, export.PublicID)
+	assert.Nil(err)
+
+	if assert.NotNil(found) {
+		assert.Equal(accountmanagement.ExportReady, found.Status)
+		assert.Equal("exports/key.zip", found.ObjectKey)
+		assert.Equal(int64(42), found.Size)
+	}
+
+	// another applicant can't see it
+	other := testhelper.Helper_RandomApplicant(t)
+
+	found, err = repository.GetExport(other.PublicID, export.PublicID)
+	assert.Nil(err)
+	assert.Nil(found)
+
+	next, err := repository.CreateExport(applicant.PublicID, applicant.PublicID)
+	assert.Nil(err)
+	assert.NotEqual(export.PublicID, next.PublicID)
+
+	exports, err := repository.GetExports(applicant.PublicID)
+	assert.Nil(err)
+	assert.Len(exports, 2)
+}
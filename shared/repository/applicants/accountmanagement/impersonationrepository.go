@@ -0,0 +1,153 @@
+package accountmanagement
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+)
+
+// CREATE TABLE impersonations (
+//     id SERIAL PRIMARY KEY,
+//     jti text NOT NULL UNIQUE,
+//     actorid integer NOT NULL REFERENCES applicants(id) ON DELETE CASCADE ON UPDATE CASCADE,
+//     applicantid integer NOT NULL REFERENCES applicants(id) ON DELETE CASCADE ON UPDATE CASCADE,
+//     write boolean NOT NULL DEFAULT false,
+//     reason text NOT NULL,
+//     expiresat timestamp with time zone NOT NULL,
+//     createdate timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP
+// );
+// CREATE TABLE impersonationrequests (
+//     id SERIAL PRIMARY KEY,
+//     impersonationid integer NOT NULL REFERENCES impersonations(id) ON DELETE CASCADE ON UPDATE CASCADE,
+//     method text NOT NULL,
+//     path text NOT NULL,
+//     status integer,
+//     ipaddress text,
+//     createdate timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP
+// );
+
+// ImpersonatedRequest is one request made with an impersonation token
+type ImpersonatedRequest struct {
+	ID         int       `json:"-"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Status     int       `json:"status"`
+	IPAddress  string    `json:"ipaddress"`
+	CreateDate time.Time `json:"createdate"`
+}
+
+type ImpersonationRepository struct {
+	Database *sql.DB
+}
+
+func NewImpersonationRepository(db *sql.DB) *ImpersonationRepository {
+	return &ImpersonationRepository{Database: db}
+}
+
+// CreateImpersonation records that actorPublicID was issued the token jti to act as applicantPublicID
+func (repository *ImpersonationRepository) CreateImpersonation(jti, actorPublicID, applicantPublicID, reason string, write bool, expiresAt time.Time) error {
+
+	if jti == "" || actorPublicID == "" || applicantPublicID == "" || reason == "" {
+		return errors.New("missing required value")
+	}
+
+	stmt, err := repository.Database.Prepare(`
+		INSERT INTO impersonations(jti, actorid, applicantid, write, reason, expiresat)
+		VALUES ($1, (SELECT id FROM applicants WHERE publicid=$2), (SELECT id FROM applicants WHERE publicid=$3), $4, $5, $6);`)
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.Exec(jti, actorPublicID, applicantPublicID, write, reason, expiresAt)
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+// RecordImpersonatedRequest logs a request made with the impersonation token jti and returns
+// the entry's id so the response status can be added once it is known. It fails if jti was
+// never issued through CreateImpersonation.
+func (repository *ImpersonationRepository) RecordImpersonatedRequest(jti, method, path, ipAddress string) (int, error) {
+
+	if jti == "" || method == "" || path == "" {
+		return 0, errors.New("missing required value")
+	}
+
+	var id int
+
+	err := repository.Database.QueryRow(`
+		INSERT INTO impersonationrequests(impersonationid, method, path, ipaddress)
+		SELECT id, $2, $3, $4 FROM impersonations WHERE jti=$1
+		RETURNING id;`, jti, method, path, ipAddress).Scan(&id)
+
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			return 0, errors.New("unknown impersonation token")
+		}
+		log.Println(err)
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// SetImpersonatedRequestStatus stores the status code the request was answered with
+func (repository *ImpersonationRepository) SetImpersonatedRequestStatus(id, status int) error {
+
+	_, err := repository.Database.Exec(`UPDATE impersonationrequests SET status=$1 WHERE id=$2;`, status, id)
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+// GetImpersonatedRequests lists the requests made with the impersonation token jti, oldest first
+func (repository *ImpersonationRepository) GetImpersonatedRequests(jti string) ([]*ImpersonatedRequest, error) {
+
+	rows, err := repository.Database.Query(`
+		SELECT impersonationrequests.id, method, path, status, ipaddress, impersonationrequests.createdate
+		FROM impersonationrequests
+		JOIN impersonations ON impersonations.id=impersonationrequests.impersonationid
+		WHERE impersonations.jti=$1
+		ORDER BY impersonationrequests.id;`, jti)
+
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	requests := []*ImpersonatedRequest{}
+
+	for rows.Next() {
+		var request ImpersonatedRequest
+		var status sql.NullInt64
+		var ipAddress sql.NullString
+
+		err = rows.Scan(&request.ID, &request.Method, &request.Path, &status, &ipAddress, &request.CreateDate)
+
+		if err != nil {
+			log.Println(err)
+			return nil, err
+		}
+
+		request.Status = int(status.Int64)
+		request.IPAddress = ipAddress.String
+		requests = append(requests, &request)
+	}
+
+	return requests, rows.Err()
+}
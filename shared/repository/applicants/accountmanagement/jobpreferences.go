@@ -0,0 +1,290 @@
+package accountmanagement
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"autumnomous-jobs-applicant-api/shared/services/utils"
+
+	"github.com/lib/pq"
+)
+
+// CREATE TABLE jobpreferences (
+//     applicantid integer PRIMARY KEY REFERENCES applicants(id) ON DELETE CASCADE,
+//     jobtypes text[] NOT NULL DEFAULT '{}',
+//     categories text[] NOT NULL DEFAULT '{}',
+//     workplaces text[] NOT NULL DEFAULT '{}',
+//     minsalary bigint NOT NULL DEFAULT 0,
+//     payperiod text,
+//     willingtorelocate boolean NOT NULL DEFAULT false,
+//     maxcommuteradius integer NOT NULL DEFAULT 0,
+//     startdate date,
+//     updatedate timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP
+// );
+
+// Where the applicant is willing to work
+const (
+	WorkplaceRemote = "remote"
+	WorkplaceHybrid = "hybrid"
+	WorkplaceOnsite = "onsite"
+)
+
+// Workplaces lists the places of work an applicant can prefer
+var Workplaces = []string{WorkplaceRemote, WorkplaceHybrid, WorkplaceOnsite}
+
+// PayPeriods lists the periods a minimum salary can be given per
+var PayPeriods = []string{"hourly", "daily", "weekly", "monthly", "yearly"}
+
+// Limits on job preferences
+const (
+	MaxPreferenceListLength = 20
+	MaxPreferenceLength     = 100
+	MaxCommuteRadius        = 500
+	MaxDesiredCities        = 20
+)
+
+// Coordinate is a latitude or longitude in degrees. It can be sent as a number or, as older
+// clients do, a string holding one.
+type Coordinate float64
+
+func (coordinate *Coordinate) UnmarshalJSON(data []byte) error {
+
+	var value float64
+
+	if len(data) > 0 && data[0] == '"' {
+		var text string
+
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+
+		if err != nil {
+			return fmt.Errorf("coordinate %q is not a number", text)
+		}
+
+		*coordinate = Coordinate(parsed)
+		return nil
+	}
+
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	*coordinate = Coordinate(value)
+	return nil
+}
+
+// Preferences are the kinds of job the applicant wants, used to match them with jobs. Empty
+// lists and zero values mean the applicant has no preference. MaxCommuteRadius is in miles.
+type Preferences struct {
+	DesiredCities     []*DesiredCity `json:"desiredcities"`
+	JobTypes          []string       `json:"jobtypes"`
+	Categories        []string       `json:"categories"`
+	Workplaces        []string       `json:"workplaces"`
+	MinSalary         int64          `json:"minsalary"`
+	PayPeriod         string         `json:"payperiod"`
+	WillingToRelocate bool           `json:"willingtorelocate"`
+	MaxCommuteRadius  int            `json:"maxcommuteradius"`
+	StartDate         string         `json:"startdate"`
+}
+
+// Validate tidies the preferences and returns what's wrong with them
+func (preferences *Preferences) Validate() []FieldViolation {
+
+	var v validator
+
+	v.desiredCities("desiredcities", preferences.DesiredCities)
+
+	preferences.JobTypes = v.list("jobtypes", preferences.JobTypes, nil)
+	preferences.Categories = v.list("categories", preferences.Categories, nil)
+	preferences.Workplaces = v.list("workplaces", preferences.Workplaces, Workplaces)
+
+	if preferences.MinSalary < 0 {
+		v.add("minsalary", ViolationInvalid, "minsalary can't be negative.")
+	}
+
+	preferences.PayPeriod = strings.ToLower(strings.TrimSpace(preferences.PayPeriod))
+
+	if preferences.PayPeriod == "" && preferences.MinSalary > 0 {
+		v.add("payperiod", ViolationRequired, "payperiod is required with a minimum salary.")
+	} else if preferences.PayPeriod != "" && !contains(PayPeriods, preferences.PayPeriod) {
+		v.add("payperiod", ViolationInvalid, "payperiod must be one of "+strings.Join(PayPeriods, ", ")+".")
+	}
+
+	if preferences.MaxCommuteRadius < 0 || preferences.MaxCommuteRadius > MaxCommuteRadius {
+		v.add("maxcommuteradius", ViolationInvalid, fmt.Sprintf("maxcommuteradius must be between 0 and %d miles.", MaxCommuteRadius))
+	}
+
+	preferences.StartDate = strings.TrimSpace(preferences.StartDate)
+	v.date("startdate", preferences.StartDate, false)
+
+	return v.violations
+}
+
+// list trims the values, drops repeats, and checks there aren't too many and, when allowed is
+// given, that each is one of those (compared in lower case)
+func (v *validator) list(field string, values []string, allowed []string) []string {
+
+	tidied := []string{}
+	seen := map[string]bool{}
+
+	for _, value := range values {
+		value = strings.Join(strings.Fields(value), " ")
+
+		if allowed != nil {
+			value = strings.ToLower(value)
+		}
+
+		if value == "" || seen[strings.ToLower(value)] {
+			continue
+		}
+
+		seen[strings.ToLower(value)] = true
+
+		switch {
+		case allowed != nil && !contains(allowed, value):
+			v.add(field, ViolationInvalid, fmt.Sprintf("%s must each be one of %s.", field, strings.Join(allowed, ", ")))
+		case len([]rune(value)) > MaxPreferenceLength:
+			v.add(field, ViolationTooLong, fmt.Sprintf("each of %s must be at most %d characters.", field, MaxPreferenceLength))
+		}
+
+		tidied = append(tidied, value)
+	}
+
+	if len(tidied) > MaxPreferenceListLength {
+		v.add(field, ViolationTooLong, fmt.Sprintf("%s can have at most %d entries.", field, MaxPreferenceListLength))
+	}
+
+	return tidied
+}
+
+func contains(values []string, value string) bool {
+
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetApplicantJobPreferences returns the applicant's job preferences, which are empty until they set them
+func (repository *ApplicantRepository) GetApplicantJobPreferences(publicID string) (*Preferences, error) {
+
+	preferences := &Preferences{JobTypes: []string{}, Categories: []string{}, Workplaces: []string{}}
+	var payPeriod, startDate sql.NullString
+
+	err := repository.Database.QueryRow(`
+		SELECT jobtypes, categories, workplaces, minsalary, payperiod, willingtorelocate, maxcommuteradius, to_char(startdate, 'YYYY-MM-DD')
+		FROM jobpreferences
+		WHERE applicantid=(SELECT id FROM applicants WHERE publicid=$1);`, publicID).Scan(
+		(*pq.StringArray)(&preferences.JobTypes), (*pq.StringArray)(&preferences.Categories), (*pq.StringArray)(&preferences.Workplaces),
+		&preferences.MinSalary, &payPeriod, &preferences.WillingToRelocate, &preferences.MaxCommuteRadius, &startDate)
+
+	if err != nil && err != sql.ErrNoRows {
+		log.Println(err)
+		return nil, err
+	}
+
+	preferences.PayPeriod = payPeriod.String
+	preferences.StartDate = startDate.String
+
+	preferences.DesiredCities, err = repository.GetDesiredCities(publicID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return preferences, nil
+}
+
+// GetDesiredCities returns the cities the applicant wants to work in
+func (repository *ApplicantRepository) GetDesiredCities(publicID string) ([]*DesiredCity, error) {
+	return getDesiredCities(repository.Database, publicID)
+}
+
+// UpdateApplicantJobPreferences saves the applicant's validated job preferences and adds the desired
+// cities in them to those they already have, in one transaction. Cities they already have are
+// updated rather than added again, and ErrTooManyEntries is returned if they'd have more than
+// MaxDesiredCities. Setting preferences completes registration for an applicant on that step.
+func (repository *ApplicantRepository) UpdateApplicantJobPreferences(publicID string, preferences *Preferences) error {
+
+	if publicID == "" || preferences == nil {
+		return errors.New("missing required value")
+	}
+
+	tx, err := repository.Database.Begin()
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	defer tx.Rollback()
+
+	var applicantID int
+	var registrationStep string
+
+	err = tx.QueryRow(`SELECT id, registrationstep FROM applicants WHERE publicid=$1 FOR UPDATE;`, publicID).Scan(&applicantID, &registrationStep)
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	for _, city := range preferences.DesiredCities {
+		err = saveDesiredCity(tx, applicantID, city)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	err = checkDesiredCityCount(tx, applicantID)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO jobpreferences(applicantid, jobtypes, categories, workplaces, minsalary, payperiod, willingtorelocate, maxcommuteradius, startdate)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (applicantid) DO UPDATE SET
+			jobtypes=excluded.jobtypes, categories=excluded.categories, workplaces=excluded.workplaces,
+			minsalary=excluded.minsalary, payperiod=excluded.payperiod, willingtorelocate=excluded.willingtorelocate,
+			maxcommuteradius=excluded.maxcommuteradius, startdate=excluded.startdate, updatedate=now();`,
+		applicantID, pq.Array(preferences.JobTypes), pq.Array(preferences.Categories), pq.Array(preferences.Workplaces),
+		preferences.MinSalary, utils.NewNullString(preferences.PayPeriod), preferences.WillingToRelocate,
+		preferences.MaxCommuteRadius, utils.NewNullString(preferences.StartDate))
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	if registrationStep == JobPreferences.String() {
+		_, err = tx.Exec(`UPDATE applicants SET registrationstep=$2 WHERE id=$1;`, applicantID, RegistrationComplete.String())
+
+		if err != nil {
+			log.Println(err)
+			return err
+		}
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
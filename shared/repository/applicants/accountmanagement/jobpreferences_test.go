@@ -0,0 +1,106 @@
+package accountmanagement_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"autumnomous-jobs-applicant-api/shared/database"
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+	"autumnomous-jobs-applicant-api/shared/testhelper"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DesiredCity_Coordinates(t *testing.T) {
+	assert := assert.New(t)
+
+	var city accountmanagement.DesiredCity
+
+	// older clients send coordinates as strings
+	assert.Nil(json.Unmarshal([]byte(`{"city": "Cleveland", "latitude": "34.0165", "longitude": -86.5538}`), &city))
+	assert.Equal(accountmanagement.Coordinate(34.0165), city.Latitude)
+	assert.Equal(accountmanagement.Coordinate(-86.5538), city.Longitude)
+
+	assert.NotNil(json.Unmarshal([]byte(`{"latitude": "north"}`), &city))
+}
+
+func Test_Preferences_Validate(t *testing.T) {
+	assert := assert.New(t)
+
+	preferences := &accountmanagement.Preferences{
+		DesiredCities: []*accountmanagement.DesiredCity{{City: " Cleveland ", Latitude: 34.0165, Longitude: -86.5538}},
+		JobTypes:      []string{" Full  time ", "full time", ""},
+		Workplaces:    []string{"Remote", "hybrid"},
+		MinSalary:     50000,
+		PayPeriod:     "Yearly",
+		StartDate:     "2021-09-01",
+	}
+
+	assert.Empty(preferences.Validate())
+	assert.Equal("Cleveland", preferences.DesiredCities[0].City)
+	assert.Equal([]string{"Full time"}, preferences.JobTypes)
+	assert.Equal([]string{"remote", "hybrid"}, preferences.Workplaces)
+	assert.Equal("yearly", preferences.PayPeriod)
+
+	preferences = &accountmanagement.Preferences{
+		DesiredCities:    []*accountmanagement.DesiredCity{{City: "Nowhere"}, {City: "Offworld", Latitude: 91, Longitude: 10}},
+		Workplaces:       []string{"moon"},
+		MinSalary:        50000,
+		MaxCommuteRadius: 1000,
+		StartDate:        "September",
+	}
+
+	fields := []string{}
+
+	for _, violation := range preferences.Validate() {
+		fields = append(fields, violation.Field)
+	}
+
+	assert.Equal([]string{"desiredcities[0].latitude", "desiredcities[1].latitude", "workplaces", "payperiod", "maxcommuteradius", "startdate"}, fields)
+}
+
+func Test_ApplicantRepository_JobPreferences(t *testing.T) {
+	assert := assert.New(t)
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	repository := accountmanagement.NewApplicantRepository(database.DB)
+
+	preferences, err := repository.GetApplicantJobPreferences(applicant.PublicID)
+	assert.Nil(err)
+	assert.Equal(&accountmanagement.Preferences{
+		DesiredCities: []*accountmanagement.DesiredCity{},
+		JobTypes:      []string{},
+		Categories:    []string{},
+		Workplaces:    []string{},
+	}, preferences)
+
+	saved := &accountmanagement.Preferences{
+		DesiredCities:     []*accountmanagement.DesiredCity{{City: "Cleveland", State: "Alabama", Country: "US", Latitude: 34.0165, Longitude: -86.5538, Text: "Cleveland, Alabama, US"}},
+		JobTypes:          []string{"Full time"},
+		Categories:        []string{"Welding", "Fabrication"},
+		Workplaces:        []string{accountmanagement.WorkplaceOnsite},
+		MinSalary:         20,
+		PayPeriod:         "hourly",
+		WillingToRelocate: true,
+		MaxCommuteRadius:  30,
+		StartDate:         "2021-09-01",
+	}
+
+	assert.Empty(saved.Validate())
+	assert.Nil(repository.UpdateApplicantJobPreferences(applicant.PublicID, saved))
+
+	preferences, err = repository.GetApplicantJobPreferences(applicant.PublicID)
+	assert.Nil(err)
+	assert.Equal(saved, preferences)
+
+	// saving again replaces the preferences and adds to the cities
+	saved.DesiredCities = []*accountmanagement.DesiredCity{{City: "Akron", Latitude: 41.0814, Longitude: -81.519}}
+	saved.JobTypes = []string{}
+
+	assert.Nil(repository.UpdateApplicantJobPreferences(applicant.PublicID, saved))
+
+	preferences, err = repository.GetApplicantJobPreferences(applicant.PublicID)
+	assert.Nil(err)
+	assert.Len(preferences.DesiredCities, 2)
+	assert.Empty(preferences.JobTypes)
+}
@@ -0,0 +1,66 @@
+package accountmanagement
+
+import (
+	"autumnomous-jobs-applicant-api/shared/services/utils"
+
+	"database/sql"
+	"errors"
+	"log"
+)
+
+// CREATE TABLE lockoutevents (
+//     id SERIAL PRIMARY KEY,
+//     event text NOT NULL,
+//     email text,
+//     ipaddress text,
+//     createdate timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP
+// );
+// CREATE INDEX lockoutevents_email_idx ON lockoutevents(email);
+
+// LockoutEvent names an entry in the lockout audit table
+type LockoutEvent string
+
+const (
+	// AccountLocked is recorded when failed logins lock an email address
+	AccountLocked LockoutEvent = "account-locked"
+
+	// AddressLocked is recorded when failed logins lock an IP address
+	AddressLocked LockoutEvent = "address-locked"
+
+	// AccountUnlocked is recorded when an applicant follows their emailed unlock link
+	AccountUnlocked LockoutEvent = "account-unlocked"
+)
+
+type LockoutRepository struct {
+	Database *sql.DB
+}
+
+func NewLockoutRepository(db *sql.DB) *LockoutRepository {
+	return &LockoutRepository{Database: db}
+}
+
+// RecordLockoutEvent appends an event to the lockout audit table
+func (repository *LockoutRepository) RecordLockoutEvent(event LockoutEvent, email, ipAddress string) error {
+
+	if event == "" {
+		return errors.New("missing required value")
+	}
+
+	stmt, err := repository.Database.Prepare(`INSERT INTO lockoutevents(event, email, ipaddress) VALUES ($1, $2, $3);`)
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.Exec(event, utils.NewNullString(email), utils.NewNullString(ipAddress))
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
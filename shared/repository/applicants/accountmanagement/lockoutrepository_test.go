@@ -0,0 +1,70 @@
+package accountmanagement_test
+
+import (
+	"testing"
+	"time"
+
+	"autumnomous-jobs-applicant-api/shared/database"
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+	"autumnomous-jobs-applicant-api/shared/services/security/lockout"
+	"autumnomous-jobs-applicant-api/shared/testhelper"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LockoutRepository_RecordLockoutEvent(t *testing.T) {
+	assert := assert.New(t)
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	repository := accountmanagement.NewLockoutRepository(database.DB)
+
+	err := repository.RecordLockoutEvent(accountmanagement.AccountLocked, applicant.Email, "10.0.0.1")
+	assert.Nil(err)
+
+	var count int
+	err = database.DB.QueryRow(`SELECT COUNT(*) FROM lockoutevents WHERE email=$1 AND event=$2;`, applicant.Email, accountmanagement.AccountLocked).Scan(&count)
+
+	assert.Nil(err)
+	assert.Equal(1, count)
+
+	err = repository.RecordLockoutEvent("", applicant.Email, "")
+	assert.NotNil(err)
+}
+
+func Test_PostgresLoginAttemptStore(t *testing.T) {
+	assert := assert.New(t)
+
+	store := lockout.NewPostgresStore(database.DB)
+	key := "email:" + uuid.NewString()
+	now := time.Now().Truncate(time.Second)
+
+	counter, err := store.Get(key)
+	assert.Nil(err)
+	assert.Equal(0, counter.Failures)
+
+	store.RecordFailure(key, now, time.Minute)
+	counter, err = store.RecordFailure(key, now.Add(time.Second), time.Minute)
+
+	assert.Nil(err)
+	assert.Equal(2, counter.Failures)
+	assert.True(counter.WindowStart.Equal(now))
+
+	// a failure after the window starts a new count
+	counter, err = store.RecordFailure(key, now.Add(2*time.Minute), time.Minute)
+
+	assert.Nil(err)
+	assert.Equal(1, counter.Failures)
+
+	assert.Nil(store.Lock(key, now.Add(time.Hour)))
+
+	counter, err = store.Get(key)
+	assert.Nil(err)
+	assert.True(counter.LockedUntil.Equal(now.Add(time.Hour)))
+
+	assert.Nil(store.Reset(key))
+
+	counter, err = store.Get(key)
+	assert.Nil(err)
+	assert.Equal(0, counter.Failures)
+}
@@ -0,0 +1,213 @@
+package accountmanagement
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+)
+
+// ALTER TABLE applicants ADD COLUMN totpsecret text;
+// ALTER TABLE applicants ADD COLUMN totpenabled boolean NOT NULL DEFAULT false;
+// ALTER TABLE applicants ADD COLUMN totplaststep bigint NOT NULL DEFAULT 0;
+//
+// CREATE TABLE recoverycodes (
+//     id SERIAL PRIMARY KEY,
+//     applicantid integer NOT NULL REFERENCES applicants(id) ON DELETE CASCADE ON UPDATE CASCADE,
+//     codehash text NOT NULL,
+//     usedat timestamp with time zone,
+//     createdate timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP
+// );
+// CREATE INDEX recoverycodes_applicantid_idx ON recoverycodes(applicantid);
+
+// TOTPSettings is an applicant's authenticator enrollment. The secret is stored sealed.
+type TOTPSettings struct {
+	SealedSecret string
+	Enabled      bool
+	LastStep     int64
+}
+
+type MFARepository struct {
+	Database *sql.DB
+}
+
+func NewMFARepository(db *sql.DB) *MFARepository {
+	return &MFARepository{Database: db}
+}
+
+// GetTOTPSettings returns the applicant's enrollment, with an empty secret if they never enrolled
+func (repository *MFARepository) GetTOTPSettings(publicID string) (*TOTPSettings, error) {
+
+	if publicID == "" {
+		return nil, errors.New("missing required value")
+	}
+
+	var settings TOTPSettings
+	var secret sql.NullString
+
+	stmt, err := repository.Database.Prepare(`SELECT totpsecret, totpenabled, totplaststep FROM applicants WHERE publicid=$1;`)
+
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	err = stmt.QueryRow(publicID).Scan(&secret, &settings.Enabled, &settings.LastStep)
+
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	if secret.Valid {
+		settings.SealedSecret = secret.String
+	}
+
+	return &settings, nil
+}
+
+// SetPendingTOTPSecret stores a new secret that isn't used for login until EnableTOTP is called.
+// Applicants who already have two-factor enabled can't replace their secret this way.
+func (repository *MFARepository) SetPendingTOTPSecret(publicID, sealedSecret string) (bool, error) {
+
+	if publicID == "" || sealedSecret == "" {
+		return false, errors.New("missing required value")
+	}
+
+	stmt, err := repository.Database.Prepare(`UPDATE applicants SET totpsecret=$1, totplaststep=0 WHERE publicid=$2 AND totpenabled=false;`)
+
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	result, err := stmt.Exec(sealedSecret, publicID)
+
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	return rows == 1, nil
+}
+
+// EnableTOTP turns on two-factor login and replaces the applicant's recovery codes
+func (repository *MFARepository) EnableTOTP(publicID string, step int64, recoveryCodeHashes []string) error {
+
+	if publicID == "" || len(recoveryCodeHashes) == 0 {
+		return errors.New("missing required value")
+	}
+
+	tx, err := repository.Database.Begin()
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	defer tx.Rollback()
+
+	var applicantID int
+
+	err = tx.QueryRow(`UPDATE applicants SET totpenabled=true, totplaststep=$1 WHERE publicid=$2 AND totpsecret IS NOT NULL RETURNING id;`, step, publicID).Scan(&applicantID)
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	_, err = tx.Exec(`DELETE FROM recoverycodes WHERE applicantid=$1;`, applicantID)
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	for _, codeHash := range recoveryCodeHashes {
+		_, err = tx.Exec(`INSERT INTO recoverycodes(applicantid, codehash) VALUES ($1, $2);`, applicantID, codeHash)
+
+		if err != nil {
+			log.Println(err)
+			return err
+		}
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+// RecordTOTPStep remembers the time step of an accepted code so it can't be replayed.
+// It returns false when a code from this step or a later one was already used.
+func (repository *MFARepository) RecordTOTPStep(publicID string, step int64) (bool, error) {
+
+	stmt, err := repository.Database.Prepare(`UPDATE applicants SET totplaststep=$1 WHERE publicid=$2 AND totplaststep < $1;`)
+
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	result, err := stmt.Exec(step, publicID)
+
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	return rows == 1, nil
+}
+
+// UseRecoveryCode consumes one of the applicant's unused recovery codes
+func (repository *MFARepository) UseRecoveryCode(publicID, codeHash string) (bool, error) {
+
+	if publicID == "" || codeHash == "" {
+		return false, nil
+	}
+
+	stmt, err := repository.Database.Prepare(`
+		UPDATE recoverycodes SET usedat=now()
+		WHERE usedat IS NULL AND id=(
+			SELECT id FROM recoverycodes
+			WHERE applicantid=(SELECT id FROM applicants WHERE publicid=$1) AND codehash=$2 AND usedat IS NULL
+			LIMIT 1);`)
+
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	result, err := stmt.Exec(publicID, codeHash)
+
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	return rows == 1, nil
+}
@@ -0,0 +1,92 @@
+package accountmanagement_test
+
+import (
+	"testing"
+
+	"autumnomous-jobs-applicant-api/shared/database"
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+	"autumnomous-jobs-applicant-api/shared/services/security/encryption"
+	"autumnomous-jobs-applicant-api/shared/testhelper"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MFARepository_GetTOTPSettings_NotEnrolled(t *testing.T) {
+	assert := assert.New(t)
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	repository := accountmanagement.NewMFARepository(database.DB)
+
+	settings, err := repository.GetTOTPSettings(applicant.PublicID)
+
+	assert.Nil(err)
+	assert.False(settings.Enabled)
+	assert.Equal("", settings.SealedSecret)
+}
+
+func Test_MFARepository_EnableTOTP(t *testing.T) {
+	assert := assert.New(t)
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	repository := accountmanagement.NewMFARepository(database.DB)
+
+	stored, err := repository.SetPendingTOTPSecret(applicant.PublicID, "sealed-secret")
+
+	assert.Nil(err)
+	assert.True(stored)
+
+	err = repository.EnableTOTP(applicant.PublicID, 100, []string{encryption.HashToken("code-one"), encryption.HashToken("code-two")})
+	assert.Nil(err)
+
+	settings, err := repository.GetTOTPSettings(applicant.PublicID)
+
+	assert.Nil(err)
+	assert.True(settings.Enabled)
+	assert.Equal("sealed-secret", settings.SealedSecret)
+
+	// an enabled secret can't be swapped out
+	stored, err = repository.SetPendingTOTPSecret(applicant.PublicID, "other-secret")
+
+	assert.Nil(err)
+	assert.False(stored)
+}
+
+func Test_MFARepository_RecordTOTPStep_RejectsReplay(t *testing.T) {
+	assert := assert.New(t)
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	repository := accountmanagement.NewMFARepository(database.DB)
+
+	recorded, err := repository.RecordTOTPStep(applicant.PublicID, 200)
+
+	assert.Nil(err)
+	assert.True(recorded)
+
+	recorded, err = repository.RecordTOTPStep(applicant.PublicID, 200)
+
+	assert.Nil(err)
+	assert.False(recorded)
+}
+
+func Test_MFARepository_UseRecoveryCode_SingleUse(t *testing.T) {
+	assert := assert.New(t)
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	repository := accountmanagement.NewMFARepository(database.DB)
+
+	_, err := repository.SetPendingTOTPSecret(applicant.PublicID, "sealed-secret")
+	assert.Nil(err)
+
+	err = repository.EnableTOTP(applicant.PublicID, 0, []string{encryption.HashToken("code-one")})
+	assert.Nil(err)
+
+	used, err := repository.UseRecoveryCode(applicant.PublicID, encryption.HashToken("code-one"))
+
+	assert.Nil(err)
+	assert.True(used)
+
+	used, err = repository.UseRecoveryCode(applicant.PublicID, encryption.HashToken("code-one"))
+
+	assert.Nil(err)
+	assert.False(used)
+}
@@ -0,0 +1,137 @@
+package accountmanagement
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+)
+
+// CREATE TABLE oidcloginstates (
+//     statehash text PRIMARY KEY,
+//     provider text NOT NULL,
+//     nonce text NOT NULL,
+//     codeverifier text NOT NULL,
+//     expiresat timestamp with time zone NOT NULL,
+//     createdate timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP
+// );
+// CREATE TABLE applicantidentities (
+//     id SERIAL PRIMARY KEY,
+//     applicantid integer NOT NULL REFERENCES applicants(id) ON DELETE CASCADE ON UPDATE CASCADE,
+//     provider text NOT NULL,
+//     subject text NOT NULL,
+//     email text,
+//     lastloginat timestamp with time zone,
+//     createdate timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+//     UNIQUE (provider, subject)
+// );
+
+// OIDCLoginState is what the server keeps between sending an applicant to a provider and the callback
+type OIDCLoginState struct {
+	Provider     string
+	Nonce        string
+	CodeVerifier string
+}
+
+type OIDCRepository struct {
+	Database *sql.DB
+}
+
+func NewOIDCRepository(db *sql.DB) *OIDCRepository {
+	return &OIDCRepository{Database: db}
+}
+
+// CreateLoginState stores the nonce and PKCE verifier for a login attempt under the hash of its state
+func (repository *OIDCRepository) CreateLoginState(stateHash, provider, nonce, codeVerifier string, lifetime time.Duration) error {
+
+	if stateHash == "" || provider == "" || nonce == "" || codeVerifier == "" {
+		return errors.New("missing required value")
+	}
+
+	_, err := repository.Database.Exec(`
+		INSERT INTO oidcloginstates(statehash, provider, nonce, codeverifier, expiresat)
+		VALUES ($1, $2, $3, $4, now() + make_interval(secs => $5));`, stateHash, provider, nonce, codeVerifier, lifetime.Seconds())
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+// ConsumeLoginState deletes and returns the login attempt for stateHash, so each state can only
+// complete one login. It returns nil when the state is unknown or has expired.
+func (repository *OIDCRepository) ConsumeLoginState(stateHash string) (*OIDCLoginState, error) {
+
+	if stateHash == "" {
+		return nil, nil
+	}
+
+	var state OIDCLoginState
+	var expiresAt time.Time
+
+	err := repository.Database.QueryRow(`
+		DELETE FROM oidcloginstates WHERE statehash=$1
+		RETURNING provider, nonce, codeverifier, expiresat;`, stateHash).Scan(&state.Provider, &state.Nonce, &state.CodeVerifier, &expiresAt)
+
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			return nil, nil
+		}
+		log.Println(err)
+		return nil, err
+	}
+
+	if time.Now().After(expiresAt) {
+		return nil, nil
+	}
+
+	return &state, nil
+}
+
+// GetIdentityApplicant returns the public id of the applicant linked to the provider's subject,
+// or an empty string when the identity hasn't been linked
+func (repository *OIDCRepository) GetIdentityApplicant(provider, subject string) (string, error) {
+
+	if provider == "" || subject == "" {
+		return "", errors.New("missing required value")
+	}
+
+	var publicID string
+
+	err := repository.Database.QueryRow(`
+		UPDATE applicantidentities SET lastloginat=now()
+		FROM applicants
+		WHERE applicants.id=applicantidentities.applicantid AND applicantidentities.provider=$1 AND applicantidentities.subject=$2
+		RETURNING applicants.publicid;`, provider, subject).Scan(&publicID)
+
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			return "", nil
+		}
+		log.Println(err)
+		return "", err
+	}
+
+	return publicID, nil
+}
+
+// LinkIdentity lets the applicant sign in as the provider's subject from now on
+func (repository *OIDCRepository) LinkIdentity(publicID, provider, subject, email string) error {
+
+	if publicID == "" || provider == "" || subject == "" {
+		return errors.New("missing required value")
+	}
+
+	_, err := repository.Database.Exec(`
+		INSERT INTO applicantidentities(applicantid, provider, subject, email, lastloginat)
+		VALUES ((SELECT id FROM applicants WHERE publicid=$1), $2, $3, $4, now());`, publicID, provider, subject, email)
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
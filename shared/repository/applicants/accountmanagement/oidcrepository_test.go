@@ -0,0 +1,81 @@
+package accountmanagement_test
+
+import (
+	"testing"
+	"time"
+
+	"autumnomous-jobs-applicant-api/shared/database"
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+	"autumnomous-jobs-applicant-api/shared/services/security/encryption"
+	"autumnomous-jobs-applicant-api/shared/testhelper"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_OIDCRepository_LoginState(t *testing.T) {
+	assert := assert.New(t)
+
+	repository := accountmanagement.NewOIDCRepository(database.DB)
+
+	state, _ := encryption.GenerateToken(32)
+
+	err := repository.CreateLoginState(encryption.HashToken(state), "google", "nonce", "verifier", time.Minute)
+	assert.Nil(err)
+
+	loginState, err := repository.ConsumeLoginState(encryption.HashToken(state))
+	assert.Nil(err)
+
+	if assert.NotNil(loginState) {
+		assert.Equal("google", loginState.Provider)
+		assert.Equal("nonce", loginState.Nonce)
+		assert.Equal("verifier", loginState.CodeVerifier)
+	}
+
+	// each state completes one login
+	loginState, err = repository.ConsumeLoginState(encryption.HashToken(state))
+	assert.Nil(err)
+	assert.Nil(loginState)
+}
+
+func Test_OIDCRepository_LoginState_Expired(t *testing.T) {
+	assert := assert.New(t)
+
+	repository := accountmanagement.NewOIDCRepository(database.DB)
+
+	state, _ := encryption.GenerateToken(32)
+
+	err := repository.CreateLoginState(encryption.HashToken(state), "google", "nonce", "verifier", -time.Minute)
+	assert.Nil(err)
+
+	loginState, err := repository.ConsumeLoginState(encryption.HashToken(state))
+	assert.Nil(err)
+	assert.Nil(loginState)
+}
+
+func Test_OIDCRepository_LinkIdentity(t *testing.T) {
+	assert := assert.New(t)
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	repository := accountmanagement.NewOIDCRepository(database.DB)
+
+	subject, _ := encryption.GenerateToken(16)
+
+	publicID, err := repository.GetIdentityApplicant("google", subject)
+	assert.Nil(err)
+	assert.Equal("", publicID)
+
+	err = repository.LinkIdentity(applicant.PublicID, "google", subject, applicant.Email)
+	assert.Nil(err)
+
+	publicID, err = repository.GetIdentityApplicant("google", subject)
+	assert.Nil(err)
+	assert.Equal(applicant.PublicID, publicID)
+
+	// the same subject at another provider is a different identity
+	publicID, err = repository.GetIdentityApplicant("microsoft", subject)
+	assert.Nil(err)
+	assert.Equal("", publicID)
+
+	err = repository.LinkIdentity(applicant.PublicID, "google", subject, applicant.Email)
+	assert.NotNil(err)
+}
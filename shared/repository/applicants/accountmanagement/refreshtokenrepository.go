@@ -0,0 +1,182 @@
+package accountmanagement
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CREATE TABLE refreshtokens (
+//     id SERIAL PRIMARY KEY,
+//     applicantid integer NOT NULL REFERENCES applicants(id) ON DELETE CASCADE ON UPDATE CASCADE,
+//     familyid uuid NOT NULL,
+//     tokenhash text NOT NULL UNIQUE,
+//     expiresat timestamp with time zone NOT NULL,
+//     usedat timestamp with time zone,
+//     revokedat timestamp with time zone,
+//     createdate timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP
+// );
+// CREATE INDEX refreshtokens_familyid_idx ON refreshtokens(familyid);
+
+// RefreshTokenRepository stores hashed refresh tokens. Every token issued from one login
+// shares a family id so the whole chain can be revoked if an old token is replayed.
+type RefreshTokenRepository struct {
+	Database *sql.DB
+}
+
+func NewRefreshTokenRepository(db *sql.DB) *RefreshTokenRepository {
+	return &RefreshTokenRepository{Database: db}
+}
+
+// CreateRefreshToken starts a new token family for the applicant and returns the family id
+func (repository *RefreshTokenRepository) CreateRefreshToken(publicID, tokenHash string, lifetime time.Duration) (string, error) {
+
+	if publicID == "" || tokenHash == "" {
+		return "", errors.New("missing required value")
+	}
+
+	familyID := uuid.NewString()
+
+	stmt, err := repository.Database.Prepare(`
+		INSERT INTO refreshtokens(applicantid, familyid, tokenhash, expiresat)
+		VALUES ((SELECT id FROM applicants WHERE publicid=$1), $2, $3, now() + make_interval(secs => $4));`)
+
+	if err != nil {
+		log.Println(err)
+		return "", err
+	}
+
+	_, err = stmt.Exec(publicID, familyID, tokenHash, lifetime.Seconds())
+
+	if err != nil {
+		log.Println(err)
+		return "", err
+	}
+
+	return familyID, nil
+}
+
+// RotateRefreshToken exchanges a refresh token for a new one in the same family and returns the
+// owning applicant's public id and the family id. Presenting a token that was already rotated is
+// treated as theft: the whole family and its session are revoked and reused is true. An empty
+// public id means the token was rejected.
+func (repository *RefreshTokenRepository) RotateRefreshToken(tokenHash, newTokenHash string, lifetime time.Duration) (publicID, familyID string, reused bool, err error) {
+
+	if tokenHash == "" || newTokenHash == "" {
+		return "", "", false, nil
+	}
+
+	tx, err := repository.Database.Begin()
+
+	if err != nil {
+		log.Println(err)
+		return "", "", false, err
+	}
+
+	defer tx.Rollback()
+
+	var applicantID int
+	var expired bool
+	var usedAt, revokedAt sql.NullTime
+
+	err = tx.QueryRow(`
+		SELECT refreshtokens.applicantid, applicants.publicid, refreshtokens.familyid,
+			refreshtokens.expiresat <= now(), refreshtokens.usedat, refreshtokens.revokedat
+		FROM refreshtokens
+		JOIN applicants ON applicants.id=refreshtokens.applicantid
+		WHERE refreshtokens.tokenhash=$1
+		FOR UPDATE OF refreshtokens;`, tokenHash).Scan(&applicantID, &publicID, &familyID, &expired, &usedAt, &revokedAt)
+
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			return "", "", false, nil
+		}
+		log.Println(err)
+		return "", "", false, err
+	}
+
+	if usedAt.Valid {
+		_, err = tx.Exec(`UPDATE refreshtokens SET revokedat=now() WHERE familyid=$1 AND revokedat IS NULL;`, familyID)
+
+		if err != nil {
+			log.Println(err)
+			return "", "", false, err
+		}
+
+		_, err = tx.Exec(`UPDATE applicantsessions SET revokedat=now() WHERE publicid=$1 AND revokedat IS NULL;`, familyID)
+
+		if err != nil {
+			log.Println(err)
+			return "", "", false, err
+		}
+
+		err = tx.Commit()
+
+		if err != nil {
+			log.Println(err)
+			return "", "", false, err
+		}
+
+		return "", "", true, nil
+	}
+
+	if revokedAt.Valid || expired {
+		return "", "", false, nil
+	}
+
+	_, err = tx.Exec(`UPDATE refreshtokens SET usedat=now() WHERE tokenhash=$1;`, tokenHash)
+
+	if err != nil {
+		log.Println(err)
+		return "", "", false, err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO refreshtokens(applicantid, familyid, tokenhash, expiresat)
+		VALUES ($1, $2, $3, now() + make_interval(secs => $4));`, applicantID, familyID, newTokenHash, lifetime.Seconds())
+
+	if err != nil {
+		log.Println(err)
+		return "", "", false, err
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		log.Println(err)
+		return "", "", false, err
+	}
+
+	return publicID, familyID, false, nil
+}
+
+// RevokeRefreshTokenFamily revokes the family the applicant's refresh token belongs to
+func (repository *RefreshTokenRepository) RevokeRefreshTokenFamily(publicID, tokenHash string) error {
+
+	if publicID == "" || tokenHash == "" {
+		return errors.New("missing required value")
+	}
+
+	stmt, err := repository.Database.Prepare(`
+		UPDATE refreshtokens SET revokedat=now()
+		WHERE revokedat IS NULL AND familyid=(
+			SELECT familyid FROM refreshtokens
+			WHERE tokenhash=$1 AND applicantid=(SELECT id FROM applicants WHERE publicid=$2));`)
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	_, err = stmt.Exec(tokenHash, publicID)
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package accountmanagement_test
+
+import (
+	"testing"
+
+	"autumnomous-jobs-applicant-api/shared/database"
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+	"autumnomous-jobs-applicant-api/shared/services/security/encryption"
+	"autumnomous-jobs-applicant-api/shared/services/security/jwt"
+	"autumnomous-jobs-applicant-api/shared/testhelper"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RefreshTokenRepository_CreateRefreshToken_Fail_EmptyData(t *testing.T) {
+	assert := assert.New(t)
+
+	repository := accountmanagement.NewRefreshTokenRepository(database.DB)
+
+	familyID, err := repository.CreateRefreshToken("", encryption.HashToken("token"), jwt.RefreshTokenLifetime)
+
+	assert.NotNil(err)
+	assert.Equal("", familyID)
+}
+
+func Test_RefreshTokenRepository_RotateRefreshToken_Correct(t *testing.T) {
+	assert := assert.New(t)
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	repository := accountmanagement.NewRefreshTokenRepository(database.DB)
+
+	first, _ := jwt.GenerateRefreshToken()
+	second, _ := jwt.GenerateRefreshToken()
+	third, _ := jwt.GenerateRefreshToken()
+
+	_, err := repository.CreateRefreshToken(applicant.PublicID, encryption.HashToken(first), jwt.RefreshTokenLifetime)
+	assert.Nil(err)
+
+	publicID, _, reused, err := repository.RotateRefreshToken(encryption.HashToken(first), encryption.HashToken(second), jwt.RefreshTokenLifetime)
+
+	assert.Nil(err)
+	assert.False(reused)
+	assert.Equal(applicant.PublicID, publicID)
+
+	publicID, _, reused, err = repository.RotateRefreshToken(encryption.HashToken(second), encryption.HashToken(third), jwt.RefreshTokenLifetime)
+
+	assert.Nil(err)
+	assert.False(reused)
+	assert.Equal(applicant.PublicID, publicID)
+}
+
+func Test_RefreshTokenRepository_RotateRefreshToken_ReuseRevokesFamily(t *testing.T) {
+	assert := assert.New(t)
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	repository := accountmanagement.NewRefreshTokenRepository(database.DB)
+
+	first, _ := jwt.GenerateRefreshToken()
+	second, _ := jwt.GenerateRefreshToken()
+	replayed, _ := jwt.GenerateRefreshToken()
+	third, _ := jwt.GenerateRefreshToken()
+
+	_, err := repository.CreateRefreshToken(applicant.PublicID, encryption.HashToken(first), jwt.RefreshTokenLifetime)
+	assert.Nil(err)
+
+	_, _, _, err = repository.RotateRefreshToken(encryption.HashToken(first), encryption.HashToken(second), jwt.RefreshTokenLifetime)
+	assert.Nil(err)
+
+	// replaying the rotated token revokes the family
+	publicID, _, reused, err := repository.RotateRefreshToken(encryption.HashToken(first), encryption.HashToken(replayed), jwt.RefreshTokenLifetime)
+
+	assert.Nil(err)
+	assert.True(reused)
+	assert.Equal("", publicID)
+
+	// so the legitimate latest token no longer works either
+	publicID, _, reused, err = repository.RotateRefreshToken(encryption.HashToken(second), encryption.HashToken(third), jwt.RefreshTokenLifetime)
+
+	assert.Nil(err)
+	assert.False(reused)
+	assert.Equal("", publicID)
+}
+
+func Test_RefreshTokenRepository_RotateRefreshToken_Expired(t *testing.T) {
+	assert := assert.New(t)
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	repository := accountmanagement.NewRefreshTokenRepository(database.DB)
+
+	first, _ := jwt.GenerateRefreshToken()
+	second, _ := jwt.GenerateRefreshToken()
+
+	_, err := repository.CreateRefreshToken(applicant.PublicID, encryption.HashToken(first), -jwt.RefreshTokenLifetime)
+	assert.Nil(err)
+
+	publicID, _, reused, err := repository.RotateRefreshToken(encryption.HashToken(first), encryption.HashToken(second), jwt.RefreshTokenLifetime)
+
+	assert.Nil(err)
+	assert.False(reused)
+	assert.Equal("", publicID)
+}
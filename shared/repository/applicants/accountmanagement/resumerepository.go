@@ -0,0 +1,266 @@
+package accountmanagement
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CREATE TABLE resumes (
+//     id SERIAL PRIMARY KEY,
+//     publicid uuid NOT NULL UNIQUE,
+//     applicantid integer NOT NULL REFERENCES applicants(id) ON DELETE CASCADE,
+//     name text NOT NULL,
+//     version integer NOT NULL,
+//     objectkey text NOT NULL UNIQUE REFERENCES applicantuploads(objectkey) ON DELETE CASCADE,
+//     filename text NOT NULL,
+//     contenttype text NOT NULL,
+//     size bigint NOT NULL,
+//     extractedtext text NOT NULL DEFAULT '',
+//     createdate timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+//     UNIQUE (applicantid, name, version)
+// );
+
+// DefaultResumeName names a resume uploaded without a name
+const DefaultResumeName = "Resume"
+
+// MaxResumeVersions caps how many versions of each resume an applicant keeps; uploading another
+// removes the oldest
+const MaxResumeVersions = 10
+
+// MaxResumeNames caps how many differently named resumes an applicant can keep
+const MaxResumeNames = 10
+
+// Resume is one version of one of the applicant's resumes, which are told apart by name, such as
+// "Engineering" and "Management". Its file is stored privately and its text is kept for filling
+// in their profile.
+type Resume struct {
+	PublicID    string    `json:"id"`
+	Name        string    `json:"name"`
+	Version     int       `json:"version"`
+	FileName    string    `json:"filename"`
+	ContentType string    `json:"contenttype"`
+	Size        int64     `json:"size"`
+	CreateDate  time.Time `json:"createdate"`
+	ObjectKey   string    `json:"-"`
+	Text        string    `json:"-"`
+}
+
+// ValidateName trims the resume's name, defaulting it, and returns what's wrong with it
+func (resume *Resume) ValidateName() []FieldViolation {
+
+	var v validator
+
+	if strings.TrimSpace(resume.Name) == "" {
+		resume.Name = DefaultResumeName
+	}
+
+	v.text("name", &resume.Name, true, MaxShortTextLength)
+
+	return v.violations
+}
+
+type ResumeRepository struct {
+	Database *sql.DB
+}
+
+func NewResumeRepository(db *sql.DB) *ResumeRepository {
+	return &ResumeRepository{Database: db}
+}
+
+// CreateResume records a new version of the applicant's resume named resume.Name, stored as
+// resume.ObjectKey, and sets its id, version and create date. The file is recorded as one of the
+// applicant's uploads too. It returns ErrTooManyEntries if the name is new and the applicant
+// already has as many resumes as they can keep.
+func (repository *ResumeRepository) CreateResume(publicID string, resume *Resume) error {
+
+	if publicID == "" || resume == nil || resume.ObjectKey == "" || resume.Name == "" {
+		return errors.New("missing required value")
+	}
+
+	tx, err := repository.Database.Begin()
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	defer tx.Rollback()
+
+	var applicantID int
+
+	// locking the applicant numbers their versions one at a time
+	err = tx.QueryRow(`SELECT id FROM applicants WHERE publicid=$1 FOR UPDATE;`, publicID).Scan(&applicantID)
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	var names int
+	var exists bool
+
+	err = tx.QueryRow(`
+		SELECT count(DISTINCT name), coalesce(bool_or(name=$2), false)
+		FROM resumes
+		WHERE applicantid=$1;`, applicantID, resume.Name).Scan(&names, &exists)
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	if !exists && names >= MaxResumeNames {
+		return ErrTooManyEntries
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO applicantuploads(applicantid, objectkey, filename, contenttype, size)
+		VALUES ($1, $2, $3, $4, $5);`, applicantID, resume.ObjectKey, resume.FileName, resume.ContentType, resume.Size)
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	resume.PublicID = uuid.NewString()
+
+	err = tx.QueryRow(`
+		INSERT INTO resumes(publicid, applicantid, name, version, objectkey, filename, contenttype, size, extractedtext)
+		SELECT $1, $2, $3, coalesce(max(version), 0) + 1, $4, $5, $6, $7, $8
+		FROM resumes
+		WHERE applicantid=$2 AND name=$3
+		RETURNING version, createdate;`,
+		resume.PublicID, applicantID, resume.Name, resume.ObjectKey, resume.FileName, resume.ContentType, resume.Size, resume.Text).Scan(&resume.Version, &resume.CreateDate)
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+// GetResumes lists the versions of the applicant's resumes by name, newest first, without their text
+func (repository *ResumeRepository) GetResumes(publicID string) ([]*Resume, error) {
+
+	rows, err := repository.Database.Query(`
+		SELECT publicid, name, version, filename, contenttype, size, createdate, objectkey
+		FROM resumes
+		WHERE applicantid=(SELECT id FROM applicants WHERE publicid=$1)
+		ORDER BY name, version DESC;`, publicID)
+
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	resumes := []*Resume{}
+
+	for rows.Next() {
+		var resume Resume
+
+		err = rows.Scan(&resume.PublicID, &resume.Name, &resume.Version, &resume.FileName, &resume.ContentType, &resume.Size, &resume.CreateDate, &resume.ObjectKey)
+
+		if err != nil {
+			log.Println(err)
+			return nil, err
+		}
+
+		resumes = append(resumes, &resume)
+	}
+
+	return resumes, rows.Err()
+}
+
+// GetResume returns a version of the applicant's resume with its text, or nil if they have no such version
+func (repository *ResumeRepository) GetResume(publicID, resumeID string) (*Resume, error) {
+
+	if _, err := uuid.Parse(resumeID); err != nil {
+		return nil, nil
+	}
+
+	var resume Resume
+
+	err := repository.Database.QueryRow(`
+		SELECT publicid, name, version, filename, contenttype, size, createdate, objectkey, extractedtext
+		FROM resumes
+		WHERE publicid=$1 AND applicantid=(SELECT id FROM applicants WHERE publicid=$2);`, resumeID, publicID).Scan(
+		&resume.PublicID, &resume.Name, &resume.Version, &resume.FileName, &resume.ContentType, &resume.Size, &resume.CreateDate, &resume.ObjectKey, &resume.Text)
+
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			return nil, nil
+		}
+		log.Println(err)
+		return nil, err
+	}
+
+	return &resume, nil
+}
+
+// GetLatestResume returns the newest version of the applicant's resume with the given name, or nil
+// if they have no resume by that name
+func (repository *ResumeRepository) GetLatestResume(publicID, name string) (*Resume, error) {
+
+	var resume Resume
+
+	err := repository.Database.QueryRow(`
+		SELECT publicid, name, version, filename, contenttype, size, createdate, objectkey
+		FROM resumes
+		WHERE name=$2 AND applicantid=(SELECT id FROM applicants WHERE publicid=$1)
+		ORDER BY version DESC
+		LIMIT 1;`, publicID, name).Scan(
+		&resume.PublicID, &resume.Name, &resume.Version, &resume.FileName, &resume.ContentType, &resume.Size, &resume.CreateDate, &resume.ObjectKey)
+
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			return nil, nil
+		}
+		log.Println(err)
+		return nil, err
+	}
+
+	return &resume, nil
+}
+
+// DeleteResume removes a version of the applicant's resume along with its upload record, and
+// returns the key of its file so that can be deleted too. It returns "" if they have no such version.
+func (repository *ResumeRepository) DeleteResume(publicID, resumeID string) (string, error) {
+
+	if _, err := uuid.Parse(resumeID); err != nil {
+		return "", nil
+	}
+
+	var objectKey string
+
+	err := repository.Database.QueryRow(`
+		DELETE FROM applicantuploads
+		USING resumes
+		WHERE resumes.objectkey=applicantuploads.objectkey AND resumes.publicid=$1
+			AND resumes.applicantid=(SELECT id FROM applicants WHERE publicid=$2)
+		RETURNING applicantuploads.objectkey;`, resumeID, publicID).Scan(&objectKey)
+
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			return "", nil
+		}
+		log.Println(err)
+		return "", err
+	}
+
+	return objectKey, nil
+}
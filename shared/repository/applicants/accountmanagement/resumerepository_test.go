@@ -0,0 +1,103 @@
+package accountmanagement_test
+
+import (
+	"testing"
+
+	"autumnomous-jobs-applicant-api/shared/database"
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+	"autumnomous-jobs-applicant-api/shared/testhelper"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestResume(name string) *accountmanagement.Resume {
+	return &accountmanagement.Resume{
+		Name:        name,
+		ObjectKey:   "resumes/test/" + uuid.NewString() + ".pdf",
+		FileName:    "resume.pdf",
+		ContentType: "application/pdf",
+		Size:        1024,
+		Text:        "Engineer at Acme 2019 - Present",
+	}
+}
+
+func Test_ResumeRepository_Versions(t *testing.T) {
+	assert := assert.New(t)
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	other := testhelper.Helper_RandomApplicant(t)
+	repository := accountmanagement.NewResumeRepository(database.DB)
+
+	first := newTestResume("Engineering")
+	second := newTestResume("Engineering")
+
+	assert.Nil(repository.CreateResume(applicant.PublicID, first))
+	assert.Nil(repository.CreateResume(applicant.PublicID, second))
+	assert.Equal(1, first.Version)
+	assert.Equal(2, second.Version)
+
+	resumes, err := repository.GetResumes(applicant.PublicID)
+	assert.Nil(err)
+
+	// the newest version comes first, without its text
+	if assert.Len(resumes, 2) {
+		assert.Equal(second.PublicID, resumes[0].PublicID)
+		assert.Equal(first.PublicID, resumes[1].PublicID)
+		assert.Empty(resumes[0].Text)
+	}
+
+	resume, err := repository.GetResume(other.PublicID, first.PublicID)
+	assert.Nil(err)
+	assert.Nil(resume)
+
+	resume, err = repository.GetResume(applicant.PublicID, first.PublicID)
+	assert.Nil(err)
+
+	if assert.NotNil(resume) {
+		assert.Equal(first.Text, resume.Text)
+		assert.Equal(first.ObjectKey, resume.ObjectKey)
+	}
+
+	// both files are among the applicant's uploads
+	uploads, err := accountmanagement.NewUploadRepository(database.DB).GetUploads(applicant.PublicID)
+	assert.Nil(err)
+	assert.Len(uploads, 2)
+
+	objectKey, err := repository.DeleteResume(other.PublicID, first.PublicID)
+	assert.Nil(err)
+	assert.Empty(objectKey)
+
+	objectKey, err = repository.DeleteResume(applicant.PublicID, first.PublicID)
+	assert.Nil(err)
+	assert.Equal(first.ObjectKey, objectKey)
+
+	uploads, err = accountmanagement.NewUploadRepository(database.DB).GetUploads(applicant.PublicID)
+	assert.Nil(err)
+	assert.Len(uploads, 1)
+
+	// versions keep counting up after one is deleted
+	third := newTestResume("Engineering")
+	assert.Nil(repository.CreateResume(applicant.PublicID, third))
+	assert.Equal(3, third.Version)
+
+	// each name has its own versions
+	management := newTestResume("Management")
+	assert.Nil(repository.CreateResume(applicant.PublicID, management))
+	assert.Equal(1, management.Version)
+
+	latest, err := repository.GetLatestResume(applicant.PublicID, "Engineering")
+	assert.Nil(err)
+
+	if assert.NotNil(latest) {
+		assert.Equal(third.PublicID, latest.PublicID)
+	}
+
+	latest, err = repository.GetLatestResume(applicant.PublicID, "Sales")
+	assert.Nil(err)
+	assert.Nil(latest)
+
+	objectKey, err = repository.DeleteResume(applicant.PublicID, "not-an-id")
+	assert.Nil(err)
+	assert.Empty(objectKey)
+}
@@ -0,0 +1,145 @@
+package accountmanagement
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+)
+
+// CREATE TABLE revokedtokens (
+//     jti text PRIMARY KEY,
+//     applicantid integer NOT NULL REFERENCES applicants(id) ON DELETE CASCADE ON UPDATE CASCADE,
+//     expiresat timestamp with time zone NOT NULL,
+//     createdate timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP
+// );
+// ALTER TABLE applicants ADD COLUMN tokensrevokedat timestamp with time zone;
+
+// RevocationRepository tracks access tokens that were logged out before they expired.
+// Single tokens are revoked by jti; logging out everywhere revokes every token issued before that moment.
+type RevocationRepository struct {
+	Database *sql.DB
+}
+
+func NewRevocationRepository(db *sql.DB) *RevocationRepository {
+	return &RevocationRepository{Database: db}
+}
+
+// RevokeToken revokes a single access token until it would have expired anyway
+func (repository *RevocationRepository) RevokeToken(publicID, jti string, expiresAt time.Time) error {
+
+	if publicID == "" || jti == "" {
+		return errors.New("missing required value")
+	}
+
+	stmt, err := repository.Database.Prepare(`
+		INSERT INTO revokedtokens(jti, applicantid, expiresat)
+		VALUES ($1, (SELECT id FROM applicants WHERE publicid=$2), $3)
+		ON CONFLICT (jti) DO NOTHING;`)
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	_, err = stmt.Exec(jti, publicID, expiresAt)
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	// expired tokens are rejected on their own so their revocations can go
+	_, err = repository.Database.Exec(`DELETE FROM revokedtokens WHERE expiresat < now();`)
+
+	if err != nil {
+		log.Println(err)
+	}
+
+	return nil
+}
+
+// RevokeAllTokens logs the applicant out everywhere: every access token issued so far is
+// rejected and every refresh token family and session is revoked
+func (repository *RevocationRepository) RevokeAllTokens(publicID string) error {
+
+	if publicID == "" {
+		return errors.New("missing required value")
+	}
+
+	tx, err := repository.Database.Begin()
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`UPDATE applicants SET tokensrevokedat=now() WHERE publicid=$1;`, publicID)
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE refreshtokens SET revokedat=now()
+		WHERE applicantid=(SELECT id FROM applicants WHERE publicid=$1) AND revokedat IS NULL;`, publicID)
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE applicantsessions SET revokedat=now()
+		WHERE applicantid=(SELECT id FROM applicants WHERE publicid=$1) AND revokedat IS NULL;`, publicID)
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+// IsTokenRevoked reports whether an access token was logged out, either on its own or by a
+// log out everywhere after it was issued. issuedAt should be to the millisecond: a token issued
+// in the same millisecond as a log out everywhere is treated as revoked.
+func (repository *RevocationRepository) IsTokenRevoked(publicID, jti string, issuedAt time.Time) (bool, error) {
+
+	if publicID == "" {
+		return true, errors.New("missing required value")
+	}
+
+	var revoked bool
+
+	stmt, err := repository.Database.Prepare(`
+		SELECT
+			EXISTS (SELECT 1 FROM revokedtokens WHERE jti=$2)
+			OR COALESCE(applicants.tokensrevokedat >= $3, false)
+		FROM applicants
+		WHERE publicid=$1;`)
+
+	if err != nil {
+		log.Println(err)
+		return true, err
+	}
+
+	err = stmt.QueryRow(publicID, jti, issuedAt).Scan(&revoked)
+
+	if err != nil {
+		log.Println(err)
+		return true, err
+	}
+
+	return revoked, nil
+}
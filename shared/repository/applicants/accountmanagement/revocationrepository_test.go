@@ -0,0 +1,97 @@
+package accountmanagement_test
+
+import (
+	"testing"
+	"time"
+
+	"autumnomous-jobs-applicant-api/shared/database"
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+	"autumnomous-jobs-applicant-api/shared/services/security/encryption"
+	"autumnomous-jobs-applicant-api/shared/testhelper"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RevocationRepository_RevokeToken(t *testing.T) {
+	assert := assert.New(t)
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	repository := accountmanagement.NewRevocationRepository(database.DB)
+
+	jti := uuid.NewString()
+	issuedAt := time.Now()
+
+	revoked, err := repository.IsTokenRevoked(applicant.PublicID, jti, issuedAt)
+
+	assert.Nil(err)
+	assert.False(revoked)
+
+	err = repository.RevokeToken(applicant.PublicID, jti, time.Now().Add(time.Minute))
+	assert.Nil(err)
+
+	revoked, err = repository.IsTokenRevoked(applicant.PublicID, jti, issuedAt)
+
+	assert.Nil(err)
+	assert.True(revoked)
+}
+
+func Test_RevocationRepository_RevokeAllTokens(t *testing.T) {
+	assert := assert.New(t)
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	repository := accountmanagement.NewRevocationRepository(database.DB)
+
+	issuedAt := time.Now().Add(-time.Minute)
+
+	err := repository.RevokeAllTokens(applicant.PublicID)
+	assert.Nil(err)
+
+	revoked, err := repository.IsTokenRevoked(applicant.PublicID, uuid.NewString(), issuedAt)
+
+	assert.Nil(err)
+	assert.True(revoked)
+
+	// tokens issued afterwards are still accepted
+	revoked, err = repository.IsTokenRevoked(applicant.PublicID, uuid.NewString(), time.Now().Add(time.Minute))
+
+	assert.Nil(err)
+	assert.False(revoked)
+}
+
+func Test_RevocationRepository_RevokeAllTokens_SameSecond(t *testing.T) {
+	assert := assert.New(t)
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	repository := accountmanagement.NewRevocationRepository(database.DB)
+
+	// a token issued moments before, within the same second, is still revoked
+	issuedAt := time.Now().Truncate(time.Millisecond)
+
+	err := repository.RevokeAllTokens(applicant.PublicID)
+	assert.Nil(err)
+
+	revoked, err := repository.IsTokenRevoked(applicant.PublicID, uuid.NewString(), issuedAt)
+
+	assert.Nil(err)
+	assert.True(revoked)
+}
+
+func Test_ApplicantRepository_UpdateApplicantPassword_RevokesTokens(t *testing.T) {
+	assert := assert.New(t)
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	issuedAt := time.Now().Add(-time.Minute)
+
+	repository := accountmanagement.NewApplicantRepository(database.DB)
+
+	updated, err := repository.UpdateApplicantPassword(applicant.PublicID, applicant.Password, string(encryption.GeneratePassword(12)))
+
+	assert.True(updated)
+	assert.Nil(err)
+
+	revoked, err := accountmanagement.NewRevocationRepository(database.DB).IsTokenRevoked(applicant.PublicID, uuid.NewString(), issuedAt)
+
+	assert.Nil(err)
+	assert.True(revoked)
+}
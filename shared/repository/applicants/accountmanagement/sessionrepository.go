@@ -0,0 +1,212 @@
+package accountmanagement
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+)
+
+// CREATE TABLE applicantsessions (
+//     id SERIAL PRIMARY KEY,
+//     publicid uuid NOT NULL UNIQUE,
+//     applicantid integer NOT NULL REFERENCES applicants(id) ON DELETE CASCADE ON UPDATE CASCADE,
+//     authmethod text NOT NULL,
+//     useragent text,
+//     ipaddress text,
+//     lastseenat timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+//     lastseenip text,
+//     revokedat timestamp with time zone,
+//     createdate timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP
+// );
+// CREATE INDEX applicantsessions_applicantid_idx ON applicantsessions(applicantid);
+
+// lastSeenInterval limits how often a session's last-seen time is written
+const lastSeenInterval = time.Minute
+
+// maxUserAgentLength stops a client filling the table with an enormous user agent
+const maxUserAgentLength = 512
+
+// Session is one signed in device. Its public id is the family id of the refresh tokens issued
+// to it, so the session lasts as long as the device keeps refreshing.
+type Session struct {
+	PublicID   string    `json:"id"`
+	AuthMethod string    `json:"authmethod"`
+	UserAgent  string    `json:"useragent"`
+	IPAddress  string    `json:"ipaddress"`
+	LastSeenIP string    `json:"lastseenip"`
+	CreateDate time.Time `json:"createdate"`
+	LastSeenAt time.Time `json:"lastseenat"`
+}
+
+type SessionRepository struct {
+	Database *sql.DB
+}
+
+func NewSessionRepository(db *sql.DB) *SessionRepository {
+	return &SessionRepository{Database: db}
+}
+
+// CreateSession records a login from the device with the given user agent and address
+func (repository *SessionRepository) CreateSession(publicID, sessionID, authMethod, userAgent, ipAddress string) error {
+
+	if publicID == "" || sessionID == "" || authMethod == "" {
+		return errors.New("missing required value")
+	}
+
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+
+	_, err := repository.Database.Exec(`
+		INSERT INTO applicantsessions(publicid, applicantid, authmethod, useragent, ipaddress, lastseenip)
+		VALUES ($1, (SELECT id FROM applicants WHERE publicid=$2), $3, $4, $5, $5);`, sessionID, publicID, authMethod, userAgent, ipAddress)
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+// TouchSession reports whether the applicant's session is still active and, at most once a
+// minute, records that it was just used from ipAddress
+func (repository *SessionRepository) TouchSession(publicID, sessionID, ipAddress string) (bool, error) {
+
+	if publicID == "" || sessionID == "" {
+		return false, nil
+	}
+
+	var revoked, stale bool
+
+	err := repository.Database.QueryRow(`
+		SELECT applicantsessions.revokedat IS NOT NULL, applicantsessions.lastseenat < now() - make_interval(secs => $3)
+		FROM applicantsessions
+		JOIN applicants ON applicants.id=applicantsessions.applicantid
+		WHERE applicantsessions.publicid::text=$1 AND applicants.publicid=$2;`, sessionID, publicID, lastSeenInterval.Seconds()).Scan(&revoked, &stale)
+
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			return false, nil
+		}
+		log.Println(err)
+		return false, err
+	}
+
+	if revoked {
+		return false, nil
+	}
+
+	if stale {
+		_, err = repository.Database.Exec(`UPDATE applicantsessions SET lastseenat=now(), lastseenip=$2 WHERE publicid::text=$1;`, sessionID, ipAddress)
+
+		if err != nil {
+			// a missed last-seen update shouldn't stop the request
+			log.Println(err)
+		}
+	}
+
+	return true, nil
+}
+
+// GetSessions lists the applicant's sessions that can still be used, most recently seen first
+func (repository *SessionRepository) GetSessions(publicID string) ([]*Session, error) {
+
+	if publicID == "" {
+		return nil, errors.New("missing required value")
+	}
+
+	rows, err := repository.Database.Query(`
+		SELECT applicantsessions.publicid, authmethod, useragent, ipaddress, lastseenip, applicantsessions.createdate, lastseenat
+		FROM applicantsessions
+		JOIN applicants ON applicants.id=applicantsessions.applicantid
+		WHERE applicants.publicid=$1 AND applicantsessions.revokedat IS NULL
+			AND EXISTS (
+				SELECT 1 FROM refreshtokens
+				WHERE refreshtokens.familyid=applicantsessions.publicid
+					AND refreshtokens.usedat IS NULL AND refreshtokens.revokedat IS NULL AND refreshtokens.expiresat > now())
+		ORDER BY lastseenat DESC;`, publicID)
+
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	sessions := []*Session{}
+
+	for rows.Next() {
+		var session Session
+		var userAgent, ipAddress, lastSeenIP sql.NullString
+
+		err = rows.Scan(&session.PublicID, &session.AuthMethod, &userAgent, &ipAddress, &lastSeenIP, &session.CreateDate, &session.LastSeenAt)
+
+		if err != nil {
+			log.Println(err)
+			return nil, err
+		}
+
+		session.UserAgent = userAgent.String
+		session.IPAddress = ipAddress.String
+		session.LastSeenIP = lastSeenIP.String
+		sessions = append(sessions, &session)
+	}
+
+	return sessions, rows.Err()
+}
+
+// RevokeSession signs the device out: its access tokens are rejected from now on and its
+// refresh tokens can't be exchanged. It returns false when the applicant has no such session.
+func (repository *SessionRepository) RevokeSession(publicID, sessionID string) (bool, error) {
+
+	if publicID == "" || sessionID == "" {
+		return false, errors.New("missing required value")
+	}
+
+	tx, err := repository.Database.Begin()
+
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE applicantsessions SET revokedat=now()
+		WHERE publicid::text=$1 AND revokedat IS NULL AND applicantid=(SELECT id FROM applicants WHERE publicid=$2);`, sessionID, publicID)
+
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	if rows == 0 {
+		return false, nil
+	}
+
+	_, err = tx.Exec(`UPDATE refreshtokens SET revokedat=now() WHERE familyid::text=$1 AND revokedat IS NULL;`, sessionID)
+
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+
+	return true, nil
+}
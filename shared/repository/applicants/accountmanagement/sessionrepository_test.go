@@ -0,0 +1,95 @@
+package accountmanagement_test
+
+import (
+	"strings"
+	"testing"
+
+	"autumnomous-jobs-applicant-api/shared/database"
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+	"autumnomous-jobs-applicant-api/shared/services/security/encryption"
+	"autumnomous-jobs-applicant-api/shared/services/security/jwt"
+	"autumnomous-jobs-applicant-api/shared/services/security/principal"
+	"autumnomous-jobs-applicant-api/shared/testhelper"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SessionRepository_CreateSession_Fail_EmptyData(t *testing.T) {
+	assert := assert.New(t)
+
+	repository := accountmanagement.NewSessionRepository(database.DB)
+
+	err := repository.CreateSession("", "", principal.AuthMethodPassword, "agent", "127.0.0.1")
+
+	assert.NotNil(err)
+}
+
+func Test_SessionRepository_GetSessions_And_Revoke(t *testing.T) {
+	assert := assert.New(t)
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	other := testhelper.Helper_RandomApplicant(t)
+
+	refreshTokens := accountmanagement.NewRefreshTokenRepository(database.DB)
+	repository := accountmanagement.NewSessionRepository(database.DB)
+
+	token, _ := jwt.GenerateRefreshToken()
+	sessionID, err := refreshTokens.CreateRefreshToken(applicant.PublicID, encryption.HashToken(token), jwt.RefreshTokenLifetime)
+	assert.Nil(err)
+
+	err = repository.CreateSession(applicant.PublicID, sessionID, principal.AuthMethodPassword, strings.Repeat("a", 1000), "127.0.0.1")
+	assert.Nil(err)
+
+	sessions, err := repository.GetSessions(applicant.PublicID)
+	assert.Nil(err)
+
+	if assert.Len(sessions, 1) {
+		assert.Equal(sessionID, sessions[0].PublicID)
+		assert.Equal(principal.AuthMethodPassword, sessions[0].AuthMethod)
+		assert.Equal(512, len(sessions[0].UserAgent))
+		assert.Equal("127.0.0.1", sessions[0].IPAddress)
+	}
+
+	active, err := repository.TouchSession(applicant.PublicID, sessionID, "127.0.0.2")
+	assert.Nil(err)
+	assert.True(active)
+
+	// another applicant can neither use nor revoke the session
+	active, err = repository.TouchSession(other.PublicID, sessionID, "127.0.0.2")
+	assert.Nil(err)
+	assert.False(active)
+
+	revoked, err := repository.RevokeSession(other.PublicID, sessionID)
+	assert.Nil(err)
+	assert.False(revoked)
+
+	revoked, err = repository.RevokeSession(applicant.PublicID, sessionID)
+	assert.Nil(err)
+	assert.True(revoked)
+
+	active, err = repository.TouchSession(applicant.PublicID, sessionID, "127.0.0.2")
+	assert.Nil(err)
+	assert.False(active)
+
+	sessions, err = repository.GetSessions(applicant.PublicID)
+	assert.Nil(err)
+	assert.Len(sessions, 0)
+
+	// the session's refresh tokens were revoked with it
+	next, _ := jwt.GenerateRefreshToken()
+	publicID, _, _, err := refreshTokens.RotateRefreshToken(encryption.HashToken(token), encryption.HashToken(next), jwt.RefreshTokenLifetime)
+	assert.Nil(err)
+	assert.Equal("", publicID)
+}
+
+func Test_SessionRepository_TouchSession_Unknown(t *testing.T) {
+	assert := assert.New(t)
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	repository := accountmanagement.NewSessionRepository(database.DB)
+
+	active, err := repository.TouchSession(applicant.PublicID, "not-a-session", "127.0.0.1")
+
+	assert.Nil(err)
+	assert.False(active)
+}
@@ -0,0 +1,235 @@
+package accountmanagement
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// CREATE TABLE skills (
+//     id SERIAL PRIMARY KEY,
+//     publicid uuid NOT NULL UNIQUE,
+//     name text NOT NULL,
+//     normalizedname text NOT NULL UNIQUE
+// );
+// CREATE TABLE applicantskills (
+//     applicantid integer NOT NULL REFERENCES applicants(id) ON DELETE CASCADE,
+//     skillid integer NOT NULL REFERENCES skills(id) ON DELETE CASCADE,
+//     proficiency text NOT NULL,
+//     createdate timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+//     updatedate timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+//     PRIMARY KEY (applicantid, skillid)
+// );
+
+// Proficiency levels, from least to most skilled
+const (
+	ProficiencyBeginner     = "beginner"
+	ProficiencyIntermediate = "intermediate"
+	ProficiencyAdvanced     = "advanced"
+	ProficiencyExpert       = "expert"
+)
+
+// ProficiencyLevels lists the levels in order
+var ProficiencyLevels = []string{ProficiencyBeginner, ProficiencyIntermediate, ProficiencyAdvanced, ProficiencyExpert}
+
+// MaxSkillNameLength caps the length of a skill's name
+const MaxSkillNameLength = 100
+
+// Skill is something the applicant can do and how well. Skills are shared between applicants, so
+// "Go", " go " and "GO" are all the same skill, named as it was first entered.
+type Skill struct {
+	PublicID    string `json:"id"`
+	Name        string `json:"name"`
+	Proficiency string `json:"proficiency"`
+}
+
+// Validate trims the skill's name and returns what's wrong with it
+func (skill *Skill) Validate() []FieldViolation {
+
+	var v validator
+
+	skill.Name = strings.Join(strings.Fields(skill.Name), " ")
+	v.text("name", &skill.Name, true, MaxSkillNameLength)
+	v.proficiency(skill.Proficiency)
+
+	return v.violations
+}
+
+// ValidateProficiency returns what's wrong with the skill's proficiency, for changing only that
+func (skill *Skill) ValidateProficiency() []FieldViolation {
+
+	var v validator
+
+	v.proficiency(skill.Proficiency)
+
+	return v.violations
+}
+
+func (v *validator) proficiency(value string) {
+
+	for _, level := range ProficiencyLevels {
+		if value == level {
+			return
+		}
+	}
+
+	if value == "" {
+		v.add("proficiency", ViolationRequired, "proficiency is required.")
+		return
+	}
+
+	v.add("proficiency", ViolationInvalid, "proficiency must be one of "+strings.Join(ProficiencyLevels, ", ")+".")
+}
+
+// NormalizeSkillName returns the form of name used to tell whether two skills are the same
+func NormalizeSkillName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+type SkillRepository struct {
+	Database *sql.DB
+}
+
+func NewSkillRepository(db *sql.DB) *SkillRepository {
+	return &SkillRepository{Database: db}
+}
+
+// GetSkills returns the applicant's skills, strongest first
+func (repository *SkillRepository) GetSkills(publicID string) ([]*Skill, error) {
+
+	rows, err := repository.Database.Query(`
+		SELECT skills.publicid, skills.name, applicantskills.proficiency
+		FROM applicantskills
+		JOIN skills ON skills.id=applicantskills.skillid
+		WHERE applicantskills.applicantid=(SELECT id FROM applicants WHERE publicid=$1)
+		ORDER BY array_position($2::text[], applicantskills.proficiency) DESC, skills.name;`, publicID, "{"+strings.Join(ProficiencyLevels, ",")+"}")
+
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	skills := []*Skill{}
+
+	for rows.Next() {
+		var skill Skill
+
+		err = rows.Scan(&skill.PublicID, &skill.Name, &skill.Proficiency)
+
+		if err != nil {
+			log.Println(err)
+			return nil, err
+		}
+
+		skills = append(skills, &skill)
+	}
+
+	return skills, rows.Err()
+}
+
+// AddSkill gives the applicant a validated skill, or changes their proficiency if they already
+// have it, and sets the skill's id and name
+func (repository *SkillRepository) AddSkill(publicID string, skill *Skill) error {
+
+	if publicID == "" || skill == nil || skill.Name == "" {
+		return errors.New("missing required value")
+	}
+
+	tx, err := repository.Database.Begin()
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	defer tx.Rollback()
+
+	var skillID int
+
+	err = tx.QueryRow(`
+		WITH inserted AS (
+			INSERT INTO skills(publicid, name, normalizedname) VALUES ($1, $2, $3)
+			ON CONFLICT (normalizedname) DO NOTHING
+			RETURNING id, publicid, name)
+		SELECT id, publicid, name FROM inserted
+		UNION ALL
+		SELECT id, publicid, name FROM skills WHERE normalizedname=$3;`,
+		uuid.NewString(), skill.Name, NormalizeSkillName(skill.Name)).Scan(&skillID, &skill.PublicID, &skill.Name)
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO applicantskills(applicantid, skillid, proficiency)
+		SELECT applicants.id, $2, $3
+		FROM applicants
+		WHERE applicants.publicid=$1
+			AND ((SELECT count(*) FROM applicantskills WHERE applicantid=applicants.id) < $4
+				OR EXISTS (SELECT 1 FROM applicantskills WHERE applicantid=applicants.id AND skillid=$2))
+		ON CONFLICT (applicantid, skillid) DO UPDATE SET proficiency=excluded.proficiency, updatedate=now();`,
+		publicID, skillID, skill.Proficiency, MaxProfileEntries)
+
+	err = insertedOne(result, err)
+
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+// UpdateSkillProficiency changes how well the applicant has the skill with the given id. It returns
+// false if they don't have it.
+func (repository *SkillRepository) UpdateSkillProficiency(publicID string, skill *Skill) (bool, error) {
+
+	if _, err := uuid.Parse(skill.PublicID); err != nil {
+		return false, nil
+	}
+
+	err := repository.Database.QueryRow(`
+		UPDATE applicantskills SET proficiency=$3, updatedate=now()
+		FROM skills
+		WHERE skills.id=applicantskills.skillid AND skills.publicid=$2
+			AND applicantskills.applicantid=(SELECT id FROM applicants WHERE publicid=$1)
+		RETURNING skills.name;`, publicID, skill.PublicID, skill.Proficiency).Scan(&skill.Name)
+
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			return false, nil
+		}
+		log.Println(err)
+		return false, err
+	}
+
+	return true, nil
+}
+
+// RemoveSkill takes the skill with the given id off the applicant's profile. It returns false if they don't have it.
+func (repository *SkillRepository) RemoveSkill(publicID, skillID string) (bool, error) {
+
+	if _, err := uuid.Parse(skillID); err != nil {
+		return false, nil
+	}
+
+	result, err := repository.Database.Exec(`
+		DELETE FROM applicantskills
+		USING skills
+		WHERE skills.id=applicantskills.skillid AND skills.publicid=$2
+			AND applicantskills.applicantid=(SELECT id FROM applicants WHERE publicid=$1);`, publicID, skillID)
+
+	return affectedAny(result, err)
+}
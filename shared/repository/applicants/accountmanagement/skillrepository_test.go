@@ -0,0 +1,76 @@
+package accountmanagement_test
+
+import (
+	"testing"
+
+	"autumnomous-jobs-applicant-api/shared/database"
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+	"autumnomous-jobs-applicant-api/shared/testhelper"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Skill_Validate(t *testing.T) {
+	assert := assert.New(t)
+
+	skill := &accountmanagement.Skill{Name: "  Project   management ", Proficiency: accountmanagement.ProficiencyExpert}
+	assert.Empty(skill.Validate())
+	assert.Equal("Project management", skill.Name)
+
+	skill = &accountmanagement.Skill{Name: "Go", Proficiency: "wizard"}
+	violations := skill.Validate()
+
+	if assert.Len(violations, 1) {
+		assert.Equal("proficiency", violations[0].Field)
+		assert.Equal(accountmanagement.ViolationInvalid, violations[0].Code)
+	}
+
+	assert.Equal("project management", accountmanagement.NormalizeSkillName(" Project\tManagement "))
+}
+
+func Test_SkillRepository_SharedSkills(t *testing.T) {
+	assert := assert.New(t)
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	other := testhelper.Helper_RandomApplicant(t)
+	repository := accountmanagement.NewSkillRepository(database.DB)
+
+	name := "Skill " + applicant.PublicID
+
+	first := &accountmanagement.Skill{Name: name, Proficiency: accountmanagement.ProficiencyBeginner}
+	assert.Nil(repository.AddSkill(applicant.PublicID, first))
+
+	// the same skill written differently is shared, and keeps its first name
+	second := &accountmanagement.Skill{Name: "SKILL " + applicant.PublicID, Proficiency: accountmanagement.ProficiencyExpert}
+	assert.Nil(repository.AddSkill(other.PublicID, second))
+	assert.Equal(first.PublicID, second.PublicID)
+	assert.Equal(name, second.Name)
+
+	// adding it again changes the proficiency
+	again := &accountmanagement.Skill{Name: name, Proficiency: accountmanagement.ProficiencyAdvanced}
+	assert.Nil(repository.AddSkill(applicant.PublicID, again))
+
+	skills, err := repository.GetSkills(applicant.PublicID)
+	assert.Nil(err)
+
+	if assert.Len(skills, 1) {
+		assert.Equal(accountmanagement.ProficiencyAdvanced, skills[0].Proficiency)
+	}
+
+	updated, err := repository.UpdateSkillProficiency(applicant.PublicID, &accountmanagement.Skill{PublicID: first.PublicID, Proficiency: accountmanagement.ProficiencyIntermediate})
+	assert.Nil(err)
+	assert.True(updated)
+
+	removed, err := repository.RemoveSkill(applicant.PublicID, first.PublicID)
+	assert.Nil(err)
+	assert.True(removed)
+
+	skills, err = repository.GetSkills(applicant.PublicID)
+	assert.Nil(err)
+	assert.Len(skills, 0)
+
+	// the other applicant still has it
+	skills, err = repository.GetSkills(other.PublicID)
+	assert.Nil(err)
+	assert.Len(skills, 1)
+}
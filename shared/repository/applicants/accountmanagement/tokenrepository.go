@@ -0,0 +1,195 @@
+package accountmanagement
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+)
+
+// CREATE TABLE applicanttokens (
+//     id SERIAL PRIMARY KEY,
+//     applicantid integer NOT NULL REFERENCES applicants(id) ON DELETE CASCADE ON UPDATE CASCADE,
+//     purpose text NOT NULL,
+//     tokenhash text NOT NULL UNIQUE,
+//     expiresat timestamp with time zone NOT NULL,
+//     usedat timestamp with time zone,
+//     createdate timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP
+// );
+// CREATE INDEX applicanttokens_applicantid_purpose_idx ON applicanttokens(applicantid, purpose);
+
+// TokenPurpose identifies what a single-use applicant token may be redeemed for
+type TokenPurpose string
+
+const (
+	// PasswordReset tokens are emailed by the forgot password flow
+	PasswordReset TokenPurpose = "password-reset"
+
+	// EmailVerification tokens confirm the email an applicant signed up with
+	EmailVerification TokenPurpose = "email-verification"
+
+	// EmailChange tokens confirm a pending email before it replaces the current one
+	EmailChange TokenPurpose = "email-change"
+
+	// AccountUnlock tokens are emailed when failed logins lock an account
+	AccountUnlock TokenPurpose = "account-unlock"
+
+	// MagicLink tokens sign the applicant in without a password
+	MagicLink TokenPurpose = "magic-link"
+)
+
+const (
+	// PasswordResetTokenLifetime is how long an emailed reset link stays valid
+	PasswordResetTokenLifetime = time.Hour
+
+	// EmailVerificationTokenLifetime is how long an emailed verification link stays valid
+	EmailVerificationTokenLifetime = time.Hour * 48
+
+	// AccountUnlockTokenLifetime is how long an emailed unlock link stays valid
+	AccountUnlockTokenLifetime = time.Hour * 24
+
+	// MagicLinkTokenLifetime is kept short since the link signs the applicant straight in
+	MagicLinkTokenLifetime = time.Minute * 15
+)
+
+type TokenRepository struct {
+	Database *sql.DB
+}
+
+func NewTokenRepository(db *sql.DB) *TokenRepository {
+	return &TokenRepository{Database: db}
+}
+
+// CreateToken stores the hash of a single-use token for the applicant
+func (repository *TokenRepository) CreateToken(publicID string, purpose TokenPurpose, tokenHash string, lifetime time.Duration) error {
+
+	if publicID == "" || purpose == "" || tokenHash == "" {
+		return errors.New("missing required value")
+	}
+
+	stmt, err := repository.Database.Prepare(`
+		INSERT INTO applicanttokens(applicantid, purpose, tokenhash, expiresat)
+		VALUES ((SELECT id FROM applicants WHERE publicid=$1), $2, $3, now() + make_interval(secs => $4));`)
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	_, err = stmt.Exec(publicID, string(purpose), tokenHash, lifetime.Seconds())
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+// LookupToken returns the public id a valid token belongs to without redeeming it,
+// or an empty string when the token is unknown, expired or already used
+func (repository *TokenRepository) LookupToken(purpose TokenPurpose, tokenHash string) (string, error) {
+
+	if purpose == "" || tokenHash == "" {
+		return "", nil
+	}
+
+	var publicID string
+
+	err := repository.Database.QueryRow(`
+		SELECT applicants.publicid
+		FROM applicanttokens
+		JOIN applicants ON applicants.id=applicanttokens.applicantid
+		WHERE applicanttokens.tokenhash=$1 AND applicanttokens.purpose=$2
+			AND applicanttokens.usedat IS NULL AND applicanttokens.expiresat > now();`, tokenHash, string(purpose)).Scan(&publicID)
+
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			return "", nil
+		}
+		log.Println(err)
+		return "", err
+	}
+
+	return publicID, nil
+}
+
+// RedeemToken consumes an unexpired, unused token and returns the owning applicant's public id.
+// Every other outstanding token the applicant holds for the same purpose is invalidated with it.
+// An empty public id is returned when the token is unknown, expired or already used.
+func (repository *TokenRepository) RedeemToken(purpose TokenPurpose, tokenHash string) (string, error) {
+
+	if purpose == "" || tokenHash == "" {
+		return "", nil
+	}
+
+	tx, err := repository.Database.Begin()
+
+	if err != nil {
+		log.Println(err)
+		return "", err
+	}
+
+	defer tx.Rollback()
+
+	var applicantID int
+	var publicID string
+
+	err = tx.QueryRow(`
+		SELECT applicanttokens.applicantid, applicants.publicid
+		FROM applicanttokens
+		JOIN applicants ON applicants.id=applicanttokens.applicantid
+		WHERE applicanttokens.tokenhash=$1 AND applicanttokens.purpose=$2
+			AND applicanttokens.usedat IS NULL AND applicanttokens.expiresat > now()
+		FOR UPDATE OF applicanttokens;`, tokenHash, string(purpose)).Scan(&applicantID, &publicID)
+
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			return "", nil
+		}
+		log.Println(err)
+		return "", err
+	}
+
+	_, err = tx.Exec(`UPDATE applicanttokens SET usedat=now() WHERE applicantid=$1 AND purpose=$2 AND usedat IS NULL;`, applicantID, string(purpose))
+
+	if err != nil {
+		log.Println(err)
+		return "", err
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		log.Println(err)
+		return "", err
+	}
+
+	return publicID, nil
+}
+
+// RevokeTokens invalidates every outstanding token the applicant holds for a purpose
+func (repository *TokenRepository) RevokeTokens(publicID string, purpose TokenPurpose) error {
+
+	if publicID == "" || purpose == "" {
+		return errors.New("missing required value")
+	}
+
+	stmt, err := repository.Database.Prepare(`
+		UPDATE applicanttokens SET usedat=now()
+		WHERE applicantid=(SELECT id FROM applicants WHERE publicid=$1) AND purpose=$2 AND usedat IS NULL;`)
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	_, err = stmt.Exec(publicID, string(purpose))
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package accountmanagement_test
+
+import (
+	"testing"
+
+	"autumnomous-jobs-applicant-api/shared/database"
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+	"autumnomous-jobs-applicant-api/shared/services/security/encryption"
+	"autumnomous-jobs-applicant-api/shared/testhelper"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_TokenRepository_CreateToken_Fail_EmptyData(t *testing.T) {
+	assert := assert.New(t)
+
+	repository := accountmanagement.NewTokenRepository(database.DB)
+
+	err := repository.CreateToken("", accountmanagement.PasswordReset, encryption.HashToken("token"), accountmanagement.PasswordResetTokenLifetime)
+
+	assert.NotNil(err)
+}
+
+func Test_TokenRepository_RedeemToken_Correct(t *testing.T) {
+	assert := assert.New(t)
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	repository := accountmanagement.NewTokenRepository(database.DB)
+
+	token, err := encryption.GenerateToken(32)
+
+	if err != nil {
+		t.Fatal()
+	}
+
+	err = repository.CreateToken(applicant.PublicID, accountmanagement.PasswordReset, encryption.HashToken(token), accountmanagement.PasswordResetTokenLifetime)
+	assert.Nil(err)
+
+	publicID, err := repository.RedeemToken(accountmanagement.PasswordReset, encryption.HashToken(token))
+
+	assert.Nil(err)
+	assert.Equal(applicant.PublicID, publicID)
+}
+
+func Test_TokenRepository_RedeemToken_SingleUse(t *testing.T) {
+	assert := assert.New(t)
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	repository := accountmanagement.NewTokenRepository(database.DB)
+
+	first, _ := encryption.GenerateToken(32)
+	second, _ := encryption.GenerateToken(32)
+
+	assert.Nil(repository.CreateToken(applicant.PublicID, accountmanagement.PasswordReset, encryption.HashToken(first), accountmanagement.PasswordResetTokenLifetime))
+	assert.Nil(repository.CreateToken(applicant.PublicID, accountmanagement.PasswordReset, encryption.HashToken(second), accountmanagement.PasswordResetTokenLifetime))
+
+	publicID, err := repository.RedeemToken(accountmanagement.PasswordReset, encryption.HashToken(first))
+	assert.Nil(err)
+	assert.Equal(applicant.PublicID, publicID)
+
+	// the same token can't be redeemed twice
+	publicID, err = repository.RedeemToken(accountmanagement.PasswordReset, encryption.HashToken(first))
+	assert.Nil(err)
+	assert.Equal("", publicID)
+
+	// and redeeming one token invalidates the applicant's others
+	publicID, err = repository.RedeemToken(accountmanagement.PasswordReset, encryption.HashToken(second))
+	assert.Nil(err)
+	assert.Equal("", publicID)
+}
+
+func Test_TokenRepository_RedeemToken_Expired(t *testing.T) {
+	assert := assert.New(t)
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	repository := accountmanagement.NewTokenRepository(database.DB)
+
+	token, _ := encryption.GenerateToken(32)
+
+	assert.Nil(repository.CreateToken(applicant.PublicID, accountmanagement.PasswordReset, encryption.HashToken(token), -accountmanagement.PasswordResetTokenLifetime))
+
+	publicID, err := repository.RedeemToken(accountmanagement.PasswordReset, encryption.HashToken(token))
+
+	assert.Nil(err)
+	assert.Equal("", publicID)
+}
+
+func Test_TokenRepository_RedeemToken_UnknownToken(t *testing.T) {
+	assert := assert.New(t)
+
+	repository := accountmanagement.NewTokenRepository(database.DB)
+
+	publicID, err := repository.RedeemToken(accountmanagement.PasswordReset, encryption.HashToken("not-a-token"))
+
+	assert.Nil(err)
+	assert.Equal("", publicID)
+}
+
+func Test_TokenRepository_RevokeTokens(t *testing.T) {
+	assert := assert.New(t)
+
+	applicant := testhelper.Helper_RandomApplicant(t)
+	repository := accountmanagement.NewTokenRepository(database.DB)
+
+	token, _ := encryption.GenerateToken(32)
+
+	assert.Nil(repository.CreateToken(applicant.PublicID, accountmanagement.EmailVerification, encryption.HashToken(token), accountmanagement.EmailVerificationTokenLifetime))
+	assert.Nil(repository.RevokeTokens(applicant.PublicID, accountmanagement.EmailVerification))
+
+	publicID, err := repository.RedeemToken(accountmanagement.EmailVerification, encryption.HashToken(token))
+
+	assert.Nil(err)
+	assert.Equal("", publicID)
+}
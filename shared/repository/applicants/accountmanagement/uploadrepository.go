@@ -0,0 +1,99 @@
+package accountmanagement
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+)
+
+// CREATE TABLE applicantuploads (
+//     id SERIAL PRIMARY KEY,
+//     applicantid integer NOT NULL REFERENCES applicants(id) ON DELETE CASCADE ON UPDATE CASCADE,
+//     objectkey text NOT NULL UNIQUE,
+//     filename text,
+//     contenttype text,
+//     size bigint NOT NULL DEFAULT 0,
+//     createdate timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP
+// );
+// CREATE INDEX applicantuploads_applicantid_idx ON applicantuploads(applicantid);
+
+// Upload is a file an applicant stored in Spaces
+type Upload struct {
+	ObjectKey   string    `json:"objectkey"`
+	FileName    string    `json:"filename"`
+	ContentType string    `json:"contenttype"`
+	Size        int64     `json:"size"`
+	CreateDate  time.Time `json:"createdate"`
+}
+
+// UploadRepository remembers which Spaces objects belong to which applicant, so they can be
+// found again when the applicant's data is exported or deleted
+type UploadRepository struct {
+	Database *sql.DB
+}
+
+func NewUploadRepository(db *sql.DB) *UploadRepository {
+	return &UploadRepository{Database: db}
+}
+
+// CreateUpload records that the applicant stored the object with key objectKey
+func (repository *UploadRepository) CreateUpload(publicID, objectKey, fileName, contentType string, size int64) error {
+
+	if publicID == "" || objectKey == "" {
+		return errors.New("missing required value")
+	}
+
+	_, err := repository.Database.Exec(`
+		INSERT INTO applicantuploads(applicantid, objectkey, filename, contenttype, size)
+		VALUES ((SELECT id FROM applicants WHERE publicid=$1), $2, $3, $4, $5);`, publicID, objectKey, fileName, contentType, size)
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+// GetUploads lists the applicant's uploads, oldest first
+func (repository *UploadRepository) GetUploads(publicID string) ([]*Upload, error) {
+
+	if publicID == "" {
+		return nil, errors.New("missing required value")
+	}
+
+	rows, err := repository.Database.Query(`
+		SELECT objectkey, filename, contenttype, size, applicantuploads.createdate
+		FROM applicantuploads
+		JOIN applicants ON applicants.id=applicantuploads.applicantid
+		WHERE applicants.publicid=$1
+		ORDER BY applicantuploads.id;`, publicID)
+
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	uploads := []*Upload{}
+
+	for rows.Next() {
+		var upload Upload
+		var fileName, contentType sql.NullString
+
+		err = rows.Scan(&upload.ObjectKey, &fileName, &contentType, &upload.Size, &upload.CreateDate)
+
+		if err != nil {
+			log.Println(err)
+			return nil, err
+		}
+
+		upload.FileName = fileName.String
+		upload.ContentType = contentType.String
+		uploads = append(uploads, &upload)
+	}
+
+	return uploads, rows.Err()
+}
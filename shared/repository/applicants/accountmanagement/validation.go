@@ -0,0 +1,106 @@
+package accountmanagement
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// DateLayout is how dates on an applicant's profile are written, e.g. 2021-09-01
+const DateLayout = "2006-01-02"
+
+// Field lengths on an applicant's profile
+const (
+	MaxShortTextLength = 200
+	MaxLongTextLength  = 5000
+)
+
+// Violation codes
+const (
+	ViolationRequired    = "required"
+	ViolationInvalid     = "invalid"
+	ViolationTooLong     = "too-long"
+	ViolationBeforeStart = "before-start"
+)
+
+// FieldViolation explains why one field of a profile entry was rejected
+type FieldViolation struct {
+	Field   string
+	Code    string
+	Message string
+}
+
+// validator collects the violations found while checking an entry
+type validator struct {
+	violations []FieldViolation
+}
+
+func (v *validator) add(field, code, message string) {
+	v.violations = append(v.violations, FieldViolation{Field: field, Code: code, Message: message})
+}
+
+// text trims value, and checks it's present when required and no longer than max characters
+func (v *validator) text(field string, value *string, required bool, max int) {
+
+	*value = strings.TrimSpace(*value)
+
+	if *value == "" {
+		if required {
+			v.add(field, ViolationRequired, fmt.Sprintf("%s is required.", field))
+		}
+		return
+	}
+
+	if utf8.RuneCountInString(*value) > max {
+		v.add(field, ViolationTooLong, fmt.Sprintf("%s must be at most %d characters.", field, max))
+	}
+}
+
+// date checks value is a date in DateLayout, and returns it
+func (v *validator) date(field, value string, required bool) (time.Time, bool) {
+
+	if value == "" {
+		if required {
+			v.add(field, ViolationRequired, fmt.Sprintf("%s is required.", field))
+		}
+		return time.Time{}, false
+	}
+
+	date, err := time.Parse(DateLayout, value)
+
+	if err != nil {
+		v.add(field, ViolationInvalid, fmt.Sprintf("%s must be a date like 2021-09-01.", field))
+		return time.Time{}, false
+	}
+
+	return date, true
+}
+
+// dateRange checks that end, when given, isn't before start
+func (v *validator) dateRange(startField, start, endField, end string, startRequired bool) {
+
+	startDate, hasStart := v.date(startField, start, startRequired)
+	endDate, hasEnd := v.date(endField, end, false)
+
+	if hasStart && hasEnd && endDate.Before(startDate) {
+		v.add(endField, ViolationBeforeStart, fmt.Sprintf("%s can't be before %s.", endField, startField))
+	}
+}
+
+// link checks value, when given, is an http or https URL
+func (v *validator) link(field string, value *string) {
+
+	v.text(field, value, false, MaxShortTextLength*2)
+
+	if *value == "" {
+		return
+	}
+
+	parsed, err := url.Parse(*value)
+
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		v.add(field, ViolationInvalid, fmt.Sprintf("%s must be a web address starting with http:// or https://.", field))
+	}
+}
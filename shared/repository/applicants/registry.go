@@ -1,10 +1,16 @@
 package applicants
 
 import (
+	"os"
+
 	"autumnomous-jobs-applicant-api/shared/database"
 	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+	"autumnomous-jobs-applicant-api/shared/services/security/lockout"
 )
 
+// memoryLoginAttemptStore is shared by every request when LOGIN_ATTEMPT_STORE=memory
+var memoryLoginAttemptStore = lockout.NewMemoryStore()
+
 type ApplicantRegistry struct {
 }
 
@@ -15,3 +21,92 @@ func NewApplicantRegistry() *ApplicantRegistry {
 func (*ApplicantRegistry) GetApplicantRepository() *accountmanagement.ApplicantRepository {
 	return accountmanagement.NewApplicantRepository(database.DB)
 }
+
+func (*ApplicantRegistry) GetTokenRepository() *accountmanagement.TokenRepository {
+	return accountmanagement.NewTokenRepository(database.DB)
+}
+
+func (*ApplicantRegistry) GetRefreshTokenRepository() *accountmanagement.RefreshTokenRepository {
+	return accountmanagement.NewRefreshTokenRepository(database.DB)
+}
+
+func (*ApplicantRegistry) GetRevocationRepository() *accountmanagement.RevocationRepository {
+	return accountmanagement.NewRevocationRepository(database.DB)
+}
+
+func (*ApplicantRegistry) GetMFARepository() *accountmanagement.MFARepository {
+	return accountmanagement.NewMFARepository(database.DB)
+}
+
+func (*ApplicantRegistry) GetImpersonationRepository() *accountmanagement.ImpersonationRepository {
+	return accountmanagement.NewImpersonationRepository(database.DB)
+}
+
+func (*ApplicantRegistry) GetLockoutRepository() *accountmanagement.LockoutRepository {
+	return accountmanagement.NewLockoutRepository(database.DB)
+}
+
+// GetLoginAttemptStore returns the failed login counters, kept in Postgres unless
+// LOGIN_ATTEMPT_STORE is set to "memory"
+func (*ApplicantRegistry) GetLoginAttemptStore() lockout.Store {
+	if os.Getenv("LOGIN_ATTEMPT_STORE") == "memory" {
+		return memoryLoginAttemptStore
+	}
+	return lockout.NewPostgresStore(database.DB)
+}
+
+func (*ApplicantRegistry) GetOIDCRepository() *accountmanagement.OIDCRepository {
+	return accountmanagement.NewOIDCRepository(database.DB)
+}
+
+func (*ApplicantRegistry) GetSessionRepository() *accountmanagement.SessionRepository {
+	return accountmanagement.NewSessionRepository(database.DB)
+}
+
+func (*ApplicantRegistry) GetAuditRepository() *accountmanagement.AuditRepository {
+	return accountmanagement.NewAuditRepository(database.DB)
+}
+
+func (*ApplicantRegistry) GetUploadRepository() *accountmanagement.UploadRepository {
+	return accountmanagement.NewUploadRepository(database.DB)
+}
+
+func (*ApplicantRegistry) GetDeletionRepository() *accountmanagement.DeletionRepository {
+	return accountmanagement.NewDeletionRepository(database.DB)
+}
+
+func (*ApplicantRegistry) GetExportRepository() *accountmanagement.ExportRepository {
+	return accountmanagement.NewExportRepository(database.DB)
+}
+
+func (*ApplicantRegistry) GetWorkExperienceRepository() *accountmanagement.WorkExperienceRepository {
+	return accountmanagement.NewWorkExperienceRepository(database.DB)
+}
+
+func (*ApplicantRegistry) GetEducationRepository() *accountmanagement.EducationRepository {
+	return accountmanagement.NewEducationRepository(database.DB)
+}
+
+func (*ApplicantRegistry) GetCertificationRepository() *accountmanagement.CertificationRepository {
+	return accountmanagement.NewCertificationRepository(database.DB)
+}
+
+func (*ApplicantRegistry) GetSkillRepository() *accountmanagement.SkillRepository {
+	return accountmanagement.NewSkillRepository(database.DB)
+}
+
+func (*ApplicantRegistry) GetResumeRepository() *accountmanagement.ResumeRepository {
+	return accountmanagement.NewResumeRepository(database.DB)
+}
+
+func (*ApplicantRegistry) GetCoverLetterRepository() *accountmanagement.CoverLetterRepository {
+	return accountmanagement.NewCoverLetterRepository(database.DB)
+}
+
+func (*ApplicantRegistry) GetDocumentSetRepository() *accountmanagement.DocumentSetRepository {
+	return accountmanagement.NewDocumentSetRepository(database.DB)
+}
+
+func (*ApplicantRegistry) GetDesiredCityRepository() *accountmanagement.DesiredCityRepository {
+	return accountmanagement.NewDesiredCityRepository(database.DB)
+}
@@ -7,7 +7,36 @@ const (
 	MissingRequiredValue = "Missing a required value."
 	InvalidCredentials   = "Username or password is incorrect."
 	InvalidAPIKey        = "A valid API Key was not supplied."
+	InsufficientScope    = "This API Key is not allowed to use this endpoint."
+	InvalidScope         = "One or more scopes are not recognised."
+	InvalidExpiry        = "The expiry date must be in the future."
 	Unauthorized         = "Authorization failed."
+	Forbidden            = "You don't have permission to do that."
 	Success              = "Success!"
 	EmptyResult          = "The result was empty."
+	InvalidToken         = "The link is invalid or has expired."
+	EmailNotVerified     = "Please verify your email address to continue."
+	EmailAlreadyVerified = "Your email address is already verified."
+	MFAAlreadyEnabled    = "Two-factor authentication is already enabled."
+	MFANotEnrolled       = "Two-factor authentication has not been set up."
+	InvalidMFACode       = "The verification code is incorrect."
+	TooManyAttempts      = "Too many login attempts, please try again later."
+	TooManyRequests      = "Too many requests, please try again later."
+	AccountLocked        = "This account is temporarily locked. Check your email for a link to unlock it."
+	WeakPassword         = "The new password doesn't meet the password requirements."
+	UnknownProvider      = "That sign-in provider isn't supported."
+	ProviderLoginFailed  = "Signing in with that provider failed, please try again."
+	InvalidQuery         = "One or more query parameters are invalid."
+	InvalidFields        = "One or more fields are invalid."
+	TooManyEntries       = "You can't add any more entries to this section."
+	UnsupportedResume    = "Resumes must be PDF or Word (.docx) files."
+	UnsupportedImage     = "Images must be PNG, JPEG, GIF or WebP files."
+	FileTooLarge         = "The file is too large."
+	UnknownDocument      = "That resume or cover letter doesn't exist."
+	EmailTaken           = "That email address is already in use by another account."
+
+	PasswordResetRequested  = "If an account exists for that email, a reset link has been sent."
+	MagicLinkRequested      = "If an account exists for that email, a sign-in link has been sent."
+	ReadOnlyImpersonation   = "This impersonation session is read-only."
+	ProviderEmailUnverified = "Your sign-in provider hasn't verified your email address."
 )
@@ -46,3 +46,37 @@ func SendJSONMessage(w http.ResponseWriter, status http.ConnState, message strin
 	w.WriteHeader(int(status))
 	w.Write(js)
 }
+
+// FieldError explains why one field of a request was rejected
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type ValidationResponse struct {
+	Status  http.ConnState `json:"status"`
+	Message string         `json:"message"`
+	Errors  []FieldError   `json:"errors"`
+}
+
+// SendValidationErrors responds 400 with the errors for each rejected field
+func SendValidationErrors(w http.ResponseWriter, message string, errors []FieldError) {
+
+	i := &ValidationResponse{
+		Status:  http.StatusBadRequest,
+		Message: message,
+		Errors:  errors}
+
+	js, err := json.Marshal(i)
+	if err != nil {
+		http.Error(w, "JSON error:"+err.Error(), http.StatusInternalServerError)
+		log.Println(err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(http.StatusBadRequest)
+	w.Write(js)
+}
@@ -0,0 +1,81 @@
+// Package audit appends events to the audit log, attributing them to whoever made the request
+package audit
+
+import (
+	"log"
+	"net/http"
+	"reflect"
+
+	"autumnomous-jobs-applicant-api/shared/repository/applicants"
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+	"autumnomous-jobs-applicant-api/shared/services/security/principal"
+	"autumnomous-jobs-applicant-api/shared/services/utils"
+)
+
+// Changes maps each changed field to its value before and after
+type Changes map[string]accountmanagement.AuditChange
+
+// Record appends an event about targetID made by the request's principal. Staff acting through
+// an impersonation token are recorded as the actor, not the applicant.
+func Record(r *http.Request, eventType, targetID string, changes Changes) {
+	RecordAs(r, Actor(r), eventType, targetID, changes)
+}
+
+// RecordAs appends an event made by actorID, for requests such as logins that aren't yet
+// authenticated. A failure is logged rather than failing the request that caused the event.
+func RecordAs(r *http.Request, actorID, eventType, targetID string, changes Changes) {
+
+	err := applicants.NewApplicantRegistry().GetAuditRepository().RecordEvent(&accountmanagement.AuditEvent{
+		EventType: eventType,
+		ActorID:   actorID,
+		TargetID:  targetID,
+		IPAddress: utils.ClientIP(r),
+		RequestID: utils.RequestID(r),
+		Changes:   changes,
+	})
+
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// Actor returns the public id of whoever is really behind the request, or "" when it isn't authenticated
+func Actor(r *http.Request) string {
+
+	current := principal.FromRequest(r)
+
+	if current == nil {
+		return ""
+	}
+
+	if current.IsImpersonation() {
+		return current.ActorID
+	}
+
+	return current.ApplicantID
+}
+
+// Diff returns the fields whose values differ between before and after. A field missing from
+// one side is compared as nil.
+func Diff(before, after map[string]interface{}) Changes {
+
+	changes := Changes{}
+
+	compare := func(field string) {
+		if !reflect.DeepEqual(before[field], after[field]) {
+			changes[field] = accountmanagement.AuditChange{Before: before[field], After: after[field]}
+		}
+	}
+
+	for field := range before {
+		compare(field)
+	}
+
+	for field := range after {
+		if _, ok := before[field]; !ok {
+			compare(field)
+		}
+	}
+
+	return changes
+}
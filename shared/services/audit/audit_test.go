@@ -0,0 +1,42 @@
+package audit_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+	"autumnomous-jobs-applicant-api/shared/services/audit"
+	"autumnomous-jobs-applicant-api/shared/services/security/principal"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Diff(t *testing.T) {
+	assert := assert.New(t)
+
+	changes := audit.Diff(
+		map[string]interface{}{"firstname": "Ann", "city": "Leeds", "phonenumber": "123"},
+		map[string]interface{}{"firstname": "Ann", "city": "York", "pendingemail": "ann@example.com"},
+	)
+
+	assert.Equal(audit.Changes{
+		"city":         accountmanagement.AuditChange{Before: "Leeds", After: "York"},
+		"phonenumber":  accountmanagement.AuditChange{Before: "123", After: nil},
+		"pendingemail": accountmanagement.AuditChange{Before: nil, After: "ann@example.com"},
+	}, changes)
+
+	assert.Empty(audit.Diff(map[string]interface{}{"a": 1}, map[string]interface{}{"a": 1}))
+}
+
+func Test_Actor(t *testing.T) {
+	assert := assert.New(t)
+
+	r := httptest.NewRequest("GET", "/", nil)
+	assert.Equal("", audit.Actor(r))
+
+	r = principal.WithPrincipal(r, &principal.Principal{ApplicantID: "applicant"})
+	assert.Equal("applicant", audit.Actor(r))
+
+	r = principal.WithPrincipal(r, &principal.Principal{ApplicantID: "applicant", ActorID: "staff"})
+	assert.Equal("staff", audit.Actor(r))
+}
@@ -0,0 +1,159 @@
+// Package dataexport writes a copy of an applicant's data as a ZIP of JSON files plus the files
+// they uploaded
+package dataexport
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"time"
+
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+	"autumnomous-jobs-applicant-api/shared/services/storage"
+)
+
+// unsafeFileName matches characters that are left out of file names inside the archive
+var unsafeFileName = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Archive is everything to be written for one export. Each section becomes <name>.json.
+type Archive struct {
+	ExportID    string
+	ApplicantID string
+	CreatedAt   time.Time
+	Sections    map[string]interface{}
+	Files       []*accountmanagement.Upload
+
+	// Notes explain sections that are empty or missing, for the manifest
+	Notes map[string]string
+}
+
+// manifestFile describes one uploaded file and where it is in the archive
+type manifestFile struct {
+	Path        string    `json:"path"`
+	FileName    string    `json:"filename"`
+	ContentType string    `json:"contenttype"`
+	Size        int64     `json:"size"`
+	CreateDate  time.Time `json:"createdate"`
+	Missing     bool      `json:"missing,omitempty"`
+}
+
+type manifest struct {
+	ExportID    string            `json:"exportid"`
+	ApplicantID string            `json:"applicantid"`
+	CreatedAt   time.Time         `json:"createdat"`
+	Sections    []string          `json:"sections"`
+	Files       []manifestFile    `json:"files"`
+	Notes       map[string]string `json:"notes,omitempty"`
+}
+
+// Write writes the archive to w as a ZIP, copying each uploaded file out of store. A file that is
+// no longer in store is listed in the manifest as missing rather than failing the export.
+func (archive *Archive) Write(w io.Writer, store storage.Store) error {
+
+	writer := zip.NewWriter(w)
+
+	names := make([]string, 0, len(archive.Sections))
+
+	for name := range archive.Sections {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		err := writeJSON(writer, name+".json", archive.Sections[name])
+
+		if err != nil {
+			return err
+		}
+	}
+
+	files := []manifestFile{}
+
+	for i, upload := range archive.Files {
+		file := manifestFile{
+			Path:        fmt.Sprintf("files/%d-%s", i+1, safeFileName(upload.FileName)),
+			FileName:    upload.FileName,
+			ContentType: upload.ContentType,
+			Size:        upload.Size,
+			CreateDate:  upload.CreateDate,
+		}
+
+		err := copyFile(writer, file.Path, upload.ObjectKey, store)
+
+		if err == storage.ErrNotFound {
+			file.Path = ""
+			file.Missing = true
+		} else if err != nil {
+			return err
+		}
+
+		files = append(files, file)
+	}
+
+	err := writeJSON(writer, "manifest.json", manifest{
+		ExportID:    archive.ExportID,
+		ApplicantID: archive.ApplicantID,
+		CreatedAt:   archive.CreatedAt,
+		Sections:    names,
+		Files:       files,
+		Notes:       archive.Notes,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return writer.Close()
+}
+
+func writeJSON(writer *zip.Writer, name string, value interface{}) error {
+
+	file, err := writer.Create(name)
+
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(value)
+}
+
+func copyFile(writer *zip.Writer, name, key string, store storage.Store) error {
+
+	body, err := store.Get(key)
+
+	if err != nil {
+		return err
+	}
+
+	defer body.Close()
+
+	file, err := writer.Create(name)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(file, body)
+
+	return err
+}
+
+// safeFileName keeps an uploaded file's name from escaping the files directory of the archive
+func safeFileName(name string) string {
+
+	name = unsafeFileName.ReplaceAllString(path.Base(name), "_")
+
+	if name == "" || name == "." || name == ".." || name == "_" {
+		return "file"
+	}
+
+	return name
+}
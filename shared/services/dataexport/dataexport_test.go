@@ -0,0 +1,87 @@
+package dataexport_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+	"autumnomous-jobs-applicant-api/shared/services/dataexport"
+	"autumnomous-jobs-applicant-api/shared/services/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readZip(t *testing.T, data []byte) map[string][]byte {
+
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string][]byte{}
+
+	for _, file := range reader.File {
+		body, err := file.Open()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		files[file.Name], _ = ioutil.ReadAll(body)
+		body.Close()
+	}
+
+	return files
+}
+
+func Test_Archive_Write(t *testing.T) {
+	assert := assert.New(t)
+
+	store := storage.NewMemoryStore()
+	store.Put("uploads/a.png", strings.NewReader("picture"), "image/png", true)
+
+	archive := &dataexport.Archive{
+		ExportID:    "export",
+		ApplicantID: "applicant",
+		CreatedAt:   time.Now(),
+		Sections: map[string]interface{}{
+			"profile":       map[string]string{"firstname": "Jo"},
+			"desiredcities": []string{},
+		},
+		Files: []*accountmanagement.Upload{
+			{ObjectKey: "uploads/a.png", FileName: "../../me.png", ContentType: "image/png", Size: 7},
+			{ObjectKey: "uploads/gone.png", FileName: "gone.png"},
+		},
+		Notes: map[string]string{"savedjobs": "not stored"},
+	}
+
+	var buffer bytes.Buffer
+
+	err := archive.Write(&buffer, store)
+	assert.Nil(err)
+
+	files := readZip(t, buffer.Bytes())
+
+	assert.JSONEq(`{"firstname": "Jo"}`, string(files["profile.json"]))
+	assert.JSONEq(`[]`, string(files["desiredcities.json"]))
+	assert.Equal("picture", string(files["files/1-me.png"]))
+
+	var manifest map[string]interface{}
+	assert.Nil(json.Unmarshal(files["manifest.json"], &manifest))
+
+	assert.Equal("export", manifest["exportid"])
+	assert.Equal([]interface{}{"desiredcities", "profile"}, manifest["sections"])
+
+	listed := manifest["files"].([]interface{})
+
+	if assert.Len(listed, 2) {
+		assert.Equal("files/1-me.png", listed[0].(map[string]interface{})["path"])
+		assert.Equal(true, listed[1].(map[string]interface{})["missing"])
+	}
+}
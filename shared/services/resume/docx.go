@@ -0,0 +1,82 @@
+package resume
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+)
+
+// docxDocument is the part of a Word document holding its body
+const docxDocument = "word/document.xml"
+
+// maxDOCXDocumentSize stops a small, highly compressed upload from expanding without limit
+const maxDOCXDocumentSize = 20 << 20
+
+// extractDOCX reads the text runs of the document body, with a line per paragraph
+func extractDOCX(data []byte) (string, error) {
+
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+
+	if err != nil {
+		return "", err
+	}
+
+	file := findZipFile(reader, docxDocument)
+
+	if file == nil {
+		return "", errors.New("resume: word document has no body")
+	}
+
+	body, err := file.Open()
+
+	if err != nil {
+		return "", err
+	}
+
+	defer body.Close()
+
+	decoder := xml.NewDecoder(io.LimitReader(body, maxDOCXDocumentSize))
+
+	var text strings.Builder
+	inText := false
+
+	for {
+		token, err := decoder.Token()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			switch element.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				text.WriteString("\t")
+			case "br", "cr":
+				text.WriteString("\n")
+			}
+		case xml.EndElement:
+			switch element.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				text.WriteString("\n")
+			}
+		case xml.CharData:
+			if inText {
+				text.Write(element)
+			}
+		}
+	}
+
+	return text.String(), nil
+}
@@ -0,0 +1,285 @@
+package resume
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+)
+
+// MaxSuggestedSkills caps how many skills are picked out of one resume
+const MaxSuggestedSkills = accountmanagement.MaxProfileEntries
+
+// Suggestions are profile entries picked out of a resume, for the applicant to check before
+// they're added to their profile. Work experience is most recent first, as it's usually written.
+type Suggestions struct {
+	WorkExperience []*accountmanagement.WorkExperience `json:"workexperience"`
+	Skills         []string                            `json:"skills"`
+}
+
+const (
+	sectionNone = iota
+	sectionExperience
+	sectionSkills
+	sectionOther
+)
+
+// headings are the section titles resumes commonly use, lower cased and without punctuation
+var headings = map[string]int{
+	"experience":              sectionExperience,
+	"work experience":         sectionExperience,
+	"professional experience": sectionExperience,
+	"relevant experience":     sectionExperience,
+	"employment":              sectionExperience,
+	"employment history":      sectionExperience,
+	"work history":            sectionExperience,
+	"career history":          sectionExperience,
+	"skills":                  sectionSkills,
+	"key skills":              sectionSkills,
+	"core skills":             sectionSkills,
+	"technical skills":        sectionSkills,
+	"skills and abilities":    sectionSkills,
+	"competencies":            sectionSkills,
+	"core competencies":       sectionSkills,
+	"education":               sectionOther,
+	"qualifications":          sectionOther,
+	"certifications":          sectionOther,
+	"certificates":            sectionOther,
+	"licenses":                sectionOther,
+	"projects":                sectionOther,
+	"summary":                 sectionOther,
+	"profile":                 sectionOther,
+	"objective":               sectionOther,
+	"references":              sectionOther,
+	"interests":               sectionOther,
+	"hobbies":                 sectionOther,
+	"awards":                  sectionOther,
+	"achievements":            sectionOther,
+	"languages":               sectionOther,
+	"volunteering":            sectionOther,
+	"volunteer experience":    sectionOther,
+	"publications":            sectionOther,
+	"contact":                 sectionOther,
+}
+
+const monthPattern = `(?:jan|feb|mar|apr|may|jun|jul|aug|sep|sept|oct|nov|dec)[a-z]*\.?`
+const datePattern = `(?:` + monthPattern + `\s+\d{4}|\d{1,2}/\d{4}|\d{4})`
+
+// dateRange matches ranges like "Jan 2019 - Present", "03/2015 to 06/2018" and "2012 – 2014"
+var dateRange = regexp.MustCompile(`(?i)\(?\b(` + datePattern + `)\s*(?:-|–|—|to)\s*(` + datePattern + `|present|current|now|today)\b\)?`)
+
+var months = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+// titleSeparators split a job's heading into its title and employer, most telling first
+var titleSeparators = []string{" at ", " @ ", " | ", " – ", " — ", " - ", ", "}
+
+// Parse picks the jobs and skills out of a resume's text. It recognises the usual section
+// headings, and a job as a line holding its dates with its title and employer on it or just above.
+func Parse(text string) *Suggestions {
+
+	suggestions := &Suggestions{
+		WorkExperience: []*accountmanagement.WorkExperience{},
+		Skills:         []string{},
+	}
+
+	sections := map[int][]string{}
+	section := sectionNone
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+
+		if line == "" {
+			continue
+		}
+
+		if heading, ok := sectionHeading(line); ok {
+			section = heading
+			continue
+		}
+
+		sections[section] = append(sections[section], line)
+	}
+
+	suggestions.WorkExperience = parseExperience(sections[sectionExperience])
+	suggestions.Skills = parseSkills(sections[sectionSkills])
+
+	return suggestions
+}
+
+func sectionHeading(line string) (int, bool) {
+
+	if len(line) > 40 {
+		return sectionNone, false
+	}
+
+	heading := strings.ToLower(strings.Trim(line, ":-–— "))
+	heading = strings.ReplaceAll(heading, "&", "and")
+	heading = strings.Join(strings.Fields(heading), " ")
+
+	section, ok := headings[heading]
+
+	return section, ok
+}
+
+func parseExperience(lines []string) []*accountmanagement.WorkExperience {
+
+	experience := []*accountmanagement.WorkExperience{}
+
+	// the lines on which each job's heading and description start
+	type job struct {
+		heading, dates int
+		entry          *accountmanagement.WorkExperience
+	}
+
+	jobs := []job{}
+
+	for i, line := range lines {
+		match := dateRange.FindStringSubmatchIndex(line)
+
+		if match == nil {
+			continue
+		}
+
+		entry := &accountmanagement.WorkExperience{
+			StartDate: parseDate(line[match[2]:match[3]]),
+			EndDate:   parseDate(line[match[4]:match[5]]),
+		}
+
+		if entry.StartDate == "" {
+			continue
+		}
+
+		heading := i
+		context := strings.Trim(line[:match[0]]+" "+line[match[1]:], " ,|-–—()")
+
+		if context == "" && i > 0 && (len(jobs) == 0 || jobs[len(jobs)-1].dates < i-1) {
+			heading = i - 1
+			context = lines[i-1]
+		}
+
+		entry.Title, entry.Employer = splitJobHeading(context)
+		jobs = append(jobs, job{heading: heading, dates: i, entry: entry})
+	}
+
+	for i, job := range jobs {
+		end := len(lines)
+
+		if i+1 < len(jobs) {
+			end = jobs[i+1].heading
+		}
+
+		description := []string{}
+
+		for _, line := range lines[job.dates+1 : end] {
+			description = append(description, trimBullet(line))
+		}
+
+		job.entry.Description = strings.Join(description, "\n")
+
+		if len(job.entry.Description) > accountmanagement.MaxLongTextLength {
+			job.entry.Description = job.entry.Description[:accountmanagement.MaxLongTextLength]
+			job.entry.Description = strings.ToValidUTF8(job.entry.Description, "")
+		}
+
+		experience = append(experience, job.entry)
+	}
+
+	return experience
+}
+
+func splitJobHeading(heading string) (string, string) {
+
+	for _, separator := range titleSeparators {
+		if parts := strings.SplitN(heading, separator, 2); len(parts) == 2 {
+			return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		}
+	}
+
+	return strings.TrimSpace(heading), ""
+}
+
+// parseDate turns a date from a resume into the profile's date layout, with the first of the
+// month or year when the day or month isn't given. Dates that run to the present are empty.
+func parseDate(value string) string {
+
+	value = strings.ToLower(strings.TrimSpace(value))
+	fields := strings.Fields(value)
+
+	switch {
+	case len(fields) == 2:
+		if len(fields[0]) < 3 {
+			return ""
+		}
+
+		month, ok := months[fields[0][:3]]
+		year, err := strconv.Atoi(fields[1])
+
+		if !ok || err != nil {
+			return ""
+		}
+
+		return fmt.Sprintf("%04d-%02d-01", year, month)
+	case strings.Contains(value, "/"):
+		parts := strings.SplitN(value, "/", 2)
+		month, err := strconv.Atoi(parts[0])
+		year, yearErr := strconv.Atoi(parts[1])
+
+		if err != nil || yearErr != nil || month < 1 || month > 12 {
+			return ""
+		}
+
+		return fmt.Sprintf("%04d-%02d-01", year, month)
+	}
+
+	if year, err := strconv.Atoi(value); err == nil {
+		return fmt.Sprintf("%04d-01-01", year)
+	}
+
+	return ""
+}
+
+// skillSeparators split a line listing several skills
+var skillSeparators = regexp.MustCompile(`[,;|•·▪\t]`)
+
+func parseSkills(lines []string) []string {
+
+	skills := []string{}
+	seen := map[string]bool{}
+
+	for _, line := range lines {
+		line = trimBullet(line)
+
+		// "Languages: Go, Python" lists the skills after its label
+		if label := strings.Index(line, ":"); label >= 0 {
+			line = line[label+1:]
+		}
+
+		for _, skill := range skillSeparators.Split(line, -1) {
+			skill = strings.Join(strings.Fields(strings.Trim(skill, " .")), " ")
+			normalized := accountmanagement.NormalizeSkillName(skill)
+
+			// long phrases are sentences about skills rather than skills
+			if skill == "" || len(skill) > accountmanagement.MaxSkillNameLength || len(strings.Fields(skill)) > 4 || seen[normalized] {
+				continue
+			}
+
+			seen[normalized] = true
+			skills = append(skills, skill)
+
+			if len(skills) == MaxSuggestedSkills {
+				return skills
+			}
+		}
+	}
+
+	return skills
+}
+
+func trimBullet(line string) string {
+	return strings.TrimSpace(strings.TrimLeft(line, "•▪◦‣·*-–— "))
+}
@@ -0,0 +1,58 @@
+package resume_test
+
+import (
+	"strings"
+	"testing"
+
+	"autumnomous-jobs-applicant-api/shared/repository/applicants/accountmanagement"
+	"autumnomous-jobs-applicant-api/shared/services/resume"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Parse(t *testing.T) {
+	assert := assert.New(t)
+
+	text := strings.Join([]string{
+		"Jo Bloggs",
+		"jo@example.com",
+		"Work Experience",
+		"Senior Engineer at Acme Corp Jan 2019 - Present",
+		"• Led the payments team",
+		"• Moved billing to Go",
+		"Developer | Initech",
+		"03/2015 to 12/2018",
+		"Kept the TPS reports running",
+		"Intern, Globex (2013 – 2014)",
+		"Education",
+		"BSc Computer Science, 2010 - 2013",
+		"Skills & Abilities:",
+		"Languages: Go, Python; SQL",
+		"• go • Kubernetes",
+		"Able to explain complicated systems to anyone who asks",
+	}, "\n")
+
+	suggestions := resume.Parse(text)
+
+	assert.Equal([]*accountmanagement.WorkExperience{
+		{Title: "Senior Engineer", Employer: "Acme Corp", StartDate: "2019-01-01", Description: "Led the payments team\nMoved billing to Go"},
+		{Title: "Developer", Employer: "Initech", StartDate: "2015-03-01", EndDate: "2018-12-01", Description: "Kept the TPS reports running"},
+		{Title: "Intern", Employer: "Globex", StartDate: "2013-01-01", EndDate: "2014-01-01", Description: ""},
+	}, suggestions.WorkExperience)
+
+	assert.Equal([]string{"Go", "Python", "SQL", "Kubernetes"}, suggestions.Skills)
+}
+
+func Test_Parse_NoSections(t *testing.T) {
+	assert := assert.New(t)
+
+	suggestions := resume.Parse("Jo Bloggs\nEngineer at Acme 2019 - 2020")
+
+	assert.Equal([]*accountmanagement.WorkExperience{}, suggestions.WorkExperience)
+	assert.Equal([]string{}, suggestions.Skills)
+
+	suggestions = resume.Parse("")
+
+	assert.NotNil(suggestions.WorkExperience)
+	assert.NotNil(suggestions.Skills)
+}
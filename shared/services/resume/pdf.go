@@ -0,0 +1,379 @@
+package resume
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Limits that stop a small file of highly compressed streams from expanding without limit. Text
+// is gathered to a few times what tidy keeps, leaving room for the spacing it takes out.
+const (
+	maxPDFStreamSize = 20 << 20
+	maxPDFInflated   = 50 << 20
+	maxPDFText       = 4 * MaxTextLength
+)
+
+var pdfStream = regexp.MustCompile(`stream\r?\n`)
+
+// extractPDF reads the text drawn by the page content streams of a PDF. It understands the
+// common case of text shown with simple fonts, uncompressed or Flate compressed; text in fonts
+// that need a ToUnicode map, or in scanned images, isn't recovered.
+func extractPDF(data []byte) (string, error) {
+
+	var text strings.Builder
+	inflated := 0
+
+	for _, match := range pdfStream.FindAllIndex(data, -1) {
+		if inflated >= maxPDFInflated || text.Len() >= maxPDFText {
+			break
+		}
+
+		start := match[1]
+		end := bytes.Index(data[start:], []byte("endstream"))
+
+		if end < 0 {
+			break
+		}
+
+		content, ok := pdfStreamContent(streamDictionary(data[:match[0]]), data[start:start+end], maxPDFInflated-inflated)
+		inflated += len(content)
+
+		if !ok || !bytes.Contains(content, []byte("BT")) {
+			continue
+		}
+
+		text.WriteString(pdfContentText(content, maxPDFText-text.Len()))
+		text.WriteString("\n")
+	}
+
+	return text.String(), nil
+}
+
+// streamDictionary returns the dictionary written just before a stream
+func streamDictionary(before []byte) []byte {
+
+	start := bytes.LastIndex(before, []byte("obj"))
+
+	if start < 0 {
+		start = 0
+	}
+
+	return before[start:]
+}
+
+// pdfStreamContent decodes a stream to at most limit bytes, returning false for streams that can't hold page text
+func pdfStreamContent(dictionary, raw []byte, limit int) ([]byte, bool) {
+
+	if bytes.Contains(dictionary, []byte("/Image")) || bytes.Contains(dictionary, []byte("/Length1")) {
+		return nil, false
+	}
+
+	if !bytes.Contains(dictionary, []byte("/Filter")) {
+		return raw, true
+	}
+
+	if !bytes.Contains(dictionary, []byte("/FlateDecode")) {
+		return nil, false
+	}
+
+	reader, err := zlib.NewReader(bytes.NewReader(raw))
+
+	if err != nil {
+		return nil, false
+	}
+
+	defer reader.Close()
+
+	if limit > maxPDFStreamSize {
+		limit = maxPDFStreamSize
+	}
+
+	content, err := ioutil.ReadAll(io.LimitReader(reader, int64(limit)))
+
+	// streams are often cut short of their checksum, so keep whatever was inflated
+	if err != nil && len(content) == 0 {
+		return nil, false
+	}
+
+	return content, true
+}
+
+// pdfContentText runs the text operators of a content stream, starting a new line whenever the
+// text moves down the page and a space when it jumps across it. It stops once it has limit bytes.
+func pdfContentText(content []byte, limit int) string {
+
+	var text strings.Builder
+	lexer := pdfLexer{data: content}
+	operands := []pdfToken{}
+
+	for text.Len() < limit {
+		token, ok := lexer.next()
+
+		if !ok {
+			break
+		}
+
+		if token.kind != pdfOperator {
+			operands = append(operands, token)
+			continue
+		}
+
+		switch token.value {
+		case "Tj":
+			writeOperandText(&text, operands)
+		case "'", "\"":
+			text.WriteString("\n")
+			writeOperandText(&text, operands)
+		case "TJ":
+			writeOperandText(&text, operands)
+		case "T*", "ET":
+			text.WriteString("\n")
+		case "Td", "TD":
+			if len(operands) >= 2 && operands[len(operands)-1].number != 0 {
+				text.WriteString("\n")
+			} else {
+				text.WriteString(" ")
+			}
+		case "Tm":
+			text.WriteString("\n")
+		}
+
+		operands = operands[:0]
+	}
+
+	return text.String()
+}
+
+// writeOperandText writes the strings among a text operator's operands, spacing out the words
+// that TJ arrays separate with large gaps
+func writeOperandText(text *strings.Builder, operands []pdfToken) {
+
+	for _, operand := range operands {
+		switch operand.kind {
+		case pdfString:
+			text.WriteString(operand.value)
+		case pdfNumber:
+			if operand.number < -200 {
+				text.WriteString(" ")
+			}
+		}
+	}
+}
+
+type pdfTokenKind int
+
+const (
+	pdfOperator pdfTokenKind = iota
+	pdfString
+	pdfNumber
+	pdfOther
+)
+
+type pdfToken struct {
+	kind   pdfTokenKind
+	value  string
+	number float64
+}
+
+// pdfLexer splits a content stream into the operands and operators it needs
+type pdfLexer struct {
+	data []byte
+	pos  int
+}
+
+func (lexer *pdfLexer) next() (pdfToken, bool) {
+
+	for lexer.pos < len(lexer.data) {
+		c := lexer.data[lexer.pos]
+
+		switch {
+		case isPDFSpace(c):
+			lexer.pos++
+		case c == '%':
+			for lexer.pos < len(lexer.data) && lexer.data[lexer.pos] != '\n' && lexer.data[lexer.pos] != '\r' {
+				lexer.pos++
+			}
+		case c == '(':
+			return pdfToken{kind: pdfString, value: lexer.literalString()}, true
+		case c == '<' && lexer.peek(1) == '<', c == '>' && lexer.peek(1) == '>':
+			lexer.pos += 2
+			return pdfToken{kind: pdfOther}, true
+		case c == '<':
+			return pdfToken{kind: pdfString, value: lexer.hexString()}, true
+		case c == '[' || c == ']' || c == '{' || c == '}' || c == '>' || c == ')':
+			lexer.pos++
+			return pdfToken{kind: pdfOther}, true
+		case c == '/':
+			lexer.pos++
+			lexer.word()
+			return pdfToken{kind: pdfOther}, true
+		default:
+			word := lexer.word()
+
+			if word == "" {
+				lexer.pos++
+				continue
+			}
+
+			if number, err := strconv.ParseFloat(word, 64); err == nil {
+				return pdfToken{kind: pdfNumber, number: number}, true
+			}
+
+			return pdfToken{kind: pdfOperator, value: word}, true
+		}
+	}
+
+	return pdfToken{}, false
+}
+
+func (lexer *pdfLexer) peek(offset int) byte {
+
+	if lexer.pos+offset < len(lexer.data) {
+		return lexer.data[lexer.pos+offset]
+	}
+
+	return 0
+}
+
+func (lexer *pdfLexer) word() string {
+
+	start := lexer.pos
+
+	for lexer.pos < len(lexer.data) && !isPDFSpace(lexer.data[lexer.pos]) && !isPDFDelimiter(lexer.data[lexer.pos]) {
+		lexer.pos++
+	}
+
+	return string(lexer.data[start:lexer.pos])
+}
+
+// literalString reads a (string), with its escapes and balanced parentheses
+func (lexer *pdfLexer) literalString() string {
+
+	var value []byte
+	depth := 0
+	lexer.pos++
+
+	for lexer.pos < len(lexer.data) {
+		c := lexer.data[lexer.pos]
+		lexer.pos++
+
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				return decodePDFText(value)
+			}
+			depth--
+		case '\\':
+			if lexer.pos >= len(lexer.data) {
+				continue
+			}
+
+			escaped := lexer.data[lexer.pos]
+			lexer.pos++
+
+			switch escaped {
+			case 'n', 'r':
+				c = ' '
+			case 't':
+				c = '\t'
+			case 'b', 'f':
+				continue
+			case '\r', '\n':
+				continue
+			case '0', '1', '2', '3', '4', '5', '6', '7':
+				code := int(escaped - '0')
+
+				for i := 0; i < 2 && lexer.pos < len(lexer.data) && lexer.data[lexer.pos] >= '0' && lexer.data[lexer.pos] <= '7'; i++ {
+					code = code*8 + int(lexer.data[lexer.pos]-'0')
+					lexer.pos++
+				}
+
+				c = byte(code)
+			default:
+				c = escaped
+			}
+		}
+
+		value = append(value, c)
+	}
+
+	return decodePDFText(value)
+}
+
+// hexString reads a <hex string>
+func (lexer *pdfLexer) hexString() string {
+
+	var value []byte
+	digits := []byte{}
+	lexer.pos++
+
+	for lexer.pos < len(lexer.data) && lexer.data[lexer.pos] != '>' {
+		c := lexer.data[lexer.pos]
+		lexer.pos++
+
+		if isPDFSpace(c) {
+			continue
+		}
+
+		digits = append(digits, c)
+
+		if len(digits) == 2 {
+			if b, err := strconv.ParseUint(string(digits), 16, 8); err == nil {
+				value = append(value, byte(b))
+			}
+			digits = digits[:0]
+		}
+	}
+
+	lexer.pos++
+
+	if len(digits) == 1 {
+		if b, err := strconv.ParseUint(string(digits)+"0", 16, 8); err == nil {
+			value = append(value, byte(b))
+		}
+	}
+
+	return decodePDFText(value)
+}
+
+// decodePDFText reads bytes shown by a simple font as Latin-1, and two byte codes that are
+// plainly UTF-16 as such
+func decodePDFText(value []byte) string {
+
+	if len(value) >= 2 && len(value)%2 == 0 && (value[0] == 0 || (value[0] == 0xfe && value[1] == 0xff)) {
+		if value[0] == 0xfe {
+			value = value[2:]
+		}
+
+		runes := make([]rune, 0, len(value)/2)
+
+		for i := 0; i+1 < len(value); i += 2 {
+			runes = append(runes, rune(value[i])<<8|rune(value[i+1]))
+		}
+
+		return string(runes)
+	}
+
+	runes := make([]rune, len(value))
+
+	for i, b := range value {
+		runes[i] = rune(b)
+	}
+
+	return string(runes)
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isPDFDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
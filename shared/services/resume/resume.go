@@ -0,0 +1,99 @@
+// Package resume reads the text out of resumes uploaded as PDF or DOCX, and picks out the work
+// history and skills in it. Everything runs locally; nothing is sent to a parsing service.
+package resume
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// Content types of the resumes we accept
+const (
+	ContentTypePDF  = "application/pdf"
+	ContentTypeDOCX = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+)
+
+// MaxTextLength caps how many characters of text are kept from one resume
+const MaxTextLength = 100000
+
+// ErrUnsupportedType is returned for files that aren't a PDF or a Word document
+var ErrUnsupportedType = errors.New("resume: unsupported file type")
+
+// DetectType works out from its content whether data is a PDF or a DOCX file, whatever it's named
+func DetectType(data []byte) (string, error) {
+
+	if bytes.HasPrefix(data, []byte("%PDF-")) {
+		return ContentTypePDF, nil
+	}
+
+	if http.DetectContentType(data) == "application/zip" {
+		reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+
+		if err == nil && findZipFile(reader, docxDocument) != nil {
+			return ContentTypeDOCX, nil
+		}
+	}
+
+	return "", ErrUnsupportedType
+}
+
+// ExtractText returns the text of a resume of the given content type, one line per line or
+// paragraph of the document
+func ExtractText(data []byte, contentType string) (string, error) {
+
+	var text string
+	var err error
+
+	switch contentType {
+	case ContentTypePDF:
+		text, err = extractPDF(data)
+	case ContentTypeDOCX:
+		text, err = extractDOCX(data)
+	default:
+		return "", ErrUnsupportedType
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return tidy(text), nil
+}
+
+// tidy collapses runs of spaces, drops blank lines and invalid UTF-8, and caps the length
+func tidy(text string) string {
+
+	text = strings.ToValidUTF8(text, "")
+	lines := []string{}
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.Join(strings.Fields(line), " ")
+
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	text = strings.Join(lines, "\n")
+
+	if utf8.RuneCountInString(text) > MaxTextLength {
+		text = string([]rune(text)[:MaxTextLength])
+	}
+
+	return text
+}
+
+func findZipFile(reader *zip.Reader, name string) *zip.File {
+
+	for _, file := range reader.File {
+		if file.Name == name {
+			return file
+		}
+	}
+
+	return nil
+}
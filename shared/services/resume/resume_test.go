@@ -0,0 +1,151 @@
+package resume_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"autumnomous-jobs-applicant-api/shared/services/resume"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newDOCX(t *testing.T, paragraphs ...string) []byte {
+
+	var buffer bytes.Buffer
+	writer := zip.NewWriter(&buffer)
+
+	part, err := writer.Create("[Content_Types].xml")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	part.Write([]byte(`<?xml version="1.0"?><Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"/>`))
+
+	part, err = writer.Create("word/document.xml")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := `<?xml version="1.0"?><w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>`
+
+	for _, paragraph := range paragraphs {
+		body += `<w:p><w:r><w:t xml:space="preserve">` + paragraph + `</w:t></w:r></w:p>`
+	}
+
+	part.Write([]byte(body + `</w:body></w:document>`))
+
+	if err = writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buffer.Bytes()
+}
+
+func newPDF(t *testing.T, content string) []byte {
+
+	var compressed bytes.Buffer
+	writer := zlib.NewWriter(&compressed)
+	writer.Write([]byte(content))
+	writer.Close()
+
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.4\n")
+	pdf.WriteString("1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+	fmt.Fprintf(&pdf, "4 0 obj\n<< /Length %d /Filter /FlateDecode >>\nstream\n", compressed.Len())
+	pdf.Write(compressed.Bytes())
+	pdf.WriteString("\nendstream\nendobj\n")
+	pdf.WriteString("5 0 obj\n<< /Subtype /Image /Length 4 /Filter /DCTDecode >>\nstream\nBT\xff\xd8\nendstream\nendobj\n")
+	pdf.WriteString("%%EOF\n")
+
+	return pdf.Bytes()
+}
+
+func Test_DetectType(t *testing.T) {
+	assert := assert.New(t)
+
+	contentType, err := resume.DetectType(newPDF(t, "BT ET"))
+	assert.Nil(err)
+	assert.Equal(resume.ContentTypePDF, contentType)
+
+	contentType, err = resume.DetectType(newDOCX(t, "Jo Bloggs"))
+	assert.Nil(err)
+	assert.Equal(resume.ContentTypeDOCX, contentType)
+
+	var buffer bytes.Buffer
+	writer := zip.NewWriter(&buffer)
+	part, _ := writer.Create("notes.txt")
+	part.Write([]byte("not a resume"))
+	writer.Close()
+
+	for _, data := range [][]byte{buffer.Bytes(), []byte("plain text"), []byte("\x89PNG\r\n\x1a\n")} {
+		_, err = resume.DetectType(data)
+		assert.Equal(resume.ErrUnsupportedType, err)
+	}
+}
+
+func Test_ExtractText_DOCX(t *testing.T) {
+	assert := assert.New(t)
+
+	text, err := resume.ExtractText(newDOCX(t, "Jo Bloggs", "", "Experience", "Engineer at Acme &amp; Co   Jan 2019 - Present"), resume.ContentTypeDOCX)
+
+	assert.Nil(err)
+	assert.Equal("Jo Bloggs\nExperience\nEngineer at Acme & Co Jan 2019 - Present", text)
+}
+
+func Test_ExtractText_PDF(t *testing.T) {
+	assert := assert.New(t)
+
+	content := "BT /F1 12 Tf 72 720 Td (Jo Bloggs) Tj 0 -14 Td [(Engin) 20 (eer) -300 (\\(Acme\\))] TJ T* <4A616E20323031392D50726573656E74> Tj ET"
+
+	text, err := resume.ExtractText(newPDF(t, content), resume.ContentTypePDF)
+
+	assert.Nil(err)
+	assert.Equal("Jo Bloggs\nEngineer (Acme)\nJan 2019-Present", text)
+}
+
+func Test_ExtractText_PDFBomb(t *testing.T) {
+	assert := assert.New(t)
+
+	// a megabyte of text that compresses to almost nothing, repeated in hundreds of streams
+	var compressed bytes.Buffer
+	writer := zlib.NewWriter(&compressed)
+	writer.Write([]byte("BT (" + strings.Repeat("word ", 200000) + ") Tj ET"))
+	writer.Close()
+
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.4\n")
+
+	for i := 0; i < 500; i++ {
+		fmt.Fprintf(&pdf, "%d 0 obj\n<< /Length %d /Filter /FlateDecode >>\nstream\n", i+1, compressed.Len())
+		pdf.Write(compressed.Bytes())
+		pdf.WriteString("\nendstream\nendobj\n")
+	}
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	text, err := resume.ExtractText(pdf.Bytes(), resume.ContentTypePDF)
+
+	runtime.ReadMemStats(&after)
+
+	assert.Nil(err)
+	assert.Equal(resume.MaxTextLength, len(text))
+	assert.Less(after.TotalAlloc-before.TotalAlloc, uint64(256<<20))
+}
+
+func Test_ExtractText_Unsupported(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := resume.ExtractText([]byte("plain text"), "text/plain")
+	assert.Equal(resume.ErrUnsupportedType, err)
+
+	_, err = resume.ExtractText([]byte("not a zip"), resume.ContentTypeDOCX)
+	assert.NotNil(err)
+}
@@ -0,0 +1,182 @@
+package encryption
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"sync"
+)
+
+// commonPasswords are always treated as breached, even when no list is configured
+var commonPasswords = []string{
+	"123456", "123456789", "12345678", "1234567890", "password", "password1", "password123",
+	"qwerty", "qwerty123", "qwertyuiop", "abc123", "111111", "123123", "1q2w3e4r", "iloveyou",
+	"admin", "welcome", "welcome1", "letmein", "monkey", "dragon", "football", "baseball",
+	"sunshine", "princess", "shadow", "superman", "trustno1", "passw0rd", "p@ssw0rd",
+	"Password1", "Password123", "Password1!", "Welcome1", "Qwerty123", "changeme", "starwars",
+}
+
+var breachedPasswords *BloomFilter
+var breachedPasswordsErr error
+var breachedPasswordsOnce sync.Once
+
+// BloomFilter is a compact set that can answer "definitely not present" or "probably present".
+// It lets us hold millions of breached password hashes in a few megabytes.
+type BloomFilter struct {
+	bits   []uint64
+	size   uint64
+	hashes uint64
+}
+
+// NewBloomFilter sizes a filter for n entries with the given false positive rate
+func NewBloomFilter(n int, falsePositiveRate float64) *BloomFilter {
+
+	if n < 1 {
+		n = 1
+	}
+
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		falsePositiveRate = 0.001
+	}
+
+	size := uint64(math.Ceil(-float64(n) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	hashes := uint64(math.Max(1, math.Round(float64(size)/float64(n)*math.Ln2)))
+
+	return &BloomFilter{bits: make([]uint64, (size+63)/64), size: size, hashes: hashes}
+}
+
+// locations uses double hashing to derive the filter's bit positions for key
+func (filter *BloomFilter) locations(key string) []uint64 {
+
+	sum := sha256.Sum256([]byte(key))
+	h1 := binary.BigEndian.Uint64(sum[0:8])
+	h2 := binary.BigEndian.Uint64(sum[8:16]) | 1
+
+	locations := make([]uint64, filter.hashes)
+
+	for i := range locations {
+		locations[i] = (h1 + uint64(i)*h2) % filter.size
+	}
+
+	return locations
+}
+
+func (filter *BloomFilter) Add(key string) {
+	for _, location := range filter.locations(key) {
+		filter.bits[location/64] |= 1 << (location % 64)
+	}
+}
+
+func (filter *BloomFilter) Contains(key string) bool {
+	for _, location := range filter.locations(key) {
+		if filter.bits[location/64]&(1<<(location%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// BreachedPasswordKey is the upper case hex SHA-1 of password, the form breached password lists
+// such as Have I Been Pwned publish, so the list never has to hold plain text passwords
+func BreachedPasswordKey(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// breachedListKey reads one line of a breached password list. Lines are either a plain password
+// or a SHA-1 hash optionally followed by ":count" as in the Have I Been Pwned downloads.
+func breachedListKey(line string) string {
+
+	line = strings.TrimSpace(line)
+
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ""
+	}
+
+	hash := line
+	if colon := strings.Index(hash, ":"); colon >= 0 {
+		hash = hash[:colon]
+	}
+
+	if len(hash) == 40 {
+		if _, err := hex.DecodeString(hash); err == nil {
+			return strings.ToUpper(hash)
+		}
+	}
+
+	return BreachedPasswordKey(line)
+}
+
+// LoadBreachedPasswords builds a filter from the list in r plus commonPasswords. n is the expected
+// number of entries; it only affects the false positive rate.
+func LoadBreachedPasswords(r io.Reader, n int) (*BloomFilter, error) {
+
+	filter := NewBloomFilter(n+len(commonPasswords), 0.001)
+
+	for _, password := range commonPasswords {
+		filter.Add(BreachedPasswordKey(password))
+	}
+
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		if key := breachedListKey(scanner.Text()); key != "" {
+			filter.Add(key)
+		}
+	}
+
+	return filter, scanner.Err()
+}
+
+// LoadBreachedPasswordsFile loads the list at path, counting its lines first to size the filter
+func LoadBreachedPasswordsFile(path string) (*BloomFilter, error) {
+
+	file, err := os.Open(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	lines := 0
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		lines++
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	return LoadBreachedPasswords(file, lines)
+}
+
+// GetBreachedPasswords loads the list at BREACHED_PASSWORDS_FILE once. Without one only
+// commonPasswords are checked.
+func GetBreachedPasswords() (*BloomFilter, error) {
+
+	breachedPasswordsOnce.Do(func() {
+		path := os.Getenv("BREACHED_PASSWORDS_FILE")
+
+		if path == "" {
+			breachedPasswords, breachedPasswordsErr = LoadBreachedPasswords(strings.NewReader(""), 0)
+			return
+		}
+
+		breachedPasswords, breachedPasswordsErr = LoadBreachedPasswordsFile(path)
+	})
+
+	return breachedPasswords, breachedPasswordsErr
+}
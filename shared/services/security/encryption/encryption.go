@@ -1,55 +1,86 @@
 package encryption
 
 import (
-	"math/rand"
-	"time"
-
-	"golang.org/x/crypto/bcrypt"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 )
 
-// bcrypt stuff
-
+// HashPassword hashes password with CurrentHasher
 func HashPassword(password []byte) ([]byte, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword(password, bcrypt.DefaultCost)
-
-	return hashedPassword, err
+	return CurrentHasher().Hash(password)
 }
 
+// CompareHashes reports whether inputpassword matches a hash from any of the known hashers
 func CompareHashes(dbhashword []byte, inputpassword []byte) bool {
 
-	err := bcrypt.CompareHashAndPassword(dbhashword, inputpassword)
+	match, _, err := VerifyPassword(dbhashword, inputpassword)
 
 	if err != nil {
 		return false
 	}
 
-	return true
+	return match
+}
+
+// HashToken returns the hex encoded SHA-256 digest of a token so only the digest needs to be stored
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Seal encrypts plaintext with AES-GCM under a 16, 24 or 32 byte key for storing secrets we need to read back
+func Seal(key, plaintext []byte) (string, error) {
+
+	block, err := aes.NewCipher(key)
+
+	if err != nil {
+		return "", err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
 
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, plaintext, nil)), nil
 }
 
-func GeneratePassword(length int) []byte {
-	// from https://yourbasic.org/golang/generate-random-string/
-	rand.Seed(time.Now().UnixNano())
-	digits := "0123456789"
-	specials := "~=+%^*/()[]{}/!@#$?|"
-	all := "ABCDEFGHIJKLMNOPQRSTUVWXYZ" +
-		"abcdefghijklmnopqrstuvwxyz" +
-		digits + specials
+// Open decrypts a value produced by Seal
+func Open(key []byte, sealed string) ([]byte, error) {
+
+	data, err := base64.StdEncoding.DecodeString(sealed)
+
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
 
-	if length == 0 {
-		length = 8
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+
+	if err != nil {
+		return nil, err
 	}
 
-	buf := make([]byte, length)
-	buf[0] = digits[rand.Intn(len(digits))]
-	buf[1] = specials[rand.Intn(len(specials))]
-	for i := 2; i < length; i++ {
-		buf[i] = all[rand.Intn(len(all))]
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("sealed value is too short")
 	}
-	rand.Shuffle(len(buf), func(i, j int) {
-		buf[i], buf[j] = buf[j], buf[i]
-	})
-	str := string(buf) // E.g. "3i[g0|)z"
 
-	return []byte(str)
+	return gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
 }
@@ -0,0 +1,275 @@
+package encryption
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Hasher hashes passwords into a self-describing string. The string starts with the hasher's
+// identifier and records the parameters used, so hashes made under old settings can still be
+// verified and then spotted for rehashing.
+type Hasher interface {
+	// ID names the algorithm, e.g. "bcrypt" or "argon2id"
+	ID() string
+
+	// Recognizes reports whether hash was produced by this algorithm
+	Recognizes(hash []byte) bool
+
+	Hash(password []byte) ([]byte, error)
+	Verify(hash, password []byte) (bool, error)
+
+	// NeedsRehash reports whether hash was made with parameters other than the hasher's own
+	NeedsRehash(hash []byte) bool
+}
+
+// BcryptHasher produces the standard "$2a$<cost>$..." strings
+type BcryptHasher struct {
+	Cost int
+}
+
+func (hasher BcryptHasher) ID() string {
+	return "bcrypt"
+}
+
+func (hasher BcryptHasher) Recognizes(hash []byte) bool {
+	return strings.HasPrefix(string(hash), "$2")
+}
+
+func (hasher BcryptHasher) Hash(password []byte) ([]byte, error) {
+	return bcrypt.GenerateFromPassword(password, hasher.Cost)
+}
+
+func (hasher BcryptHasher) Verify(hash, password []byte) (bool, error) {
+
+	err := bcrypt.CompareHashAndPassword(hash, password)
+
+	if err == bcrypt.ErrMismatchedHashAndPassword {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+func (hasher BcryptHasher) NeedsRehash(hash []byte) bool {
+
+	cost, err := bcrypt.Cost(hash)
+
+	return err != nil || cost != hasher.Cost
+}
+
+// Argon2idHasher produces PHC format strings: "$argon2id$v=19$m=<KiB>,t=<iterations>,p=<threads>$<salt>$<key>"
+type Argon2idHasher struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// maxArgon2Memory, maxArgon2Iterations, maxArgon2Parallelism and maxArgon2KeyLength bound the
+// parameters read back from a stored hash, so a bad row can't make verifying it exhaust the server
+const (
+	maxArgon2Memory      = 256 * 1024
+	maxArgon2Iterations  = 16
+	maxArgon2Parallelism = 16
+	maxArgon2KeyLength   = 128
+)
+
+// DefaultArgon2idHasher follows the OWASP recommended minimum of 19 MiB, 2 iterations and 1 thread
+var DefaultArgon2idHasher = Argon2idHasher{
+	Memory:      19 * 1024,
+	Iterations:  2,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+func (hasher Argon2idHasher) ID() string {
+	return "argon2id"
+}
+
+func (hasher Argon2idHasher) Recognizes(hash []byte) bool {
+	return strings.HasPrefix(string(hash), "$argon2id$")
+}
+
+func (hasher Argon2idHasher) Hash(password []byte) ([]byte, error) {
+
+	salt := make([]byte, hasher.SaltLength)
+
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	key := argon2.IDKey(password, salt, hasher.Iterations, hasher.Memory, hasher.Parallelism, hasher.KeyLength)
+
+	encoded := fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, hasher.Memory, hasher.Iterations, hasher.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+
+	return []byte(encoded), nil
+}
+
+// decodeArgon2id returns the parameters, salt and key stored in hash
+func decodeArgon2id(hash []byte) (Argon2idHasher, []byte, []byte, error) {
+
+	var params Argon2idHasher
+	var version int
+
+	parts := strings.Split(string(hash), "$")
+
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, errors.New("invalid argon2id hash")
+	}
+
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params, nil, nil, err
+	}
+
+	if version != argon2.Version {
+		return params, nil, nil, errors.New("unsupported argon2 version")
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, err
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+
+	if err != nil {
+		return params, nil, nil, err
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+
+	if err != nil {
+		return params, nil, nil, err
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+
+	if params.Memory == 0 || params.Memory > maxArgon2Memory ||
+		params.Iterations == 0 || params.Iterations > maxArgon2Iterations ||
+		params.Parallelism == 0 || params.Parallelism > maxArgon2Parallelism ||
+		len(salt) == 0 || len(key) == 0 || len(key) > maxArgon2KeyLength {
+		return params, nil, nil, errors.New("argon2id hash parameters out of range")
+	}
+
+	return params, salt, key, nil
+}
+
+func (hasher Argon2idHasher) Verify(hash, password []byte) (bool, error) {
+
+	params, salt, key, err := decodeArgon2id(hash)
+
+	if err != nil {
+		return false, err
+	}
+
+	computed := argon2.IDKey(password, salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+
+	return subtle.ConstantTimeCompare(key, computed) == 1, nil
+}
+
+func (hasher Argon2idHasher) NeedsRehash(hash []byte) bool {
+
+	params, _, _, err := decodeArgon2id(hash)
+
+	return err != nil || params != hasher
+}
+
+var currentHasher Hasher
+var currentHasherOnce sync.Once
+
+// knownHashers can verify every format we have ever stored
+var knownHashers = []Hasher{BcryptHasher{}, Argon2idHasher{}}
+
+// CurrentHasher returns the hasher new passwords are hashed with. PASSWORD_HASHER picks
+// "bcrypt" (the default, at BCRYPT_COST) or "argon2id" (with ARGON2_MEMORY in KiB,
+// ARGON2_ITERATIONS and ARGON2_PARALLELISM, each ignored above the most a stored hash may use).
+func CurrentHasher() Hasher {
+
+	currentHasherOnce.Do(func() {
+		if currentHasher != nil {
+			return
+		}
+
+		switch os.Getenv("PASSWORD_HASHER") {
+		case "argon2id":
+			hasher := DefaultArgon2idHasher
+
+			if n, err := strconv.ParseUint(os.Getenv("ARGON2_MEMORY"), 10, 32); err == nil && n > 0 && n <= maxArgon2Memory {
+				hasher.Memory = uint32(n)
+			}
+
+			if n, err := strconv.ParseUint(os.Getenv("ARGON2_ITERATIONS"), 10, 32); err == nil && n > 0 && n <= maxArgon2Iterations {
+				hasher.Iterations = uint32(n)
+			}
+
+			if n, err := strconv.ParseUint(os.Getenv("ARGON2_PARALLELISM"), 10, 8); err == nil && n > 0 && n <= maxArgon2Parallelism {
+				hasher.Parallelism = uint8(n)
+			}
+
+			currentHasher = hasher
+		default:
+			hasher := BcryptHasher{Cost: bcrypt.DefaultCost}
+
+			if n, err := strconv.Atoi(os.Getenv("BCRYPT_COST")); err == nil && n >= bcrypt.MinCost && n <= bcrypt.MaxCost {
+				hasher.Cost = n
+			}
+
+			currentHasher = hasher
+		}
+	})
+
+	return currentHasher
+}
+
+// SetHasher replaces the hasher new passwords are hashed with
+func SetHasher(hasher Hasher) {
+	currentHasherOnce.Do(func() {})
+	currentHasher = hasher
+}
+
+// VerifyPassword checks password against hash using whichever hasher produced it. rehash is
+// true when the password matched but hash should be replaced with one from CurrentHasher. A hash
+// that is empty, unrecognised or malformed is logged and never matches, so it fails like any
+// other wrong password. err is kept for hashers that can fail for other reasons.
+func VerifyPassword(hash, password []byte) (match bool, rehash bool, err error) {
+
+	for _, hasher := range knownHashers {
+
+		if !hasher.Recognizes(hash) {
+			continue
+		}
+
+		match, err = hasher.Verify(hash, password)
+
+		if err != nil {
+			log.Printf("unusable %s password hash: %v", hasher.ID(), err)
+			return false, false, nil
+		}
+
+		if !match {
+			return false, false, nil
+		}
+
+		current := CurrentHasher()
+
+		return true, current.ID() != hasher.ID() || current.NeedsRehash(hash), nil
+	}
+
+	log.Println("unrecognised password hash format")
+
+	return false, false, nil
+}
@@ -0,0 +1,135 @@
+package encryption_test
+
+import (
+	"strings"
+	"testing"
+
+	"autumnomous-jobs-applicant-api/shared/services/security/encryption"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// a light argon2id configuration keeps the tests fast
+var testArgon2idHasher = encryption.Argon2idHasher{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func Test_Hashers_RoundTrip(t *testing.T) {
+	assert := assert.New(t)
+
+	hashers := []encryption.Hasher{encryption.BcryptHasher{Cost: bcrypt.MinCost}, testArgon2idHasher}
+
+	for _, hasher := range hashers {
+		hash, err := hasher.Hash([]byte("correct horse"))
+		assert.Nil(err)
+		assert.True(hasher.Recognizes(hash), hasher.ID())
+
+		match, err := hasher.Verify(hash, []byte("correct horse"))
+		assert.Nil(err)
+		assert.True(match, hasher.ID())
+
+		match, err = hasher.Verify(hash, []byte("wrong horse"))
+		assert.Nil(err)
+		assert.False(match, hasher.ID())
+
+		assert.False(hasher.NeedsRehash(hash), hasher.ID())
+	}
+}
+
+func Test_Argon2idHasher_Format(t *testing.T) {
+	assert := assert.New(t)
+
+	hash, err := testArgon2idHasher.Hash([]byte("correct horse"))
+	assert.Nil(err)
+	assert.True(strings.HasPrefix(string(hash), "$argon2id$v=19$m=1024,t=1,p=1$"))
+
+	// the same password gets a different salt each time
+	other, _ := testArgon2idHasher.Hash([]byte("correct horse"))
+	assert.NotEqual(hash, other)
+
+	_, err = testArgon2idHasher.Verify([]byte("$argon2id$v=19$garbage"), []byte("correct horse"))
+	assert.NotNil(err)
+}
+
+func Test_Hashers_NeedsRehash(t *testing.T) {
+	assert := assert.New(t)
+
+	hash, _ := encryption.BcryptHasher{Cost: bcrypt.MinCost}.Hash([]byte("correct horse"))
+	assert.True(encryption.BcryptHasher{Cost: bcrypt.MinCost + 1}.NeedsRehash(hash))
+
+	hash, _ = testArgon2idHasher.Hash([]byte("correct horse"))
+
+	stronger := testArgon2idHasher
+	stronger.Iterations = 2
+	assert.True(stronger.NeedsRehash(hash))
+}
+
+func Test_VerifyPassword(t *testing.T) {
+	assert := assert.New(t)
+
+	defer encryption.SetHasher(encryption.BcryptHasher{Cost: bcrypt.DefaultCost})
+
+	encryption.SetHasher(encryption.BcryptHasher{Cost: bcrypt.MinCost})
+
+	legacy, err := encryption.HashPassword([]byte("correct horse"))
+	assert.Nil(err)
+
+	match, rehash, err := encryption.VerifyPassword(legacy, []byte("correct horse"))
+	assert.Nil(err)
+	assert.True(match)
+	assert.False(rehash)
+
+	// moving to argon2id flags bcrypt hashes for upgrade, but they still verify
+	encryption.SetHasher(testArgon2idHasher)
+
+	match, rehash, err = encryption.VerifyPassword(legacy, []byte("correct horse"))
+	assert.Nil(err)
+	assert.True(match)
+	assert.True(rehash)
+
+	// a wrong password is never flagged
+	match, rehash, err = encryption.VerifyPassword(legacy, []byte("wrong horse"))
+	assert.Nil(err)
+	assert.False(match)
+	assert.False(rehash)
+
+	upgraded, err := encryption.HashPassword([]byte("correct horse"))
+	assert.Nil(err)
+	assert.True(encryption.CompareHashes(upgraded, []byte("correct horse")))
+
+	// a hash we can't read is a failed match rather than an error, so login fails normally
+	for _, unusable := range []string{"plaintext", "", "$argon2id$v=19$broken"} {
+		match, rehash, err = encryption.VerifyPassword([]byte(unusable), []byte(unusable))
+		assert.Nil(err)
+		assert.False(match)
+		assert.False(rehash)
+	}
+
+	assert.False(encryption.CompareHashes([]byte("plaintext"), []byte("plaintext")))
+}
+
+func Test_Argon2idHasher_UnsafeParameters(t *testing.T) {
+	assert := assert.New(t)
+
+	salt := "c2FsdHNhbHRzYWx0c2FsdA"
+	key := "a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2U"
+
+	// parameters that would panic argon2 or use unbounded memory are refused without running it
+	for _, params := range []string{"m=1024,t=1,p=0", "m=1024,t=0,p=1", "m=0,t=1,p=1", "m=4294967295,t=1,p=1", "m=1024,t=1000000,p=1"} {
+		hash := []byte("$argon2id$v=19$" + params + "$" + salt + "$" + key)
+
+		assert.NotPanics(func() {
+			match, err := encryption.Argon2idHasher{}.Verify(hash, []byte("password"))
+			assert.NotNil(err, params)
+			assert.False(match, params)
+		}, params)
+
+		match, _, err := encryption.VerifyPassword(hash, []byte("password"))
+		assert.Nil(err)
+		assert.False(match)
+	}
+
+	for _, hash := range []string{"$argon2id$v=19$m=1024,t=1,p=1$$" + key, "$argon2id$v=19$m=1024,t=1,p=1$" + salt + "$"} {
+		_, err := encryption.Argon2idHasher{}.Verify([]byte(hash), []byte("password"))
+		assert.NotNil(err, hash)
+	}
+}
@@ -0,0 +1,187 @@
+package encryption
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Codes identifying why a password was rejected, so the frontend can show its own wording
+const (
+	PasswordTooShort     = "too-short"
+	PasswordTooLong      = "too-long"
+	PasswordTooWeak      = "too-weak"
+	PasswordPersonalInfo = "contains-personal-info"
+	PasswordBreached     = "breached"
+)
+
+// PasswordViolation is one reason a password doesn't meet the policy
+type PasswordViolation struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// PasswordOwner is what we know about the person choosing the password. The password may not contain any of it.
+type PasswordOwner struct {
+	Email     string
+	FirstName string
+	LastName  string
+}
+
+// PasswordPolicy is the set of rules new passwords are checked against
+type PasswordPolicy struct {
+	MinLength int
+	MaxLength int
+
+	// MinEntropy is the minimum estimated strength in bits, see EstimateEntropy
+	MinEntropy float64
+
+	// Breached, when set, rejects passwords found in a list of known breached passwords
+	Breached *BloomFilter
+}
+
+// DefaultPasswordPolicy is used when PASSWORD_MIN_LENGTH and PASSWORD_MIN_ENTROPY aren't set.
+// MaxLength stops very long inputs reaching bcrypt, which ignores anything past 72 bytes.
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength:  8,
+	MaxLength:  72,
+	MinEntropy: 30,
+}
+
+// PasswordPolicyFromEnv returns DefaultPasswordPolicy with any limits set in the environment
+// and the breached password list from GetBreachedPasswords
+func PasswordPolicyFromEnv() (PasswordPolicy, error) {
+
+	policy := DefaultPasswordPolicy
+
+	if n, err := strconv.Atoi(os.Getenv("PASSWORD_MIN_LENGTH")); err == nil && n > 0 {
+		policy.MinLength = n
+	}
+
+	if f, err := strconv.ParseFloat(os.Getenv("PASSWORD_MIN_ENTROPY"), 64); err == nil && f >= 0 {
+		policy.MinEntropy = f
+	}
+
+	breached, err := GetBreachedPasswords()
+
+	if err != nil {
+		return policy, err
+	}
+
+	policy.Breached = breached
+
+	return policy, nil
+}
+
+// Check returns every rule password breaks, or nil when it is acceptable
+func (policy PasswordPolicy) Check(password string, owner PasswordOwner) []PasswordViolation {
+
+	var violations []PasswordViolation
+
+	length := len([]rune(password))
+
+	if length < policy.MinLength {
+		violations = append(violations, PasswordViolation{Code: PasswordTooShort, Message: fmt.Sprintf("Password must be at least %d characters.", policy.MinLength)})
+	}
+
+	if policy.MaxLength > 0 && len(password) > policy.MaxLength {
+		violations = append(violations, PasswordViolation{Code: PasswordTooLong, Message: fmt.Sprintf("Password must be at most %d characters.", policy.MaxLength)})
+	}
+
+	if length >= policy.MinLength && EstimateEntropy(password) < policy.MinEntropy {
+		violations = append(violations, PasswordViolation{Code: PasswordTooWeak, Message: "Password is too easy to guess. Try a longer password or mix in other kinds of characters."})
+	}
+
+	if containsPersonalInfo(password, owner) {
+		violations = append(violations, PasswordViolation{Code: PasswordPersonalInfo, Message: "Password can't contain your name or email address."})
+	}
+
+	if policy.Breached != nil && policy.Breached.Contains(BreachedPasswordKey(password)) {
+		violations = append(violations, PasswordViolation{Code: PasswordBreached, Message: "This password has appeared in a data breach. Please choose a different one."})
+	}
+
+	return violations
+}
+
+// EstimateEntropy scores a password in bits: each character is worth log2 of the size of the
+// character classes the password draws from, except characters that repeat or continue a run
+// such as "aaa", "abc" or "321", which are worth one bit
+func EstimateEntropy(password string) float64 {
+
+	characters := []rune(password)
+
+	if len(characters) == 0 {
+		return 0
+	}
+
+	pool := 0
+	var lower, upper, digit, other bool
+
+	for _, c := range characters {
+		switch {
+		case unicode.IsLower(c):
+			lower = true
+		case unicode.IsUpper(c):
+			upper = true
+		case unicode.IsDigit(c):
+			digit = true
+		default:
+			other = true
+		}
+	}
+
+	if lower {
+		pool += 26
+	}
+	if upper {
+		pool += 26
+	}
+	if digit {
+		pool += 10
+	}
+	if other {
+		pool += 33
+	}
+
+	bitsPerCharacter := math.Log2(float64(pool))
+	entropy := bitsPerCharacter
+
+	for i := 1; i < len(characters); i++ {
+		step := characters[i] - characters[i-1]
+
+		if step >= -1 && step <= 1 {
+			entropy++
+		} else {
+			entropy += bitsPerCharacter
+		}
+	}
+
+	return entropy
+}
+
+// containsPersonalInfo reports whether the password contains the owner's email, the part of it
+// before the @, or their first or last name, ignoring case. Parts shorter than 3 characters are skipped.
+func containsPersonalInfo(password string, owner PasswordOwner) bool {
+
+	password = strings.ToLower(password)
+	email := strings.ToLower(strings.TrimSpace(owner.Email))
+
+	parts := []string{email, owner.FirstName, owner.LastName}
+
+	if at := strings.Index(email, "@"); at > 0 {
+		parts = append(parts, email[:at])
+	}
+
+	for _, part := range parts {
+		part = strings.ToLower(strings.TrimSpace(part))
+
+		if len(part) >= 3 && strings.Contains(password, part) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,94 @@
+package encryption_test
+
+import (
+	"strings"
+	"testing"
+
+	"autumnomous-jobs-applicant-api/shared/services/security/encryption"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func violationCodes(violations []encryption.PasswordViolation) []string {
+	codes := []string{}
+	for _, violation := range violations {
+		codes = append(codes, violation.Code)
+	}
+	return codes
+}
+
+func Test_PasswordPolicy_Check(t *testing.T) {
+	assert := assert.New(t)
+
+	breached, err := encryption.LoadBreachedPasswords(strings.NewReader("hunter2hunter2\n5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:3730471\n"), 2)
+	assert.Nil(err)
+
+	policy := encryption.DefaultPasswordPolicy
+	policy.Breached = breached
+
+	owner := encryption.PasswordOwner{Email: "laverne.cox@amazing.com", FirstName: "Laverne", LastName: "Cox"}
+
+	tests := []struct {
+		password string
+		codes    []string
+	}{
+		{"", []string{encryption.PasswordTooShort}},
+		{"a", []string{encryption.PasswordTooShort}},
+		{"aaaaaaaaaaaa", []string{encryption.PasswordTooWeak}},
+		{"abcdefghijkl", []string{encryption.PasswordTooWeak}},
+		{strings.Repeat("kq7!", 20), []string{encryption.PasswordTooLong}},
+		{"Laverne-rules-2021", []string{encryption.PasswordPersonalInfo}},
+		{"my.LAVERNE.COX.pw", []string{encryption.PasswordPersonalInfo}},
+		{"hunter2hunter2", []string{encryption.PasswordBreached}},
+		// SHA-1 of "password" is in the list, and it's also one of the built in common passwords
+		{"password", []string{encryption.PasswordBreached}},
+		{"Password123", []string{encryption.PasswordBreached}},
+		{"correct horse battery", []string{}},
+		{"v8#Kq2!mZr", []string{}},
+	}
+
+	for _, test := range tests {
+		assert.Equal(test.codes, violationCodes(policy.Check(test.password, owner)), test.password)
+	}
+}
+
+func Test_PasswordPolicy_ShortNamesIgnored(t *testing.T) {
+	assert := assert.New(t)
+
+	// a two letter surname would otherwise rule out a large share of passwords
+	owner := encryption.PasswordOwner{Email: "jo@x.io", FirstName: "Jo", LastName: "Li"}
+
+	assert.Empty(encryption.DefaultPasswordPolicy.Check("jolly-limerick-42", owner))
+}
+
+func Test_EstimateEntropy(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(0.0, encryption.EstimateEntropy(""))
+	assert.Less(encryption.EstimateEntropy("aaaaaaaa"), encryption.EstimateEntropy("qmwnebrv"))
+	assert.Less(encryption.EstimateEntropy("12345678"), encryption.EstimateEntropy("19375286"))
+	assert.Less(encryption.EstimateEntropy("qmwnebrv"), encryption.EstimateEntropy("qmWn3b!v"))
+}
+
+func Test_BloomFilter(t *testing.T) {
+	assert := assert.New(t)
+
+	filter := encryption.NewBloomFilter(1000, 0.01)
+
+	for i := 0; i < 1000; i++ {
+		filter.Add(string(encryption.GeneratePassword(12)) + "-in")
+	}
+
+	filter.Add("present")
+	assert.True(filter.Contains("present"))
+
+	falsePositives := 0
+	for i := 0; i < 10000; i++ {
+		if filter.Contains(string(encryption.GeneratePassword(12)) + "-out") {
+			falsePositives++
+		}
+	}
+
+	// 1% expected, allow plenty of room
+	assert.Less(falsePositives, 300)
+}
@@ -0,0 +1,225 @@
+package encryption
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"strings"
+)
+
+const (
+	UppercaseLetters = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	LowercaseLetters = "abcdefghijklmnopqrstuvwxyz"
+	Digits           = "0123456789"
+	Specials         = "~=+%^*/()[]{}!@#$?|"
+
+	// TokenByteLength is the amount of randomness in tokens from NewSecureToken
+	TokenByteLength = 32
+)
+
+// CharacterClass is a set of characters and how many of them a generated password must contain
+type CharacterClass struct {
+	Characters string
+	Min        int
+}
+
+// GeneratorPolicy describes the passwords GeneratePasswordWithPolicy produces. Characters
+// beyond each class's minimum are drawn uniformly from all of the classes combined.
+type GeneratorPolicy struct {
+	Length  int
+	Classes []CharacterClass
+}
+
+// DefaultGeneratorPolicy requires at least one character from each class
+var DefaultGeneratorPolicy = GeneratorPolicy{
+	Length: 8,
+	Classes: []CharacterClass{
+		{Characters: UppercaseLetters, Min: 1},
+		{Characters: LowercaseLetters, Min: 1},
+		{Characters: Digits, Min: 1},
+		{Characters: Specials, Min: 1},
+	},
+}
+
+// TemporaryPasswordPolicy is used for the password emailed when an applicant signs up
+var TemporaryPasswordPolicy = GeneratorPolicy{
+	Length:  12,
+	Classes: DefaultGeneratorPolicy.Classes,
+}
+
+// Validate checks that a password satisfying the policy can be generated
+func (policy GeneratorPolicy) Validate() error {
+
+	if len(policy.Classes) == 0 {
+		return errors.New("generator policy has no character classes")
+	}
+
+	required := 0
+	seen := map[rune]bool{}
+
+	for _, class := range policy.Classes {
+
+		if class.Characters == "" || class.Min < 0 {
+			return errors.New("generator policy has an invalid character class")
+		}
+
+		for _, c := range class.Characters {
+			if seen[c] {
+				return errors.New("generator policy character classes overlap")
+			}
+			seen[c] = true
+		}
+
+		required += class.Min
+	}
+
+	if policy.Length <= 0 || policy.Length < required {
+		return errors.New("generator policy length is shorter than its required characters")
+	}
+
+	return nil
+}
+
+// GeneratePasswordWithPolicy returns a random password built with crypto/rand
+func GeneratePasswordWithPolicy(policy GeneratorPolicy) ([]byte, error) {
+
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	var all strings.Builder
+	password := make([]rune, 0, policy.Length)
+
+	for _, class := range policy.Classes {
+
+		all.WriteString(class.Characters)
+
+		for i := 0; i < class.Min; i++ {
+			c, err := RandomString(1, class.Characters)
+
+			if err != nil {
+				return nil, err
+			}
+
+			password = append(password, []rune(c)...)
+		}
+	}
+
+	rest, err := RandomString(policy.Length-len(password), all.String())
+
+	if err != nil {
+		return nil, err
+	}
+
+	password = append(password, []rune(rest)...)
+
+	// Fisher-Yates so the required characters don't sit at fixed positions
+	for i := len(password) - 1; i > 0; i-- {
+		j, err := randomIndex(i + 1)
+
+		if err != nil {
+			return nil, err
+		}
+
+		password[i], password[j] = password[j], password[i]
+	}
+
+	return []byte(string(password)), nil
+}
+
+// GeneratePassword returns a random password of length characters using DefaultGeneratorPolicy.
+// A length of 0 or less gives the policy's own length, and one too short to hold a character from
+// each class is raised to the shortest that can. It panics only if the system's random source fails.
+func GeneratePassword(length int) []byte {
+
+	policy := DefaultGeneratorPolicy
+
+	if length > 0 {
+		policy.Length = length
+	}
+
+	required := 0
+
+	for _, class := range policy.Classes {
+		required += class.Min
+	}
+
+	if policy.Length < required {
+		policy.Length = required
+	}
+
+	password, err := GeneratePasswordWithPolicy(policy)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return password
+}
+
+// RandomString returns length characters chosen uniformly and independently from alphabet
+func RandomString(length int, alphabet string) (string, error) {
+
+	characters := []rune(alphabet)
+
+	if len(characters) == 0 {
+		return "", errors.New("alphabet is empty")
+	}
+
+	result := make([]rune, length)
+
+	for i := range result {
+		n, err := randomIndex(len(characters))
+
+		if err != nil {
+			return "", err
+		}
+
+		result[i] = characters[n]
+	}
+
+	return string(result), nil
+}
+
+// randomIndex returns a uniform integer in [0, n). rand.Int rejects out of range samples
+// rather than reducing modulo n, so there's no bias towards the start of the alphabet.
+func randomIndex(n int) (int, error) {
+
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+
+	if err != nil {
+		return 0, err
+	}
+
+	return int(i.Int64()), nil
+}
+
+// GenerateToken returns a URL-safe random token built from byteLength bytes of crypto/rand output
+func GenerateToken(byteLength int) (string, error) {
+
+	if byteLength <= 0 {
+		byteLength = TokenByteLength
+	}
+
+	buf := make([]byte, byteLength)
+
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// NewSecureToken returns a token to send to the user, e.g. in a reset, verification or invite
+// link, along with the hash to store in its place
+func NewSecureToken() (string, string, error) {
+
+	token, err := GenerateToken(TokenByteLength)
+
+	if err != nil {
+		return "", "", err
+	}
+
+	return token, HashToken(token), nil
+}
@@ -0,0 +1,210 @@
+package encryption_test
+
+import (
+	"encoding/base64"
+	"math"
+	"strings"
+	"testing"
+	"unicode"
+
+	"autumnomous-jobs-applicant-api/shared/services/security/encryption"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// chiSquaredLimit approximates the chi-squared critical value for df degrees of freedom
+// (Wilson-Hilferty) at z standard deviations, so a uniform source fails with probability ~3e-5
+func chiSquaredLimit(df int) float64 {
+	const z = 4.0
+	k := float64(df)
+	return k * math.Pow(1-2/(9*k)+z*math.Sqrt(2/(9*k)), 3)
+}
+
+func chiSquared(counts map[rune]int, categories int, samples int) float64 {
+
+	expected := float64(samples) / float64(categories)
+	statistic := 0.0
+
+	for _, observed := range counts {
+		statistic += math.Pow(float64(observed)-expected, 2) / expected
+	}
+
+	// categories that never appeared contribute expected each
+	statistic += float64(categories-len(counts)) * expected
+
+	return statistic
+}
+
+func Test_RandomString_Uniform(t *testing.T) {
+	assert := assert.New(t)
+
+	alphabet := encryption.UppercaseLetters + encryption.LowercaseLetters + encryption.Digits
+	samples := 200000
+
+	value, err := encryption.RandomString(samples, alphabet)
+	assert.Nil(err)
+
+	counts := map[rune]int{}
+	for _, c := range value {
+		counts[c]++
+	}
+
+	statistic := chiSquared(counts, len(alphabet), samples)
+
+	assert.Len(counts, len(alphabet))
+	assert.Less(statistic, chiSquaredLimit(len(alphabet)-1))
+}
+
+func Test_RandomString_EmptyAlphabet(t *testing.T) {
+	_, err := encryption.RandomString(5, "")
+	assert.NotNil(t, err)
+}
+
+func Test_GenerateToken_Uniform(t *testing.T) {
+	assert := assert.New(t)
+
+	counts := map[rune]int{}
+	samples := 0
+	seen := map[string]bool{}
+
+	for i := 0; i < 4000; i++ {
+		token, err := encryption.GenerateToken(32)
+		assert.Nil(err)
+		assert.False(seen[token])
+		seen[token] = true
+
+		raw, err := base64.RawURLEncoding.DecodeString(token)
+		assert.Nil(err)
+		assert.Len(raw, 32)
+
+		for _, b := range raw {
+			counts[rune(b)]++
+			samples++
+		}
+	}
+
+	assert.Less(chiSquared(counts, 256, samples), chiSquaredLimit(255))
+}
+
+func Test_NewSecureToken(t *testing.T) {
+	assert := assert.New(t)
+
+	token, hash, err := encryption.NewSecureToken()
+
+	assert.Nil(err)
+	assert.NotEmpty(token)
+	assert.Equal(encryption.HashToken(token), hash)
+}
+
+func Test_GeneratePasswordWithPolicy_MeetsClasses(t *testing.T) {
+	assert := assert.New(t)
+
+	for i := 0; i < 1000; i++ {
+		password, err := encryption.GeneratePasswordWithPolicy(encryption.TemporaryPasswordPolicy)
+		assert.Nil(err)
+		assert.Len(password, encryption.TemporaryPasswordPolicy.Length)
+
+		for _, class := range encryption.TemporaryPasswordPolicy.Classes {
+			assert.True(strings.ContainsAny(string(password), class.Characters), "%s has no character from %s", password, class.Characters)
+		}
+	}
+}
+
+func Test_GeneratePasswordWithPolicy_PositionsUniform(t *testing.T) {
+	assert := assert.New(t)
+
+	// the one required digit has to be equally likely to land in any position
+	policy := encryption.GeneratorPolicy{
+		Length: 8,
+		Classes: []encryption.CharacterClass{
+			{Characters: encryption.Digits, Min: 1},
+			{Characters: encryption.LowercaseLetters},
+		},
+	}
+
+	counts := map[rune]int{}
+	samples := 0
+
+	for i := 0; i < 20000; i++ {
+		password, err := encryption.GeneratePasswordWithPolicy(policy)
+		assert.Nil(err)
+
+		for position, c := range string(password) {
+			if unicode.IsDigit(c) {
+				counts[rune(position)]++
+				samples++
+			}
+		}
+	}
+
+	assert.Less(chiSquared(counts, policy.Length, samples), chiSquaredLimit(policy.Length-1))
+}
+
+func Test_GeneratePasswordWithPolicy_CharactersUniform(t *testing.T) {
+	assert := assert.New(t)
+
+	// with no minimums every character of the combined alphabet is equally likely
+	policy := encryption.GeneratorPolicy{
+		Length: 20,
+		Classes: []encryption.CharacterClass{
+			{Characters: encryption.UppercaseLetters},
+			{Characters: encryption.Digits},
+			{Characters: encryption.Specials},
+		},
+	}
+
+	alphabet := encryption.UppercaseLetters + encryption.Digits + encryption.Specials
+	counts := map[rune]int{}
+	samples := 0
+
+	for i := 0; i < 10000; i++ {
+		password, err := encryption.GeneratePasswordWithPolicy(policy)
+		assert.Nil(err)
+
+		for _, c := range string(password) {
+			counts[c]++
+			samples++
+		}
+	}
+
+	assert.Len(counts, len(alphabet))
+	assert.Less(chiSquared(counts, len(alphabet), samples), chiSquaredLimit(len(alphabet)-1))
+}
+
+func Test_GeneratorPolicy_Validate(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Nil(encryption.DefaultGeneratorPolicy.Validate())
+
+	invalid := []encryption.GeneratorPolicy{
+		{Length: 8},
+		{Length: 2, Classes: encryption.DefaultGeneratorPolicy.Classes},
+		{Length: 8, Classes: []encryption.CharacterClass{{Characters: ""}}},
+		{Length: 8, Classes: []encryption.CharacterClass{{Characters: "abc"}, {Characters: "cde"}}},
+	}
+
+	for _, policy := range invalid {
+		_, err := encryption.GeneratePasswordWithPolicy(policy)
+		assert.NotNil(err)
+	}
+}
+
+func Test_GeneratePassword_DefaultLength(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Len(encryption.GeneratePassword(0), encryption.DefaultGeneratorPolicy.Length)
+	assert.Len(encryption.GeneratePassword(9), 9)
+}
+
+func Test_GeneratePassword_ShortLength(t *testing.T) {
+	assert := assert.New(t)
+
+	required := len(encryption.DefaultGeneratorPolicy.Classes)
+
+	// lengths that can't hold every class are raised rather than panicking
+	for _, length := range []int{1, required - 1, required} {
+		assert.Len(encryption.GeneratePassword(length), required)
+	}
+
+	assert.Len(encryption.GeneratePassword(-5), encryption.DefaultGeneratorPolicy.Length)
+}
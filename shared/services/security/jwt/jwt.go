@@ -1,17 +1,35 @@
 package jwt
 
 import (
-	"encoding/base64"
 	"errors"
 	"log"
-	"net/http"
-	"os"
-	"strings"
 	"time"
 
+	"autumnomous-jobs-applicant-api/shared/services/security/encryption"
+	"autumnomous-jobs-applicant-api/shared/services/security/principal"
+	"autumnomous-jobs-applicant-api/shared/services/security/rbac"
+
 	jwt "github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
 )
 
+const (
+	// AccessTokenLifetime is kept short since access tokens can't be revoked; clients renew them with a refresh token
+	AccessTokenLifetime = time.Minute * 15
+
+	// RefreshTokenLifetime is how long an unused refresh token can be exchanged for a new access token
+	RefreshTokenLifetime = time.Hour * 24 * 30
+
+	// MFAChallengeLifetime is how long an applicant has to enter their second factor after their password
+	MFAChallengeLifetime = time.Minute * 5
+
+	// ImpersonationLifetime is how long support can act as an applicant before minting a new token
+	ImpersonationLifetime = time.Minute * 10
+)
+
+// MFAChallengePurpose marks tokens that only prove the password step of a two-step login
+const MFAChallengePurpose = "mfa-challenge"
+
 // Token represents a JWT token
 type Token struct {
 	JWToken string `json:"token"`
@@ -37,22 +55,135 @@ type JWTData struct {
 	// https://tools.ietf.org/html/rfc7519
 	jwt.StandardClaims
 	CustomClaims map[string]string `json:"custom,omitempty"`
+
+	// Roles and the Permissions they grant, for staff accounts
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+
+	// AuthMethod records how the user authenticated to get the token
+	AuthMethod string `json:"authmethod,omitempty"`
+
+	// SessionID names the login session the token belongs to, so it can be revoked
+	SessionID string `json:"sid,omitempty"`
+
+	// IssuedAtMillis is when the token was issued in milliseconds since the epoch. iat only has
+	// whole seconds, which can't tell a token from a logout everywhere in the same second.
+	IssuedAtMillis int64 `json:"iatms,omitempty"`
+
+	// Act is set on impersonation tokens and names the staff member acting as the user (RFC 8693)
+	Act *Actor `json:"act,omitempty"`
+}
+
+// Actor is the party really behind an impersonation token
+type Actor struct {
+	Subject string `json:"sub"`
+
+	// Write is true when the actor may also make changes as the user
+	Write bool `json:"write,omitempty"`
 }
 
 func GenerateToken(userId string) (string, error) {
+	return GenerateAccessToken(userId, nil, principal.AuthMethodPassword, "")
+}
+
+// GenerateAccessToken issues an access token carrying the user's roles and their permissions, how
+// they authenticated and the session it belongs to
+func GenerateAccessToken(userId string, roles []string, authMethod, sessionID string) (string, error) {
+
+	claims := JWTData{CustomClaims: map[string]string{}, AuthMethod: authMethod, SessionID: sessionID}
+
+	if len(roles) > 0 {
+		claims.Roles = roles
+		claims.Permissions = rbac.PermissionsFor(roles)
+	}
+
+	return generateToken(claims, userId, AccessTokenLifetime)
+}
+
+// GenerateImpersonationToken issues a short-lived access token for userId that is marked as
+// being used by actorId. It never carries roles, and is read-only unless write is true.
+func GenerateImpersonationToken(userId, actorId string, write bool) (string, error) {
+
+	if actorId == "" {
+		return "", errors.New("missing actor")
+	}
 
 	claims := JWTData{
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(time.Hour * 168).Unix(), // week
-			IssuedAt:  time.Now().Unix(),
-		},
-		CustomClaims: map[string]string{
-			"user": userId,
-		},
+		CustomClaims: map[string]string{},
+		AuthMethod:   principal.AuthMethodImpersonation,
+		Act:          &Actor{Subject: actorId, Write: write},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(os.Getenv("KNIT_SIGNING_KEY")))
+	return generateToken(claims, userId, ImpersonationLifetime)
+}
+
+// GenerateMFAChallengeToken issues the short-lived token returned by Login when a second factor is still needed.
+// It can't be used as an access token.
+func GenerateMFAChallengeToken(userId string) (string, error) {
+	return generateToken(JWTData{CustomClaims: map[string]string{"purpose": MFAChallengePurpose}}, userId, MFAChallengeLifetime)
+}
+
+// ParseMFAChallengeToken returns the user an MFA challenge token was issued to
+func ParseMFAChallengeToken(inputTokenString string) (string, error) {
+
+	data, err := ParseToken(inputTokenString)
+
+	if err != nil {
+		return "", err
+	}
+
+	if data.CustomClaims["purpose"] != MFAChallengePurpose || data.CustomClaims["user"] == "" {
+		return "", errors.New("not an mfa challenge token")
+	}
+
+	return data.CustomClaims["user"], nil
+}
+
+// HasRole reports whether the token was issued to a user holding role
+func (data *JWTData) HasRole(role string) bool {
+	return rbac.Contains(data.Roles, role)
+}
+
+// HasPermission reports whether the token's roles grant permission
+func (data *JWTData) HasPermission(permission string) bool {
+	return rbac.Contains(data.Permissions, permission)
+}
+
+// IsImpersonation reports whether the token was minted for staff acting as the user
+func (data *JWTData) IsImpersonation() bool {
+	return data.Act != nil
+}
+
+// CanWrite reports whether the token may be used to change the user's data. Only read-only
+// impersonation tokens can't.
+func (data *JWTData) CanWrite() bool {
+	return data.Act == nil || data.Act.Write
+}
+
+// IsAccessToken reports whether the claims belong to a regular access token rather than a single-purpose token
+func (data *JWTData) IsAccessToken() bool {
+	return data.CustomClaims["purpose"] == ""
+}
+
+func generateToken(claims JWTData, userId string, lifetime time.Duration) (string, error) {
+
+	now := time.Now()
+
+	claims.CustomClaims["user"] = userId
+	claims.IssuedAtMillis = now.UnixNano() / int64(time.Millisecond)
+	claims.StandardClaims = jwt.StandardClaims{
+		Id:        uuid.NewString(),
+		ExpiresAt: now.Add(lifetime).Unix(),
+		IssuedAt:  now.Unix(),
+	}
+
+	keys, err := GetKeyring()
+
+	if err != nil {
+		return "", err
+	}
+
+	tokenString, err := keys.Sign(claims)
 
 	if err != nil {
 		return "", err
@@ -61,15 +192,22 @@ func GenerateToken(userId string) (string, error) {
 	return tokenString, err
 }
 
+// GenerateRefreshToken returns an opaque refresh token. Only its hash should be stored.
+func GenerateRefreshToken() (string, error) {
+	return encryption.GenerateToken(32)
+}
+
 // ParseToken parses a given JWT token
 func ParseToken(inputTokenString string) (*JWTData, error) {
 
-	claims, err := jwt.ParseWithClaims(inputTokenString, &JWTData{}, func(token *jwt.Token) (interface{}, error) {
-		if jwt.SigningMethodHS256 != token.Method && !token.Valid {
-			return nil, errors.New("invalid signing algorithm")
-		}
-		return []byte(os.Getenv("KNIT_SIGNING_KEY")), nil
-	})
+	keys, err := GetKeyring()
+
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	claims, err := jwt.ParseWithClaims(inputTokenString, &JWTData{}, keys.Keyfunc)
 
 	if err != nil {
 		log.Println(err)
@@ -80,32 +218,34 @@ func ParseToken(inputTokenString string) (*JWTData, error) {
 
 }
 
-func GetUserClaim(r *http.Request) string {
+// IssuedAtTime returns when the token was issued, to the millisecond when it says
+func (data *JWTData) IssuedAtTime() time.Time {
 
-	if r.Header.Get("Authorization") == "" {
-		log.Println(errors.New("problem with bearer token"))
-		return ""
+	if data.IssuedAtMillis > 0 {
+		return time.Unix(0, data.IssuedAtMillis*int64(time.Millisecond))
 	}
 
-	auth := strings.SplitN(r.Header.Get("Authorization"), " ", 2)
-
-	if len(auth) >= 2 {
-		authKey, err := base64.StdEncoding.DecodeString(auth[1])
-
-		if err != nil {
-			log.Println(err)
-			return ""
-		}
-
-		tokenClaims, err := ParseToken(string(authKey))
+	return time.Unix(data.IssuedAt, 0)
+}
 
-		if err != nil {
-			log.Println(err)
-			return ""
-		}
+// Principal returns the request principal the claims authenticate
+func (data *JWTData) Principal() *principal.Principal {
+
+	p := &principal.Principal{
+		ApplicantID: data.CustomClaims["user"],
+		Roles:       data.Roles,
+		Permissions: data.Permissions,
+		TokenID:     data.Id,
+		IssuedAt:    data.IssuedAtTime(),
+		ExpiresAt:   time.Unix(data.ExpiresAt, 0),
+		AuthMethod:  data.AuthMethod,
+		SessionID:   data.SessionID,
+	}
 
-		return tokenClaims.CustomClaims["user"]
+	if data.Act != nil {
+		p.ActorID = data.Act.Subject
+		p.WriteAccess = data.Act.Write
 	}
 
-	return ""
+	return p
 }
@@ -0,0 +1,135 @@
+package jwt_test
+
+import (
+	"testing"
+	"time"
+
+	"autumnomous-jobs-applicant-api/shared/services/security/jwt"
+	"autumnomous-jobs-applicant-api/shared/services/security/principal"
+	"autumnomous-jobs-applicant-api/shared/services/security/rbac"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GenerateAccessToken_Roles(t *testing.T) {
+	assert := assert.New(t)
+
+	keys, err := jwt.LoadKeyring("", "", "secret")
+	assert.Nil(err)
+	jwt.SetKeyring(keys)
+
+	token, err := jwt.GenerateAccessToken("applicant-id", []string{rbac.RoleSupport}, principal.AuthMethodMFA, "")
+	assert.Nil(err)
+
+	data, err := jwt.ParseToken(token)
+	assert.Nil(err)
+	assert.Equal("applicant-id", data.CustomClaims["user"])
+	assert.True(data.IsAccessToken())
+	assert.True(data.HasRole(rbac.RoleSupport))
+	assert.False(data.HasRole(rbac.RoleAdmin))
+	assert.True(data.HasPermission(rbac.PermissionUnlockApplicants))
+	assert.False(data.HasPermission("apikeys:manage"))
+
+	// ordinary applicants carry no roles
+	token, err = jwt.GenerateToken("applicant-id")
+	assert.Nil(err)
+
+	data, err = jwt.ParseToken(token)
+	assert.Nil(err)
+	assert.Empty(data.Roles)
+	assert.False(data.HasPermission(rbac.PermissionViewApplicants))
+}
+
+func Test_GenerateToken_IssuedAtMillis(t *testing.T) {
+	assert := assert.New(t)
+
+	keys, err := jwt.LoadKeyring("", "", "secret")
+	assert.Nil(err)
+	jwt.SetKeyring(keys)
+
+	before := time.Now().Truncate(time.Millisecond)
+
+	token, err := jwt.GenerateToken("applicant-id")
+	assert.Nil(err)
+
+	data, err := jwt.ParseToken(token)
+	assert.Nil(err)
+
+	// the issue time is kept to the millisecond, not just the second iat has
+	issuedAt := data.Principal().IssuedAt
+	assert.False(issuedAt.Before(before))
+	assert.WithinDuration(time.Now(), issuedAt, time.Second)
+	assert.Equal(data.IssuedAt, issuedAt.Unix())
+
+	// tokens from before the claim was added fall back to iat
+	data.IssuedAtMillis = 0
+	assert.Equal(time.Unix(data.IssuedAt, 0), data.IssuedAtTime())
+}
+
+func Test_GenerateImpersonationToken(t *testing.T) {
+	assert := assert.New(t)
+
+	keys, err := jwt.LoadKeyring("", "", "secret")
+	assert.Nil(err)
+	jwt.SetKeyring(keys)
+
+	token, err := jwt.GenerateImpersonationToken("applicant-id", "staff-id", false)
+	assert.Nil(err)
+
+	data, err := jwt.ParseToken(token)
+	assert.Nil(err)
+	assert.Equal("applicant-id", data.CustomClaims["user"])
+	assert.True(data.IsAccessToken())
+	assert.True(data.IsImpersonation())
+	assert.Equal("staff-id", data.Act.Subject)
+	assert.False(data.CanWrite())
+	assert.Empty(data.Roles)
+	assert.LessOrEqual(data.ExpiresAt-data.IssuedAt, int64(jwt.ImpersonationLifetime.Seconds()))
+
+	token, err = jwt.GenerateImpersonationToken("applicant-id", "staff-id", true)
+	assert.Nil(err)
+
+	data, err = jwt.ParseToken(token)
+	assert.Nil(err)
+	assert.True(data.CanWrite())
+
+	_, err = jwt.GenerateImpersonationToken("applicant-id", "", false)
+	assert.NotNil(err)
+
+	// ordinary tokens aren't impersonation and can always write
+	token, _ = jwt.GenerateToken("applicant-id")
+	data, _ = jwt.ParseToken(token)
+	assert.False(data.IsImpersonation())
+	assert.True(data.CanWrite())
+}
+
+func Test_JWTData_Principal(t *testing.T) {
+	assert := assert.New(t)
+
+	keys, err := jwt.LoadKeyring("", "", "secret")
+	assert.Nil(err)
+	jwt.SetKeyring(keys)
+
+	token, _ := jwt.GenerateAccessToken("applicant-id", []string{rbac.RoleAdmin}, principal.AuthMethodMFA, "session-id")
+	data, _ := jwt.ParseToken(token)
+
+	p := data.Principal()
+	assert.Equal("applicant-id", p.ApplicantID)
+	assert.Equal(data.Id, p.TokenID)
+	assert.Equal(principal.AuthMethodMFA, p.AuthMethod)
+	assert.Equal("session-id", p.SessionID)
+	assert.Equal(data.ExpiresAt, p.ExpiresAt.Unix())
+	assert.True(p.HasPermission(rbac.PermissionImpersonateWrite))
+	assert.False(p.IsImpersonation())
+
+	token, _ = jwt.GenerateImpersonationToken("applicant-id", "staff-id", false)
+	data, _ = jwt.ParseToken(token)
+
+	p = data.Principal()
+	assert.Equal("applicant-id", p.ApplicantID)
+	assert.Equal("staff-id", p.ActorID)
+	assert.Equal(principal.AuthMethodImpersonation, p.AuthMethod)
+	assert.Equal("", p.SessionID)
+	assert.True(p.IsImpersonation())
+	assert.False(p.CanWrite())
+}
@@ -0,0 +1,240 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	jwt "github.com/golang-jwt/jwt"
+)
+
+// LegacyKeyID identifies the shared KNIT_SIGNING_KEY secret, which tokens issued before key ids existed were signed with
+const LegacyKeyID = "legacy"
+
+// Key is one signing or verification key in the keyring
+type Key struct {
+	ID         string
+	Method     jwt.SigningMethod
+	SigningKey interface{} // nil for keys that only verify
+	VerifyKey  interface{}
+}
+
+// Keyring holds the key new tokens are signed with plus every key that tokens may still be verified with
+type Keyring struct {
+	signing *Key
+	keys    map[string]*Key
+}
+
+// JSONWebKey is the public part of a key as published in a JWKS document
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+// JSONWebKeySet is the document served at /.well-known/jwks.json
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+var (
+	keyring     *Keyring
+	keyringErr  error
+	keyringOnce sync.Once
+)
+
+// GetKeyring returns the process keyring, loading it from the environment on first use:
+// JWT_KEYS_DIR holds <kid>.pem files, JWT_SIGNING_KEY_ID picks the one to sign with, and
+// KNIT_SIGNING_KEY is kept as an HS256 key so older tokens stay valid.
+func GetKeyring() (*Keyring, error) {
+	keyringOnce.Do(func() {
+		keyring, keyringErr = LoadKeyring(os.Getenv("JWT_KEYS_DIR"), os.Getenv("JWT_SIGNING_KEY_ID"), os.Getenv("KNIT_SIGNING_KEY"))
+	})
+
+	return keyring, keyringErr
+}
+
+// SetKeyring replaces the process keyring
+func SetKeyring(k *Keyring) {
+	keyringOnce.Do(func() {})
+	keyring, keyringErr = k, nil
+}
+
+// LoadKeyring reads every .pem file in dir into a keyring. Private keys (PKCS#1 or PKCS#8 RSA, PKCS#8 Ed25519)
+// can sign and verify; public keys only verify, which is how a retired key keeps working until its tokens expire.
+// With no PEM keys the legacy secret signs, otherwise signingKeyID must name a private key.
+func LoadKeyring(dir, signingKeyID, legacySecret string) (*Keyring, error) {
+
+	k := &Keyring{keys: map[string]*Key{}}
+
+	if legacySecret != "" {
+		k.keys[LegacyKeyID] = &Key{ID: LegacyKeyID, Method: jwt.SigningMethodHS256, SigningKey: []byte(legacySecret), VerifyKey: []byte(legacySecret)}
+	}
+
+	if dir != "" {
+		files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range files {
+			data, err := ioutil.ReadFile(file)
+
+			if err != nil {
+				return nil, err
+			}
+
+			key, err := ParsePEMKey(strings.TrimSuffix(filepath.Base(file), ".pem"), data)
+
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", file, err)
+			}
+
+			k.keys[key.ID] = key
+		}
+	}
+
+	if signingKeyID == "" && dir == "" {
+		signingKeyID = LegacyKeyID
+	}
+
+	signing, ok := k.keys[signingKeyID]
+
+	if !ok || signing.SigningKey == nil {
+		return nil, fmt.Errorf("no private key found for signing key id %q", signingKeyID)
+	}
+
+	k.signing = signing
+
+	return k, nil
+}
+
+// ParsePEMKey parses an RSA or Ed25519 private or public key
+func ParsePEMKey(id string, data []byte) (*Key, error) {
+
+	block, _ := pem.Decode(data)
+
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var parsed interface{}
+	var err error
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch key := parsed.(type) {
+	case *rsa.PrivateKey:
+		return &Key{ID: id, Method: jwt.SigningMethodRS256, SigningKey: key, VerifyKey: &key.PublicKey}, nil
+	case *rsa.PublicKey:
+		return &Key{ID: id, Method: jwt.SigningMethodRS256, VerifyKey: key}, nil
+	case ed25519.PrivateKey:
+		return &Key{ID: id, Method: jwt.SigningMethodEdDSA, SigningKey: key, VerifyKey: key.Public()}, nil
+	case ed25519.PublicKey:
+		return &Key{ID: id, Method: jwt.SigningMethodEdDSA, VerifyKey: key}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported key type %T", parsed)
+}
+
+// Sign signs claims with the active signing key and sets the kid header
+func (k *Keyring) Sign(claims jwt.Claims) (string, error) {
+
+	token := jwt.NewWithClaims(k.signing.Method, claims)
+	token.Header["kid"] = k.signing.ID
+
+	return token.SignedString(k.signing.SigningKey)
+}
+
+// Keyfunc picks the verification key named by the token's kid header. Tokens without a kid
+// predate the keyring and were signed with the legacy secret. The token's algorithm has to
+// match the key's so an RS256 public key can never be used as an HMAC secret.
+func (k *Keyring) Keyfunc(token *jwt.Token) (interface{}, error) {
+
+	kid, _ := token.Header["kid"].(string)
+
+	if kid == "" {
+		kid = LegacyKeyID
+	}
+
+	key, ok := k.keys[kid]
+
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, errors.New("invalid signing algorithm")
+	}
+
+	return key.VerifyKey, nil
+}
+
+// JWKS returns the public keys other services can verify our tokens with. Shared secrets are never published.
+func (k *Keyring) JWKS() JSONWebKeySet {
+
+	set := JSONWebKeySet{Keys: []JSONWebKey{}}
+
+	ids := make([]string, 0, len(k.keys))
+	for id := range k.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		key := k.keys[id]
+
+		switch public := key.VerifyKey.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JSONWebKey{
+				KeyType:   "RSA",
+				Use:       "sig",
+				Algorithm: key.Method.Alg(),
+				KeyID:     key.ID,
+				N:         base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+				E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, JSONWebKey{
+				KeyType:   "OKP",
+				Use:       "sig",
+				Algorithm: key.Method.Alg(),
+				KeyID:     key.ID,
+				Curve:     "Ed25519",
+				X:         base64.RawURLEncoding.EncodeToString(public),
+			})
+		}
+	}
+
+	return set
+}
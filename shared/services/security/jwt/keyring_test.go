@@ -0,0 +1,185 @@
+package jwt_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"autumnomous-jobs-applicant-api/shared/services/security/jwt"
+
+	jwtgo "github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func writePrivateKey(t *testing.T, dir, kid string, key interface{}) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = ioutil.WriteFile(filepath.Join(dir, kid+".pem"), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writePublicKey(t *testing.T, dir, kid string, key interface{}) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = ioutil.WriteFile(filepath.Join(dir, kid+".pem"), pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0600)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func claimsFor(userID string) jwt.JWTData {
+	return jwt.JWTData{
+		StandardClaims: jwtgo.StandardClaims{ExpiresAt: time.Now().Add(time.Minute).Unix(), IssuedAt: time.Now().Unix()},
+		CustomClaims:   map[string]string{"user": userID},
+	}
+}
+
+func Test_Keyring_SignAndVerify(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writePrivateKey(t, dir, "rsa-2021", rsaKey)
+	writePrivateKey(t, dir, "ed-2022", edKey)
+
+	for _, kid := range []string{"rsa-2021", "ed-2022"} {
+		keys, err := jwt.LoadKeyring(dir, kid, "")
+		assert.Nil(err)
+
+		tokenString, err := keys.Sign(claimsFor("user-id"))
+		assert.Nil(err)
+
+		parsed, err := jwtgo.ParseWithClaims(tokenString, &jwt.JWTData{}, keys.Keyfunc)
+		assert.Nil(err)
+		assert.Equal(kid, parsed.Header["kid"])
+		assert.Equal("user-id", parsed.Claims.(*jwt.JWTData).CustomClaims["user"])
+	}
+}
+
+func Test_Keyring_RotatedKeyStillVerifies(t *testing.T) {
+	assert := assert.New(t)
+
+	oldDir := t.TempDir()
+	newDir := t.TempDir()
+
+	_, oldKey, _ := ed25519.GenerateKey(rand.Reader)
+	_, newKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	writePrivateKey(t, oldDir, "old", oldKey)
+
+	oldKeyring, err := jwt.LoadKeyring(oldDir, "old", "")
+	assert.Nil(err)
+
+	tokenString, err := oldKeyring.Sign(claimsFor("user-id"))
+	assert.Nil(err)
+
+	// after rotation the old key is kept as public only
+	writePrivateKey(t, newDir, "new", newKey)
+	writePublicKey(t, newDir, "old", oldKey.Public())
+
+	newKeyring, err := jwt.LoadKeyring(newDir, "new", "")
+	assert.Nil(err)
+
+	_, err = jwtgo.ParseWithClaims(tokenString, &jwt.JWTData{}, newKeyring.Keyfunc)
+	assert.Nil(err)
+
+	// but it can't be chosen to sign with
+	_, err = jwt.LoadKeyring(newDir, "old", "")
+	assert.NotNil(err)
+}
+
+func Test_Keyring_LegacySecret(t *testing.T) {
+	assert := assert.New(t)
+
+	keys, err := jwt.LoadKeyring("", "", "secret")
+	assert.Nil(err)
+
+	// tokens issued before key ids existed carry no kid header
+	legacy := jwtgo.NewWithClaims(jwtgo.SigningMethodHS256, claimsFor("user-id"))
+	tokenString, err := legacy.SignedString([]byte("secret"))
+	assert.Nil(err)
+
+	_, err = jwtgo.ParseWithClaims(tokenString, &jwt.JWTData{}, keys.Keyfunc)
+	assert.Nil(err)
+
+	assert.Empty(keys.JWKS().Keys)
+}
+
+func Test_Keyring_RejectsAlgorithmConfusion(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writePrivateKey(t, dir, "rsa", rsaKey)
+
+	keys, err := jwt.LoadKeyring(dir, "rsa", "")
+	assert.Nil(err)
+
+	// an HS256 token keyed with the RSA public key must not verify
+	publicDER, _ := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	forged := jwtgo.NewWithClaims(jwtgo.SigningMethodHS256, claimsFor("user-id"))
+	forged.Header["kid"] = "rsa"
+	tokenString, err := forged.SignedString(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}))
+	assert.Nil(err)
+
+	_, err = jwtgo.ParseWithClaims(tokenString, &jwt.JWTData{}, keys.Keyfunc)
+	assert.NotNil(err)
+}
+
+func Test_Keyring_JWKS(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	writePrivateKey(t, dir, "a-rsa", rsaKey)
+	writePrivateKey(t, dir, "b-ed", edKey)
+
+	keys, err := jwt.LoadKeyring(dir, "a-rsa", "secret")
+	assert.Nil(err)
+
+	set := keys.JWKS()
+
+	assert.Len(set.Keys, 2)
+	assert.Equal("RSA", set.Keys[0].KeyType)
+	assert.Equal("RS256", set.Keys[0].Algorithm)
+	assert.Equal("AQAB", set.Keys[0].E)
+	assert.Equal("OKP", set.Keys[1].KeyType)
+	assert.Equal("EdDSA", set.Keys[1].Algorithm)
+	assert.Equal("Ed25519", set.Keys[1].Curve)
+}
@@ -0,0 +1,236 @@
+// Package lockout slows down and temporarily blocks repeated failed login attempts
+package lockout
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Counter is the failure history for one key, such as an email address or an IP
+type Counter struct {
+	Failures    int
+	WindowStart time.Time
+	LastFailure time.Time
+	LockedUntil time.Time
+}
+
+// Store persists counters. Implementations must make RecordFailure atomic per key.
+type Store interface {
+	Get(key string) (Counter, error)
+	RecordFailure(key string, now time.Time, window time.Duration) (Counter, error)
+	Lock(key string, until time.Time) error
+	Reset(key string) error
+}
+
+// Policy describes how many failures a key gets before it is slowed down and then locked
+type Policy struct {
+	// MaxAttempts failures inside Window lock the key for LockoutDuration
+	MaxAttempts     int
+	Window          time.Duration
+	LockoutDuration time.Duration
+
+	// FreeAttempts failures are allowed with no delay, after which each attempt waits
+	// BaseDelay doubled per extra failure, capped at MaxDelay
+	FreeAttempts int
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+}
+
+// DefaultEmailPolicy applies to failures against a single account
+var DefaultEmailPolicy = Policy{
+	MaxAttempts:     5,
+	Window:          15 * time.Minute,
+	LockoutDuration: 15 * time.Minute,
+	FreeAttempts:    2,
+	BaseDelay:       time.Second,
+	MaxDelay:        30 * time.Second,
+}
+
+// DefaultIPPolicy applies to failures from a single address across any number of accounts
+var DefaultIPPolicy = Policy{
+	MaxAttempts:     50,
+	Window:          15 * time.Minute,
+	LockoutDuration: 15 * time.Minute,
+	FreeAttempts:    10,
+	BaseDelay:       time.Second,
+	MaxDelay:        10 * time.Second,
+}
+
+// PolicyFromEnv overrides fields of fallback with <prefix>_MAX_ATTEMPTS, <prefix>_WINDOW, <prefix>_LOCKOUT,
+// <prefix>_FREE_ATTEMPTS, <prefix>_BASE_DELAY and <prefix>_MAX_DELAY. Durations use Go syntax, e.g. "15m".
+func PolicyFromEnv(prefix string, fallback Policy) Policy {
+
+	policy := fallback
+
+	readInt := func(name string, value *int) {
+		if n, err := strconv.Atoi(os.Getenv(prefix + name)); err == nil && n > 0 {
+			*value = n
+		}
+	}
+
+	readDuration := func(name string, value *time.Duration) {
+		if d, err := time.ParseDuration(os.Getenv(prefix + name)); err == nil && d >= 0 {
+			*value = d
+		}
+	}
+
+	readInt("_MAX_ATTEMPTS", &policy.MaxAttempts)
+	readDuration("_WINDOW", &policy.Window)
+	readDuration("_LOCKOUT", &policy.LockoutDuration)
+	readInt("_FREE_ATTEMPTS", &policy.FreeAttempts)
+	readDuration("_BASE_DELAY", &policy.BaseDelay)
+	readDuration("_MAX_DELAY", &policy.MaxDelay)
+
+	return policy
+}
+
+// delay is how long to wait after the given number of failures
+func (policy Policy) delay(failures int) time.Duration {
+
+	if failures <= policy.FreeAttempts {
+		return 0
+	}
+
+	delay := policy.BaseDelay
+	for i := policy.FreeAttempts + 1; i < failures && delay < policy.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > policy.MaxDelay {
+		delay = policy.MaxDelay
+	}
+
+	return delay
+}
+
+// retryAfter returns how long the key has to wait before its next attempt and whether it is locked
+func (policy Policy) retryAfter(counter Counter, now time.Time) (time.Duration, bool) {
+
+	if now.Before(counter.LockedUntil) {
+		return counter.LockedUntil.Sub(now), true
+	}
+
+	if counter.Failures == 0 || now.After(counter.WindowStart.Add(policy.Window)) {
+		return 0, false
+	}
+
+	next := counter.LastFailure.Add(policy.delay(counter.Failures))
+
+	if now.Before(next) {
+		return next.Sub(now), false
+	}
+
+	return 0, false
+}
+
+// Decision is the outcome of checking whether an attempt may go ahead
+type Decision struct {
+	Allowed     bool
+	RetryAfter  time.Duration
+	EmailLocked bool
+	IPLocked    bool
+}
+
+// Result reports which keys a failed attempt locked
+type Result struct {
+	EmailLocked bool
+	IPLocked    bool
+}
+
+// Limiter tracks failed attempts per email and per IP
+type Limiter struct {
+	Store       Store
+	EmailPolicy Policy
+	IPPolicy    Policy
+	Now         func() time.Time
+
+	// Namespace keeps the counters of limiters sharing a Store apart. Login counters have none.
+	Namespace string
+}
+
+func NewLimiter(store Store, emailPolicy, ipPolicy Policy) *Limiter {
+	return &Limiter{Store: store, EmailPolicy: emailPolicy, IPPolicy: ipPolicy, Now: time.Now}
+}
+
+func (limiter *Limiter) emailKey(email string) string {
+	return limiter.Namespace + "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func (limiter *Limiter) ipKey(ip string) string {
+	return limiter.Namespace + "ip:" + ip
+}
+
+// Check reports whether an attempt for email from ip may go ahead now
+func (limiter *Limiter) Check(email, ip string) (Decision, error) {
+
+	now := limiter.Now()
+
+	emailCounter, err := limiter.Store.Get(limiter.emailKey(email))
+
+	if err != nil {
+		return Decision{}, err
+	}
+
+	ipCounter, err := limiter.Store.Get(limiter.ipKey(ip))
+
+	if err != nil {
+		return Decision{}, err
+	}
+
+	emailWait, emailLocked := limiter.EmailPolicy.retryAfter(emailCounter, now)
+	ipWait, ipLocked := limiter.IPPolicy.retryAfter(ipCounter, now)
+
+	wait := emailWait
+	if ipWait > wait {
+		wait = ipWait
+	}
+
+	return Decision{Allowed: wait == 0, RetryAfter: wait, EmailLocked: emailLocked, IPLocked: ipLocked}, nil
+}
+
+// Fail records a failed attempt and locks any key that has reached its limit
+func (limiter *Limiter) Fail(email, ip string) (Result, error) {
+
+	var result Result
+	var err error
+
+	now := limiter.Now()
+
+	result.EmailLocked, err = limiter.fail(limiter.emailKey(email), limiter.EmailPolicy, now)
+
+	if err != nil {
+		return result, err
+	}
+
+	result.IPLocked, err = limiter.fail(limiter.ipKey(ip), limiter.IPPolicy, now)
+
+	return result, err
+}
+
+func (limiter *Limiter) fail(key string, policy Policy, now time.Time) (bool, error) {
+
+	counter, err := limiter.Store.RecordFailure(key, now, policy.Window)
+
+	if err != nil {
+		return false, err
+	}
+
+	if counter.Failures < policy.MaxAttempts || now.Before(counter.LockedUntil) {
+		return false, nil
+	}
+
+	return true, limiter.Store.Lock(key, now.Add(policy.LockoutDuration))
+}
+
+// Succeed clears the account's failures after a successful login. The IP's are left to expire
+// so a valid login can't be used to reset the counter between guesses at other accounts.
+func (limiter *Limiter) Succeed(email string) error {
+	return limiter.Store.Reset(limiter.emailKey(email))
+}
+
+// Unlock clears a locked account, e.g. once its owner follows the emailed unlock link
+func (limiter *Limiter) Unlock(email string) error {
+	return limiter.Store.Reset(limiter.emailKey(email))
+}
@@ -0,0 +1,187 @@
+package lockout_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"autumnomous-jobs-applicant-api/shared/services/security/lockout"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testPolicy = lockout.Policy{
+	MaxAttempts:     4,
+	Window:          10 * time.Minute,
+	LockoutDuration: 15 * time.Minute,
+	FreeAttempts:    1,
+	BaseDelay:       time.Second,
+	MaxDelay:        3 * time.Second,
+}
+
+func newTestLimiter(now *time.Time) *lockout.Limiter {
+	limiter := lockout.NewLimiter(lockout.NewMemoryStore(), testPolicy, lockout.Policy{MaxAttempts: 100, Window: time.Minute, LockoutDuration: time.Minute})
+	limiter.Now = func() time.Time { return *now }
+	return limiter
+}
+
+func Test_Limiter_ProgressiveDelay(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	limiter := newTestLimiter(&now)
+
+	decision, err := limiter.Check("someone@test.com", "10.0.0.1")
+	assert.Nil(err)
+	assert.True(decision.Allowed)
+
+	// the first failure is free
+	_, err = limiter.Fail("someone@test.com", "10.0.0.1")
+	assert.Nil(err)
+
+	decision, _ = limiter.Check("someone@test.com", "10.0.0.1")
+	assert.True(decision.Allowed)
+
+	// then each failure doubles the wait
+	limiter.Fail("someone@test.com", "10.0.0.1")
+
+	decision, _ = limiter.Check("someone@test.com", "10.0.0.1")
+	assert.False(decision.Allowed)
+	assert.False(decision.EmailLocked)
+	assert.Equal(time.Second, decision.RetryAfter)
+
+	now = now.Add(time.Second)
+	limiter.Fail("someone@test.com", "10.0.0.1")
+
+	decision, _ = limiter.Check("someone@test.com", "10.0.0.1")
+	assert.Equal(2*time.Second, decision.RetryAfter)
+
+	// other accounts aren't affected
+	decision, _ = limiter.Check("other@test.com", "10.0.0.2")
+	assert.True(decision.Allowed)
+}
+
+func Test_Limiter_LocksAndUnlocks(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	limiter := newTestLimiter(&now)
+
+	for i := 1; i < testPolicy.MaxAttempts; i++ {
+		result, err := limiter.Fail("Someone@Test.com", "10.0.0.1")
+		assert.Nil(err)
+		assert.False(result.EmailLocked)
+		now = now.Add(time.Minute)
+	}
+
+	result, err := limiter.Fail("someone@test.com", "10.0.0.1")
+	assert.Nil(err)
+	assert.True(result.EmailLocked)
+	assert.False(result.IPLocked)
+
+	decision, _ := limiter.Check("someone@test.com", "10.0.0.9")
+	assert.False(decision.Allowed)
+	assert.True(decision.EmailLocked)
+	assert.Equal(testPolicy.LockoutDuration, decision.RetryAfter)
+
+	// failing again while locked doesn't report a second lockout
+	result, _ = limiter.Fail("someone@test.com", "10.0.0.1")
+	assert.False(result.EmailLocked)
+
+	assert.Nil(limiter.Unlock("someone@test.com"))
+
+	decision, _ = limiter.Check("someone@test.com", "10.0.0.9")
+	assert.True(decision.Allowed)
+}
+
+func Test_Limiter_WindowExpires(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	limiter := newTestLimiter(&now)
+
+	for i := 1; i < testPolicy.MaxAttempts; i++ {
+		limiter.Fail("someone@test.com", "10.0.0.1")
+	}
+
+	now = now.Add(testPolicy.Window + time.Second)
+
+	decision, _ := limiter.Check("someone@test.com", "10.0.0.1")
+	assert.True(decision.Allowed)
+
+	// the counter starts again so one more failure doesn't lock
+	result, _ := limiter.Fail("someone@test.com", "10.0.0.1")
+	assert.False(result.EmailLocked)
+}
+
+func Test_Limiter_PerIP(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	limiter := lockout.NewLimiter(lockout.NewMemoryStore(), lockout.Policy{MaxAttempts: 100, Window: time.Minute, LockoutDuration: time.Minute}, lockout.Policy{MaxAttempts: 3, Window: time.Minute, LockoutDuration: time.Minute})
+	limiter.Now = func() time.Time { return now }
+
+	limiter.Fail("a@test.com", "10.0.0.1")
+	limiter.Fail("b@test.com", "10.0.0.1")
+	result, _ := limiter.Fail("c@test.com", "10.0.0.1")
+	assert.True(result.IPLocked)
+	assert.False(result.EmailLocked)
+
+	decision, _ := limiter.Check("d@test.com", "10.0.0.1")
+	assert.False(decision.Allowed)
+	assert.True(decision.IPLocked)
+
+	// a successful login doesn't clear the address
+	limiter.Succeed("d@test.com")
+
+	decision, _ = limiter.Check("d@test.com", "10.0.0.1")
+	assert.False(decision.Allowed)
+
+	decision, _ = limiter.Check("d@test.com", "10.0.0.2")
+	assert.True(decision.Allowed)
+}
+
+func Test_Limiter_Namespace(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	store := lockout.NewMemoryStore()
+	policy := lockout.Policy{MaxAttempts: 1, Window: time.Minute, LockoutDuration: time.Minute}
+
+	login := lockout.NewLimiter(store, policy, policy)
+	login.Now = func() time.Time { return now }
+
+	links := lockout.NewLimiter(store, policy, policy)
+	links.Namespace = "links:"
+	links.Now = func() time.Time { return now }
+
+	result, _ := links.Fail("someone@test.com", "10.0.0.1")
+	assert.True(result.EmailLocked)
+
+	decision, _ := links.Check("someone@test.com", "10.0.0.1")
+	assert.False(decision.Allowed)
+
+	// limiters sharing a store don't see each other's counters
+	decision, _ = login.Check("someone@test.com", "10.0.0.1")
+	assert.True(decision.Allowed)
+}
+
+func Test_PolicyFromEnv(t *testing.T) {
+	assert := assert.New(t)
+
+	os.Setenv("TEST_LOCKOUT_MAX_ATTEMPTS", "9")
+	os.Setenv("TEST_LOCKOUT_LOCKOUT", "1h")
+	os.Setenv("TEST_LOCKOUT_WINDOW", "not a duration")
+
+	defer func() {
+		os.Unsetenv("TEST_LOCKOUT_MAX_ATTEMPTS")
+		os.Unsetenv("TEST_LOCKOUT_LOCKOUT")
+		os.Unsetenv("TEST_LOCKOUT_WINDOW")
+	}()
+
+	policy := lockout.PolicyFromEnv("TEST_LOCKOUT", lockout.DefaultEmailPolicy)
+
+	assert.Equal(9, policy.MaxAttempts)
+	assert.Equal(time.Hour, policy.LockoutDuration)
+	assert.Equal(lockout.DefaultEmailPolicy.Window, policy.Window)
+}
@@ -0,0 +1,62 @@
+package lockout
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps counters in process. Counters are lost on restart and aren't shared
+// between instances, so it suits tests and single instance deployments.
+type MemoryStore struct {
+	mu       sync.Mutex
+	counters map[string]Counter
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{counters: map[string]Counter{}}
+}
+
+func (store *MemoryStore) Get(key string) (Counter, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return store.counters[key], nil
+}
+
+func (store *MemoryStore) RecordFailure(key string, now time.Time, window time.Duration) (Counter, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	counter := store.counters[key]
+
+	if counter.Failures == 0 || now.After(counter.WindowStart.Add(window)) {
+		counter.Failures = 0
+		counter.WindowStart = now
+	}
+
+	counter.Failures++
+	counter.LastFailure = now
+	store.counters[key] = counter
+
+	return counter, nil
+}
+
+func (store *MemoryStore) Lock(key string, until time.Time) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	counter := store.counters[key]
+	counter.LockedUntil = until
+	store.counters[key] = counter
+
+	return nil
+}
+
+func (store *MemoryStore) Reset(key string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	delete(store.counters, key)
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package lockout
+
+import (
+	"database/sql"
+	"log"
+	"time"
+)
+
+// CREATE TABLE loginattempts (
+//     key text PRIMARY KEY,
+//     failures integer NOT NULL DEFAULT 0,
+//     windowstart timestamp with time zone NOT NULL,
+//     lastfailure timestamp with time zone,
+//     lockeduntil timestamp with time zone
+// );
+
+// PostgresStore keeps counters in the loginattempts table so they are shared by every instance
+type PostgresStore struct {
+	Database *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{Database: db}
+}
+
+func (store *PostgresStore) Get(key string) (Counter, error) {
+
+	var counter Counter
+	var lastFailure, lockedUntil sql.NullTime
+
+	err := store.Database.QueryRow(`SELECT failures, windowstart, lastfailure, lockeduntil FROM loginattempts WHERE key=$1;`, key).Scan(&counter.Failures, &counter.WindowStart, &lastFailure, &lockedUntil)
+
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			return Counter{}, nil
+		}
+		log.Println(err)
+		return Counter{}, err
+	}
+
+	counter.LastFailure = lastFailure.Time
+	counter.LockedUntil = lockedUntil.Time
+
+	return counter, nil
+}
+
+func (store *PostgresStore) RecordFailure(key string, now time.Time, window time.Duration) (Counter, error) {
+
+	var counter Counter
+	var lastFailure, lockedUntil sql.NullTime
+
+	err := store.Database.QueryRow(`
+		INSERT INTO loginattempts(key, failures, windowstart, lastfailure)
+		VALUES ($1, 1, $2, $2)
+		ON CONFLICT (key) DO UPDATE SET
+			failures=CASE WHEN loginattempts.windowstart < $3 THEN 1 ELSE loginattempts.failures + 1 END,
+			windowstart=CASE WHEN loginattempts.windowstart < $3 THEN $2 ELSE loginattempts.windowstart END,
+			lastfailure=$2
+		RETURNING failures, windowstart, lastfailure, lockeduntil;`, key, now, now.Add(-window)).Scan(&counter.Failures, &counter.WindowStart, &lastFailure, &lockedUntil)
+
+	if err != nil {
+		log.Println(err)
+		return Counter{}, err
+	}
+
+	counter.LastFailure = lastFailure.Time
+	counter.LockedUntil = lockedUntil.Time
+
+	return counter, nil
+}
+
+func (store *PostgresStore) Lock(key string, until time.Time) error {
+
+	_, err := store.Database.Exec(`UPDATE loginattempts SET lockeduntil=$1 WHERE key=$2;`, until, key)
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+func (store *PostgresStore) Reset(key string) error {
+
+	_, err := store.Database.Exec(`DELETE FROM loginattempts WHERE key=$1;`, key)
+
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
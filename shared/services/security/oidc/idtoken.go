@@ -0,0 +1,321 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt"
+)
+
+// ClockSkew is how far the provider's clock may be ahead of or behind ours
+const ClockSkew = time.Minute
+
+// jwksRefreshInterval stops a stream of tokens with unknown key ids from hammering the provider
+const jwksRefreshInterval = time.Minute
+
+// IDToken holds the claims of a verified ID token
+type IDToken struct {
+	Issuer          string   `json:"iss"`
+	Subject         string   `json:"sub"`
+	Audience        audience `json:"aud"`
+	AuthorizedParty string   `json:"azp"`
+	ExpiresAt       int64    `json:"exp"`
+	IssuedAt        int64    `json:"iat"`
+	Nonce           string   `json:"nonce"`
+
+	Email         string       `json:"email"`
+	EmailVerified flexibleBool `json:"email_verified"`
+	Name          string       `json:"name"`
+	GivenName     string       `json:"given_name"`
+	FamilyName    string       `json:"family_name"`
+}
+
+// audience accepts both forms of the aud claim, a single string or an array
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+
+	var single string
+
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+
+	var list []string
+
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+
+	*a = list
+
+	return nil
+}
+
+func (a audience) contains(clientID string) bool {
+	for _, value := range a {
+		if value == clientID {
+			return true
+		}
+	}
+	return false
+}
+
+// flexibleBool accepts the "true"/"false" strings some providers send for boolean claims
+type flexibleBool bool
+
+func (b *flexibleBool) UnmarshalJSON(data []byte) error {
+
+	var value interface{}
+
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case bool:
+		*b = flexibleBool(v)
+	case string:
+		*b = flexibleBool(v == "true")
+	default:
+		*b = false
+	}
+
+	return nil
+}
+
+// Valid checks the token's lifetime. The issuer, audience and nonce are checked by VerifyIDToken.
+func (token *IDToken) Valid() error {
+
+	now := time.Now()
+
+	if token.ExpiresAt == 0 || now.After(time.Unix(token.ExpiresAt, 0).Add(ClockSkew)) {
+		return errors.New("id token is expired")
+	}
+
+	if token.IssuedAt != 0 && now.Add(ClockSkew).Before(time.Unix(token.IssuedAt, 0)) {
+		return errors.New("id token used before issued")
+	}
+
+	return nil
+}
+
+// HasVerifiedEmail reports whether the provider vouches that the applicant owns Email
+func (token *IDToken) HasVerifiedEmail() bool {
+	return token.Email != "" && bool(token.EmailVerified)
+}
+
+// VerifyIDToken checks raw's signature against the provider's published keys and that it was
+// issued by the provider, for our client, in response to the login attempt that sent nonce.
+func (provider *Provider) VerifyIDToken(ctx context.Context, raw, nonce string) (*IDToken, error) {
+
+	if err := provider.discover(ctx); err != nil {
+		return nil, err
+	}
+
+	parsed, err := jwt.ParseWithClaims(raw, &IDToken{}, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return provider.keySet().key(ctx, kid, token.Method.Alg())
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	token := parsed.Claims.(*IDToken)
+
+	if token.Issuer != provider.Issuer {
+		return nil, fmt.Errorf("id token issuer %q doesn't match %q", token.Issuer, provider.Issuer)
+	}
+
+	if !token.Audience.contains(provider.ClientID) {
+		return nil, errors.New("id token was not issued for this client")
+	}
+
+	if len(token.Audience) > 1 && token.AuthorizedParty != provider.ClientID {
+		return nil, errors.New("id token authorized party doesn't match this client")
+	}
+
+	if token.Nonce == "" || token.Nonce != nonce {
+		return nil, errors.New("id token nonce doesn't match")
+	}
+
+	if token.Subject == "" {
+		return nil, errors.New("id token has no subject")
+	}
+
+	return token, nil
+}
+
+// keySet returns the provider's key cache. It must only be called once discovery has filled in JWKSURI.
+func (provider *Provider) keySet() *keySet {
+
+	keySetsMutex.Lock()
+	defer keySetsMutex.Unlock()
+
+	if provider.keys == nil {
+		provider.keys = &keySet{uri: provider.JWKSURI, keys: map[string]*jsonWebKey{}}
+	}
+
+	return provider.keys
+}
+
+var keySetsMutex sync.Mutex
+
+// keySet caches a provider's JWKS and refetches it when a token names a key it hasn't seen
+type keySet struct {
+	uri string
+
+	mutex       sync.Mutex
+	keys        map[string]*jsonWebKey
+	lastFetched time.Time
+}
+
+type jsonWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n"`
+	E         string `json:"e"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	Y         string `json:"y"`
+
+	public interface{}
+}
+
+func (set *keySet) key(ctx context.Context, kid, alg string) (interface{}, error) {
+
+	set.mutex.Lock()
+	defer set.mutex.Unlock()
+
+	key, ok := set.keys[kid]
+
+	if !ok && time.Since(set.lastFetched) > jwksRefreshInterval {
+
+		if err := set.fetch(ctx); err != nil {
+			return nil, err
+		}
+
+		key, ok = set.keys[kid]
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	// the algorithm comes from the token, so only accept the one the key is for
+	expected := map[string]string{"RSA": "RS256", "EC": "ES256"}[key.KeyType]
+
+	if alg != expected || (key.Algorithm != "" && key.Algorithm != alg) {
+		return nil, errors.New("invalid signing algorithm")
+	}
+
+	return key.public, nil
+}
+
+func (set *keySet) fetch(ctx context.Context) error {
+
+	var document struct {
+		Keys []*jsonWebKey `json:"keys"`
+	}
+
+	if err := getJSON(ctx, set.uri, &document); err != nil {
+		return err
+	}
+
+	keys := map[string]*jsonWebKey{}
+
+	for _, key := range document.Keys {
+
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+
+		public, err := key.publicKey()
+
+		if err != nil {
+			// skip key types we don't support rather than failing every login
+			continue
+		}
+
+		key.public = public
+		keys[key.KeyID] = key
+	}
+
+	set.keys = keys
+	set.lastFetched = time.Now()
+
+	return nil
+}
+
+func (key *jsonWebKey) publicKey() (interface{}, error) {
+
+	switch key.KeyType {
+	case "RSA":
+		n, err := decodeBigInt(key.N)
+
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decodeBigInt(key.E)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		if key.Curve != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", key.Curve)
+		}
+
+		x, err := decodeBigInt(key.X)
+
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := decodeBigInt(key.Y)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !elliptic.P256().IsOnCurve(x, y) {
+			return nil, errors.New("EC key is not on the curve")
+		}
+
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported key type %q", key.KeyType)
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(data), nil
+}
@@ -0,0 +1,146 @@
+package oidc_test
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"autumnomous-jobs-applicant-api/shared/services/security/oidc"
+	"autumnomous-jobs-applicant-api/shared/services/security/oidc/oidctest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newServer(t *testing.T) *oidctest.Server {
+
+	server, err := oidctest.NewServer()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(server.Close)
+
+	server.SetUser(oidctest.User{Subject: "subject-1", Email: "applicant@example.com", EmailVerified: true, GivenName: "Ada", FamilyName: "Lovelace"})
+
+	return server
+}
+
+func Test_LoadProviders(t *testing.T) {
+	assert := assert.New(t)
+
+	providers, err := oidc.LoadProviders([]byte(`[
+		{"name": "google", "issuer": "https://accounts.google.com", "clientid": "id", "redirecturl": "https://example.com/callback"},
+		{"name": "linkedin", "displayname": "LinkedIn", "issuer": "https://www.linkedin.com/oauth", "clientid": "id", "redirecturl": "https://example.com/callback", "scopes": ["openid", "email"]}
+	]`))
+
+	assert.Nil(err)
+	assert.Equal([]string{"google", "linkedin"}, providers.Names())
+	assert.Equal(oidc.DefaultScopes, providers["google"].Scopes)
+	assert.Equal("google", providers["google"].DisplayName)
+	assert.Equal([]string{"openid", "email"}, providers["linkedin"].Scopes)
+
+	providers, err = oidc.LoadProviders(nil)
+	assert.Nil(err)
+	assert.Empty(providers)
+
+	_, err = oidc.LoadProviders([]byte(`[{"name": "google", "issuer": "https://accounts.google.com"}]`))
+	assert.NotNil(err)
+
+	_, err = oidc.LoadProviders([]byte(`[
+		{"name": "google", "issuer": "https://a", "clientid": "id", "redirecturl": "https://b"},
+		{"name": "google", "issuer": "https://a", "clientid": "id", "redirecturl": "https://b"}
+	]`))
+	assert.NotNil(err)
+}
+
+func Test_CodeChallenge(t *testing.T) {
+	// RFC 7636 appendix B
+	assert.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", oidc.CodeChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+}
+
+func Test_Provider_AuthorizationCodeFlow(t *testing.T) {
+	assert := assert.New(t)
+
+	server := newServer(t)
+	provider := server.Provider("mock", "https://jobs.example.com/oidc/callback")
+
+	attempt, err := oidc.NewLoginAttempt()
+	assert.Nil(err)
+
+	authorizationURL, err := provider.AuthCodeURL(context.Background(), attempt.State, attempt.Nonce, oidc.CodeChallenge(attempt.Verifier))
+	assert.Nil(err)
+	assert.True(strings.HasPrefix(authorizationURL, server.URL+"/authorize?"))
+
+	parsed, _ := url.Parse(authorizationURL)
+	assert.Equal("S256", parsed.Query().Get("code_challenge_method"))
+	assert.Equal("openid email profile", parsed.Query().Get("scope"))
+
+	code, state, err := server.Authorize(authorizationURL)
+	assert.Nil(err)
+	assert.Equal(attempt.State, state)
+
+	token, err := provider.Exchange(context.Background(), code, attempt.Verifier, attempt.Nonce)
+
+	if assert.Nil(err) {
+		assert.Equal("subject-1", token.Subject)
+		assert.Equal("applicant@example.com", token.Email)
+		assert.True(token.HasVerifiedEmail())
+		assert.Equal("Ada", token.GivenName)
+	}
+
+	// codes are single use
+	_, err = provider.Exchange(context.Background(), code, attempt.Verifier, attempt.Nonce)
+	assert.NotNil(err)
+}
+
+func Test_Provider_Exchange_WrongVerifier(t *testing.T) {
+	assert := assert.New(t)
+
+	server := newServer(t)
+	provider := server.Provider("mock", "https://jobs.example.com/oidc/callback")
+
+	attempt, _ := oidc.NewLoginAttempt()
+	authorizationURL, _ := provider.AuthCodeURL(context.Background(), attempt.State, attempt.Nonce, oidc.CodeChallenge(attempt.Verifier))
+	code, _, _ := server.Authorize(authorizationURL)
+
+	other, _ := oidc.NewLoginAttempt()
+
+	_, err := provider.Exchange(context.Background(), code, other.Verifier, attempt.Nonce)
+	assert.NotNil(err)
+}
+
+func Test_Provider_VerifyIDToken(t *testing.T) {
+	assert := assert.New(t)
+
+	server := newServer(t)
+	provider := server.Provider("mock", "https://jobs.example.com/oidc/callback")
+	user := oidctest.User{Subject: "subject-1", Email: "applicant@example.com"}
+
+	token, _ := server.IDToken(user, oidctest.ClientID, "nonce", time.Hour)
+
+	verified, err := provider.VerifyIDToken(context.Background(), token, "nonce")
+	assert.Nil(err)
+	assert.False(verified.HasVerifiedEmail())
+
+	_, err = provider.VerifyIDToken(context.Background(), token, "other-nonce")
+	assert.NotNil(err, "nonce mismatch")
+
+	token, _ = server.IDToken(user, "another-client", "nonce", time.Hour)
+	_, err = provider.VerifyIDToken(context.Background(), token, "nonce")
+	assert.NotNil(err, "wrong audience")
+
+	token, _ = server.IDToken(user, oidctest.ClientID, "nonce", -time.Hour)
+	_, err = provider.VerifyIDToken(context.Background(), token, "nonce")
+	assert.NotNil(err, "expired")
+
+	// a token signed with a key the provider never published
+	other, _ := oidctest.NewServer()
+	defer other.Close()
+
+	token, _ = other.IDToken(user, oidctest.ClientID, "nonce", time.Hour)
+	_, err = provider.VerifyIDToken(context.Background(), token, "nonce")
+	assert.NotNil(err, "wrong key")
+}
@@ -0,0 +1,252 @@
+// Package oidctest runs a local OpenID Connect provider for tests
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"autumnomous-jobs-applicant-api/shared/services/security/oidc"
+
+	jwt "github.com/golang-jwt/jwt"
+)
+
+// ClientID is the only client the server issues tokens to
+const ClientID = "test-client"
+
+// KeyID names the server's signing key
+const KeyID = "test-key"
+
+// User is who the next login signs in as
+type User struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	GivenName     string
+	FamilyName    string
+}
+
+// Server is a provider that signs in as User without asking anyone, so the full
+// authorization code flow with PKCE can run in a test
+type Server struct {
+	*httptest.Server
+
+	mutex sync.Mutex
+	User  User
+	Key   *rsa.PrivateKey
+
+	codes map[string]authorization
+}
+
+type authorization struct {
+	user        User
+	nonce       string
+	challenge   string
+	redirectURI string
+}
+
+// NewServer starts a provider. Close it when the test is done.
+func NewServer() (*Server, error) {
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+
+	if err != nil {
+		return nil, err
+	}
+
+	server := &Server{Key: key, codes: map[string]authorization{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", server.discovery)
+	mux.HandleFunc("/authorize", server.authorize)
+	mux.HandleFunc("/token", server.token)
+	mux.HandleFunc("/jwks", server.jwks)
+
+	server.Server = httptest.NewServer(mux)
+
+	return server, nil
+}
+
+// Provider returns the configuration for signing in with the server. Its endpoints are left
+// for discovery to fill in.
+func (server *Server) Provider(name, redirectURL string) *oidc.Provider {
+	return &oidc.Provider{
+		Name:        name,
+		DisplayName: name,
+		Issuer:      server.URL,
+		ClientID:    ClientID,
+		RedirectURL: redirectURL,
+		Scopes:      oidc.DefaultScopes,
+	}
+}
+
+// SetUser changes who the next login signs in as
+func (server *Server) SetUser(user User) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	server.User = user
+}
+
+// Authorize follows an authorization URL the way a browser would and returns the code and
+// state the provider redirected back with
+func (server *Server) Authorize(authorizationURL string) (code, state string, err error) {
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+
+	response, err := client.Get(authorizationURL)
+
+	if err != nil {
+		return "", "", err
+	}
+
+	defer response.Body.Close()
+
+	location, err := response.Location()
+
+	if err != nil {
+		return "", "", err
+	}
+
+	return location.Query().Get("code"), location.Query().Get("state"), nil
+}
+
+// IDToken signs an ID token for user with the server's key
+func (server *Server) IDToken(user User, audience, nonce string, lifetime time.Duration) (string, error) {
+
+	claims := jwt.MapClaims{
+		"iss":            server.URL,
+		"sub":            user.Subject,
+		"aud":            audience,
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(lifetime).Unix(),
+		"nonce":          nonce,
+		"email":          user.Email,
+		"email_verified": user.EmailVerified,
+		"given_name":     user.GivenName,
+		"family_name":    user.FamilyName,
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = KeyID
+
+	return token.SignedString(server.Key)
+}
+
+func (server *Server) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 server.URL,
+		"authorization_endpoint": server.URL + "/authorize",
+		"token_endpoint":         server.URL + "/token",
+		"jwks_uri":               server.URL + "/jwks",
+	})
+}
+
+func (server *Server) authorize(w http.ResponseWriter, r *http.Request) {
+
+	query := r.URL.Query()
+
+	if query.Get("client_id") != ClientID || query.Get("response_type") != "code" || query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		http.Error(w, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+
+	redirect, err := url.Parse(query.Get("redirect_uri"))
+
+	if err != nil {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	code, err := randomString()
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	server.mutex.Lock()
+	server.codes[code] = authorization{user: server.User, nonce: query.Get("nonce"), challenge: query.Get("code_challenge"), redirectURI: redirect.String()}
+	server.mutex.Unlock()
+
+	values := redirect.Query()
+	values.Set("code", code)
+	values.Set("state", query.Get("state"))
+	redirect.RawQuery = values.Encode()
+
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (server *Server) token(w http.ResponseWriter, r *http.Request) {
+
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	server.mutex.Lock()
+	auth, ok := server.codes[r.PostForm.Get("code")]
+	delete(server.codes, r.PostForm.Get("code"))
+	server.mutex.Unlock()
+
+	if !ok || r.PostForm.Get("client_id") != ClientID || r.PostForm.Get("redirect_uri") != auth.redirectURI {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	if oidc.CodeChallenge(r.PostForm.Get("code_verifier")) != auth.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "PKCE verification failed"})
+		return
+	}
+
+	idToken, err := server.IDToken(auth.user, ClientID, auth.nonce, time.Hour)
+
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": "unused",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+func (server *Server) jwks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": KeyID,
+			"n":   base64.RawURLEncoding.EncodeToString(server.Key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(server.Key.E)).Bytes()),
+		}},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func randomString() (string, error) {
+
+	buf := make([]byte, 16)
+
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
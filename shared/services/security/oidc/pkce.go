@@ -0,0 +1,43 @@
+package oidc
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+
+	"autumnomous-jobs-applicant-api/shared/services/security/encryption"
+)
+
+// LoginAttempt is the secret material for one authorization request. The applicant's browser
+// only ever sees State; Nonce and Verifier stay on the server until the callback.
+type LoginAttempt struct {
+	State    string
+	Nonce    string
+	Verifier string
+}
+
+// NewLoginAttempt generates a fresh state, nonce and PKCE code verifier
+func NewLoginAttempt() (*LoginAttempt, error) {
+
+	attempt := &LoginAttempt{}
+
+	for _, value := range []*string{&attempt.State, &attempt.Nonce, &attempt.Verifier} {
+
+		token, err := encryption.GenerateToken(encryption.TokenByteLength)
+
+		if err != nil {
+			return nil, err
+		}
+
+		*value = token
+	}
+
+	return attempt, nil
+}
+
+// CodeChallenge returns the S256 PKCE challenge for verifier (RFC 7636)
+func CodeChallenge(verifier string) string {
+
+	sum := sha256.Sum256([]byte(verifier))
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,299 @@
+// Package oidc signs applicants in with third-party OpenID Connect providers using the
+// authorization code flow with PKCE
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultScopes are requested when a provider doesn't list its own
+var DefaultScopes = []string{"openid", "email", "profile"}
+
+// HTTPClient is used for every call to a provider
+var HTTPClient = &http.Client{Timeout: time.Second * 10}
+
+// Provider is one OpenID Connect identity provider. Only Name, Issuer, ClientID and RedirectURL
+// are required; the endpoints are read from the issuer's discovery document when left empty.
+type Provider struct {
+	Name         string   `json:"name"`
+	DisplayName  string   `json:"displayname"`
+	Issuer       string   `json:"issuer"`
+	ClientID     string   `json:"clientid"`
+	ClientSecret string   `json:"clientsecret"`
+	RedirectURL  string   `json:"redirecturl"`
+	Scopes       []string `json:"scopes"`
+
+	AuthorizationEndpoint string `json:"authorizationendpoint"`
+	TokenEndpoint         string `json:"tokenendpoint"`
+	JWKSURI               string `json:"jwksuri"`
+
+	discoverOnce sync.Once
+	discoverErr  error
+	keys         *keySet
+}
+
+// discoveryDocument is the subset of /.well-known/openid-configuration we use
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Providers are the configured providers by name
+type Providers map[string]*Provider
+
+var (
+	providers     Providers
+	providersErr  error
+	providersOnce sync.Once
+)
+
+// GetProviders returns the providers configured for the process, read on first use from the
+// JSON file named by OIDC_PROVIDERS_FILE or else the JSON in OIDC_PROVIDERS. A provider's
+// client secret can be left out of the file and supplied as OIDC_<NAME>_CLIENT_SECRET.
+func GetProviders() (Providers, error) {
+	providersOnce.Do(func() {
+
+		var data []byte
+
+		if file := os.Getenv("OIDC_PROVIDERS_FILE"); file != "" {
+			data, providersErr = ioutil.ReadFile(file)
+		} else {
+			data = []byte(os.Getenv("OIDC_PROVIDERS"))
+		}
+
+		if providersErr == nil {
+			providers, providersErr = LoadProviders(data)
+		}
+	})
+
+	return providers, providersErr
+}
+
+// SetProviders replaces the process providers
+func SetProviders(p Providers) {
+	providersOnce.Do(func() {})
+	providers, providersErr = p, nil
+}
+
+// LoadProviders parses a JSON array of providers. Empty input means no providers.
+func LoadProviders(data []byte) (Providers, error) {
+
+	p := Providers{}
+
+	if strings.TrimSpace(string(data)) == "" {
+		return p, nil
+	}
+
+	var list []*Provider
+
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+
+	for _, provider := range list {
+
+		if provider.Name == "" || provider.Issuer == "" || provider.ClientID == "" || provider.RedirectURL == "" {
+			return nil, fmt.Errorf("provider %q: name, issuer, clientid and redirecturl are required", provider.Name)
+		}
+
+		if _, exists := p[provider.Name]; exists {
+			return nil, fmt.Errorf("provider %q is configured twice", provider.Name)
+		}
+
+		if secret := os.Getenv("OIDC_" + strings.ToUpper(provider.Name) + "_CLIENT_SECRET"); secret != "" {
+			provider.ClientSecret = secret
+		}
+
+		if len(provider.Scopes) == 0 {
+			provider.Scopes = DefaultScopes
+		}
+
+		if provider.DisplayName == "" {
+			provider.DisplayName = provider.Name
+		}
+
+		p[provider.Name] = provider
+	}
+
+	return p, nil
+}
+
+// Names returns the configured provider names in order
+func (p Providers) Names() []string {
+
+	names := make([]string, 0, len(p))
+	for name := range p {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// discover fills in any endpoint missing from the configuration from the issuer's discovery document
+func (provider *Provider) discover(ctx context.Context) error {
+	provider.discoverOnce.Do(func() {
+
+		if provider.AuthorizationEndpoint != "" && provider.TokenEndpoint != "" && provider.JWKSURI != "" {
+			return
+		}
+
+		var document discoveryDocument
+
+		provider.discoverErr = getJSON(ctx, strings.TrimSuffix(provider.Issuer, "/")+"/.well-known/openid-configuration", &document)
+
+		if provider.discoverErr != nil {
+			return
+		}
+
+		if document.Issuer != provider.Issuer {
+			provider.discoverErr = fmt.Errorf("discovery document issuer %q doesn't match %q", document.Issuer, provider.Issuer)
+			return
+		}
+
+		if provider.AuthorizationEndpoint == "" {
+			provider.AuthorizationEndpoint = document.AuthorizationEndpoint
+		}
+
+		if provider.TokenEndpoint == "" {
+			provider.TokenEndpoint = document.TokenEndpoint
+		}
+
+		if provider.JWKSURI == "" {
+			provider.JWKSURI = document.JWKSURI
+		}
+	})
+
+	return provider.discoverErr
+}
+
+// AuthCodeURL returns the provider URL to send the applicant to. state and nonce tie the
+// response to this login attempt, and challenge is the PKCE code challenge.
+func (provider *Provider) AuthCodeURL(ctx context.Context, state, nonce, challenge string) (string, error) {
+
+	if err := provider.discover(ctx); err != nil {
+		return "", err
+	}
+
+	endpoint, err := url.Parse(provider.AuthorizationEndpoint)
+
+	if err != nil {
+		return "", err
+	}
+
+	values := endpoint.Query()
+	values.Set("response_type", "code")
+	values.Set("client_id", provider.ClientID)
+	values.Set("redirect_uri", provider.RedirectURL)
+	values.Set("scope", strings.Join(provider.Scopes, " "))
+	values.Set("state", state)
+	values.Set("nonce", nonce)
+	values.Set("code_challenge", challenge)
+	values.Set("code_challenge_method", "S256")
+	endpoint.RawQuery = values.Encode()
+
+	return endpoint.String(), nil
+}
+
+// tokenResponse is the provider's answer to the code exchange
+type tokenResponse struct {
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Exchange redeems an authorization code and returns the verified ID token. nonce must be
+// the one sent with the authorization request and verifier the PKCE code verifier.
+func (provider *Provider) Exchange(ctx context.Context, code, verifier, nonce string) (*IDToken, error) {
+
+	if code == "" || verifier == "" || nonce == "" {
+		return nil, errors.New("missing required value")
+	}
+
+	if err := provider.discover(ctx); err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", provider.RedirectURL)
+	form.Set("client_id", provider.ClientID)
+	form.Set("code_verifier", verifier)
+
+	if provider.ClientSecret != "" {
+		form.Set("client_secret", provider.ClientSecret)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, provider.TokenEndpoint, strings.NewReader(form.Encode()))
+
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+
+	response, err := HTTPClient.Do(request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer response.Body.Close()
+
+	var token tokenResponse
+
+	if err := json.NewDecoder(io.LimitReader(response.Body, 1<<20)).Decode(&token); err != nil {
+		return nil, fmt.Errorf("token endpoint returned %d: %w", response.StatusCode, err)
+	}
+
+	if response.StatusCode != http.StatusOK || token.Error != "" {
+		return nil, fmt.Errorf("token endpoint returned %d: %s %s", response.StatusCode, token.Error, token.ErrorDescription)
+	}
+
+	if token.IDToken == "" {
+		return nil, errors.New("token endpoint returned no id_token")
+	}
+
+	return provider.VerifyIDToken(ctx, token.IDToken, nonce)
+}
+
+func getJSON(ctx context.Context, endpoint string, v interface{}) error {
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Accept", "application/json")
+
+	response, err := HTTPClient.Do(request)
+
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %d", endpoint, response.StatusCode)
+	}
+
+	return json.NewDecoder(io.LimitReader(response.Body, 1<<20)).Decode(v)
+}
@@ -0,0 +1,97 @@
+// Package principal carries the authenticated caller of a request in its context.Context
+package principal
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"autumnomous-jobs-applicant-api/shared/services/security/rbac"
+)
+
+// How the principal proved who they are
+const (
+	AuthMethodPassword      = "password"
+	AuthMethodMFA           = "mfa"
+	AuthMethodRefresh       = "refresh"
+	AuthMethodOIDC          = "oidc"
+	AuthMethodMagicLink     = "magic-link"
+	AuthMethodImpersonation = "impersonation"
+)
+
+// Principal is the applicant (or staff member) a request was authenticated as
+type Principal struct {
+	ApplicantID string
+	Roles       []string
+	Permissions []string
+
+	// TokenID is the jti of the access token the request carried
+	TokenID   string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+
+	AuthMethod string
+
+	// SessionID is the login session the token belongs to, empty for tokens not tied to one
+	SessionID string
+
+	// ActorID is the staff member behind an impersonation token, and WriteAccess whether they may make changes
+	ActorID     string
+	WriteAccess bool
+}
+
+type contextKey struct{}
+
+// HasRole reports whether the principal holds role
+func (principal *Principal) HasRole(role string) bool {
+	return rbac.Contains(principal.Roles, role)
+}
+
+// HasPermission reports whether the principal's roles grant permission
+func (principal *Principal) HasPermission(permission string) bool {
+	return rbac.Contains(principal.Permissions, permission)
+}
+
+// IsImpersonation reports whether support is acting as the applicant
+func (principal *Principal) IsImpersonation() bool {
+	return principal.ActorID != ""
+}
+
+// CanWrite reports whether the principal may change the applicant's data. Only read-only
+// impersonation can't.
+func (principal *Principal) CanWrite() bool {
+	return !principal.IsImpersonation() || principal.WriteAccess
+}
+
+// NewContext returns a copy of ctx carrying principal
+func NewContext(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, principal)
+}
+
+// FromContext returns the principal stored in ctx, or nil if there isn't one
+func FromContext(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(contextKey{}).(*Principal)
+	return principal
+}
+
+// WithPrincipal returns a shallow copy of r authenticated as principal
+func WithPrincipal(r *http.Request, principal *Principal) *http.Request {
+	return r.WithContext(NewContext(r.Context(), principal))
+}
+
+// FromRequest returns the principal the request was authenticated as, or nil
+func FromRequest(r *http.Request) *Principal {
+	return FromContext(r.Context())
+}
+
+// ApplicantID returns the ID of the applicant the request was authenticated as, or "" when it wasn't
+func ApplicantID(r *http.Request) string {
+
+	principal := FromRequest(r)
+
+	if principal == nil {
+		return ""
+	}
+
+	return principal.ApplicantID
+}
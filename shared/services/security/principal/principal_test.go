@@ -0,0 +1,50 @@
+package principal_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"autumnomous-jobs-applicant-api/shared/services/security/principal"
+	"autumnomous-jobs-applicant-api/shared/services/security/rbac"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Principal_Request(t *testing.T) {
+	assert := assert.New(t)
+
+	r := httptest.NewRequest("GET", "/applicant/get", nil)
+
+	assert.Nil(principal.FromRequest(r))
+	assert.Equal("", principal.ApplicantID(r))
+
+	r = principal.WithPrincipal(r, &principal.Principal{
+		ApplicantID: "applicant-id",
+		Roles:       []string{rbac.RoleSupport},
+		Permissions: rbac.PermissionsFor([]string{rbac.RoleSupport}),
+		AuthMethod:  principal.AuthMethodPassword,
+	})
+
+	p := principal.FromRequest(r)
+
+	if assert.NotNil(p) {
+		assert.Equal("applicant-id", principal.ApplicantID(r))
+		assert.True(p.HasRole(rbac.RoleSupport))
+		assert.False(p.HasRole(rbac.RoleAdmin))
+		assert.True(p.HasPermission(rbac.PermissionViewApplicants))
+		assert.False(p.IsImpersonation())
+		assert.True(p.CanWrite())
+	}
+}
+
+func Test_Principal_Impersonation(t *testing.T) {
+	assert := assert.New(t)
+
+	p := &principal.Principal{ApplicantID: "applicant-id", ActorID: "staff-id", AuthMethod: principal.AuthMethodImpersonation}
+
+	assert.True(p.IsImpersonation())
+	assert.False(p.CanWrite())
+
+	p.WriteAccess = true
+	assert.True(p.CanWrite())
+}
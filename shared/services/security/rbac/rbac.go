@@ -0,0 +1,83 @@
+// Package rbac defines the roles staff accounts can hold and the permissions each role grants
+package rbac
+
+import "sort"
+
+const (
+	// RoleSupport is for staff who help applicants with their accounts
+	RoleSupport = "support"
+
+	// RoleAdmin is for staff who run the service. It can also impersonate applicants with write access.
+	RoleAdmin = "admin"
+)
+
+const (
+	PermissionViewApplicants     = "applicants:read"
+	PermissionForcePasswordReset = "applicants:force-password-reset"
+	PermissionUnlockApplicants   = "applicants:unlock"
+	PermissionViewAuditLog       = "audit:read"
+	PermissionExportApplicants   = "applicants:export"
+
+	// PermissionImpersonate allows read-only impersonation, PermissionImpersonateWrite allows changes too
+	PermissionImpersonate      = "applicants:impersonate"
+	PermissionImpersonateWrite = "applicants:impersonate-write"
+)
+
+// rolePermissions maps each role to the permissions it grants
+var rolePermissions = map[string][]string{
+	RoleSupport: {
+		PermissionViewApplicants,
+		PermissionForcePasswordReset,
+		PermissionUnlockApplicants,
+		PermissionViewAuditLog,
+		PermissionExportApplicants,
+		PermissionImpersonate,
+	},
+	RoleAdmin: {
+		PermissionViewApplicants,
+		PermissionForcePasswordReset,
+		PermissionUnlockApplicants,
+		PermissionViewAuditLog,
+		PermissionExportApplicants,
+		PermissionImpersonate,
+		PermissionImpersonateWrite,
+	},
+}
+
+// ValidRole reports whether role is one we know about
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// PermissionsFor returns the sorted, de-duplicated permissions granted by roles. Unknown roles grant nothing.
+func PermissionsFor(roles []string) []string {
+
+	set := map[string]bool{}
+
+	for _, role := range roles {
+		for _, permission := range rolePermissions[role] {
+			set[permission] = true
+		}
+	}
+
+	permissions := make([]string, 0, len(set))
+
+	for permission := range set {
+		permissions = append(permissions, permission)
+	}
+
+	sort.Strings(permissions)
+
+	return permissions
+}
+
+// Contains reports whether list holds value, for checking a token's roles or permissions
+func Contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package rbac_test
+
+import (
+	"testing"
+
+	"autumnomous-jobs-applicant-api/shared/services/security/rbac"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_PermissionsFor(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Empty(rbac.PermissionsFor(nil))
+	assert.Empty(rbac.PermissionsFor([]string{"not-a-role"}))
+
+	support := rbac.PermissionsFor([]string{rbac.RoleSupport})
+	assert.True(rbac.Contains(support, rbac.PermissionViewApplicants))
+	assert.True(rbac.Contains(support, rbac.PermissionUnlockApplicants))
+	assert.False(rbac.Contains(support, "apikeys:manage"))
+
+	// overlapping roles don't repeat permissions
+	both := rbac.PermissionsFor([]string{rbac.RoleSupport, rbac.RoleAdmin, "not-a-role"})
+	assert.Equal(rbac.PermissionsFor([]string{rbac.RoleAdmin}), both)
+}
+
+func Test_ValidRole(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.True(rbac.ValidRole(rbac.RoleSupport))
+	assert.True(rbac.ValidRole(rbac.RoleAdmin))
+	assert.False(rbac.ValidRole("applicant"))
+}
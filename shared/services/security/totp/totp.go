@@ -0,0 +1,114 @@
+// Package totp implements RFC 6238 time-based one-time passwords as used by authenticator apps
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// Digits is the length of generated codes
+	Digits = 6
+
+	// Period is how long each code is valid for
+	Period = 30 * time.Second
+
+	// Skew is how many periods either side of now are accepted to allow for clock drift
+	Skew = 1
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret returns a new random base32 encoded secret
+func GenerateSecret() (string, error) {
+
+	buf := make([]byte, 20)
+
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return encoding.EncodeToString(buf), nil
+}
+
+// URI builds the otpauth:// URI authenticator apps read from a QR code
+func URI(issuer, account, secret string) string {
+
+	values := url.Values{}
+	values.Set("secret", secret)
+	values.Set("issuer", issuer)
+	values.Set("algorithm", "SHA1")
+	values.Set("digits", fmt.Sprint(Digits))
+	values.Set("period", fmt.Sprint(int(Period.Seconds())))
+
+	label := url.PathEscape(issuer + ":" + account)
+
+	return "otpauth://totp/" + label + "?" + values.Encode()
+}
+
+// Step returns the time step t falls in
+func Step(t time.Time) int64 {
+	return t.Unix() / int64(Period.Seconds())
+}
+
+// GenerateCode returns the code for a secret at a time step
+func GenerateCode(secret string, step int64) (string, error) {
+
+	key, err := encoding.DecodeString(strings.ToUpper(strings.TrimSpace(secret)))
+
+	if err != nil {
+		return "", err
+	}
+
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulo := uint32(1)
+	for i := 0; i < Digits; i++ {
+		modulo *= 10
+	}
+
+	return fmt.Sprintf("%0*d", Digits, value%modulo), nil
+}
+
+// Validate checks a code against the steps around t. It returns the matching step so callers
+// can refuse to accept the same code twice, or -1 when the code doesn't match.
+func Validate(secret, code string, t time.Time) (int64, error) {
+
+	code = strings.TrimSpace(code)
+
+	if len(code) != Digits {
+		return -1, nil
+	}
+
+	current := Step(t)
+
+	for step := current - Skew; step <= current+Skew; step++ {
+		expected, err := GenerateCode(secret, step)
+
+		if err != nil {
+			return -1, err
+		}
+
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, nil
+		}
+	}
+
+	return -1, nil
+}
@@ -0,0 +1,78 @@
+package totp_test
+
+import (
+	"encoding/base32"
+	"net/url"
+	"testing"
+	"time"
+
+	"autumnomous-jobs-applicant-api/shared/services/security/totp"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// RFC 6238 appendix B, SHA1 secret "12345678901234567890", truncated to six digits
+func Test_GenerateCode_RFC6238Vectors(t *testing.T) {
+	assert := assert.New(t)
+
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+	tests := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+
+	for unix, expected := range tests {
+		code, err := totp.GenerateCode(secret, totp.Step(time.Unix(unix, 0)))
+
+		assert.Nil(err)
+		assert.Equal(expected, code)
+	}
+}
+
+func Test_Validate(t *testing.T) {
+	assert := assert.New(t)
+
+	secret, err := totp.GenerateSecret()
+	assert.Nil(err)
+
+	now := time.Now()
+
+	code, err := totp.GenerateCode(secret, totp.Step(now))
+	assert.Nil(err)
+
+	step, err := totp.Validate(secret, code, now)
+	assert.Nil(err)
+	assert.Equal(totp.Step(now), step)
+
+	// one period of drift is tolerated
+	step, err = totp.Validate(secret, code, now.Add(totp.Period))
+	assert.Nil(err)
+	assert.Equal(totp.Step(now), step)
+
+	// but not more
+	step, err = totp.Validate(secret, code, now.Add(3*totp.Period))
+	assert.Nil(err)
+	assert.Equal(int64(-1), step)
+
+	step, err = totp.Validate(secret, "12345", now)
+	assert.Nil(err)
+	assert.Equal(int64(-1), step)
+}
+
+func Test_URI(t *testing.T) {
+	assert := assert.New(t)
+
+	uri, err := url.Parse(totp.URI("BiT Jobs", "someone@site.com", "JBSWY3DPEHPK3PXP"))
+
+	assert.Nil(err)
+	assert.Equal("otpauth", uri.Scheme)
+	assert.Equal("totp", uri.Host)
+	assert.Equal("/BiT Jobs:someone@site.com", uri.Path)
+	assert.Equal("JBSWY3DPEHPK3PXP", uri.Query().Get("secret"))
+	assert.Equal("BiT Jobs", uri.Query().Get("issuer"))
+}
@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"sync"
+)
+
+// MemoryObject is an object held by a MemoryStore
+type MemoryObject struct {
+	Body        []byte
+	ContentType string
+	Public      bool
+	Attachment  bool
+}
+
+// MemoryStore keeps objects in process, for tests
+type MemoryStore struct {
+	mu      sync.Mutex
+	objects map[string]MemoryObject
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{objects: map[string]MemoryObject{}}
+}
+
+func (store *MemoryStore) Put(key string, body io.ReadSeeker, contentType string, public bool) error {
+
+	data, err := ioutil.ReadAll(body)
+
+	if err != nil {
+		return err
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.objects[key] = MemoryObject{Body: data, ContentType: contentType, Public: public, Attachment: public && !IsImage(contentType)}
+
+	return nil
+}
+
+func (store *MemoryStore) Get(key string) (io.ReadCloser, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	object, ok := store.objects[key]
+
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return ioutil.NopCloser(bytes.NewReader(object.Body)), nil
+}
+
+func (store *MemoryStore) Delete(key string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	delete(store.objects, key)
+
+	return nil
+}
+
+func (store *MemoryStore) URL(key string) string {
+	return "memory://" + key
+}
+
+// Object returns the object stored under key and whether there is one
+func (store *MemoryStore) Object(key string) (MemoryObject, bool) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	object, ok := store.objects[key]
+
+	return object, ok
+}
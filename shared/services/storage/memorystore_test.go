@@ -0,0 +1,60 @@
+package storage_test
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"autumnomous-jobs-applicant-api/shared/services/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MemoryStore(t *testing.T) {
+	assert := assert.New(t)
+
+	store := storage.NewMemoryStore()
+
+	err := store.Put("key", strings.NewReader("body"), "text/plain", false)
+	assert.Nil(err)
+
+	object, ok := store.Object("key")
+	assert.True(ok)
+	assert.Equal("body", string(object.Body))
+	assert.Equal("text/plain", object.ContentType)
+	assert.False(object.Public)
+
+	body, err := store.Get("key")
+	assert.Nil(err)
+
+	data, _ := ioutil.ReadAll(body)
+	assert.Equal("body", string(data))
+
+	assert.Nil(store.Delete("key"))
+	assert.Nil(store.Delete("key"))
+
+	_, ok = store.Object("key")
+	assert.False(ok)
+
+	_, err = store.Get("key")
+	assert.Equal(storage.ErrNotFound, err)
+}
+
+func Test_MemoryStore_PublicAttachment(t *testing.T) {
+	assert := assert.New(t)
+
+	store := storage.NewMemoryStore()
+
+	assert.Nil(store.Put("photo", strings.NewReader("png"), "image/png", true))
+	assert.Nil(store.Put("page", strings.NewReader("<script>"), "text/html", true))
+	assert.Nil(store.Put("private", strings.NewReader("<script>"), "text/html", false))
+
+	photo, _ := store.Object("photo")
+	assert.False(photo.Attachment)
+
+	page, _ := store.Object("page")
+	assert.True(page.Attachment)
+
+	private, _ := store.Object("private")
+	assert.False(private.Attachment)
+}
@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// SpacesStore keeps objects in the bucket named by SPACES_BUCKET
+type SpacesStore struct {
+	client   *s3.S3
+	bucket   string
+	endpoint string
+}
+
+// NewSpacesStore connects to Spaces with the SPACES_KEY, SPACES_SECRET, SPACES_ENDPOINT and
+// SPACES_BUCKET environment variables
+func NewSpacesStore() (Store, error) {
+
+	config := &aws.Config{
+		Credentials: credentials.NewStaticCredentials(os.Getenv("SPACES_KEY"), os.Getenv("SPACES_SECRET"), ""),
+		Endpoint:    aws.String(os.Getenv("SPACES_ENDPOINT")),
+		Region:      aws.String("us-east-1"),
+	}
+
+	newSession, err := session.NewSession(config)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &SpacesStore{
+		client:   s3.New(newSession),
+		bucket:   os.Getenv("SPACES_BUCKET"),
+		endpoint: os.Getenv("SPACES_ENDPOINT"),
+	}, nil
+}
+
+// Put stores the object. Public objects that aren't images are marked as attachments so browsers
+// download them rather than showing them from the bucket's domain.
+func (store *SpacesStore) Put(key string, body io.ReadSeeker, contentType string, public bool) error {
+
+	acl := s3.ObjectCannedACLPrivate
+
+	if public {
+		acl = s3.ObjectCannedACLPublicRead
+	}
+
+	object := &s3.PutObjectInput{
+		Bucket: aws.String(store.bucket),
+		Key:    aws.String(key),
+		Body:   body,
+		ACL:    aws.String(acl),
+	}
+
+	if contentType != "" {
+		object.ContentType = aws.String(contentType)
+	}
+
+	if public && !IsImage(contentType) {
+		object.ContentDisposition = aws.String("attachment")
+	}
+
+	_, err := store.client.PutObject(object)
+
+	return err
+}
+
+// Get opens the object for reading. The caller closes it.
+func (store *SpacesStore) Get(key string) (io.ReadCloser, error) {
+
+	output, err := store.client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(store.bucket),
+		Key:    aws.String(key),
+	})
+
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == s3.ErrCodeNoSuchKey {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return output.Body, nil
+}
+
+// Delete removes the object. Deleting a key that doesn't exist isn't an error.
+func (store *SpacesStore) Delete(key string) error {
+
+	_, err := store.client.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(store.bucket),
+		Key:    aws.String(key),
+	})
+
+	return err
+}
+
+func (store *SpacesStore) URL(key string) string {
+	return fmt.Sprintf("https://%s.%s/%s", store.bucket, store.endpoint, key)
+}
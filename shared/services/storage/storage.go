@@ -0,0 +1,33 @@
+// Package storage keeps files in a Spaces (S3 compatible) bucket
+package storage
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrNotFound is returned by Get when there is no object with the key
+var ErrNotFound = errors.New("storage: object not found")
+
+// imageTypes are the content types a browser may show inline from the bucket. Anything else that's
+// public, such as HTML or SVG that could run script, is served as a download.
+var imageTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// IsImage reports whether contentType is an image that is safe to show inline
+func IsImage(contentType string) bool {
+	return imageTypes[contentType]
+}
+
+// Store holds objects by key. Public objects can be fetched by anyone from URL; private ones only
+// through the API.
+type Store interface {
+	Put(key string, body io.ReadSeeker, contentType string, public bool) error
+	Get(key string) (io.ReadCloser, error)
+	Delete(key string) error
+	URL(key string) string
+}
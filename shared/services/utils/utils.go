@@ -1,6 +1,14 @@
 package utils
 
-import "database/sql"
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+)
 
 func NewNullString(s string) sql.NullString {
 	if len(s) == 0 {
@@ -11,3 +19,109 @@ func NewNullString(s string) sql.NullString {
 		Valid:  true,
 	}
 }
+
+// ClientIP returns the address of the client that made the request. X-Forwarded-For is only
+// believed when the request comes from one of TRUSTED_PROXIES, a comma separated list of addresses
+// or CIDR ranges; anyone else could put whatever they like in it. Each proxy appends the address
+// that connected to it, so the header is read from the right, skipping our own proxies.
+func ClientIP(r *http.Request) string {
+
+	client := r.RemoteAddr
+
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		client = host
+	}
+
+	forwarded := r.Header.Values("X-Forwarded-For")
+
+	if len(forwarded) == 0 {
+		return client
+	}
+
+	proxies := trustedProxies()
+
+	if !isTrustedProxy(proxies, client) {
+		return client
+	}
+
+	addresses := strings.Split(strings.Join(forwarded, ","), ",")
+
+	for i := len(addresses) - 1; i >= 0; i-- {
+		address := strings.TrimSpace(addresses[i])
+
+		if net.ParseIP(address) == nil {
+			break
+		}
+
+		client = address
+
+		if !isTrustedProxy(proxies, address) {
+			break
+		}
+	}
+
+	return client
+}
+
+// trustedProxies parses TRUSTED_PROXIES, treating a lone address as a range holding just it
+func trustedProxies() []*net.IPNet {
+
+	var proxies []*net.IPNet
+
+	for _, entry := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		entry = strings.TrimSpace(entry)
+
+		if entry == "" {
+			continue
+		}
+
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil && ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+
+		_, network, err := net.ParseCIDR(entry)
+
+		if err != nil {
+			log.Printf("ignoring trusted proxy %q: %v", entry, err)
+			continue
+		}
+
+		proxies = append(proxies, network)
+	}
+
+	return proxies
+}
+
+func isTrustedProxy(proxies []*net.IPNet, address string) bool {
+
+	ip := net.ParseIP(address)
+
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range proxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns a shallow copy of r carrying the id the request is logged and audited under
+func WithRequestID(r *http.Request, id string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id))
+}
+
+// RequestID returns the id given to the request by the requestid middleware, or "" outside it
+func RequestID(r *http.Request) string {
+	id, _ := r.Context().Value(requestIDKey{}).(string)
+	return id
+}
@@ -0,0 +1,64 @@
+package utils_test
+
+import (
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"autumnomous-jobs-applicant-api/shared/services/utils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func useTrustedProxies(t *testing.T, proxies string) {
+
+	previous, set := os.LookupEnv("TRUSTED_PROXIES")
+	os.Setenv("TRUSTED_PROXIES", proxies)
+
+	t.Cleanup(func() {
+		if set {
+			os.Setenv("TRUSTED_PROXIES", previous)
+		} else {
+			os.Unsetenv("TRUSTED_PROXIES")
+		}
+	})
+}
+
+func Test_ClientIP_UntrustedForwardedFor(t *testing.T) {
+	assert := assert.New(t)
+
+	useTrustedProxies(t, "10.0.0.0/8")
+
+	// a client connecting directly can't choose its address by sending the header
+	request := httptest.NewRequest("GET", "/", nil)
+	request.RemoteAddr = "203.0.113.7:52000"
+	request.Header.Set("X-Forwarded-For", "198.51.100.1")
+
+	assert.Equal("203.0.113.7", utils.ClientIP(request))
+
+	useTrustedProxies(t, "")
+	assert.Equal("203.0.113.7", utils.ClientIP(request))
+}
+
+func Test_ClientIP_TrustedProxy(t *testing.T) {
+	assert := assert.New(t)
+
+	useTrustedProxies(t, "10.0.0.0/8, 192.0.2.10")
+
+	request := httptest.NewRequest("GET", "/", nil)
+	request.RemoteAddr = "10.1.2.3:443"
+
+	// the address the proxy saw is the last one; what the client sent before it is ignored
+	request.Header.Add("X-Forwarded-For", "198.51.100.1, 203.0.113.7")
+	assert.Equal("203.0.113.7", utils.ClientIP(request))
+
+	// every line of the header counts, not just the first, and our own proxies are skipped
+	request.Header.Add("X-Forwarded-For", "192.0.2.10")
+	assert.Equal("203.0.113.7", utils.ClientIP(request))
+
+	request.Header.Set("X-Forwarded-For", "198.51.100.1, not-an-address")
+	assert.Equal("10.1.2.3", utils.ClientIP(request))
+
+	request.Header.Del("X-Forwarded-For")
+	assert.Equal("10.1.2.3", utils.ClientIP(request))
+}
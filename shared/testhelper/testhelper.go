@@ -12,6 +12,7 @@ import (
 	"autumnomous-jobs-applicant-api/shared/services/security/encryption"
 
 	"github.com/joho/godotenv"
+	"github.com/lib/pq"
 )
 
 type TestApplicant struct {
@@ -139,6 +140,21 @@ func Helper_RandomApplicant(t *testing.T) *TestApplicant {
 	return Helper_CreateApplicant(applicant, t)
 }
 
+// Helper_RandomStaff creates an applicant account holding the given staff roles
+func Helper_RandomStaff(t *testing.T, roles ...string) *TestApplicant {
+
+	applicant := Helper_RandomApplicant(t)
+
+	_, err := database.DB.Exec(`UPDATE applicants SET roles=$1 WHERE publicid=$2;`, pq.Array(roles), applicant.PublicID)
+
+	if err != nil {
+		log.Println(err)
+		t.Fatal()
+	}
+
+	return applicant
+}
+
 func Helper_GetApplicant(publicID string, t *testing.T) *TestApplicant {
 
 	stmt, err := database.DB.Prepare(`SELECT 